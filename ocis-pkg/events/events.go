@@ -0,0 +1,90 @@
+// Package events contains the events published by ocis services in addition to the events defined by reva.
+// They are published and consumed with the reva events package.
+package events
+
+import (
+	"encoding/json"
+	"time"
+
+	user "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+)
+
+// UserSignedIn is emitted when the proxy authenticated a new session of a user
+type UserSignedIn struct {
+	UserID     string
+	Username   string
+	Method     string
+	RemoteAddr string
+	UserAgent  string
+	Timestamp  time.Time
+}
+
+// Unmarshal to fulfill umarshaller interface
+func (UserSignedIn) Unmarshal(v []byte) (interface{}, error) {
+	e := UserSignedIn{}
+	err := json.Unmarshal(v, &e)
+	return e, err
+}
+
+// UserSignInFailed is emitted when the proxy rejected the credentials of a user
+type UserSignInFailed struct {
+	Username   string
+	Method     string
+	Reason     string
+	RemoteAddr string
+	UserAgent  string
+	Timestamp  time.Time
+}
+
+// Unmarshal to fulfill umarshaller interface
+func (UserSignInFailed) Unmarshal(v []byte) (interface{}, error) {
+	e := UserSignInFailed{}
+	err := json.Unmarshal(v, &e)
+	return e, err
+}
+
+// UserPasswordChanged is emitted when a user changed their own password
+type UserPasswordChanged struct {
+	Executant *user.UserId
+	UserID    string
+	Timestamp time.Time
+}
+
+// Unmarshal to fulfill umarshaller interface
+func (UserPasswordChanged) Unmarshal(v []byte) (interface{}, error) {
+	e := UserPasswordChanged{}
+	err := json.Unmarshal(v, &e)
+	return e, err
+}
+
+// RoleAssigned is emitted when a role was assigned to a user
+type RoleAssigned struct {
+	Executant    *user.UserId
+	UserID       string
+	RoleID       string
+	AssignmentID string
+	Timestamp    time.Time
+}
+
+// Unmarshal to fulfill umarshaller interface
+func (RoleAssigned) Unmarshal(v []byte) (interface{}, error) {
+	e := RoleAssigned{}
+	err := json.Unmarshal(v, &e)
+	return e, err
+}
+
+// RoleUnassigned is emitted when a role assignment of a user was removed
+type RoleUnassigned struct {
+	Executant    *user.UserId
+	UserID       string
+	RoleID       string
+	AssignmentID string
+	Timestamp    time.Time
+}
+
+// Unmarshal to fulfill umarshaller interface
+func (RoleUnassigned) Unmarshal(v []byte) (interface{}, error) {
+	e := RoleUnassigned{}
+	err := json.Unmarshal(v, &e)
+	return e, err
+}
@@ -0,0 +1,79 @@
+package events
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/cs3org/reva/v2/pkg/events"
+	mevents "go-micro.dev/v4/events"
+)
+
+const (
+	minConnectBackoff = time.Second
+	maxConnectBackoff = time.Minute
+)
+
+// ErrNotConnected is returned by a LazyPublisher which waits before it tries to connect again
+var ErrNotConnected = errors.New("not connected to the event system")
+
+// LazyPublisher connects to the event system when the first event is published, so that services which only
+// publish events don't wait for the event system when they start. A failed connection is tried again with the next
+// event after a backoff, events published in between are dropped with ErrNotConnected.
+type LazyPublisher struct {
+	connect func() (events.Publisher, error)
+	now     func() time.Time
+
+	mu        sync.Mutex
+	publisher events.Publisher
+	backoff   time.Duration
+	retryAt   time.Time
+}
+
+// NewLazyPublisher returns a LazyPublisher which connects with the given function. The function should try to
+// connect once instead of retrying, it is called while publishing.
+func NewLazyPublisher(connect func() (events.Publisher, error)) *LazyPublisher {
+	return &LazyPublisher{
+		connect: connect,
+		now:     time.Now,
+	}
+}
+
+// Publish publishes an event, it connects to the event system first if needed
+func (p *LazyPublisher) Publish(topic string, ev interface{}, opts ...mevents.PublishOption) error {
+	publisher, err := p.get()
+	if err != nil {
+		return err
+	}
+	return publisher.Publish(topic, ev, opts...)
+}
+
+func (p *LazyPublisher) get() (events.Publisher, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.publisher != nil {
+		return p.publisher, nil
+	}
+	if p.now().Before(p.retryAt) {
+		return nil, ErrNotConnected
+	}
+
+	publisher, err := p.connect()
+	if err != nil {
+		switch {
+		case p.backoff == 0:
+			p.backoff = minConnectBackoff
+		case p.backoff < maxConnectBackoff:
+			p.backoff *= 2
+			if p.backoff > maxConnectBackoff {
+				p.backoff = maxConnectBackoff
+			}
+		}
+		p.retryAt = p.now().Add(p.backoff)
+		return nil, err
+	}
+
+	p.publisher = publisher
+	return publisher, nil
+}
@@ -0,0 +1,60 @@
+package events
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cs3org/reva/v2/pkg/events"
+	"github.com/stretchr/testify/assert"
+	mevents "go-micro.dev/v4/events"
+)
+
+type recordingPublisher struct {
+	published []interface{}
+}
+
+func (p *recordingPublisher) Publish(_ string, ev interface{}, _ ...mevents.PublishOption) error {
+	p.published = append(p.published, ev)
+	return nil
+}
+
+func TestLazyPublisher(t *testing.T) {
+	now := time.Now()
+	connects := 0
+	down := true
+	rec := &recordingPublisher{}
+
+	p := NewLazyPublisher(func() (events.Publisher, error) {
+		connects++
+		if down {
+			return nil, errors.New("connection refused")
+		}
+		return rec, nil
+	})
+	p.now = func() time.Time { return now }
+
+	assert.Equal(t, 0, connects, "the publisher must not connect before publishing")
+
+	assert.EqualError(t, p.Publish("main-queue", "first"), "connection refused")
+	assert.Equal(t, 1, connects)
+
+	// events are dropped until the backoff passed
+	assert.ErrorIs(t, p.Publish("main-queue", "second"), ErrNotConnected)
+	assert.Equal(t, 1, connects)
+
+	now = now.Add(time.Second)
+	assert.EqualError(t, p.Publish("main-queue", "third"), "connection refused")
+	assert.Equal(t, 2, connects)
+
+	// the backoff doubles after every failure
+	now = now.Add(time.Second)
+	assert.ErrorIs(t, p.Publish("main-queue", "fourth"), ErrNotConnected)
+
+	down = false
+	now = now.Add(time.Second)
+	assert.NoError(t, p.Publish("main-queue", "fifth"))
+	assert.NoError(t, p.Publish("main-queue", "sixth"))
+	assert.Equal(t, 3, connects, "the publisher must connect only once")
+	assert.Equal(t, []interface{}{"fifth", "sixth"}, rec.published)
+}
@@ -0,0 +1,112 @@
+package store
+
+import (
+	"errors"
+	"strings"
+	"sync"
+
+	natsjs "github.com/go-micro/plugins/v4/store/nats-js"
+	"github.com/nats-io/nats.go"
+	"go-micro.dev/v4/store"
+)
+
+// natsJSStore adapts the nats-js store to the behaviour of the other implementations: keys are listed and
+// read without their table prefix, reading a missing key returns store.ErrNotFound and an empty table
+// is listed without an error. It connects on first use, the nats-js store must not be initialized concurrently.
+type natsJSStore struct {
+	store.Store
+
+	mu        sync.Mutex
+	connected bool
+}
+
+// newNatsJSStore returns a store keeping its records in a JetStream object store, the bucket of the
+// database is created when the store is used for the first time.
+func newNatsJSStore(database string, opts ...store.Option) store.Store {
+	if database != "" {
+		opts = append(opts, store.Database(database))
+	}
+	return &natsJSStore{Store: natsjs.NewStore(opts...)}
+}
+
+func (s *natsJSStore) connect() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.connected {
+		return nil
+	}
+	if err := s.Store.Init(); err != nil {
+		return err
+	}
+	s.connected = true
+	return nil
+}
+
+func (s *natsJSStore) Write(r *store.Record, opts ...store.WriteOption) error {
+	if err := s.connect(); err != nil {
+		return err
+	}
+	return s.Store.Write(r, opts...)
+}
+
+func (s *natsJSStore) Delete(key string, opts ...store.DeleteOption) error {
+	if err := s.connect(); err != nil {
+		return err
+	}
+	return s.Store.Delete(key, opts...)
+}
+
+func (s *natsJSStore) Read(key string, opts ...store.ReadOption) ([]*store.Record, error) {
+	if err := s.connect(); err != nil {
+		return nil, err
+	}
+
+	o := store.ReadOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	recs, err := s.Store.Read(key, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if len(recs) == 0 && !o.Prefix && !o.Suffix {
+		return nil, store.ErrNotFound
+	}
+	for _, r := range recs {
+		r.Key = strings.TrimPrefix(r.Key, tablePrefix(o.Table))
+	}
+	return recs, nil
+}
+
+func (s *natsJSStore) List(opts ...store.ListOption) ([]string, error) {
+	if err := s.connect(); err != nil {
+		return nil, err
+	}
+
+	o := store.ListOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	keys, err := s.Store.List(opts...)
+	if errors.Is(err, nats.ErrNoObjectsFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i, k := range keys {
+		keys[i] = strings.TrimPrefix(k, tablePrefix(o.Table))
+	}
+	return keys, nil
+}
+
+// tablePrefix is the prefix the nats-js store adds to the keys of a table
+func tablePrefix(table string) string {
+	if table == "" {
+		return ""
+	}
+	return table + "_"
+}
@@ -0,0 +1,109 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	nserver "github.com/nats-io/nats-server/v2/server"
+	"go-micro.dev/v4/store"
+)
+
+// startNats starts a nats server with JetStream enabled and returns the store connecting to it
+func startNats(t *testing.T) store.Store {
+	srv, err := nserver.NewServer(&nserver.Options{
+		Host:      "127.0.0.1",
+		Port:      -1,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Start()
+	t.Cleanup(srv.Shutdown)
+	if !srv.ReadyForConnections(10 * time.Second) {
+		t.Fatal("nats server did not start")
+	}
+
+	return GetStore(OcisStoreOptions{
+		Type:     "nats-js",
+		Address:  srv.ClientURL(),
+		Database: "services",
+	})
+}
+
+func TestNatsJSStore(t *testing.T) {
+	s := startNats(t)
+	defer s.Close()
+
+	keys, err := s.List(store.ListFrom("services", "services/test/"))
+	if err != nil || len(keys) != 0 {
+		t.Fatalf("expected an empty table, got %v, %v", keys, err)
+	}
+	if _, err := s.Read("missing", store.ReadFrom("services", "services/test/")); err != store.ErrNotFound {
+		t.Fatalf("expected store.ErrNotFound, got %v", err)
+	}
+
+	for _, k := range []string{"b", "a"} {
+		if err := s.Write(&store.Record{Key: k, Value: []byte("value " + k)}, store.WriteTo("services", "services/test/")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	keys, err = s.List(store.ListFrom("services", "services/test/"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(keys)
+	if len(keys) != 2 || keys[0] != "a" || keys[1] != "b" {
+		t.Fatalf("expected the keys without the table prefix, got %v", keys)
+	}
+
+	recs, err := s.Read("a", store.ReadFrom("services", "services/test/"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 1 || recs[0].Key != "a" || string(recs[0].Value) != "value a" {
+		t.Fatalf("unexpected records %v", recs)
+	}
+
+	if err := s.Delete("a", store.DeleteFrom("services", "services/test/")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Read("a", store.ReadFrom("services", "services/test/")); err != store.ErrNotFound {
+		t.Fatalf("expected store.ErrNotFound after deleting, got %v", err)
+	}
+}
+
+func TestNatsJSStoreConcurrentUse(t *testing.T) {
+	s := startNats(t)
+	defer s.Close()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- s.Write(&store.Record{Key: fmt.Sprintf("outbox/%d", i)}, store.WriteTo("services", "services/test/"))
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	keys, err := s.List(store.ListPrefix("outbox/"), store.ListFrom("services", "services/test/"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 10 {
+		t.Fatalf("expected 10 keys, got %v", keys)
+	}
+}
@@ -17,14 +17,20 @@ type OcisStoreOptions struct {
 	// * "etcd", for etcd
 	// * "ocmem", custom in-memory implementation, with fixed size and optimized prefix
 	// and suffix search
+	// * "nats-js", for a NATS JetStream object store, which persists the records
 	// * "memory", for a in-memory implementation, which is the default if noone matches
 	Type string
 
 	// Address is a comma-separated list of nodes that the store
-	// will use. This is currently usable only with the etcd implementation. If it
-	// isn't provided, "127.0.0.1:2379" will be the only node used.
+	// will use. This is currently usable only with the etcd and nats-js implementations.
+	// If it isn't provided, "127.0.0.1:2379" will be the only node used for etcd.
 	Address string
 
+	// Database is the database of the records. The nats-js implementation creates a
+	// bucket for it when the store is used for the first time, the other
+	// implementations ignore it.
+	Database string
+
 	// Size configures the maximum capacity of the cache for
 	// the "ocmem" implementation, in number of items that the cache can hold per table.
 	// You can use 5000 to make the cache hold up to 5000 elements.
@@ -53,6 +59,8 @@ func GetStore(ocisOpts OcisStoreOptions) store.Store {
 		s = store.NewNoopStore(opts...)
 	case "etcd":
 		s = etcd.NewEtcdStore(opts...)
+	case "nats-js":
+		s = newNatsJSStore(ocisOpts.Database, opts...)
 	case "ocmem":
 		if ocMemStore == nil {
 			var memStore store.Store
@@ -0,0 +1,16 @@
+// Code generated by protoc-gen-micro. DO NOT EDIT.
+// source: ocis/messages/postprocessing/v0/postprocessing.proto
+
+package v0
+
+import (
+	fmt "fmt"
+	proto "google.golang.org/protobuf/proto"
+	_ "google.golang.org/protobuf/types/known/timestamppb"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
@@ -0,0 +1,119 @@
+// Code generated by protoc-gen-microweb. DO NOT EDIT.
+// source: v0.proto
+
+package v0
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/golang/protobuf/jsonpb"
+)
+
+// ResourceIDJSONMarshaler describes the default jsonpb.Marshaler used by all
+// instances of ResourceID. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var ResourceIDJSONMarshaler = new(jsonpb.Marshaler)
+
+// MarshalJSON satisfies the encoding/json Marshaler interface. This method
+// uses the more correct jsonpb package to correctly marshal the message.
+func (m *ResourceID) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return json.Marshal(nil)
+	}
+
+	buf := &bytes.Buffer{}
+
+	if err := ResourceIDJSONMarshaler.Marshal(buf, m); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+var _ json.Marshaler = (*ResourceID)(nil)
+
+// ResourceIDJSONUnmarshaler describes the default jsonpb.Unmarshaler used by all
+// instances of ResourceID. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var ResourceIDJSONUnmarshaler = new(jsonpb.Unmarshaler)
+
+// UnmarshalJSON satisfies the encoding/json Unmarshaler interface. This method
+// uses the more correct jsonpb package to correctly unmarshal the message.
+func (m *ResourceID) UnmarshalJSON(b []byte) error {
+	return ResourceIDJSONUnmarshaler.Unmarshal(bytes.NewReader(b), m)
+}
+
+var _ json.Unmarshaler = (*ResourceID)(nil)
+
+// StepResultJSONMarshaler describes the default jsonpb.Marshaler used by all
+// instances of StepResult. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var StepResultJSONMarshaler = new(jsonpb.Marshaler)
+
+// MarshalJSON satisfies the encoding/json Marshaler interface. This method
+// uses the more correct jsonpb package to correctly marshal the message.
+func (m *StepResult) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return json.Marshal(nil)
+	}
+
+	buf := &bytes.Buffer{}
+
+	if err := StepResultJSONMarshaler.Marshal(buf, m); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+var _ json.Marshaler = (*StepResult)(nil)
+
+// StepResultJSONUnmarshaler describes the default jsonpb.Unmarshaler used by all
+// instances of StepResult. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var StepResultJSONUnmarshaler = new(jsonpb.Unmarshaler)
+
+// UnmarshalJSON satisfies the encoding/json Unmarshaler interface. This method
+// uses the more correct jsonpb package to correctly unmarshal the message.
+func (m *StepResult) UnmarshalJSON(b []byte) error {
+	return StepResultJSONUnmarshaler.Unmarshal(bytes.NewReader(b), m)
+}
+
+var _ json.Unmarshaler = (*StepResult)(nil)
+
+// PostprocessingJSONMarshaler describes the default jsonpb.Marshaler used by all
+// instances of Postprocessing. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var PostprocessingJSONMarshaler = new(jsonpb.Marshaler)
+
+// MarshalJSON satisfies the encoding/json Marshaler interface. This method
+// uses the more correct jsonpb package to correctly marshal the message.
+func (m *Postprocessing) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return json.Marshal(nil)
+	}
+
+	buf := &bytes.Buffer{}
+
+	if err := PostprocessingJSONMarshaler.Marshal(buf, m); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+var _ json.Marshaler = (*Postprocessing)(nil)
+
+// PostprocessingJSONUnmarshaler describes the default jsonpb.Unmarshaler used by all
+// instances of Postprocessing. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var PostprocessingJSONUnmarshaler = new(jsonpb.Unmarshaler)
+
+// UnmarshalJSON satisfies the encoding/json Unmarshaler interface. This method
+// uses the more correct jsonpb package to correctly unmarshal the message.
+func (m *Postprocessing) UnmarshalJSON(b []byte) error {
+	return PostprocessingJSONUnmarshaler.Unmarshal(bytes.NewReader(b), m)
+}
+
+var _ json.Unmarshaler = (*Postprocessing)(nil)
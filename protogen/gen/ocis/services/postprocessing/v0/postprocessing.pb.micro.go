@@ -0,0 +1,198 @@
+// Code generated by protoc-gen-micro. DO NOT EDIT.
+// source: ocis/services/postprocessing/v0/postprocessing.proto
+
+package v0
+
+import (
+	fmt "fmt"
+	_ "github.com/grpc-ecosystem/grpc-gateway/v2/protoc-gen-openapiv2/options"
+	_ "github.com/owncloud/ocis/v2/protogen/gen/ocis/messages/postprocessing/v0"
+	_ "google.golang.org/genproto/googleapis/api/annotations"
+	proto "google.golang.org/protobuf/proto"
+	math "math"
+)
+
+import (
+	context "context"
+	api "go-micro.dev/v4/api"
+	client "go-micro.dev/v4/client"
+	server "go-micro.dev/v4/server"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ api.Endpoint
+var _ context.Context
+var _ client.Option
+var _ server.Option
+
+// Api Endpoints for PostprocessingService service
+
+func NewPostprocessingServiceEndpoints() []*api.Endpoint {
+	return []*api.Endpoint{
+		{
+			Name:    "PostprocessingService.List",
+			Path:    []string{"/api/v0/postprocessing/list"},
+			Method:  []string{"POST"},
+			Handler: "rpc",
+		},
+		{
+			Name:    "PostprocessingService.Get",
+			Path:    []string{"/api/v0/postprocessing/get"},
+			Method:  []string{"POST"},
+			Handler: "rpc",
+		},
+		{
+			Name:    "PostprocessingService.Restart",
+			Path:    []string{"/api/v0/postprocessing/restart"},
+			Method:  []string{"POST"},
+			Handler: "rpc",
+		},
+		{
+			Name:    "PostprocessingService.Abort",
+			Path:    []string{"/api/v0/postprocessing/abort"},
+			Method:  []string{"POST"},
+			Handler: "rpc",
+		},
+	}
+}
+
+// Client API for PostprocessingService service
+
+type PostprocessingService interface {
+	// List returns all uploads which are currently postprocessed
+	List(ctx context.Context, in *ListRequest, opts ...client.CallOption) (*ListResponse, error)
+	// Get returns the postprocessing state of a single upload
+	Get(ctx context.Context, in *GetRequest, opts ...client.CallOption) (*GetResponse, error)
+	// Restart starts the current step of an upload again or skips it
+	Restart(ctx context.Context, in *RestartRequest, opts ...client.CallOption) (*RestartResponse, error)
+	// Abort finishes the postprocessing of an upload with the abort or delete outcome
+	Abort(ctx context.Context, in *AbortRequest, opts ...client.CallOption) (*AbortResponse, error)
+}
+
+type postprocessingService struct {
+	c    client.Client
+	name string
+}
+
+func NewPostprocessingService(name string, c client.Client) PostprocessingService {
+	return &postprocessingService{
+		c:    c,
+		name: name,
+	}
+}
+
+func (c *postprocessingService) List(ctx context.Context, in *ListRequest, opts ...client.CallOption) (*ListResponse, error) {
+	req := c.c.NewRequest(c.name, "PostprocessingService.List", in)
+	out := new(ListResponse)
+	err := c.c.Call(ctx, req, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *postprocessingService) Get(ctx context.Context, in *GetRequest, opts ...client.CallOption) (*GetResponse, error) {
+	req := c.c.NewRequest(c.name, "PostprocessingService.Get", in)
+	out := new(GetResponse)
+	err := c.c.Call(ctx, req, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *postprocessingService) Restart(ctx context.Context, in *RestartRequest, opts ...client.CallOption) (*RestartResponse, error) {
+	req := c.c.NewRequest(c.name, "PostprocessingService.Restart", in)
+	out := new(RestartResponse)
+	err := c.c.Call(ctx, req, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *postprocessingService) Abort(ctx context.Context, in *AbortRequest, opts ...client.CallOption) (*AbortResponse, error) {
+	req := c.c.NewRequest(c.name, "PostprocessingService.Abort", in)
+	out := new(AbortResponse)
+	err := c.c.Call(ctx, req, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for PostprocessingService service
+
+type PostprocessingServiceHandler interface {
+	// List returns all uploads which are currently postprocessed
+	List(context.Context, *ListRequest, *ListResponse) error
+	// Get returns the postprocessing state of a single upload
+	Get(context.Context, *GetRequest, *GetResponse) error
+	// Restart starts the current step of an upload again or skips it
+	Restart(context.Context, *RestartRequest, *RestartResponse) error
+	// Abort finishes the postprocessing of an upload with the abort or delete outcome
+	Abort(context.Context, *AbortRequest, *AbortResponse) error
+}
+
+func RegisterPostprocessingServiceHandler(s server.Server, hdlr PostprocessingServiceHandler, opts ...server.HandlerOption) error {
+	type postprocessingService interface {
+		List(ctx context.Context, in *ListRequest, out *ListResponse) error
+		Get(ctx context.Context, in *GetRequest, out *GetResponse) error
+		Restart(ctx context.Context, in *RestartRequest, out *RestartResponse) error
+		Abort(ctx context.Context, in *AbortRequest, out *AbortResponse) error
+	}
+	type PostprocessingService struct {
+		postprocessingService
+	}
+	h := &postprocessingServiceHandler{hdlr}
+	opts = append(opts, api.WithEndpoint(&api.Endpoint{
+		Name:    "PostprocessingService.List",
+		Path:    []string{"/api/v0/postprocessing/list"},
+		Method:  []string{"POST"},
+		Handler: "rpc",
+	}))
+	opts = append(opts, api.WithEndpoint(&api.Endpoint{
+		Name:    "PostprocessingService.Get",
+		Path:    []string{"/api/v0/postprocessing/get"},
+		Method:  []string{"POST"},
+		Handler: "rpc",
+	}))
+	opts = append(opts, api.WithEndpoint(&api.Endpoint{
+		Name:    "PostprocessingService.Restart",
+		Path:    []string{"/api/v0/postprocessing/restart"},
+		Method:  []string{"POST"},
+		Handler: "rpc",
+	}))
+	opts = append(opts, api.WithEndpoint(&api.Endpoint{
+		Name:    "PostprocessingService.Abort",
+		Path:    []string{"/api/v0/postprocessing/abort"},
+		Method:  []string{"POST"},
+		Handler: "rpc",
+	}))
+	return s.Handle(s.NewHandler(&PostprocessingService{h}, opts...))
+}
+
+type postprocessingServiceHandler struct {
+	PostprocessingServiceHandler
+}
+
+func (h *postprocessingServiceHandler) List(ctx context.Context, in *ListRequest, out *ListResponse) error {
+	return h.PostprocessingServiceHandler.List(ctx, in, out)
+}
+
+func (h *postprocessingServiceHandler) Get(ctx context.Context, in *GetRequest, out *GetResponse) error {
+	return h.PostprocessingServiceHandler.Get(ctx, in, out)
+}
+
+func (h *postprocessingServiceHandler) Restart(ctx context.Context, in *RestartRequest, out *RestartResponse) error {
+	return h.PostprocessingServiceHandler.Restart(ctx, in, out)
+}
+
+func (h *postprocessingServiceHandler) Abort(ctx context.Context, in *AbortRequest, out *AbortResponse) error {
+	return h.PostprocessingServiceHandler.Abort(ctx, in, out)
+}
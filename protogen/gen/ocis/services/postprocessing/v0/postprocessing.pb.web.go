@@ -0,0 +1,428 @@
+// Code generated by protoc-gen-microweb. DO NOT EDIT.
+// source: v0.proto
+
+package v0
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/golang/protobuf/jsonpb"
+	merrors "go-micro.dev/v4/errors"
+)
+
+type webPostprocessingServiceHandler struct {
+	r chi.Router
+	h PostprocessingServiceHandler
+}
+
+func (h *webPostprocessingServiceHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.r.ServeHTTP(w, r)
+}
+
+func (h *webPostprocessingServiceHandler) List(w http.ResponseWriter, r *http.Request) {
+	req := &ListRequest{}
+	resp := &ListResponse{}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+
+	if err := h.h.List(
+		r.Context(),
+		req,
+		resp,
+	); err != nil {
+		if merr, ok := merrors.As(err); ok && merr.Code == http.StatusNotFound {
+			http.Error(w, err.Error(), http.StatusNotFound)
+		} else {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, resp)
+}
+
+func (h *webPostprocessingServiceHandler) Get(w http.ResponseWriter, r *http.Request) {
+	req := &GetRequest{}
+	resp := &GetResponse{}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+
+	if err := h.h.Get(
+		r.Context(),
+		req,
+		resp,
+	); err != nil {
+		if merr, ok := merrors.As(err); ok && merr.Code == http.StatusNotFound {
+			http.Error(w, err.Error(), http.StatusNotFound)
+		} else {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, resp)
+}
+
+func (h *webPostprocessingServiceHandler) Restart(w http.ResponseWriter, r *http.Request) {
+	req := &RestartRequest{}
+	resp := &RestartResponse{}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+
+	if err := h.h.Restart(
+		r.Context(),
+		req,
+		resp,
+	); err != nil {
+		if merr, ok := merrors.As(err); ok && merr.Code == http.StatusNotFound {
+			http.Error(w, err.Error(), http.StatusNotFound)
+		} else {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, resp)
+}
+
+func (h *webPostprocessingServiceHandler) Abort(w http.ResponseWriter, r *http.Request) {
+	req := &AbortRequest{}
+	resp := &AbortResponse{}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+
+	if err := h.h.Abort(
+		r.Context(),
+		req,
+		resp,
+	); err != nil {
+		if merr, ok := merrors.As(err); ok && merr.Code == http.StatusNotFound {
+			http.Error(w, err.Error(), http.StatusNotFound)
+		} else {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, resp)
+}
+
+func RegisterPostprocessingServiceWeb(r chi.Router, i PostprocessingServiceHandler, middlewares ...func(http.Handler) http.Handler) {
+	handler := &webPostprocessingServiceHandler{
+		r: r,
+		h: i,
+	}
+
+	r.MethodFunc("POST", "/api/v0/postprocessing/list", handler.List)
+	r.MethodFunc("POST", "/api/v0/postprocessing/get", handler.Get)
+	r.MethodFunc("POST", "/api/v0/postprocessing/restart", handler.Restart)
+	r.MethodFunc("POST", "/api/v0/postprocessing/abort", handler.Abort)
+}
+
+// ListRequestJSONMarshaler describes the default jsonpb.Marshaler used by all
+// instances of ListRequest. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var ListRequestJSONMarshaler = new(jsonpb.Marshaler)
+
+// MarshalJSON satisfies the encoding/json Marshaler interface. This method
+// uses the more correct jsonpb package to correctly marshal the message.
+func (m *ListRequest) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return json.Marshal(nil)
+	}
+
+	buf := &bytes.Buffer{}
+
+	if err := ListRequestJSONMarshaler.Marshal(buf, m); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+var _ json.Marshaler = (*ListRequest)(nil)
+
+// ListRequestJSONUnmarshaler describes the default jsonpb.Unmarshaler used by all
+// instances of ListRequest. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var ListRequestJSONUnmarshaler = new(jsonpb.Unmarshaler)
+
+// UnmarshalJSON satisfies the encoding/json Unmarshaler interface. This method
+// uses the more correct jsonpb package to correctly unmarshal the message.
+func (m *ListRequest) UnmarshalJSON(b []byte) error {
+	return ListRequestJSONUnmarshaler.Unmarshal(bytes.NewReader(b), m)
+}
+
+var _ json.Unmarshaler = (*ListRequest)(nil)
+
+// ListResponseJSONMarshaler describes the default jsonpb.Marshaler used by all
+// instances of ListResponse. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var ListResponseJSONMarshaler = new(jsonpb.Marshaler)
+
+// MarshalJSON satisfies the encoding/json Marshaler interface. This method
+// uses the more correct jsonpb package to correctly marshal the message.
+func (m *ListResponse) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return json.Marshal(nil)
+	}
+
+	buf := &bytes.Buffer{}
+
+	if err := ListResponseJSONMarshaler.Marshal(buf, m); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+var _ json.Marshaler = (*ListResponse)(nil)
+
+// ListResponseJSONUnmarshaler describes the default jsonpb.Unmarshaler used by all
+// instances of ListResponse. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var ListResponseJSONUnmarshaler = new(jsonpb.Unmarshaler)
+
+// UnmarshalJSON satisfies the encoding/json Unmarshaler interface. This method
+// uses the more correct jsonpb package to correctly unmarshal the message.
+func (m *ListResponse) UnmarshalJSON(b []byte) error {
+	return ListResponseJSONUnmarshaler.Unmarshal(bytes.NewReader(b), m)
+}
+
+var _ json.Unmarshaler = (*ListResponse)(nil)
+
+// GetRequestJSONMarshaler describes the default jsonpb.Marshaler used by all
+// instances of GetRequest. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var GetRequestJSONMarshaler = new(jsonpb.Marshaler)
+
+// MarshalJSON satisfies the encoding/json Marshaler interface. This method
+// uses the more correct jsonpb package to correctly marshal the message.
+func (m *GetRequest) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return json.Marshal(nil)
+	}
+
+	buf := &bytes.Buffer{}
+
+	if err := GetRequestJSONMarshaler.Marshal(buf, m); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+var _ json.Marshaler = (*GetRequest)(nil)
+
+// GetRequestJSONUnmarshaler describes the default jsonpb.Unmarshaler used by all
+// instances of GetRequest. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var GetRequestJSONUnmarshaler = new(jsonpb.Unmarshaler)
+
+// UnmarshalJSON satisfies the encoding/json Unmarshaler interface. This method
+// uses the more correct jsonpb package to correctly unmarshal the message.
+func (m *GetRequest) UnmarshalJSON(b []byte) error {
+	return GetRequestJSONUnmarshaler.Unmarshal(bytes.NewReader(b), m)
+}
+
+var _ json.Unmarshaler = (*GetRequest)(nil)
+
+// GetResponseJSONMarshaler describes the default jsonpb.Marshaler used by all
+// instances of GetResponse. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var GetResponseJSONMarshaler = new(jsonpb.Marshaler)
+
+// MarshalJSON satisfies the encoding/json Marshaler interface. This method
+// uses the more correct jsonpb package to correctly marshal the message.
+func (m *GetResponse) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return json.Marshal(nil)
+	}
+
+	buf := &bytes.Buffer{}
+
+	if err := GetResponseJSONMarshaler.Marshal(buf, m); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+var _ json.Marshaler = (*GetResponse)(nil)
+
+// GetResponseJSONUnmarshaler describes the default jsonpb.Unmarshaler used by all
+// instances of GetResponse. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var GetResponseJSONUnmarshaler = new(jsonpb.Unmarshaler)
+
+// UnmarshalJSON satisfies the encoding/json Unmarshaler interface. This method
+// uses the more correct jsonpb package to correctly unmarshal the message.
+func (m *GetResponse) UnmarshalJSON(b []byte) error {
+	return GetResponseJSONUnmarshaler.Unmarshal(bytes.NewReader(b), m)
+}
+
+var _ json.Unmarshaler = (*GetResponse)(nil)
+
+// RestartRequestJSONMarshaler describes the default jsonpb.Marshaler used by all
+// instances of RestartRequest. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var RestartRequestJSONMarshaler = new(jsonpb.Marshaler)
+
+// MarshalJSON satisfies the encoding/json Marshaler interface. This method
+// uses the more correct jsonpb package to correctly marshal the message.
+func (m *RestartRequest) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return json.Marshal(nil)
+	}
+
+	buf := &bytes.Buffer{}
+
+	if err := RestartRequestJSONMarshaler.Marshal(buf, m); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+var _ json.Marshaler = (*RestartRequest)(nil)
+
+// RestartRequestJSONUnmarshaler describes the default jsonpb.Unmarshaler used by all
+// instances of RestartRequest. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var RestartRequestJSONUnmarshaler = new(jsonpb.Unmarshaler)
+
+// UnmarshalJSON satisfies the encoding/json Unmarshaler interface. This method
+// uses the more correct jsonpb package to correctly unmarshal the message.
+func (m *RestartRequest) UnmarshalJSON(b []byte) error {
+	return RestartRequestJSONUnmarshaler.Unmarshal(bytes.NewReader(b), m)
+}
+
+var _ json.Unmarshaler = (*RestartRequest)(nil)
+
+// RestartResponseJSONMarshaler describes the default jsonpb.Marshaler used by all
+// instances of RestartResponse. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var RestartResponseJSONMarshaler = new(jsonpb.Marshaler)
+
+// MarshalJSON satisfies the encoding/json Marshaler interface. This method
+// uses the more correct jsonpb package to correctly marshal the message.
+func (m *RestartResponse) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return json.Marshal(nil)
+	}
+
+	buf := &bytes.Buffer{}
+
+	if err := RestartResponseJSONMarshaler.Marshal(buf, m); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+var _ json.Marshaler = (*RestartResponse)(nil)
+
+// RestartResponseJSONUnmarshaler describes the default jsonpb.Unmarshaler used by all
+// instances of RestartResponse. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var RestartResponseJSONUnmarshaler = new(jsonpb.Unmarshaler)
+
+// UnmarshalJSON satisfies the encoding/json Unmarshaler interface. This method
+// uses the more correct jsonpb package to correctly unmarshal the message.
+func (m *RestartResponse) UnmarshalJSON(b []byte) error {
+	return RestartResponseJSONUnmarshaler.Unmarshal(bytes.NewReader(b), m)
+}
+
+var _ json.Unmarshaler = (*RestartResponse)(nil)
+
+// AbortRequestJSONMarshaler describes the default jsonpb.Marshaler used by all
+// instances of AbortRequest. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var AbortRequestJSONMarshaler = new(jsonpb.Marshaler)
+
+// MarshalJSON satisfies the encoding/json Marshaler interface. This method
+// uses the more correct jsonpb package to correctly marshal the message.
+func (m *AbortRequest) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return json.Marshal(nil)
+	}
+
+	buf := &bytes.Buffer{}
+
+	if err := AbortRequestJSONMarshaler.Marshal(buf, m); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+var _ json.Marshaler = (*AbortRequest)(nil)
+
+// AbortRequestJSONUnmarshaler describes the default jsonpb.Unmarshaler used by all
+// instances of AbortRequest. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var AbortRequestJSONUnmarshaler = new(jsonpb.Unmarshaler)
+
+// UnmarshalJSON satisfies the encoding/json Unmarshaler interface. This method
+// uses the more correct jsonpb package to correctly unmarshal the message.
+func (m *AbortRequest) UnmarshalJSON(b []byte) error {
+	return AbortRequestJSONUnmarshaler.Unmarshal(bytes.NewReader(b), m)
+}
+
+var _ json.Unmarshaler = (*AbortRequest)(nil)
+
+// AbortResponseJSONMarshaler describes the default jsonpb.Marshaler used by all
+// instances of AbortResponse. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var AbortResponseJSONMarshaler = new(jsonpb.Marshaler)
+
+// MarshalJSON satisfies the encoding/json Marshaler interface. This method
+// uses the more correct jsonpb package to correctly marshal the message.
+func (m *AbortResponse) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return json.Marshal(nil)
+	}
+
+	buf := &bytes.Buffer{}
+
+	if err := AbortResponseJSONMarshaler.Marshal(buf, m); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+var _ json.Marshaler = (*AbortResponse)(nil)
+
+// AbortResponseJSONUnmarshaler describes the default jsonpb.Unmarshaler used by all
+// instances of AbortResponse. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var AbortResponseJSONUnmarshaler = new(jsonpb.Unmarshaler)
+
+// UnmarshalJSON satisfies the encoding/json Unmarshaler interface. This method
+// uses the more correct jsonpb package to correctly unmarshal the message.
+func (m *AbortResponse) UnmarshalJSON(b []byte) error {
+	return AbortResponseJSONUnmarshaler.Unmarshal(bytes.NewReader(b), m)
+}
+
+var _ json.Unmarshaler = (*AbortResponse)(nil)
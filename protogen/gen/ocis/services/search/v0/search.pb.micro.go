@@ -47,6 +47,24 @@ func NewSearchProviderEndpoints() []*api.Endpoint {
 			Method:  []string{"POST"},
 			Handler: "rpc",
 		},
+		{
+			Name:    "SearchProvider.IndexAllSpaces",
+			Path:    []string{"/api/v0/search/index-all-spaces"},
+			Method:  []string{"POST"},
+			Handler: "rpc",
+		},
+		{
+			Name:    "SearchProvider.VerifyIndex",
+			Path:    []string{"/api/v0/search/index/verify"},
+			Method:  []string{"POST"},
+			Handler: "rpc",
+		},
+		{
+			Name:    "SearchProvider.IndexStats",
+			Path:    []string{"/api/v0/search/index/stats"},
+			Method:  []string{"POST"},
+			Handler: "rpc",
+		},
 	}
 }
 
@@ -55,6 +73,9 @@ func NewSearchProviderEndpoints() []*api.Endpoint {
 type SearchProviderService interface {
 	Search(ctx context.Context, in *SearchRequest, opts ...client.CallOption) (*SearchResponse, error)
 	IndexSpace(ctx context.Context, in *IndexSpaceRequest, opts ...client.CallOption) (*IndexSpaceResponse, error)
+	IndexAllSpaces(ctx context.Context, in *IndexAllSpacesRequest, opts ...client.CallOption) (*IndexAllSpacesResponse, error)
+	VerifyIndex(ctx context.Context, in *VerifyIndexRequest, opts ...client.CallOption) (*VerifyIndexResponse, error)
+	IndexStats(ctx context.Context, in *IndexStatsRequest, opts ...client.CallOption) (*IndexStatsResponse, error)
 }
 
 type searchProviderService struct {
@@ -89,17 +110,53 @@ func (c *searchProviderService) IndexSpace(ctx context.Context, in *IndexSpaceRe
 	return out, nil
 }
 
+func (c *searchProviderService) IndexAllSpaces(ctx context.Context, in *IndexAllSpacesRequest, opts ...client.CallOption) (*IndexAllSpacesResponse, error) {
+	req := c.c.NewRequest(c.name, "SearchProvider.IndexAllSpaces", in)
+	out := new(IndexAllSpacesResponse)
+	err := c.c.Call(ctx, req, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *searchProviderService) VerifyIndex(ctx context.Context, in *VerifyIndexRequest, opts ...client.CallOption) (*VerifyIndexResponse, error) {
+	req := c.c.NewRequest(c.name, "SearchProvider.VerifyIndex", in)
+	out := new(VerifyIndexResponse)
+	err := c.c.Call(ctx, req, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *searchProviderService) IndexStats(ctx context.Context, in *IndexStatsRequest, opts ...client.CallOption) (*IndexStatsResponse, error) {
+	req := c.c.NewRequest(c.name, "SearchProvider.IndexStats", in)
+	out := new(IndexStatsResponse)
+	err := c.c.Call(ctx, req, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Server API for SearchProvider service
 
 type SearchProviderHandler interface {
 	Search(context.Context, *SearchRequest, *SearchResponse) error
 	IndexSpace(context.Context, *IndexSpaceRequest, *IndexSpaceResponse) error
+	IndexAllSpaces(context.Context, *IndexAllSpacesRequest, *IndexAllSpacesResponse) error
+	VerifyIndex(context.Context, *VerifyIndexRequest, *VerifyIndexResponse) error
+	IndexStats(context.Context, *IndexStatsRequest, *IndexStatsResponse) error
 }
 
 func RegisterSearchProviderHandler(s server.Server, hdlr SearchProviderHandler, opts ...server.HandlerOption) error {
 	type searchProvider interface {
 		Search(ctx context.Context, in *SearchRequest, out *SearchResponse) error
 		IndexSpace(ctx context.Context, in *IndexSpaceRequest, out *IndexSpaceResponse) error
+		IndexAllSpaces(ctx context.Context, in *IndexAllSpacesRequest, out *IndexAllSpacesResponse) error
+		VerifyIndex(ctx context.Context, in *VerifyIndexRequest, out *VerifyIndexResponse) error
+		IndexStats(ctx context.Context, in *IndexStatsRequest, out *IndexStatsResponse) error
 	}
 	type SearchProvider struct {
 		searchProvider
@@ -117,6 +174,24 @@ func RegisterSearchProviderHandler(s server.Server, hdlr SearchProviderHandler,
 		Method:  []string{"POST"},
 		Handler: "rpc",
 	}))
+	opts = append(opts, api.WithEndpoint(&api.Endpoint{
+		Name:    "SearchProvider.IndexAllSpaces",
+		Path:    []string{"/api/v0/search/index-all-spaces"},
+		Method:  []string{"POST"},
+		Handler: "rpc",
+	}))
+	opts = append(opts, api.WithEndpoint(&api.Endpoint{
+		Name:    "SearchProvider.VerifyIndex",
+		Path:    []string{"/api/v0/search/index/verify"},
+		Method:  []string{"POST"},
+		Handler: "rpc",
+	}))
+	opts = append(opts, api.WithEndpoint(&api.Endpoint{
+		Name:    "SearchProvider.IndexStats",
+		Path:    []string{"/api/v0/search/index/stats"},
+		Method:  []string{"POST"},
+		Handler: "rpc",
+	}))
 	return s.Handle(s.NewHandler(&SearchProvider{h}, opts...))
 }
 
@@ -132,6 +207,18 @@ func (h *searchProviderHandler) IndexSpace(ctx context.Context, in *IndexSpaceRe
 	return h.SearchProviderHandler.IndexSpace(ctx, in, out)
 }
 
+func (h *searchProviderHandler) IndexAllSpaces(ctx context.Context, in *IndexAllSpacesRequest, out *IndexAllSpacesResponse) error {
+	return h.SearchProviderHandler.IndexAllSpaces(ctx, in, out)
+}
+
+func (h *searchProviderHandler) VerifyIndex(ctx context.Context, in *VerifyIndexRequest, out *VerifyIndexResponse) error {
+	return h.SearchProviderHandler.VerifyIndex(ctx, in, out)
+}
+
+func (h *searchProviderHandler) IndexStats(ctx context.Context, in *IndexStatsRequest, out *IndexStatsResponse) error {
+	return h.SearchProviderHandler.IndexStats(ctx, in, out)
+}
+
 // Api Endpoints for IndexProvider service
 
 func NewIndexProviderEndpoints() []*api.Endpoint {
@@ -75,6 +75,84 @@ func (h *webSearchProviderHandler) IndexSpace(w http.ResponseWriter, r *http.Req
 	render.JSON(w, r, resp)
 }
 
+func (h *webSearchProviderHandler) IndexAllSpaces(w http.ResponseWriter, r *http.Request) {
+	req := &IndexAllSpacesRequest{}
+	resp := &IndexAllSpacesResponse{}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+
+	if err := h.h.IndexAllSpaces(
+		r.Context(),
+		req,
+		resp,
+	); err != nil {
+		if merr, ok := merrors.As(err); ok && merr.Code == http.StatusNotFound {
+			http.Error(w, err.Error(), http.StatusNotFound)
+		} else {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, resp)
+}
+
+func (h *webSearchProviderHandler) VerifyIndex(w http.ResponseWriter, r *http.Request) {
+	req := &VerifyIndexRequest{}
+	resp := &VerifyIndexResponse{}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+
+	if err := h.h.VerifyIndex(
+		r.Context(),
+		req,
+		resp,
+	); err != nil {
+		if merr, ok := merrors.As(err); ok && merr.Code == http.StatusNotFound {
+			http.Error(w, err.Error(), http.StatusNotFound)
+		} else {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, resp)
+}
+
+func (h *webSearchProviderHandler) IndexStats(w http.ResponseWriter, r *http.Request) {
+	req := &IndexStatsRequest{}
+	resp := &IndexStatsResponse{}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+
+	if err := h.h.IndexStats(
+		r.Context(),
+		req,
+		resp,
+	); err != nil {
+		if merr, ok := merrors.As(err); ok && merr.Code == http.StatusNotFound {
+			http.Error(w, err.Error(), http.StatusNotFound)
+		} else {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, resp)
+}
+
 func RegisterSearchProviderWeb(r chi.Router, i SearchProviderHandler, middlewares ...func(http.Handler) http.Handler) {
 	handler := &webSearchProviderHandler{
 		r: r,
@@ -83,6 +161,9 @@ func RegisterSearchProviderWeb(r chi.Router, i SearchProviderHandler, middleware
 
 	r.MethodFunc("POST", "/api/v0/search/search", handler.Search)
 	r.MethodFunc("POST", "/api/v0/search/index-space", handler.IndexSpace)
+	r.MethodFunc("POST", "/api/v0/search/index-all-spaces", handler.IndexAllSpaces)
+	r.MethodFunc("POST", "/api/v0/search/index/verify", handler.VerifyIndex)
+	r.MethodFunc("POST", "/api/v0/search/index/stats", handler.IndexStats)
 }
 
 type webIndexProviderHandler struct {
@@ -344,3 +425,219 @@ func (m *IndexSpaceResponse) UnmarshalJSON(b []byte) error {
 }
 
 var _ json.Unmarshaler = (*IndexSpaceResponse)(nil)
+
+// IndexAllSpacesRequestJSONMarshaler describes the default jsonpb.Marshaler used by all
+// instances of IndexAllSpacesRequest. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var IndexAllSpacesRequestJSONMarshaler = new(jsonpb.Marshaler)
+
+// MarshalJSON satisfies the encoding/json Marshaler interface. This method
+// uses the more correct jsonpb package to correctly marshal the message.
+func (m *IndexAllSpacesRequest) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return json.Marshal(nil)
+	}
+
+	buf := &bytes.Buffer{}
+
+	if err := IndexAllSpacesRequestJSONMarshaler.Marshal(buf, m); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+var _ json.Marshaler = (*IndexAllSpacesRequest)(nil)
+
+// IndexAllSpacesRequestJSONUnmarshaler describes the default jsonpb.Unmarshaler used by all
+// instances of IndexAllSpacesRequest. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var IndexAllSpacesRequestJSONUnmarshaler = new(jsonpb.Unmarshaler)
+
+// UnmarshalJSON satisfies the encoding/json Unmarshaler interface. This method
+// uses the more correct jsonpb package to correctly unmarshal the message.
+func (m *IndexAllSpacesRequest) UnmarshalJSON(b []byte) error {
+	return IndexAllSpacesRequestJSONUnmarshaler.Unmarshal(bytes.NewReader(b), m)
+}
+
+var _ json.Unmarshaler = (*IndexAllSpacesRequest)(nil)
+
+// IndexAllSpacesResponseJSONMarshaler describes the default jsonpb.Marshaler used by all
+// instances of IndexAllSpacesResponse. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var IndexAllSpacesResponseJSONMarshaler = new(jsonpb.Marshaler)
+
+// MarshalJSON satisfies the encoding/json Marshaler interface. This method
+// uses the more correct jsonpb package to correctly marshal the message.
+func (m *IndexAllSpacesResponse) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return json.Marshal(nil)
+	}
+
+	buf := &bytes.Buffer{}
+
+	if err := IndexAllSpacesResponseJSONMarshaler.Marshal(buf, m); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+var _ json.Marshaler = (*IndexAllSpacesResponse)(nil)
+
+// IndexAllSpacesResponseJSONUnmarshaler describes the default jsonpb.Unmarshaler used by all
+// instances of IndexAllSpacesResponse. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var IndexAllSpacesResponseJSONUnmarshaler = new(jsonpb.Unmarshaler)
+
+// UnmarshalJSON satisfies the encoding/json Unmarshaler interface. This method
+// uses the more correct jsonpb package to correctly unmarshal the message.
+func (m *IndexAllSpacesResponse) UnmarshalJSON(b []byte) error {
+	return IndexAllSpacesResponseJSONUnmarshaler.Unmarshal(bytes.NewReader(b), m)
+}
+
+var _ json.Unmarshaler = (*IndexAllSpacesResponse)(nil)
+
+// VerifyIndexRequestJSONMarshaler describes the default jsonpb.Marshaler used by all
+// instances of VerifyIndexRequest. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var VerifyIndexRequestJSONMarshaler = new(jsonpb.Marshaler)
+
+// MarshalJSON satisfies the encoding/json Marshaler interface. This method
+// uses the more correct jsonpb package to correctly marshal the message.
+func (m *VerifyIndexRequest) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return json.Marshal(nil)
+	}
+
+	buf := &bytes.Buffer{}
+
+	if err := VerifyIndexRequestJSONMarshaler.Marshal(buf, m); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+var _ json.Marshaler = (*VerifyIndexRequest)(nil)
+
+// VerifyIndexRequestJSONUnmarshaler describes the default jsonpb.Unmarshaler used by all
+// instances of VerifyIndexRequest. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var VerifyIndexRequestJSONUnmarshaler = new(jsonpb.Unmarshaler)
+
+// UnmarshalJSON satisfies the encoding/json Unmarshaler interface. This method
+// uses the more correct jsonpb package to correctly unmarshal the message.
+func (m *VerifyIndexRequest) UnmarshalJSON(b []byte) error {
+	return VerifyIndexRequestJSONUnmarshaler.Unmarshal(bytes.NewReader(b), m)
+}
+
+var _ json.Unmarshaler = (*VerifyIndexRequest)(nil)
+
+// VerifyIndexResponseJSONMarshaler describes the default jsonpb.Marshaler used by all
+// instances of VerifyIndexResponse. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var VerifyIndexResponseJSONMarshaler = new(jsonpb.Marshaler)
+
+// MarshalJSON satisfies the encoding/json Marshaler interface. This method
+// uses the more correct jsonpb package to correctly marshal the message.
+func (m *VerifyIndexResponse) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return json.Marshal(nil)
+	}
+
+	buf := &bytes.Buffer{}
+
+	if err := VerifyIndexResponseJSONMarshaler.Marshal(buf, m); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+var _ json.Marshaler = (*VerifyIndexResponse)(nil)
+
+// VerifyIndexResponseJSONUnmarshaler describes the default jsonpb.Unmarshaler used by all
+// instances of VerifyIndexResponse. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var VerifyIndexResponseJSONUnmarshaler = new(jsonpb.Unmarshaler)
+
+// UnmarshalJSON satisfies the encoding/json Unmarshaler interface. This method
+// uses the more correct jsonpb package to correctly unmarshal the message.
+func (m *VerifyIndexResponse) UnmarshalJSON(b []byte) error {
+	return VerifyIndexResponseJSONUnmarshaler.Unmarshal(bytes.NewReader(b), m)
+}
+
+var _ json.Unmarshaler = (*VerifyIndexResponse)(nil)
+
+// IndexStatsRequestJSONMarshaler describes the default jsonpb.Marshaler used by all
+// instances of IndexStatsRequest. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var IndexStatsRequestJSONMarshaler = new(jsonpb.Marshaler)
+
+// MarshalJSON satisfies the encoding/json Marshaler interface. This method
+// uses the more correct jsonpb package to correctly marshal the message.
+func (m *IndexStatsRequest) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return json.Marshal(nil)
+	}
+
+	buf := &bytes.Buffer{}
+
+	if err := IndexStatsRequestJSONMarshaler.Marshal(buf, m); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+var _ json.Marshaler = (*IndexStatsRequest)(nil)
+
+// IndexStatsRequestJSONUnmarshaler describes the default jsonpb.Unmarshaler used by all
+// instances of IndexStatsRequest. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var IndexStatsRequestJSONUnmarshaler = new(jsonpb.Unmarshaler)
+
+// UnmarshalJSON satisfies the encoding/json Unmarshaler interface. This method
+// uses the more correct jsonpb package to correctly unmarshal the message.
+func (m *IndexStatsRequest) UnmarshalJSON(b []byte) error {
+	return IndexStatsRequestJSONUnmarshaler.Unmarshal(bytes.NewReader(b), m)
+}
+
+var _ json.Unmarshaler = (*IndexStatsRequest)(nil)
+
+// IndexStatsResponseJSONMarshaler describes the default jsonpb.Marshaler used by all
+// instances of IndexStatsResponse. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var IndexStatsResponseJSONMarshaler = new(jsonpb.Marshaler)
+
+// MarshalJSON satisfies the encoding/json Marshaler interface. This method
+// uses the more correct jsonpb package to correctly marshal the message.
+func (m *IndexStatsResponse) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return json.Marshal(nil)
+	}
+
+	buf := &bytes.Buffer{}
+
+	if err := IndexStatsResponseJSONMarshaler.Marshal(buf, m); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+var _ json.Marshaler = (*IndexStatsResponse)(nil)
+
+// IndexStatsResponseJSONUnmarshaler describes the default jsonpb.Unmarshaler used by all
+// instances of IndexStatsResponse. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var IndexStatsResponseJSONUnmarshaler = new(jsonpb.Unmarshaler)
+
+// UnmarshalJSON satisfies the encoding/json Unmarshaler interface. This method
+// uses the more correct jsonpb package to correctly unmarshal the message.
+func (m *IndexStatsResponse) UnmarshalJSON(b []byte) error {
+	return IndexStatsResponseJSONUnmarshaler.Unmarshal(bytes.NewReader(b), m)
+}
+
+var _ json.Unmarshaler = (*IndexStatsResponse)(nil)
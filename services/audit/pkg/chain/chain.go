@@ -0,0 +1,139 @@
+// Package chain makes audit logs tamper-evident. Every record is extended with a sequence number, the hash of the
+// previous record and optionally an HMAC signature, so that modified, removed or reordered records can be detected.
+package chain
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Fields added to every chained record. They are appended in this order after the fields of the original record.
+const (
+	FieldSequence     = "Sequence"
+	FieldPreviousHash = "PreviousHash"
+	FieldSignature    = "Signature"
+)
+
+// ErrNoObject is returned when a record is not a json object and can't be chained
+var ErrNoObject = errors.New("only json objects can be chained")
+
+// State is the position in the chain. It is persisted to continue the chain after a restart.
+type State struct {
+	Sequence uint64 `json:"sequence"`
+	Hash     string `json:"hash"`
+}
+
+// Chain appends records to a hash chain
+type Chain struct {
+	key       []byte
+	stateFile string
+
+	mu    sync.Mutex
+	state State
+}
+
+// New returns a Chain continuing from the state stored in stateFile. A new chain is started if the file doesn't exist.
+// Records are signed if key is not empty.
+func New(stateFile string, key string) (*Chain, error) {
+	c := &Chain{
+		key:       []byte(key),
+		stateFile: stateFile,
+	}
+
+	if stateFile == "" {
+		return c, nil
+	}
+
+	b, err := os.ReadFile(stateFile)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return c, nil
+	case err != nil:
+		return nil, fmt.Errorf("error reading chain state: %w", err)
+	}
+
+	if err := json.Unmarshal(b, &c.state); err != nil {
+		return nil, fmt.Errorf("error parsing chain state '%s': %w", stateFile, err)
+	}
+	return c, nil
+}
+
+// State returns the current position in the chain
+func (c *Chain) State() State {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.state
+}
+
+// Append adds the chain fields to a record, which must be a json object, and advances the chain.
+// The returned error reports a failure to persist the state, the chained record is valid nevertheless.
+func (c *Chain) Append(record []byte) ([]byte, error) {
+	record = bytes.TrimSpace(record)
+	if len(record) < 2 || record[0] != '{' || record[len(record)-1] != '}' {
+		return nil, ErrNoObject
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	seq := c.state.Sequence + 1
+
+	line := make([]byte, 0, len(record)+160)
+	line = append(line, record[:len(record)-1]...)
+	if len(bytes.TrimSpace(record[1:len(record)-1])) > 0 {
+		line = append(line, ',')
+	}
+	line = append(line, fmt.Sprintf(`"%s":%d,"%s":"%s"`, FieldSequence, seq, FieldPreviousHash, c.state.Hash)...)
+	if len(c.key) > 0 {
+		line = append(line, fmt.Sprintf(`,"%s":"%s"`, FieldSignature, sign(c.key, line))...)
+	}
+	line = append(line, '}')
+
+	c.state = State{Sequence: seq, Hash: hash(line)}
+	return line, c.persist()
+}
+
+// persist writes the state to a temporary file first, so that a crash never leaves a truncated state file behind
+func (c *Chain) persist() error {
+	if c.stateFile == "" {
+		return nil
+	}
+
+	b, err := json.Marshal(c.state)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.stateFile), 0700); err != nil {
+		return fmt.Errorf("error persisting chain state: %w", err)
+	}
+
+	tmp := c.stateFile + ".tmp"
+	if err := os.WriteFile(tmp, b, 0600); err != nil {
+		return fmt.Errorf("error persisting chain state: %w", err)
+	}
+	if err := os.Rename(tmp, c.stateFile); err != nil {
+		return fmt.Errorf("error persisting chain state: %w", err)
+	}
+	return nil
+}
+
+// hash returns the hex encoded sha256 of a complete record as it is written
+func hash(line []byte) string {
+	h := sha256.Sum256(line)
+	return hex.EncodeToString(h[:])
+}
+
+// sign returns the hex encoded HMAC-SHA256 of the record up to the signature field
+func sign(key []byte, signed []byte) string {
+	m := hmac.New(sha256.New, key)
+	m.Write(signed)
+	return hex.EncodeToString(m.Sum(nil))
+}
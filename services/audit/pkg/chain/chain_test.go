@@ -0,0 +1,104 @@
+package chain
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/test-go/testify/require"
+)
+
+func appendRecords(t *testing.T, c *Chain, records ...string) []string {
+	lines := make([]string, 0, len(records))
+	for _, r := range records {
+		b, err := c.Append([]byte(r))
+		require.NoError(t, err)
+		lines = append(lines, string(b))
+	}
+	return lines
+}
+
+func verify(t *testing.T, key string, lines ...string) []Problem {
+	problems, err := NewVerifier(key).Verify(strings.NewReader(strings.Join(lines, "\n") + "\n"))
+	require.NoError(t, err)
+	return problems
+}
+
+func TestAppend(t *testing.T) {
+	c, err := New("", "")
+	require.NoError(t, err)
+
+	lines := appendRecords(t, c, `{"Action":"file_create"}`, `{}`)
+	require.Equal(t, `{"Action":"file_create","Sequence":1,"PreviousHash":""}`, lines[0])
+	require.Equal(t, `{"Sequence":2,"PreviousHash":"`+hash([]byte(lines[0]))+`"}`, lines[1])
+
+	_, err = c.Append([]byte("file_create)"))
+	require.Equal(t, ErrNoObject, err)
+}
+
+func TestStateIsPersisted(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "audit", "chain.json")
+
+	c, err := New(stateFile, "")
+	require.NoError(t, err)
+	lines := appendRecords(t, c, `{"n":1}`, `{"n":2}`)
+
+	c, err = New(stateFile, "")
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), c.State().Sequence)
+	lines = append(lines, appendRecords(t, c, `{"n":3}`)...)
+
+	require.Empty(t, verify(t, "", lines...))
+}
+
+func TestVerify(t *testing.T) {
+	c, err := New("", "")
+	require.NoError(t, err)
+	lines := appendRecords(t, c, `{"n":1}`, `{"n":2}`, `{"n":3}`, `{"n":4}`)
+
+	require.Empty(t, verify(t, "", lines...))
+
+	modified := append([]string{}, lines...)
+	modified[1] = strings.Replace(modified[1], `"n":2`, `"n":5`, 1)
+	require.Equal(t, []Problem{{Line: 3, Message: "hash of the previous record 2 doesn't match, it was modified"}}, verify(t, "", modified...))
+
+	removed := []string{lines[0], lines[1], lines[3]}
+	require.Equal(t, []Problem{{Line: 3, Message: "sequence gap, 1 record(s) missing between 2 and 4"}}, verify(t, "", removed...))
+
+	reordered := []string{lines[0], lines[2], lines[1], lines[3]}
+	require.Len(t, verify(t, "", reordered...), 3)
+
+	require.Equal(t, []Problem{{Line: 2, Message: "not a chained record"}}, verify(t, "", lines[0], `{"n":2}`))
+
+	// verifying rotated files one after the other continues the chain
+	v := NewVerifier("")
+	p, err := v.Verify(strings.NewReader(lines[0] + "\n" + lines[1] + "\n"))
+	require.NoError(t, err)
+	require.Empty(t, p)
+	p, err = v.Verify(bytes.NewBufferString(lines[2] + "\n" + lines[3] + "\n"))
+	require.NoError(t, err)
+	require.Empty(t, p)
+	first, last := v.Range()
+	require.Equal(t, uint64(1), first)
+	require.Equal(t, uint64(4), last)
+	require.Equal(t, 4, v.Records())
+}
+
+func TestVerifySignature(t *testing.T) {
+	c, err := New("", "secret")
+	require.NoError(t, err)
+	lines := appendRecords(t, c, `{"n":1}`, `{"n":2}`)
+	require.Contains(t, lines[0], `,"Signature":"`)
+
+	require.Empty(t, verify(t, "secret", lines...))
+	require.Equal(t, []Problem{
+		{Line: 1, Message: "invalid signature, the record was modified"},
+		{Line: 2, Message: "invalid signature, the record was modified"},
+	}, verify(t, "other", lines...))
+
+	// the last record can only be checked with a signature
+	modified := []string{lines[0], strings.Replace(lines[1], `"n":2`, `"n":5`, 1)}
+	require.Empty(t, verify(t, "", modified...))
+	require.Equal(t, []Problem{{Line: 2, Message: "invalid signature, the record was modified"}}, verify(t, "secret", modified...))
+}
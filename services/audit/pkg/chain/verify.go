@@ -0,0 +1,125 @@
+package chain
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Problem describes a violation of the chain
+type Problem struct {
+	// Line is the line number of the record, starting at 1
+	Line    int
+	Message string
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("line %d: %s", p.Line, p.Message)
+}
+
+// Verifier checks chained records. It keeps its state between calls to Verify, so that rotated files
+// can be verified one after the other.
+type Verifier struct {
+	key []byte
+
+	started bool
+	state   State
+	first   uint64
+	records int
+}
+
+// NewVerifier returns a Verifier. Signatures are checked if key is not empty.
+func NewVerifier(key string) *Verifier {
+	return &Verifier{key: []byte(key)}
+}
+
+// Records returns the number of records verified so far
+func (v *Verifier) Records() int {
+	return v.records
+}
+
+// Range returns the first and the last sequence number verified so far
+func (v *Verifier) Range() (uint64, uint64) {
+	return v.first, v.state.Sequence
+}
+
+// Verify reads chained records, one per line, and returns all problems found.
+// Without a key, a modification of the very last record can't be detected because no later record refers to its hash.
+func (v *Verifier) Verify(r io.Reader) ([]Problem, error) {
+	var problems []Problem
+
+	br := bufio.NewReader(r)
+	for n := 1; ; n++ {
+		line, err := br.ReadBytes('\n')
+		if len(line) > 0 {
+			if msg := v.verifyRecord(bytes.TrimRight(line, "\r\n")); msg != "" {
+				problems = append(problems, Problem{Line: n, Message: msg})
+			}
+		}
+
+		switch {
+		case errors.Is(err, io.EOF):
+			return problems, nil
+		case err != nil:
+			return problems, err
+		}
+	}
+}
+
+// verifyRecord checks a single record and returns a description of the problem, if any
+func (v *Verifier) verifyRecord(line []byte) string {
+	if len(bytes.TrimSpace(line)) == 0 {
+		return ""
+	}
+
+	var fields struct {
+		Sequence     *uint64 `json:"Sequence"`
+		PreviousHash *string `json:"PreviousHash"`
+		Signature    *string `json:"Signature"`
+	}
+	if err := json.Unmarshal(line, &fields); err != nil || fields.Sequence == nil || fields.PreviousHash == nil {
+		return "not a chained record"
+	}
+
+	seq, prev := *fields.Sequence, *fields.PreviousHash
+
+	var msg string
+	switch {
+	case !v.started:
+		v.first = seq
+		if seq == 1 && prev != "" {
+			msg = "the first record of the chain refers to a previous record"
+		}
+	case seq <= v.state.Sequence:
+		msg = fmt.Sprintf("sequence %d follows %d, records were duplicated or reordered", seq, v.state.Sequence)
+	case seq > v.state.Sequence+1:
+		msg = fmt.Sprintf("sequence gap, %d record(s) missing between %d and %d", seq-v.state.Sequence-1, v.state.Sequence, seq)
+	case prev != v.state.Hash:
+		msg = fmt.Sprintf("hash of the previous record %d doesn't match, it was modified", v.state.Sequence)
+	}
+
+	if len(v.key) > 0 && msg == "" {
+		msg = v.verifySignature(line, fields.Signature)
+	}
+
+	v.started = true
+	v.records++
+	v.state = State{Sequence: seq, Hash: hash(line)}
+	return msg
+}
+
+func (v *Verifier) verifySignature(line []byte, signature *string) string {
+	if signature == nil {
+		return "the record is not signed"
+	}
+
+	i := bytes.LastIndex(line, []byte(fmt.Sprintf(`,"%s":"`, FieldSignature)))
+	if i < 0 || !hmac.Equal([]byte(sign(v.key, line[:i])), []byte(*signature)) {
+		return "invalid signature, the record was modified"
+	}
+	return ""
+}
@@ -10,12 +10,16 @@ import (
 	"github.com/cs3org/reva/v2/pkg/events"
 	"github.com/cs3org/reva/v2/pkg/events/stream"
 	"github.com/go-micro/plugins/v4/events/natsjs"
+	"github.com/oklog/run"
 	"github.com/owncloud/ocis/v2/ocis-pkg/config/configlog"
 	ociscrypto "github.com/owncloud/ocis/v2/ocis-pkg/crypto"
+	ogrpc "github.com/owncloud/ocis/v2/ocis-pkg/service/grpc"
 	"github.com/owncloud/ocis/v2/services/audit/pkg/config"
 	"github.com/owncloud/ocis/v2/services/audit/pkg/config/parser"
 	"github.com/owncloud/ocis/v2/services/audit/pkg/logging"
+	"github.com/owncloud/ocis/v2/services/audit/pkg/server/http"
 	svc "github.com/owncloud/ocis/v2/services/audit/pkg/service"
+	"github.com/owncloud/ocis/v2/services/audit/pkg/store"
 	"github.com/owncloud/ocis/v2/services/audit/pkg/types"
 	"github.com/urfave/cli/v2"
 )
@@ -76,8 +80,53 @@ func Server(cfg *config.Config) *cli.Command {
 				return err
 			}
 
-			svc.AuditLoggerFromConfig(ctx, cfg.Auditlog, evts, logger)
-			return nil
+			gr := run.Group{}
+
+			var st *store.Store
+			if cfg.Store.Enabled {
+				st, err = store.New(cfg.Store.Path)
+				if err != nil {
+					return fmt.Errorf("could not open the audit store: %w", err)
+				}
+				defer st.Close()
+
+				if err := ogrpc.Configure(ogrpc.GetClientOptions(cfg.GRPCClientTLS)...); err != nil {
+					return err
+				}
+
+				server, err := http.Server(
+					http.Logger(logger),
+					http.Context(ctx),
+					http.Config(cfg),
+					http.Store(st),
+				)
+				if err != nil {
+					logger.Info().Err(err).Str("transport", "http").Msg("Failed to initialize server")
+					return err
+				}
+
+				gr.Add(server.Run, func(_ error) {
+					logger.Info().Str("server", "http").Msg("Shutting down server")
+					cancel()
+				})
+
+				if cfg.Store.MaxAge > 0 {
+					gr.Add(func() error {
+						svc.PurgeStore(ctx, st, cfg.Store.MaxAge, logger)
+						return nil
+					}, func(_ error) {
+						cancel()
+					})
+				}
+			}
+
+			gr.Add(func() error {
+				return svc.AuditLoggerFromConfig(ctx, cfg.Auditlog, evts, st, logger)
+			}, func(_ error) {
+				cancel()
+			})
+
+			return gr.Run()
 		},
 	}
 }
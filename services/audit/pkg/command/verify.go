@@ -0,0 +1,79 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/owncloud/ocis/v2/ocis-pkg/config/configlog"
+	"github.com/owncloud/ocis/v2/services/audit/pkg/chain"
+	"github.com/owncloud/ocis/v2/services/audit/pkg/config"
+	"github.com/owncloud/ocis/v2/services/audit/pkg/config/parser"
+	"github.com/urfave/cli/v2"
+)
+
+// Verify is the entrypoint for the verify command.
+func Verify(cfg *config.Config) *cli.Command {
+	return &cli.Command{
+		Name:      "verify",
+		Usage:     "verify the hash chain of audit log files",
+		Category:  "audit log management",
+		ArgsUsage: "<file> [<file>...]",
+		Description: "Checks that the records of the given files form an unbroken hash chain. Rotated files must be given in the order they were written, oldest first.\n" +
+			"Records are checked against the HMAC key of the configuration (AUDIT_CHAIN_HMAC_KEY) unless --hmac-key is set.",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "hmac-key",
+				Usage: "the key the records were signed with",
+			},
+		},
+		Before: func(c *cli.Context) error {
+			return configlog.ReturnFatal(parser.ParseChainConfig(cfg))
+		},
+		Action: func(c *cli.Context) error {
+			if c.NArg() == 0 {
+				return errors.New("at least one audit log file is required")
+			}
+
+			key := cfg.Auditlog.Chain.HMACKey
+			if c.IsSet("hmac-key") {
+				key = c.String("hmac-key")
+			}
+
+			v := chain.NewVerifier(key)
+			problems := 0
+			for _, name := range c.Args().Slice() {
+				f, err := os.Open(name)
+				if err != nil {
+					return err
+				}
+
+				p, err := v.Verify(f)
+				f.Close()
+				if err != nil {
+					return fmt.Errorf("error reading '%s': %w", name, err)
+				}
+
+				for _, problem := range p {
+					fmt.Printf("%s:%d: %s\n", name, problem.Line, problem.Message)
+				}
+				problems += len(p)
+			}
+
+			first, last := v.Range()
+			fmt.Printf("verified %d records, sequence %d to %d\n", v.Records(), first, last)
+			if key == "" {
+				fmt.Println("records were not checked for signatures, the last record can't be verified without an HMAC key")
+			}
+			if first > 1 {
+				fmt.Printf("the chain starts at sequence %d, earlier records were not verified\n", first)
+			}
+
+			if problems > 0 {
+				return fmt.Errorf("the audit log is not intact, found %d problem(s)", problems)
+			}
+			fmt.Println("the audit log is intact")
+			return nil
+		},
+	}
+}
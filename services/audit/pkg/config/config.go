@@ -2,6 +2,7 @@ package config
 
 import (
 	"context"
+	"time"
 
 	"github.com/owncloud/ocis/v2/ocis-pkg/shared"
 )
@@ -17,6 +18,11 @@ type Config struct {
 
 	Events   Events   `yaml:"events"`
 	Auditlog Auditlog `yaml:"auditlog"`
+	Store    Store    `yaml:"store"`
+	API      API      `yaml:"api"`
+
+	TokenManager  *TokenManager         `yaml:"token_manager"`
+	GRPCClientTLS *shared.GRPCClientTLS `yaml:"grpc_client_tls"`
 
 	Context context.Context `yaml:"-"`
 }
@@ -36,5 +42,67 @@ type Auditlog struct {
 	LogToConsole bool   `yaml:"log_to_console" env:"AUDIT_LOG_TO_CONSOLE" desc:"Logs to Stdout if true. Independent of the log to file option."`
 	LogToFile    bool   `yaml:"log_to_file" env:"AUDIT_LOG_TO_FILE" desc:"Logs to file if true. Independent of the log to Stdout file option."`
 	FilePath     string `yaml:"filepath" env:"AUDIT_FILEPATH" desc:"Filepath to the logfile. Mandatory if LogToFile is true."`
-	Format       string `yaml:"format" env:"AUDIT_FORMAT" desc:"Log format. Using json is advised."`
+	Format       string `yaml:"format" env:"AUDIT_FORMAT" desc:"Log format. Supported values are 'json', 'minimal', 'cef' (ArcSight Common Event Format) and 'ocsf' (Open Cybersecurity Schema Framework). Using json is advised."`
+
+	File   File   `yaml:"file"`
+	Syslog Syslog `yaml:"syslog"`
+	HTTP   HTTP   `yaml:"http"`
+	Chain  Chain  `yaml:"chain"`
+}
+
+// File configures the rotation of the audit log file
+type File struct {
+	MaxSize          int           `yaml:"max_size" env:"AUDIT_FILE_MAX_SIZE" desc:"The size in megabytes after which the logfile is rotated. 0 disables size based rotation."`
+	RotationInterval time.Duration `yaml:"rotation_interval" env:"AUDIT_FILE_ROTATION_INTERVAL" desc:"The interval after which the logfile is rotated, like 24h for a daily rotation. Intervals are aligned to UTC. The duration can be set as number followed by a unit identifier like s, m or h. 0 disables time based rotation."`
+	MaxBackups       int           `yaml:"max_backups" env:"AUDIT_FILE_MAX_BACKUPS" desc:"The maximum number of rotated logfiles to keep. 0 keeps all rotated logfiles."`
+	MaxAge           time.Duration `yaml:"max_age" env:"AUDIT_FILE_MAX_AGE" desc:"The maximum age of rotated logfiles before they are deleted. The duration can be set as number followed by a unit identifier like s, m or h. 0 keeps rotated logfiles regardless of their age."`
+}
+
+// Syslog configures sending audit events to syslog
+type Syslog struct {
+	Enabled  bool   `yaml:"enabled" env:"AUDIT_LOG_TO_SYSLOG" desc:"Logs to syslog using the RFC 5424 message format if true."`
+	Network  string `yaml:"network" env:"AUDIT_SYSLOG_NETWORK" desc:"The network used to connect to syslog. Supported values are 'udp', 'tcp' or an empty string to use the local syslog socket."`
+	Address  string `yaml:"address" env:"AUDIT_SYSLOG_ADDRESS" desc:"The address of the syslog server, like 'syslog.example.com:514'. Mandatory if AUDIT_SYSLOG_NETWORK is set. The path of the local syslog socket otherwise, which is detected automatically if empty."`
+	Facility string `yaml:"facility" env:"AUDIT_SYSLOG_FACILITY" desc:"The syslog facility of the messages, like 'auth', 'authpriv' or 'local0' to 'local7'."`
+	AppName  string `yaml:"app_name" env:"AUDIT_SYSLOG_APP_NAME" desc:"The APP-NAME of the syslog messages."`
+}
+
+// HTTP configures sending batches of audit events to an HTTP endpoint
+type HTTP struct {
+	Enabled       bool          `yaml:"enabled" env:"AUDIT_LOG_TO_HTTP" desc:"Sends audit events to an HTTP endpoint if true."`
+	URL           string        `yaml:"url" env:"AUDIT_HTTP_URL" desc:"The URL the audit events are posted to. Mandatory if AUDIT_LOG_TO_HTTP is true."`
+	Authorization string        `yaml:"authorization" env:"AUDIT_HTTP_AUTHORIZATION" desc:"The value of the Authorization header sent with every request, like 'Bearer <token>'."`
+	Insecure      bool          `yaml:"insecure" env:"OCIS_INSECURE;AUDIT_HTTP_INSECURE" desc:"Whether to skip the verification of the TLS certificate of the HTTP endpoint."`
+	BatchSize     int           `yaml:"batch_size" env:"AUDIT_HTTP_BATCH_SIZE" desc:"The maximum number of audit events sent with one request. Events are separated by newlines."`
+	FlushInterval time.Duration `yaml:"flush_interval" env:"AUDIT_HTTP_FLUSH_INTERVAL" desc:"The maximum time audit events are collected before they are sent. The duration can be set as number followed by a unit identifier like s, m or h."`
+	Timeout       time.Duration `yaml:"timeout" env:"AUDIT_HTTP_TIMEOUT" desc:"The timeout of a single request. The duration can be set as number followed by a unit identifier like s, m or h."`
+	MaxRetries    int           `yaml:"max_retries" env:"AUDIT_HTTP_MAX_RETRIES" desc:"The number of times a failed request is retried with an increasing delay before the batch of audit events is dropped."`
+	QueueSize     int           `yaml:"queue_size" env:"AUDIT_HTTP_QUEUE_SIZE" desc:"The maximum number of audit events waiting to be sent. Further events are dropped while the queue is full, e.g. while the HTTP endpoint is unreachable."`
+}
+
+// Chain configures the tamper-evident hash chain of audit records
+type Chain struct {
+	Enabled   bool   `yaml:"enabled" env:"AUDIT_CHAIN_ENABLED" desc:"Adds a sequence number and the hash of the previous record to every audit record if true. Requires the 'json' or 'ocsf' format. Use 'ocis audit verify' to check the chain."`
+	HMACKey   string `yaml:"hmac_key" env:"AUDIT_CHAIN_HMAC_KEY" desc:"A secret key used to sign every audit record with HMAC-SHA256. Records are not signed if empty."`
+	StateFile string `yaml:"state_file" env:"AUDIT_CHAIN_STATE_FILE" desc:"The file storing the sequence number and hash of the last record, so that the chain is continued after a restart."`
+}
+
+// Store configures the store which makes audit events queryable
+type Store struct {
+	Enabled bool          `yaml:"enabled" env:"AUDIT_STORE_ENABLED" desc:"Keeps audit events in an embedded index if true, so that they can be queried with the HTTP API."`
+	Path    string        `yaml:"path" env:"AUDIT_STORE_PATH" desc:"The directory of the index."`
+	MaxAge  time.Duration `yaml:"max_age" env:"AUDIT_STORE_MAX_AGE" desc:"The maximum age of audit events in the store before they are removed. The duration can be set as number followed by a unit identifier like s, m or h. 0 keeps all audit events."`
+}
+
+// API configures the HTTP API to query the store
+type API struct {
+	Addr      string                `yaml:"addr" env:"AUDIT_API_ADDR" desc:"The bind address of the HTTP API. The API is only started if AUDIT_STORE_ENABLED is true."`
+	TLS       shared.HTTPServiceTLS `yaml:"tls"`
+	Root      string                `yaml:"root" env:"AUDIT_API_ROOT" desc:"Subdirectory that serves as the root for the HTTP API."`
+	Namespace string                `yaml:"-"`
+}
+
+// TokenManager is the config for using the reva token manager
+type TokenManager struct {
+	JWTSecret string `yaml:"jwt_secret" env:"OCIS_JWT_SECRET;AUDIT_JWT_SECRET" desc:"The secret to mint and validate jwt tokens."`
 }
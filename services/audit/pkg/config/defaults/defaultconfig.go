@@ -1,6 +1,12 @@
 package defaults
 
 import (
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/owncloud/ocis/v2/ocis-pkg/config/defaults"
+	"github.com/owncloud/ocis/v2/ocis-pkg/shared"
 	"github.com/owncloud/ocis/v2/services/audit/pkg/config"
 )
 
@@ -30,6 +36,28 @@ func DefaultConfig() *config.Config {
 		Auditlog: config.Auditlog{
 			LogToConsole: true,
 			Format:       "json",
+			Syslog: config.Syslog{
+				Facility: "local0",
+				AppName:  "ocis",
+			},
+			HTTP: config.HTTP{
+				BatchSize:     100,
+				FlushInterval: 5 * time.Second,
+				Timeout:       10 * time.Second,
+				MaxRetries:    5,
+				QueueSize:     10000,
+			},
+			Chain: config.Chain{
+				StateFile: filepath.Join(defaults.BaseDataPath(), "audit", "chain.json"),
+			},
+		},
+		Store: config.Store{
+			Path: filepath.Join(defaults.BaseDataPath(), "audit", "store"),
+		},
+		API: config.API{
+			Addr:      "127.0.0.1:9260",
+			Root:      "/api/v0/audit",
+			Namespace: "com.owncloud.web",
 		},
 	}
 }
@@ -47,9 +75,32 @@ func EnsureDefaults(cfg *config.Config) {
 	} else if cfg.Log == nil {
 		cfg.Log = &config.Log{}
 	}
+
+	if cfg.TokenManager == nil && cfg.Commons != nil && cfg.Commons.TokenManager != nil {
+		cfg.TokenManager = &config.TokenManager{
+			JWTSecret: cfg.Commons.TokenManager.JWTSecret,
+		}
+	} else if cfg.TokenManager == nil {
+		cfg.TokenManager = &config.TokenManager{}
+	}
+
+	if cfg.GRPCClientTLS == nil {
+		cfg.GRPCClientTLS = &shared.GRPCClientTLS{}
+		if cfg.Commons != nil && cfg.Commons.GRPCClientTLS != nil {
+			cfg.GRPCClientTLS.Mode = cfg.Commons.GRPCClientTLS.Mode
+			cfg.GRPCClientTLS.CACert = cfg.Commons.GRPCClientTLS.CACert
+		}
+	}
+
+	if cfg.Commons != nil {
+		cfg.API.TLS = cfg.Commons.HTTPServiceTLS
+	}
 }
 
 // Sanitize sanitized the configuration
 func Sanitize(cfg *config.Config) {
 	// sanitize config
+	if cfg.API.Root != "/" {
+		cfg.API.Root = strings.TrimSuffix(cfg.API.Root, "/")
+	}
 }
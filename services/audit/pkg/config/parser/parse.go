@@ -2,8 +2,10 @@ package parser
 
 import (
 	"errors"
+	"fmt"
 
 	ociscfg "github.com/owncloud/ocis/v2/ocis-pkg/config"
+	"github.com/owncloud/ocis/v2/ocis-pkg/shared"
 	"github.com/owncloud/ocis/v2/services/audit/pkg/config"
 	"github.com/owncloud/ocis/v2/services/audit/pkg/config/defaults"
 
@@ -32,7 +34,61 @@ func ParseConfig(cfg *config.Config) error {
 	return Validate(cfg)
 }
 
+// ParseChainConfig loads only the hash chain configuration, which is all the verify command needs. Unlike
+// ParseConfig it doesn't fail on settings which are only needed to run the service.
+func ParseChainConfig(cfg *config.Config) error {
+	_, err := ociscfg.BindSourcesToStructs(cfg.Service.Name, cfg)
+	if err != nil {
+		return err
+	}
+
+	if err := envdecode.Decode(&cfg.Auditlog.Chain); err != nil {
+		// no environment variable set for this config is an expected "error"
+		if !errors.Is(err, envdecode.ErrNoTargetFieldsAreSet) {
+			return err
+		}
+	}
+	return nil
+}
+
 // Validate validates the configuration
 func Validate(cfg *config.Config) error {
+	if cfg.Auditlog.LogToFile && cfg.Auditlog.FilePath == "" {
+		return fmt.Errorf("AUDIT_FILEPATH is mandatory when logging to a file")
+	}
+
+	if cfg.Auditlog.Syslog.Enabled {
+		switch cfg.Auditlog.Syslog.Network {
+		case "":
+		case "udp", "tcp":
+			if cfg.Auditlog.Syslog.Address == "" {
+				return fmt.Errorf("AUDIT_SYSLOG_ADDRESS is mandatory when using the syslog network '%s'", cfg.Auditlog.Syslog.Network)
+			}
+		default:
+			return fmt.Errorf("unknown syslog network '%s'", cfg.Auditlog.Syslog.Network)
+		}
+	}
+
+	if cfg.Auditlog.HTTP.Enabled {
+		if cfg.Auditlog.HTTP.URL == "" {
+			return fmt.Errorf("AUDIT_HTTP_URL is mandatory when logging to an HTTP endpoint")
+		}
+		if cfg.Auditlog.HTTP.BatchSize < 1 {
+			return fmt.Errorf("AUDIT_HTTP_BATCH_SIZE must be at least 1")
+		}
+	}
+
+	if cfg.Auditlog.Chain.Enabled && cfg.Auditlog.Format != "json" && cfg.Auditlog.Format != "ocsf" {
+		return fmt.Errorf("AUDIT_CHAIN_ENABLED requires the format 'json' or 'ocsf', got '%s'", cfg.Auditlog.Format)
+	}
+
+	if cfg.Store.Enabled {
+		if cfg.Store.Path == "" {
+			return fmt.Errorf("AUDIT_STORE_PATH is mandatory when the store is enabled")
+		}
+		if cfg.TokenManager.JWTSecret == "" {
+			return shared.MissingJWTTokenError(cfg.Service.Name)
+		}
+	}
 	return nil
 }
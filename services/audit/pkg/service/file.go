@@ -0,0 +1,199 @@
+package svc
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/owncloud/ocis/v2/ocis-pkg/log"
+	"github.com/owncloud/ocis/v2/services/audit/pkg/config"
+)
+
+// _backupTimeFormat is used in the names of rotated files. It sorts lexically and contains no colons.
+const _backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// WriteToFile returns a Log function writing to a file
+func WriteToFile(path string, log log.Logger) Log {
+	l, _ := WriteToRotatingFile(path, config.File{}, log)
+	return l
+}
+
+// WriteToRotatingFile returns a Log function writing to a file which is rotated by size or time.
+// Rotated files are renamed to "<path>.<timestamp>" and deleted according to the retention settings.
+// The returned function closes the file.
+func WriteToRotatingFile(path string, cfg config.File, log log.Logger) (Log, func()) {
+	f := &rotatingFile{
+		path:       path,
+		maxSize:    int64(cfg.MaxSize) * 1024 * 1024,
+		interval:   cfg.RotationInterval,
+		maxBackups: cfg.MaxBackups,
+		maxAge:     cfg.MaxAge,
+		now:        time.Now,
+	}
+
+	write := func(content []byte) {
+		if err := f.write(content); err != nil {
+			log.Error().Err(err).Msgf("error writing to file '%s'", path)
+		}
+	}
+	closeFile := func() {
+		if err := f.close(); err != nil {
+			log.Error().Err(err).Msgf("error closing file '%s'", path)
+		}
+	}
+	return write, closeFile
+}
+
+type rotatingFile struct {
+	path       string
+	maxSize    int64
+	interval   time.Duration
+	maxBackups int
+	maxAge     time.Duration
+	now        func() time.Time
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	period time.Time
+}
+
+func (f *rotatingFile) write(content []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return err
+		}
+	}
+
+	l := int64(len(content)) + 1
+	if f.size == 0 {
+		// an empty file always belongs to the current period
+		f.period = f.periodOf(f.now())
+	}
+	if f.needsRotation(l) {
+		if err := f.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := fmt.Fprintln(f.file, string(content))
+	f.size += int64(n)
+	return err
+}
+
+func (f *rotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("error opening file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	f.file = file
+	f.size = info.Size()
+	f.period = f.periodOf(f.now())
+	if f.size > 0 {
+		// an existing file belongs to the period it was written last
+		f.period = f.periodOf(info.ModTime())
+	}
+	return nil
+}
+
+func (f *rotatingFile) needsRotation(l int64) bool {
+	if f.size == 0 {
+		return false
+	}
+	if f.maxSize > 0 && f.size+l > f.maxSize {
+		return true
+	}
+	return f.interval > 0 && f.periodOf(f.now()).After(f.period)
+}
+
+// periodOf returns the start of the rotation interval t belongs to
+func (f *rotatingFile) periodOf(t time.Time) time.Time {
+	if f.interval <= 0 {
+		return time.Time{}
+	}
+	return t.UTC().Truncate(f.interval)
+}
+
+func (f *rotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	f.file = nil
+
+	backup := f.path + "." + f.now().UTC().Format(_backupTimeFormat)
+	if err := os.Rename(f.path, backup); err != nil {
+		return fmt.Errorf("error rotating file: %w", err)
+	}
+
+	if err := f.open(); err != nil {
+		return err
+	}
+	return f.cleanup()
+}
+
+// cleanup deletes rotated files exceeding the retention settings
+func (f *rotatingFile) cleanup() error {
+	if f.maxBackups <= 0 && f.maxAge <= 0 {
+		return nil
+	}
+
+	matches, err := filepath.Glob(f.path + ".*")
+	if err != nil {
+		return err
+	}
+
+	var backups []string
+	for _, m := range matches {
+		if _, err := time.Parse(_backupTimeFormat, strings.TrimPrefix(m, f.path+".")); err == nil {
+			backups = append(backups, m)
+		}
+	}
+	// newest first
+	sort.Sort(sort.Reverse(sort.StringSlice(backups)))
+
+	var errs []string
+	for i, b := range backups {
+		remove := f.maxBackups > 0 && i >= f.maxBackups
+		if !remove && f.maxAge > 0 {
+			info, err := os.Stat(b)
+			remove = err == nil && f.now().Sub(info.ModTime()) > f.maxAge
+		}
+
+		if remove {
+			if err := os.Remove(b); err != nil {
+				errs = append(errs, err.Error())
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("error deleting rotated files: %s", strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+func (f *rotatingFile) close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
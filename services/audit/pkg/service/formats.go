@@ -0,0 +1,443 @@
+package svc
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/owncloud/ocis/v2/ocis-pkg/version"
+	"github.com/owncloud/ocis/v2/services/audit/pkg/types"
+)
+
+const (
+	_vendor  = "ownCloud"
+	_product = "Infinite Scale"
+
+	// _ocsfVersion is the version of the OCSF schema the events are mapped to
+	_ocsfVersion = "1.1.0"
+)
+
+// OCSF categories and classes used for audit events
+const (
+	_ocsfCategorySystem = 1
+	_ocsfCategoryIAM    = 3
+
+	_ocsfClassBase                 = 0
+	_ocsfClassFileActivity         = 1001
+	_ocsfClassAccountChange        = 3001
+	_ocsfClassAuthentication       = 3002
+	_ocsfClassEntityManagement     = 3004
+	_ocsfClassUserAccessManagement = 3005
+	_ocsfClassGroupManagement      = 3006
+)
+
+// OCSF status ids
+const (
+	_ocsfStatusSuccess = 1
+	_ocsfStatusFailure = 2
+)
+
+var _ocsfClasses = map[int]struct {
+	name     string
+	category int
+}{
+	_ocsfClassBase:                 {"Base Event", 0},
+	_ocsfClassFileActivity:         {"File System Activity", _ocsfCategorySystem},
+	_ocsfClassAccountChange:        {"Account Change", _ocsfCategoryIAM},
+	_ocsfClassAuthentication:       {"Authentication", _ocsfCategoryIAM},
+	_ocsfClassEntityManagement:     {"Entity Management", _ocsfCategoryIAM},
+	_ocsfClassUserAccessManagement: {"User Access Management", _ocsfCategoryIAM},
+	_ocsfClassGroupManagement:      {"Group Management", _ocsfCategoryIAM},
+}
+
+var _ocsfCategories = map[int]string{
+	0:                   "Uncategorized",
+	_ocsfCategorySystem: "System Activity",
+	_ocsfCategoryIAM:    "Identity & Access Management",
+}
+
+// actionMapping describes how an audit action is represented in CEF and OCSF
+type actionMapping struct {
+	severity int    // CEF severity from 0 (lowest) to 10 (highest)
+	class    int    // OCSF class_uid
+	activity int    // OCSF activity_id
+	name     string // OCSF activity_name
+}
+
+var _defaultMapping = actionMapping{severity: 3, class: _ocsfClassBase, activity: 99, name: "Other"}
+
+var _actionMappings = map[string]actionMapping{
+	// sharing changes the permissions of a file
+	types.ActionShareCreated:            {3, _ocsfClassFileActivity, 7, "Set Security"},
+	types.ActionSharePermissionUpdated:  {3, _ocsfClassFileActivity, 7, "Set Security"},
+	types.ActionShareDisplayNameUpdated: {3, _ocsfClassFileActivity, 7, "Set Security"},
+	types.ActionSharePasswordUpdated:    {3, _ocsfClassFileActivity, 7, "Set Security"},
+	types.ActionShareExpirationUpdated:  {3, _ocsfClassFileActivity, 7, "Set Security"},
+	types.ActionShareRemoved:            {5, _ocsfClassFileActivity, 7, "Set Security"},
+	types.ActionShareAccepted:           {3, _ocsfClassFileActivity, 12, "Mount"},
+	types.ActionShareDeclined:           {3, _ocsfClassFileActivity, 13, "Unmount"},
+	types.ActionLinkAccessed:            {3, _ocsfClassFileActivity, 14, "Open"},
+
+	types.ActionContainerCreated:    {3, _ocsfClassFileActivity, 1, "Create"},
+	types.ActionFileCreated:         {3, _ocsfClassFileActivity, 1, "Create"},
+	types.ActionFileRead:            {3, _ocsfClassFileActivity, 2, "Read"},
+	types.ActionFileTrashed:         {5, _ocsfClassFileActivity, 4, "Delete"},
+	types.ActionFileRenamed:         {3, _ocsfClassFileActivity, 5, "Rename"},
+	types.ActionFilePurged:          {5, _ocsfClassFileActivity, 4, "Delete"},
+	types.ActionFileRestored:        {3, _ocsfClassFileActivity, 99, "Other"},
+	types.ActionFileVersionRestored: {3, _ocsfClassFileActivity, 3, "Update"},
+
+	types.ActionSpaceCreated:  {3, _ocsfClassEntityManagement, 1, "Create"},
+	types.ActionSpaceRenamed:  {3, _ocsfClassEntityManagement, 3, "Update"},
+	types.ActionSpaceDisabled: {5, _ocsfClassEntityManagement, 9, "Disable"},
+	types.ActionSpaceEnabled:  {3, _ocsfClassEntityManagement, 8, "Enable"},
+	types.ActionSpaceDeleted:  {7, _ocsfClassEntityManagement, 4, "Delete"},
+	types.ActionSpaceShared:   {3, _ocsfClassEntityManagement, 3, "Update"},
+	types.ActionSpaceUnshared: {5, _ocsfClassEntityManagement, 3, "Update"},
+	types.ActionSpaceUpdated:  {3, _ocsfClassEntityManagement, 3, "Update"},
+
+	types.ActionUserCreated:         {5, _ocsfClassAccountChange, 1, "Create"},
+	types.ActionUserDeleted:         {7, _ocsfClassAccountChange, 6, "Delete"},
+	types.ActionUserFeatureChanged:  {5, _ocsfClassAccountChange, 99, "Other"},
+	types.ActionUserPasswordChanged: {5, _ocsfClassAccountChange, 3, "Password Change"},
+	types.ActionUserRoleAssigned:    {7, _ocsfClassUserAccessManagement, 1, "Assign Privileges"},
+	types.ActionUserRoleUnassigned:  {7, _ocsfClassUserAccessManagement, 2, "Revoke Privileges"},
+
+	types.ActionUserLogin:       {3, _ocsfClassAuthentication, 1, "Logon"},
+	types.ActionUserLoginFailed: {5, _ocsfClassAuthentication, 1, "Logon"},
+
+	types.ActionGroupCreated:       {5, _ocsfClassGroupManagement, 6, "Create"},
+	types.ActionGroupDeleted:       {7, _ocsfClassGroupManagement, 5, "Delete"},
+	types.ActionGroupMemberAdded:   {5, _ocsfClassGroupManagement, 3, "Add User"},
+	types.ActionGroupMemberRemoved: {5, _ocsfClassGroupManagement, 4, "Remove User"},
+}
+
+func mappingFor(action string) actionMapping {
+	if m, ok := _actionMappings[action]; ok {
+		return m
+	}
+	return _defaultMapping
+}
+
+// auditFields gives access to the fields of the different audit event types
+type auditFields map[string]interface{}
+
+func toAuditFields(ev interface{}) (auditFields, error) {
+	b, err := json.Marshal(ev)
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(auditFields)
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (f auditFields) str(key string) string {
+	switch v := f[key].(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// succeeded returns false for events which record a failed action
+func (f auditFields) succeeded() bool {
+	if f.str("Action") == types.ActionUserLoginFailed {
+		return false
+	}
+	s, ok := f["Success"].(bool)
+	return !ok || s
+}
+
+// time returns the time of the event or the current time if the event has none
+func (f auditFields) time() time.Time {
+	if t, err := time.Parse(time.RFC3339, f.str("Time")); err == nil {
+		return t
+	}
+	return time.Now()
+}
+
+// grantee returns the user or group which is the target of the action
+func (f auditFields) grantee() string {
+	for _, k := range []string{"ShareWith", "GranteeUserID", "GranteeGroupID", "UserID", "GroupID"} {
+		if v := f.str(k); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// marshalCEF marshals an audit event to the ArcSight Common Event Format
+func marshalCEF(ev interface{}) ([]byte, error) {
+	f, err := toAuditFields(ev)
+	if err != nil {
+		return nil, err
+	}
+
+	action := f.str("Action")
+	m := mappingFor(action)
+	if !f.succeeded() && m.severity < 5 {
+		m.severity = 5
+	}
+
+	ext := []cefField{
+		{"rt", fmt.Sprint(f.time().UnixMilli())},
+		{"act", action},
+		{"msg", f.str("Message")},
+		{"suser", f.str("User")},
+		{"duser", f.grantee()},
+		{"src", f.str("RemoteAddr")},
+		{"request", f.str("URL")},
+		{"requestMethod", f.str("Method")},
+		{"requestClientApplication", f.str("UserAgent")},
+		{"outcome", outcome(f.succeeded())},
+		{"filePath", f.str("Path")},
+		{"fileId", f.str("FileID")},
+		{"oldFilePath", f.str("OldPath")},
+	}
+
+	// custom strings need a label to be understood
+	custom := []struct{ label, value string }{
+		{"spaceId", f.str("SpaceID")},
+		{"shareId", f.str("ShareID")},
+		{"owner", f.str("Owner")},
+		{"shareType", f.str("ShareType")},
+	}
+	for i, c := range custom {
+		if c.value != "" {
+			ext = append(ext,
+				cefField{fmt.Sprintf("cs%dLabel", i+1), c.label},
+				cefField{fmt.Sprintf("cs%d", i+1), c.value},
+			)
+		}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "CEF:0|%s|%s|%s|%s|%s|%d|",
+		cefHeader(_vendor), cefHeader(_product), cefHeader(version.GetString()),
+		cefHeader(action), cefHeader(f.str("Message")), m.severity,
+	)
+
+	var pairs []string
+	for _, e := range ext {
+		if e.value != "" {
+			pairs = append(pairs, e.key+"="+cefExtension(e.value))
+		}
+	}
+	sb.WriteString(strings.Join(pairs, " "))
+	return []byte(sb.String()), nil
+}
+
+// cefField is a key value pair of the CEF extension
+type cefField struct {
+	key, value string
+}
+
+var (
+	_cefHeaderEscaper    = strings.NewReplacer(`\`, `\\`, `|`, `\|`, "\r", " ", "\n", " ")
+	_cefExtensionEscaper = strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\r\n", `\n`, "\r", `\r`, "\n", `\n`)
+)
+
+func cefHeader(s string) string {
+	return _cefHeaderEscaper.Replace(s)
+}
+
+func cefExtension(s string) string {
+	return _cefExtensionEscaper.Replace(s)
+}
+
+func outcome(success bool) string {
+	if success {
+		return "success"
+	}
+	return "failure"
+}
+
+// ocsfEvent is an audit event in the OCSF schema. See https://schema.ocsf.io
+type ocsfEvent struct {
+	Time         int64                  `json:"time"`
+	ClassUID     int                    `json:"class_uid"`
+	ClassName    string                 `json:"class_name"`
+	CategoryUID  int                    `json:"category_uid"`
+	CategoryName string                 `json:"category_name"`
+	ActivityID   int                    `json:"activity_id"`
+	ActivityName string                 `json:"activity_name"`
+	TypeUID      int                    `json:"type_uid"`
+	SeverityID   int                    `json:"severity_id"`
+	Severity     string                 `json:"severity"`
+	StatusID     int                    `json:"status_id"`
+	Status       string                 `json:"status"`
+	Message      string                 `json:"message,omitempty"`
+	Metadata     ocsfMetadata           `json:"metadata"`
+	Actor        *ocsfActor             `json:"actor,omitempty"`
+	SrcEndpoint  *ocsfEndpoint          `json:"src_endpoint,omitempty"`
+	HTTPRequest  *ocsfHTTPRequest       `json:"http_request,omitempty"`
+	File         *ocsfFile              `json:"file,omitempty"`
+	User         *ocsfUser              `json:"user,omitempty"`
+	Group        *ocsfGroup             `json:"group,omitempty"`
+	Entity       *ocsfEntity            `json:"entity,omitempty"`
+	Unmapped     map[string]interface{} `json:"unmapped,omitempty"`
+}
+
+type ocsfMetadata struct {
+	Version      string      `json:"version"`
+	Product      ocsfProduct `json:"product"`
+	EventCode    string      `json:"event_code,omitempty"`
+	OriginalTime string      `json:"original_time,omitempty"`
+}
+
+type ocsfProduct struct {
+	Name       string `json:"name"`
+	VendorName string `json:"vendor_name"`
+	Version    string `json:"version"`
+}
+
+type ocsfActor struct {
+	User *ocsfUser `json:"user,omitempty"`
+}
+
+type ocsfUser struct {
+	UID  string `json:"uid"`
+	Name string `json:"name,omitempty"`
+}
+
+type ocsfGroup struct {
+	UID string `json:"uid"`
+}
+
+type ocsfEndpoint struct {
+	IP string `json:"ip"`
+}
+
+type ocsfHTTPRequest struct {
+	URL        *ocsfURL `json:"url,omitempty"`
+	HTTPMethod string   `json:"http_method,omitempty"`
+	UserAgent  string   `json:"user_agent,omitempty"`
+}
+
+type ocsfURL struct {
+	URLString string `json:"url_string"`
+}
+
+type ocsfFile struct {
+	UID   string    `json:"uid,omitempty"`
+	Name  string    `json:"name,omitempty"`
+	Path  string    `json:"path,omitempty"`
+	Type  string    `json:"type"`
+	Owner *ocsfUser `json:"owner,omitempty"`
+}
+
+type ocsfEntity struct {
+	UID  string `json:"uid"`
+	Name string `json:"name,omitempty"`
+	Type string `json:"type"`
+}
+
+// fields of the audit events which are mapped to OCSF attributes and left out of the unmapped attributes
+var _ocsfMappedFields = map[string]bool{
+	"RemoteAddr": true, "User": true, "URL": true, "Method": true, "UserAgent": true, "Time": true,
+	"App": true, "Message": true, "Action": true, "Level": true, "CLI": true,
+}
+
+// marshalOCSF marshals an audit event to the OCSF json schema
+func marshalOCSF(ev interface{}) ([]byte, error) {
+	f, err := toAuditFields(ev)
+	if err != nil {
+		return nil, err
+	}
+
+	action := f.str("Action")
+	m := mappingFor(action)
+	class := _ocsfClasses[m.class]
+
+	o := ocsfEvent{
+		Time:         f.time().UnixMilli(),
+		ClassUID:     m.class,
+		ClassName:    class.name,
+		CategoryUID:  class.category,
+		CategoryName: _ocsfCategories[class.category],
+		ActivityID:   m.activity,
+		ActivityName: m.name,
+		TypeUID:      m.class*100 + m.activity,
+		SeverityID:   1,
+		Severity:     "Informational",
+		StatusID:     _ocsfStatusSuccess,
+		Status:       "Success",
+		Message:      f.str("Message"),
+		Metadata: ocsfMetadata{
+			Version: _ocsfVersion,
+			Product: ocsfProduct{
+				Name:       _product,
+				VendorName: _vendor,
+				Version:    version.GetString(),
+			},
+			EventCode:    action,
+			OriginalTime: f.str("Time"),
+		},
+	}
+
+	if !f.succeeded() {
+		o.StatusID, o.Status = _ocsfStatusFailure, "Failure"
+	}
+	if u := f.str("User"); u != "" {
+		o.Actor = &ocsfActor{User: &ocsfUser{UID: u}}
+	}
+	if ip := f.str("RemoteAddr"); ip != "" {
+		o.SrcEndpoint = &ocsfEndpoint{IP: ip}
+	}
+	if f.str("URL") != "" || f.str("Method") != "" || f.str("UserAgent") != "" {
+		o.HTTPRequest = &ocsfHTTPRequest{HTTPMethod: f.str("Method"), UserAgent: f.str("UserAgent")}
+		if u := f.str("URL"); u != "" {
+			o.HTTPRequest.URL = &ocsfURL{URLString: u}
+		}
+	}
+
+	switch m.class {
+	case _ocsfClassFileActivity:
+		o.File = &ocsfFile{UID: f.str("FileID"), Path: f.str("Path"), Type: "File"}
+		if o.File.Path != "" {
+			o.File.Name = path.Base(o.File.Path)
+		}
+		if f.str("ItemType") == "folder" || action == types.ActionContainerCreated {
+			o.File.Type = "Folder"
+		}
+		if owner := f.str("Owner"); owner != "" {
+			o.File.Owner = &ocsfUser{UID: owner}
+		}
+	case _ocsfClassEntityManagement:
+		name := f.str("Name")
+		if name == "" {
+			name = f.str("NewName")
+		}
+		o.Entity = &ocsfEntity{UID: f.str("SpaceID"), Name: name, Type: "Space"}
+	case _ocsfClassAccountChange, _ocsfClassUserAccessManagement:
+		o.User = &ocsfUser{UID: f.str("UserID")}
+	case _ocsfClassAuthentication:
+		o.User = &ocsfUser{UID: f.str("UserID"), Name: f.str("Username")}
+	case _ocsfClassGroupManagement:
+		o.Group = &ocsfGroup{UID: f.str("GroupID")}
+		if u := f.str("UserID"); u != "" {
+			o.User = &ocsfUser{UID: u}
+		}
+	}
+
+	for k, v := range f {
+		if _ocsfMappedFields[k] || v == nil || v == "" {
+			continue
+		}
+		if o.Unmapped == nil {
+			o.Unmapped = make(map[string]interface{})
+		}
+		o.Unmapped[k] = v
+	}
+
+	return json.Marshal(o)
+}
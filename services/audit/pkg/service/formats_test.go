@@ -0,0 +1,126 @@
+package svc
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/owncloud/ocis/v2/services/audit/pkg/types"
+	"github.com/test-go/testify/require"
+)
+
+func TestMarshalCEF(t *testing.T) {
+	ev := types.AuditEventFileRenamed{
+		AuditEventFiles: types.AuditEventFiles{
+			AuditEvent: types.AuditEvent{
+				User:    "uid-123",
+				Time:    "2023-01-02T03:04:05Z",
+				App:     "admin_audit",
+				Message: "user 'uid-123' moved file 'item|1' from 'a=b' to 'c'",
+				Action:  types.ActionFileRenamed,
+				Level:   1,
+			},
+			Path:   "/c",
+			Owner:  "uid-456",
+			FileID: "item|1",
+		},
+		OldPath: "/a=b",
+	}
+
+	b, err := marshalCEF(ev)
+	require.NoError(t, err)
+
+	header := strings.SplitN(string(b), "|", 8)
+	require.Len(t, header, 8)
+	require.Equal(t, "CEF:0", header[0])
+	require.Equal(t, "ownCloud", header[1])
+	require.Equal(t, "Infinite Scale", header[2])
+	require.Equal(t, types.ActionFileRenamed, header[4])
+	require.True(t, strings.HasPrefix(header[5], `user 'uid-123' moved file 'item\`), header[5])
+
+	ext := string(b)[strings.Index(string(b), "rt="):]
+	require.Equal(t, `rt=1672628645000 act=file_rename msg=user 'uid-123' moved file 'item|1' from 'a\=b' to 'c' suser=uid-123 outcome=success filePath=/c fileId=item|1 oldFilePath=/a\=b cs3Label=owner cs3=uid-456`, ext)
+}
+
+func TestMarshalOCSF(t *testing.T) {
+	tests := []struct {
+		ev       interface{}
+		class    int
+		activity int
+		check    func(t *testing.T, o ocsfEvent)
+	}{
+		{
+			ev: types.AuditEventFileDeleted{
+				AuditEventFiles: types.AuditEventFiles{
+					AuditEvent: types.AuditEvent{User: "uid-123", Action: types.ActionFileTrashed},
+					Path:       "/folder/file.txt",
+					Owner:      "uid-456",
+					FileID:     "item-1",
+				},
+			},
+			class:    1001,
+			activity: 4,
+			check: func(t *testing.T, o ocsfEvent) {
+				require.Equal(t, "uid-123", o.Actor.User.UID)
+				require.Equal(t, &ocsfFile{UID: "item-1", Name: "file.txt", Path: "/folder/file.txt", Type: "File", Owner: &ocsfUser{UID: "uid-456"}}, o.File)
+			},
+		},
+		{
+			ev: types.AuditEventLinkAccessed{
+				AuditEventSharing: types.AuditEventSharing{
+					AuditEvent: types.AuditEvent{Action: types.ActionLinkAccessed},
+					FileID:     "item-1",
+				},
+				ShareToken: "token",
+				Success:    false,
+			},
+			class:    1001,
+			activity: 14,
+			check: func(t *testing.T, o ocsfEvent) {
+				require.Equal(t, _ocsfStatusFailure, o.StatusID)
+				require.Nil(t, o.Actor)
+				require.Equal(t, "token", o.Unmapped["ShareToken"])
+			},
+		},
+		{
+			ev: types.AuditEventSpaceCreated{
+				AuditEventSpaces: types.AuditEventSpaces{
+					AuditEvent: types.AuditEvent{User: "uid-123", Action: types.ActionSpaceCreated},
+					SpaceID:    "space-1",
+				},
+				Name: "Project",
+			},
+			class:    3004,
+			activity: 1,
+			check: func(t *testing.T, o ocsfEvent) {
+				require.Equal(t, &ocsfEntity{UID: "space-1", Name: "Project", Type: "Space"}, o.Entity)
+			},
+		},
+		{
+			ev: types.AuditEventGroupMemberAdded{
+				AuditEvent: types.AuditEvent{User: "admin", Action: types.ActionGroupMemberAdded},
+				GroupID:    "group-1",
+				UserID:     "uid-123",
+			},
+			class:    3006,
+			activity: 3,
+			check: func(t *testing.T, o ocsfEvent) {
+				require.Equal(t, &ocsfGroup{UID: "group-1"}, o.Group)
+				require.Equal(t, &ocsfUser{UID: "uid-123"}, o.User)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		b, err := marshalOCSF(tc.ev)
+		require.NoError(t, err)
+
+		var o ocsfEvent
+		require.NoError(t, json.Unmarshal(b, &o))
+		require.Equal(t, tc.class, o.ClassUID)
+		require.Equal(t, tc.activity, o.ActivityID)
+		require.Equal(t, tc.class*100+tc.activity, o.TypeUID)
+		require.Equal(t, _ocsfVersion, o.Metadata.Version)
+		tc.check(t, o)
+	}
+}
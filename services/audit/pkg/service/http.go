@@ -0,0 +1,166 @@
+package svc
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/owncloud/ocis/v2/ocis-pkg/log"
+	"github.com/owncloud/ocis/v2/services/audit/pkg/config"
+)
+
+// _httpMaxBackoff limits the delay between two attempts to send a batch
+const _httpMaxBackoff = time.Minute
+
+// WriteToHTTP returns a Log function posting batches of events to an HTTP endpoint. The events of a batch are
+// separated by newlines. Failed requests are retried with an exponential backoff before the batch is dropped.
+// The Log function never blocks, events are queued and dropped when the queue is full, e.g. while the endpoint
+// is unreachable, so that a slow endpoint doesn't hold up the other sinks.
+// The returned function sends the remaining events and waits until they are delivered.
+func WriteToHTTP(cfg config.HTTP, contentType string, log log.Logger) (Log, func()) {
+	queueSize := cfg.QueueSize
+	if queueSize < cfg.BatchSize {
+		queueSize = cfg.BatchSize
+	}
+	s := &httpSink{
+		cfg:         cfg,
+		contentType: contentType,
+		client: &http.Client{
+			Timeout: cfg.Timeout,
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: cfg.Insecure}, //nolint:gosec
+			},
+		},
+		backoff: time.Second,
+		log:     log,
+		events:  make(chan []byte, queueSize),
+		done:    make(chan struct{}),
+	}
+
+	go s.run()
+
+	write := func(content []byte) {
+		select {
+		case s.events <- content:
+		default:
+			atomic.AddUint64(&s.dropped, 1)
+		}
+	}
+	closeSink := func() {
+		close(s.events)
+		<-s.done
+	}
+	return write, closeSink
+}
+
+type httpSink struct {
+	cfg         config.HTTP
+	contentType string
+	client      *http.Client
+	backoff     time.Duration
+	log         log.Logger
+	events      chan []byte
+	done        chan struct{}
+	// dropped counts the events which didn't fit into the queue since the last report
+	dropped uint64
+}
+
+func (s *httpSink) run() {
+	defer close(s.done)
+
+	var ticks <-chan time.Time
+	if s.cfg.FlushInterval > 0 {
+		ticker := time.NewTicker(s.cfg.FlushInterval)
+		defer ticker.Stop()
+		ticks = ticker.C
+	}
+
+	batch := make([][]byte, 0, s.cfg.BatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		s.send(batch)
+		batch = batch[:0]
+		s.reportDropped()
+	}
+
+	for {
+		select {
+		case ev, ok := <-s.events:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, ev)
+			if len(batch) >= s.cfg.BatchSize {
+				flush()
+			}
+		case <-ticks:
+			flush()
+		}
+	}
+}
+
+func (s *httpSink) send(batch [][]byte) {
+	body := append(bytes.Join(batch, []byte("\n")), '\n')
+
+	backoff := s.backoff
+	for attempt := 0; ; attempt++ {
+		retry, err := s.post(body)
+		if err == nil {
+			return
+		}
+
+		if !retry || attempt >= s.cfg.MaxRetries {
+			s.log.Error().Err(err).Int("events", len(batch)).Msgf("dropping audit events after %d attempts", attempt+1)
+			return
+		}
+
+		s.log.Warn().Err(err).Int("attempt", attempt+1).Msg("error sending audit events, retrying")
+		time.Sleep(backoff)
+		if backoff < _httpMaxBackoff {
+			backoff *= 2
+		}
+	}
+}
+
+// reportDropped logs the number of events which were dropped because the queue was full
+func (s *httpSink) reportDropped() {
+	if n := atomic.SwapUint64(&s.dropped, 0); n > 0 {
+		s.log.Error().Uint64("events", n).Msg("dropping audit events, the queue is full")
+	}
+}
+
+// post sends the body and reports if a failed request should be retried
+func (s *httpSink) post(body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, s.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", s.contentType)
+	if s.cfg.Authorization != "" {
+		req.Header.Set("Authorization", s.cfg.Authorization)
+	}
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, res.Body)
+
+	switch {
+	case res.StatusCode >= 200 && res.StatusCode <= 299:
+		return false, nil
+	case res.StatusCode == http.StatusRequestTimeout, res.StatusCode == http.StatusTooManyRequests, res.StatusCode >= 500:
+		return true, fmt.Errorf("unexpected status code %d", res.StatusCode)
+	default:
+		return false, fmt.Errorf("unexpected status code %d", res.StatusCode)
+	}
+}
@@ -0,0 +1,313 @@
+package svc
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	revactx "github.com/cs3org/reva/v2/pkg/ctx"
+	"github.com/go-chi/chi/v5"
+	"github.com/owncloud/ocis/v2/ocis-pkg/log"
+	"github.com/owncloud/ocis/v2/ocis-pkg/roles"
+	"github.com/owncloud/ocis/v2/services/audit/pkg/config"
+	"github.com/owncloud/ocis/v2/services/audit/pkg/store"
+	settings "github.com/owncloud/ocis/v2/services/settings/pkg/service/v0"
+)
+
+// DefaultLimit is the number of events returned per page if no limit is requested
+const DefaultLimit = 100
+
+// Service defines the service handlers.
+type Service interface {
+	ServeHTTP(http.ResponseWriter, *http.Request)
+	ListEvents(http.ResponseWriter, *http.Request)
+	ExportEvents(http.ResponseWriter, *http.Request)
+}
+
+// NewService returns a service implementation for Service.
+func NewService(opts ...Option) Service {
+	options := newOptions(opts...)
+
+	m := chi.NewMux()
+	m.Use(options.Middleware...)
+
+	svc := Audit{
+		config:      options.Config,
+		logger:      options.Logger,
+		mux:         m,
+		store:       options.Store,
+		roleManager: options.RoleManager,
+	}
+
+	m.Route(options.Config.API.Root, func(r chi.Router) {
+		r.Use(svc.RequireAdmin)
+		r.Get("/events", svc.ListEvents)
+		r.Get("/events/export", svc.ExportEvents)
+	})
+
+	_ = chi.Walk(m, func(method string, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		options.Logger.Debug().Str("method", method).Str("route", route).Int("middlewares", len(middlewares)).Msg("serving endpoint")
+		return nil
+	})
+
+	return svc
+}
+
+// Audit implements the business logic for Service.
+type Audit struct {
+	config      *config.Config
+	logger      log.Logger
+	mux         *chi.Mux
+	store       *store.Store
+	roleManager *roles.Manager
+}
+
+// ServeHTTP implements the Service interface.
+func (s Audit) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// RequireAdmin only lets users with the account management permission pass
+func (s Audit) RequireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		u, ok := revactx.ContextGetUser(r.Context())
+		if !ok || u.GetId().GetOpaqueId() == "" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		roleIDs, ok := roles.ReadRoleIDsFromContext(r.Context())
+		if !ok {
+			var err error
+			roleIDs, err = s.roleManager.FindRoleIDsForUser(r.Context(), u.GetId().GetOpaqueId())
+			if err != nil {
+				s.logger.Error().Err(err).Str("userid", u.GetId().GetOpaqueId()).Msg("failed to get roles for user")
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+
+		if s.roleManager.FindPermissionByID(r.Context(), roleIDs, settings.AccountManagementPermissionID) == nil {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// eventsResponse is a page of audit events
+type eventsResponse struct {
+	Total  uint64         `json:"total"`
+	Offset int            `json:"offset"`
+	Limit  int            `json:"limit"`
+	Events []store.Record `json:"events"`
+}
+
+// ListEvents returns a page of the audit events matching the filters of the request, the most recent first
+func (s Audit) ListEvents(w http.ResponseWriter, r *http.Request) {
+	q, err := parseQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if q.Limit == 0 {
+		q.Limit = DefaultLimit
+	}
+
+	res, err := s.store.Query(q)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("could not query the audit store")
+		http.Error(w, "could not query the audit store", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(eventsResponse{
+		Total:  res.Total,
+		Offset: q.Offset,
+		Limit:  q.Limit,
+		Events: res.Records,
+	})
+	if err != nil {
+		s.logger.Error().Err(err).Msg("could not write the audit events")
+	}
+}
+
+// ExportEvents returns all audit events matching the filters of the request as a csv or json file
+func (s Audit) ExportEvents(w http.ResponseWriter, r *http.Request) {
+	q, err := parseQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// events indexed during the export would shift the pages
+	if q.End.IsZero() {
+		q.End = time.Now()
+	}
+
+	var exp exporter
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "csv":
+		w.Header().Set("Content-Type", "text/csv")
+		exp = newCSVExporter(w)
+	case "json":
+		w.Header().Set("Content-Type", "application/json")
+		exp = newJSONExporter(w)
+	default:
+		http.Error(w, fmt.Sprintf("unknown format '%s'", format), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="audit-%s.%s"`, time.Now().UTC().Format("20060102-150405"), exp.extension()))
+
+	limit := q.Limit
+	q.Limit = store.MaxLimit
+	exported := 0
+	for limit == 0 || exported < limit {
+		res, err := s.store.Query(q)
+		if err != nil {
+			// the response has been started, all we can do is to stop
+			s.logger.Error().Err(err).Msg("could not query the audit store")
+			return
+		}
+		if len(res.Records) == 0 {
+			break
+		}
+
+		for _, rec := range res.Records {
+			if limit > 0 && exported >= limit {
+				break
+			}
+			if err := exp.write(rec); err != nil {
+				s.logger.Error().Err(err).Msg("could not write the audit events")
+				return
+			}
+			exported++
+		}
+		q.Offset += len(res.Records)
+	}
+
+	if err := exp.close(); err != nil {
+		s.logger.Error().Err(err).Msg("could not write the audit events")
+	}
+}
+
+// parseQuery reads the filters and the pagination of a request
+func parseQuery(v url.Values) (store.Query, error) {
+	q := store.Query{
+		User:       v.Get("user"),
+		ResourceID: v.Get("resource"),
+		SpaceID:    v.Get("space"),
+		Action:     v.Get("action"),
+	}
+
+	var err error
+	for name, t := range map[string]*time.Time{"start": &q.Start, "end": &q.End} {
+		if s := v.Get(name); s != "" {
+			if *t, err = time.Parse(time.RFC3339, s); err != nil {
+				return q, fmt.Errorf("invalid %s '%s', expected a time like 2006-01-02T15:04:05Z", name, s)
+			}
+		}
+	}
+	if !q.Start.IsZero() && !q.End.IsZero() && q.End.Before(q.Start) {
+		return q, fmt.Errorf("end must not be before start")
+	}
+
+	for name, i := range map[string]*int{"offset": &q.Offset, "limit": &q.Limit} {
+		if s := v.Get(name); s != "" {
+			if *i, err = strconv.Atoi(s); err != nil || *i < 0 {
+				return q, fmt.Errorf("invalid %s '%s', expected a positive number", name, s)
+			}
+		}
+	}
+	if q.Limit > store.MaxLimit {
+		return q, fmt.Errorf("limit must not exceed %d", store.MaxLimit)
+	}
+	return q, nil
+}
+
+// exporter writes records in an export format
+type exporter interface {
+	extension() string
+	write(store.Record) error
+	close() error
+}
+
+type csvExporter struct {
+	w *csv.Writer
+}
+
+func newCSVExporter(w http.ResponseWriter) *csvExporter {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"time", "time estimated", "action", "user", "resource", "space", "message"})
+	return &csvExporter{w: cw}
+}
+
+func (e *csvExporter) extension() string {
+	return "csv"
+}
+
+func (e *csvExporter) write(r store.Record) error {
+	return e.w.Write([]string{
+		r.Time.UTC().Format(time.RFC3339),
+		strconv.FormatBool(r.TimeEstimated),
+		csvCell(r.Action),
+		csvCell(r.User),
+		csvCell(r.ResourceID),
+		csvCell(r.SpaceID),
+		csvCell(r.Message),
+	})
+}
+
+// csvCell prefixes values which spreadsheet applications would evaluate as a formula with a single quote
+func csvCell(v string) string {
+	if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+		return "'" + v
+	}
+	return v
+}
+
+func (e *csvExporter) close() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// jsonExporter writes a json array of the audit events as logged
+type jsonExporter struct {
+	w     http.ResponseWriter
+	count int
+}
+
+func newJSONExporter(w http.ResponseWriter) *jsonExporter {
+	return &jsonExporter{w: w}
+}
+
+func (e *jsonExporter) extension() string {
+	return "json"
+}
+
+func (e *jsonExporter) write(r store.Record) error {
+	sep := ",\n"
+	if e.count == 0 {
+		sep = "[\n"
+	}
+	e.count++
+	if _, err := e.w.Write([]byte(sep)); err != nil {
+		return err
+	}
+	_, err := e.w.Write(r.Event)
+	return err
+}
+
+func (e *jsonExporter) close() error {
+	end := "\n]\n"
+	if e.count == 0 {
+		end = "[]\n"
+	}
+	_, err := e.w.Write([]byte(end))
+	return err
+}
@@ -0,0 +1,64 @@
+package svc
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/owncloud/ocis/v2/services/audit/pkg/store"
+	"github.com/test-go/testify/require"
+)
+
+func TestParseQuery(t *testing.T) {
+	q, err := parseQuery(url.Values{
+		"user":     {"einstein"},
+		"resource": {"pro-1$space-1!file-1"},
+		"space":    {"pro-1$space-1"},
+		"action":   {"file_read"},
+		"start":    {"2023-01-01T00:00:00Z"},
+		"end":      {"2023-01-08T00:00:00Z"},
+		"offset":   {"200"},
+		"limit":    {"100"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, store.Query{
+		User:       "einstein",
+		ResourceID: "pro-1$space-1!file-1",
+		SpaceID:    "pro-1$space-1",
+		Action:     "file_read",
+		Start:      time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+		End:        time.Date(2023, 1, 8, 0, 0, 0, 0, time.UTC),
+		Offset:     200,
+		Limit:      100,
+	}, q)
+
+	q, err = parseQuery(url.Values{})
+	require.NoError(t, err)
+	require.Equal(t, store.Query{}, q)
+
+	for _, v := range []url.Values{
+		{"start": {"yesterday"}},
+		{"start": {"2023-01-08T00:00:00Z"}, "end": {"2023-01-01T00:00:00Z"}},
+		{"offset": {"-1"}},
+		{"limit": {"ten"}},
+		{"limit": {"100000"}},
+	} {
+		_, err := parseQuery(v)
+		require.Error(t, err, v.Encode())
+	}
+}
+
+func TestCSVCell(t *testing.T) {
+	for v, expected := range map[string]string{
+		"":                         "",
+		"file_read":                "file_read",
+		"=HYPERLINK(\"http://x\")": "'=HYPERLINK(\"http://x\")",
+		"+1":                       "'+1",
+		"-1":                       "'-1",
+		"@SUM(A1)":                 "'@SUM(A1)",
+		"\t=1":                     "'\t=1",
+		"user renamed '=a' to 'b'": "user renamed '=a' to 'b'",
+	} {
+		require.Equal(t, expected, csvCell(v))
+	}
+}
@@ -4,11 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"os"
+	"time"
 
 	"github.com/cs3org/reva/v2/pkg/events"
+	ocisevents "github.com/owncloud/ocis/v2/ocis-pkg/events"
 	"github.com/owncloud/ocis/v2/ocis-pkg/log"
+	"github.com/owncloud/ocis/v2/services/audit/pkg/chain"
 	"github.com/owncloud/ocis/v2/services/audit/pkg/config"
+	"github.com/owncloud/ocis/v2/services/audit/pkg/store"
 	"github.com/owncloud/ocis/v2/services/audit/pkg/types"
 )
 
@@ -18,20 +21,59 @@ type Log func([]byte)
 // Marshaller is used to marshal events
 type Marshaller func(interface{}) ([]byte, error)
 
-// AuditLoggerFromConfig will start a new AuditLogger generated from the config
-func AuditLoggerFromConfig(ctx context.Context, cfg config.Auditlog, ch <-chan interface{}, log log.Logger) {
-	var logs []Log
+// AuditLoggerFromConfig will start a new AuditLogger generated from the config.
+// The events are added to the given store unless it is nil.
+func AuditLoggerFromConfig(ctx context.Context, cfg config.Auditlog, ch <-chan interface{}, s *store.Store, log log.Logger) error {
+	marshaller := Marshal(cfg.Format, log)
+	if cfg.Chain.Enabled {
+		c, err := chain.New(cfg.Chain.StateFile, cfg.Chain.HMACKey)
+		if err != nil {
+			return err
+		}
+		marshaller = Chained(c, marshaller, log)
+	}
+	if s != nil {
+		marshaller = Indexed(s, marshaller, cfg.Format, log)
+	}
+
+	var (
+		logs    []Log
+		closers []func()
+	)
 
 	if cfg.LogToConsole {
 		logs = append(logs, WriteToStdout())
 	}
 
 	if cfg.LogToFile {
-		logs = append(logs, WriteToFile(cfg.FilePath, log))
+		l, c := WriteToRotatingFile(cfg.FilePath, cfg.File, log)
+		logs, closers = append(logs, l), append(closers, c)
+	}
+
+	if cfg.Syslog.Enabled {
+		l, c, err := WriteToSyslog(cfg.Syslog, log)
+		if err != nil {
+			log.Error().Err(err).Msg("can't log to syslog")
+		} else {
+			logs, closers = append(logs, l), append(closers, c)
+		}
+	}
+
+	if cfg.HTTP.Enabled {
+		contentType := "text/plain"
+		if cfg.Format == "json" || cfg.Format == "ocsf" {
+			contentType = "application/x-ndjson"
+		}
+		l, c := WriteToHTTP(cfg.HTTP, contentType, log)
+		logs, closers = append(logs, l), append(closers, c)
 	}
 
-	StartAuditLogger(ctx, ch, log, Marshal(cfg.Format, log), logs...)
+	StartAuditLogger(ctx, ch, log, marshaller, logs...)
 
+	for _, c := range closers {
+		c()
+	}
+	return nil
 }
 
 // StartAuditLogger will block. run in separate go routine
@@ -109,6 +151,16 @@ func StartAuditLogger(ctx context.Context, ch <-chan interface{}, log log.Logger
 				auditEvent = types.GroupMemberAdded(ev)
 			case events.GroupMemberRemoved:
 				auditEvent = types.GroupMemberRemoved(ev)
+			case ocisevents.UserSignedIn:
+				auditEvent = types.UserSignedIn(ev)
+			case ocisevents.UserSignInFailed:
+				auditEvent = types.UserSignInFailed(ev)
+			case ocisevents.UserPasswordChanged:
+				auditEvent = types.UserPasswordChanged(ev)
+			case ocisevents.RoleAssigned:
+				auditEvent = types.RoleAssigned(ev)
+			case ocisevents.RoleUnassigned:
+				auditEvent = types.RoleUnassigned(ev)
 			default:
 				log.Error().Interface("event", ev).Msg(fmt.Sprintf("can't handle event of type '%T'", ev))
 				continue
@@ -129,21 +181,6 @@ func StartAuditLogger(ctx context.Context, ch <-chan interface{}, log log.Logger
 
 }
 
-// WriteToFile returns a Log function writing to a file
-func WriteToFile(path string, log log.Logger) Log {
-	return func(content []byte) {
-		file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
-		if err != nil {
-			log.Error().Err(err).Msgf("error opening file '%s'", path)
-			return
-		}
-		defer file.Close()
-		if _, err := fmt.Fprintln(file, string(content)); err != nil {
-			log.Error().Err(err).Msgf("error writing to file '%s'", path)
-		}
-	}
-}
-
 // WriteToStdout return a Log function writing to Stdout
 func WriteToStdout() Log {
 	return func(content []byte) {
@@ -151,6 +188,26 @@ func WriteToStdout() Log {
 	}
 }
 
+// Chained returns a Marshaller adding the fields of the hash chain to the records of the given Marshaller.
+// Errors persisting the chain state are logged, the event is logged nevertheless.
+func Chained(c *chain.Chain, m Marshaller, log log.Logger) Marshaller {
+	return func(ev interface{}) ([]byte, error) {
+		b, err := m(ev)
+		if err != nil {
+			return nil, err
+		}
+
+		b, err = c.Append(b)
+		switch {
+		case b == nil:
+			return nil, err
+		case err != nil:
+			log.Error().Err(err).Msg("error persisting the audit chain state")
+		}
+		return b, nil
+	}
+}
+
 // Marshal returns a Marshaller from the `format` string
 func Marshal(format string, log log.Logger) Marshaller {
 	switch format {
@@ -159,6 +216,10 @@ func Marshal(format string, log log.Logger) Marshaller {
 		return nil
 	case "json":
 		return json.Marshal
+	case "cef":
+		return marshalCEF
+	case "ocsf":
+		return marshalOCSF
 	case "minimal":
 		return func(ev interface{}) ([]byte, error) {
 			b, err := json.Marshal(ev)
@@ -176,3 +237,46 @@ func Marshal(format string, log log.Logger) Marshaller {
 		}
 	}
 }
+
+// Indexed returns a Marshaller adding the events to the store after they were marshalled, and chained if enabled, by
+// the given Marshaller, so that the store never holds events which are missing from the log. In the json format the
+// marshalled record is indexed, which includes the fields of the hash chain.
+// Errors adding an event to the store are logged, the event is logged nevertheless.
+func Indexed(s *store.Store, m Marshaller, format string, log log.Logger) Marshaller {
+	return func(ev interface{}) ([]byte, error) {
+		b, err := m(ev)
+		if err != nil {
+			return nil, err
+		}
+
+		record := ev
+		if format == "json" {
+			record = json.RawMessage(b)
+		}
+		if err := s.Index(record); err != nil {
+			log.Error().Err(err).Msg("error adding the event to the audit store")
+		}
+		return b, nil
+	}
+}
+
+// PurgeStore removes events older than maxAge from the store every hour. It blocks until the context is done.
+func PurgeStore(ctx context.Context, s *store.Store, maxAge time.Duration, log log.Logger) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		n, err := s.Purge(time.Now().Add(-maxAge))
+		if err != nil {
+			log.Error().Err(err).Msg("error purging the audit store")
+		} else if n > 0 {
+			log.Info().Int("events", n).Msg("purged old events from the audit store")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -4,9 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/cs3org/reva/v2/pkg/events"
+	ocisevents "github.com/owncloud/ocis/v2/ocis-pkg/events"
 	"github.com/owncloud/ocis/v2/ocis-pkg/log"
+	"github.com/owncloud/ocis/v2/services/audit/pkg/chain"
+	"github.com/owncloud/ocis/v2/services/audit/pkg/store"
 	"github.com/owncloud/ocis/v2/services/audit/pkg/types"
 	"github.com/test-go/testify/require"
 
@@ -511,6 +515,107 @@ var testCases = []struct {
 			// AuditEventSpaces fields
 			checkSpacesAuditEvent(t, ev.AuditEventSpaces, "space-123")
 		},
+	}, {
+		Alias: "User password changed",
+		SystemEvent: ocisevents.UserPasswordChanged{
+			Executant: userID("uid-123"),
+			UserID:    "uid-123",
+			Timestamp: time.Unix(0, 0),
+		},
+		CheckAuditEvent: func(t *testing.T, b []byte) {
+			ev := types.AuditEventUserPasswordChanged{}
+			require.NoError(t, json.Unmarshal(b, &ev))
+
+			// AuditEvent fields
+			checkBaseAuditEvent(t, ev.AuditEvent, "uid-123", "1970-01-01T00:00:00Z", "user 'uid-123' changed the password of user 'uid-123'", "user_password_changed")
+			// AuditEventUserPasswordChanged fields
+			require.Equal(t, "uid-123", ev.UserID)
+		},
+	}, {
+		Alias: "Role assigned",
+		SystemEvent: ocisevents.RoleAssigned{
+			Executant:    userID("admin-123"),
+			UserID:       "uid-123",
+			RoleID:       "role-123",
+			AssignmentID: "assignment-123",
+		},
+		CheckAuditEvent: func(t *testing.T, b []byte) {
+			ev := types.AuditEventUserRoleAssigned{}
+			require.NoError(t, json.Unmarshal(b, &ev))
+
+			// AuditEvent fields
+			checkBaseAuditEvent(t, ev.AuditEvent, "admin-123", "", "user 'admin-123' assigned role 'role-123' to user 'uid-123'", "user_role_assigned")
+			// AuditEventUserRoleAssigned fields
+			require.Equal(t, "uid-123", ev.UserID)
+			require.Equal(t, "role-123", ev.RoleID)
+			require.Equal(t, "assignment-123", ev.AssignmentID)
+		},
+	}, {
+		Alias: "Role unassigned",
+		SystemEvent: ocisevents.RoleUnassigned{
+			Executant:    userID("admin-123"),
+			UserID:       "uid-123",
+			RoleID:       "role-123",
+			AssignmentID: "assignment-123",
+		},
+		CheckAuditEvent: func(t *testing.T, b []byte) {
+			ev := types.AuditEventUserRoleUnassigned{}
+			require.NoError(t, json.Unmarshal(b, &ev))
+
+			// AuditEvent fields
+			checkBaseAuditEvent(t, ev.AuditEvent, "admin-123", "", "user 'admin-123' removed role 'role-123' from user 'uid-123'", "user_role_unassigned")
+			// AuditEventUserRoleUnassigned fields
+			require.Equal(t, "uid-123", ev.UserID)
+			require.Equal(t, "role-123", ev.RoleID)
+			require.Equal(t, "assignment-123", ev.AssignmentID)
+		},
+	}, {
+		Alias: "User login",
+		SystemEvent: ocisevents.UserSignedIn{
+			UserID:     "uid-123",
+			Username:   "einstein",
+			Method:     "basic",
+			RemoteAddr: "10.0.0.1",
+			UserAgent:  "curl",
+			Timestamp:  time.Unix(0, 0),
+		},
+		CheckAuditEvent: func(t *testing.T, b []byte) {
+			ev := types.AuditEventUserLogin{}
+			require.NoError(t, json.Unmarshal(b, &ev))
+
+			require.Equal(t, "uid-123", ev.User)
+			require.Equal(t, "1970-01-01T00:00:00Z", ev.Time)
+			require.Equal(t, "user 'einstein' logged in with 'basic' from '10.0.0.1'", ev.Message)
+			require.Equal(t, "user_login", ev.Action)
+			require.Equal(t, "10.0.0.1", ev.RemoteAddr)
+			require.Equal(t, "curl", ev.UserAgent)
+			// AuditEventUserLogin fields
+			require.Equal(t, "uid-123", ev.UserID)
+			require.Equal(t, "einstein", ev.Username)
+			require.Equal(t, "basic", ev.AuthMethod)
+		},
+	}, {
+		Alias: "User login failed",
+		SystemEvent: ocisevents.UserSignInFailed{
+			Username:   "einstein",
+			Method:     "basic",
+			Reason:     "invalid credentials",
+			RemoteAddr: "10.0.0.1",
+			UserAgent:  "curl",
+		},
+		CheckAuditEvent: func(t *testing.T, b []byte) {
+			ev := types.AuditEventUserLoginFailed{}
+			require.NoError(t, json.Unmarshal(b, &ev))
+
+			require.Equal(t, "", ev.User)
+			require.Equal(t, "failed login of user 'einstein' with 'basic' from '10.0.0.1'", ev.Message)
+			require.Equal(t, "user_login_failed", ev.Action)
+			require.Equal(t, "10.0.0.1", ev.RemoteAddr)
+			// AuditEventUserLoginFailed fields
+			require.Equal(t, "einstein", ev.Username)
+			require.Equal(t, "basic", ev.AuthMethod)
+			require.Equal(t, "invalid credentials", ev.Reason)
+		},
 	},
 }
 
@@ -539,6 +644,32 @@ func TestAuditLogging(t *testing.T) {
 	}
 }
 
+func TestIndexedChainedRecords(t *testing.T) {
+	log := log.NewLogger()
+	s, err := store.NewMemOnly()
+	require.NoError(t, err)
+	defer s.Close()
+	c, err := chain.New("", "")
+	require.NoError(t, err)
+
+	m := Indexed(s, Chained(c, Marshal("json", log), log), "json", log)
+	_, err = m(types.AuditEvent{Time: "2023-01-01T10:00:00Z", Action: "file_read", User: "einstein"})
+	require.NoError(t, err)
+
+	// events which can't be chained are neither logged nor indexed
+	_, err = Indexed(s, Chained(c, Marshal("minimal", log), log), "json", log)(types.AuditEvent{Time: "2023-01-01T11:00:00Z", Action: "file_read"})
+	require.Error(t, err)
+
+	res, err := s.Query(store.Query{})
+	require.NoError(t, err)
+	require.Len(t, res.Records, 1)
+	require.Equal(t, "einstein", res.Records[0].User)
+
+	ev := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(res.Records[0].Event, &ev))
+	require.Equal(t, float64(1), ev[chain.FieldSequence])
+}
+
 func checkBaseAuditEvent(t *testing.T, ev types.AuditEvent, user string, time string, message string, action string) {
 	require.Equal(t, "", ev.RemoteAddr) // not implemented atm
 	require.Equal(t, user, ev.User)
@@ -0,0 +1,199 @@
+package svc
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/owncloud/ocis/v2/ocis-pkg/log"
+	"github.com/owncloud/ocis/v2/services/audit/pkg/config"
+	"github.com/test-go/testify/require"
+)
+
+func TestRotatingFileBySize(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "audit.log")
+
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	f := &rotatingFile{
+		path:       path,
+		maxSize:    10,
+		maxBackups: 2,
+		now: func() time.Time {
+			now = now.Add(time.Second)
+			return now
+		},
+	}
+	defer f.close()
+
+	for _, line := range []string{"first", "second", "third", "fourth"} {
+		require.NoError(t, f.write([]byte(line)))
+	}
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "fourth\n", string(b))
+
+	backups, err := filepath.Glob(path + ".*")
+	require.NoError(t, err)
+	require.Len(t, backups, 2, "the oldest backup should have been deleted")
+
+	b, err = os.ReadFile(backups[1])
+	require.NoError(t, err)
+	require.Equal(t, "third\n", string(b))
+}
+
+func TestRotatingFileByTime(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "audit.log")
+
+	now := time.Date(2023, 1, 1, 23, 0, 0, 0, time.UTC)
+	f := &rotatingFile{
+		path:     path,
+		interval: 24 * time.Hour,
+		now:      func() time.Time { return now },
+	}
+	defer f.close()
+
+	require.NoError(t, f.write([]byte("monday")))
+	now = now.Add(30 * time.Minute)
+	require.NoError(t, f.write([]byte("still monday")))
+	now = now.Add(time.Hour)
+	require.NoError(t, f.write([]byte("tuesday")))
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "tuesday\n", string(b))
+
+	b, err = os.ReadFile(path + "." + now.Format(_backupTimeFormat))
+	require.NoError(t, err)
+	require.Equal(t, "monday\nstill monday\n", string(b))
+}
+
+func TestWriteToHTTP(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests int
+		bodies   []string
+		headers  []http.Header
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		requests++
+		if requests == 1 {
+			// the first request fails and must be retried
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		headers = append(headers, r.Header)
+	}))
+	defer srv.Close()
+
+	l, closeSink := WriteToHTTP(config.HTTP{
+		URL:           srv.URL,
+		Authorization: "Bearer secret",
+		BatchSize:     2,
+		QueueSize:     10,
+		Timeout:       time.Second,
+		MaxRetries:    1,
+	}, "application/x-ndjson", log.NewLogger())
+
+	l([]byte(`{"n":1}`))
+	l([]byte(`{"n":2}`))
+	l([]byte(`{"n":3}`))
+	closeSink()
+
+	require.Equal(t, 3, requests)
+	require.Equal(t, []string{"{\"n\":1}\n{\"n\":2}\n", "{\"n\":3}\n"}, bodies)
+	for _, h := range headers {
+		require.Equal(t, "Bearer secret", h.Get("Authorization"))
+		require.Equal(t, "application/x-ndjson", h.Get("Content-Type"))
+	}
+}
+
+func TestWriteToHTTPDoesNotBlock(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received int
+	)
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the endpoint hangs until the events have been logged
+		<-release
+		b, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		received += strings.Count(string(b), "\n")
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	l, closeSink := WriteToHTTP(config.HTTP{
+		URL:       srv.URL,
+		BatchSize: 1,
+		QueueSize: 2,
+		Timeout:   time.Minute,
+	}, "application/x-ndjson", log.NewLogger())
+
+	logged := make(chan struct{})
+	go func() {
+		for i := 0; i < 100; i++ {
+			l([]byte(`{}`))
+		}
+		close(logged)
+	}()
+
+	select {
+	case <-logged:
+	case <-time.After(5 * time.Second):
+		t.Fatal("logging blocked on the unreachable endpoint")
+	}
+	close(release)
+	closeSink()
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.True(t, received >= 1, "the first event should have been sent")
+	require.True(t, received <= 3, "the events which didn't fit into the queue should have been dropped")
+}
+
+func TestWriteToSyslog(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	l, closeSyslog, err := WriteToSyslog(config.Syslog{
+		Network:  "udp",
+		Address:  conn.LocalAddr().String(),
+		Facility: "local4",
+		AppName:  "ocis",
+	}, log.NewLogger())
+	require.NoError(t, err)
+	defer closeSyslog()
+
+	l([]byte(`{"Action":"file_delete"}`))
+
+	buf := make([]byte, 1024)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+
+	msg := string(buf[:n])
+	// local4 (20) * 8 + informational (6)
+	require.True(t, strings.HasPrefix(msg, "<166>1 "), msg)
+	require.True(t, strings.HasSuffix(msg, ` ocis `+strings.Fields(msg)[4]+` audit - {"Action":"file_delete"}`), msg)
+
+	_, _, err = WriteToSyslog(config.Syslog{Facility: "unknown"}, log.NewLogger())
+	require.Error(t, err)
+}
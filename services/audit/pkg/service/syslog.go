@@ -0,0 +1,187 @@
+package svc
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/owncloud/ocis/v2/ocis-pkg/log"
+	"github.com/owncloud/ocis/v2/services/audit/pkg/config"
+)
+
+const (
+	// _syslogSeverity is the severity of all audit messages: informational
+	_syslogSeverity = 6
+	// _syslogMsgID is the MSGID of all audit messages
+	_syslogMsgID = "audit"
+	// _syslogTimeout limits dialing and writing to syslog
+	_syslogTimeout = 5 * time.Second
+)
+
+var _syslogFacilities = map[string]int{
+	"kern":     0,
+	"user":     1,
+	"mail":     2,
+	"daemon":   3,
+	"auth":     4,
+	"syslog":   5,
+	"lpr":      6,
+	"news":     7,
+	"uucp":     8,
+	"cron":     9,
+	"authpriv": 10,
+	"ftp":      11,
+	"local0":   16,
+	"local1":   17,
+	"local2":   18,
+	"local3":   19,
+	"local4":   20,
+	"local5":   21,
+	"local6":   22,
+	"local7":   23,
+}
+
+// local syslog sockets in the order they are tried
+var _syslogSockets = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// WriteToSyslog returns a Log function sending RFC 5424 messages to syslog.
+// The connection is established on the first message and again after errors. The returned function closes the connection.
+func WriteToSyslog(cfg config.Syslog, log log.Logger) (Log, func(), error) {
+	facility, ok := _syslogFacilities[strings.ToLower(cfg.Facility)]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown syslog facility '%s'", cfg.Facility)
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+
+	w := &syslogWriter{
+		network:  cfg.Network,
+		address:  cfg.Address,
+		priority: facility*8 + _syslogSeverity,
+		hostname: hostname,
+		appName:  nilValue(cfg.AppName),
+		procID:   fmt.Sprint(os.Getpid()),
+	}
+
+	write := func(content []byte) {
+		if err := w.write(content); err != nil {
+			log.Error().Err(err).Msg("error writing to syslog")
+		}
+	}
+	return write, w.close, nil
+}
+
+type syslogWriter struct {
+	network  string
+	address  string
+	priority int
+	hostname string
+	appName  string
+	procID   string
+
+	mu   sync.Mutex
+	conn net.Conn
+	// stream is true for connection oriented transports which need framing
+	stream bool
+}
+
+func (w *syslogWriter) write(content []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	msg := w.format(time.Now(), content)
+
+	// try once more with a new connection, the old one might have been closed by the syslog server
+	var err error
+	for i := 0; i < 2; i++ {
+		if w.conn == nil {
+			if err = w.connect(); err != nil {
+				return err
+			}
+		}
+
+		_ = w.conn.SetWriteDeadline(time.Now().Add(_syslogTimeout))
+		if _, err = w.conn.Write(w.frame(msg)); err == nil {
+			return nil
+		}
+
+		w.conn.Close()
+		w.conn = nil
+	}
+	return err
+}
+
+// format returns an RFC 5424 message: <PRI>VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+func (w *syslogWriter) format(t time.Time, content []byte) string {
+	return fmt.Sprintf("<%d>1 %s %s %s %s %s - %s",
+		w.priority,
+		t.Format("2006-01-02T15:04:05.000000Z07:00"),
+		w.hostname,
+		w.appName,
+		w.procID,
+		_syslogMsgID,
+		content,
+	)
+}
+
+// frame prepares a message for the transport. TCP uses octet counting as described in RFC 6587.
+func (w *syslogWriter) frame(msg string) []byte {
+	switch {
+	case w.network == "tcp":
+		return []byte(fmt.Sprintf("%d %s", len(msg), msg))
+	case w.stream:
+		return []byte(msg + "\n")
+	default:
+		return []byte(msg)
+	}
+}
+
+func (w *syslogWriter) connect() error {
+	if w.network != "" {
+		conn, err := net.DialTimeout(w.network, w.address, _syslogTimeout)
+		if err != nil {
+			return err
+		}
+		w.conn, w.stream = conn, w.network == "tcp"
+		return nil
+	}
+
+	sockets := _syslogSockets
+	if w.address != "" {
+		sockets = []string{w.address}
+	}
+	for _, s := range sockets {
+		for _, network := range []string{"unixgram", "unix"} {
+			conn, err := net.DialTimeout(network, s, _syslogTimeout)
+			if err == nil {
+				w.conn, w.stream = conn, network == "unix"
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("unable to connect to a local syslog socket, tried %s", strings.Join(sockets, ", "))
+}
+
+func (w *syslogWriter) close() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn != nil {
+		w.conn.Close()
+		w.conn = nil
+	}
+}
+
+// nilValue returns the NILVALUE of RFC 5424 for empty header fields
+func nilValue(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,307 @@
+// Package store keeps audit events in an embedded bleve index, so that they can be queried.
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/keyword"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/search/query"
+	"github.com/cs3org/reva/v2/pkg/storagespace"
+)
+
+const (
+	// MaxLimit is the maximum number of records returned by a single query
+	MaxLimit = 1000
+
+	purgeBatchSize = 1000
+)
+
+// Record is an audit event as kept in the store
+type Record struct {
+	ID         string    `json:"id"`
+	Time       time.Time `json:"time"`
+	Action     string    `json:"action"`
+	User       string    `json:"user"`
+	ResourceID string    `json:"resourceId"`
+	SpaceID    string    `json:"spaceId"`
+	Message    string    `json:"message"`
+	// TimeEstimated is true if the event carried no time and the time of indexing was stored instead
+	TimeEstimated bool `json:"timeEstimated"`
+	// Event is the audit event as logged in the json format
+	Event json.RawMessage `json:"event"`
+}
+
+// Query filters the records of the store. Empty fields don't filter.
+type Query struct {
+	User       string
+	ResourceID string
+	SpaceID    string
+	Action     string
+	Start      time.Time
+	End        time.Time
+
+	Offset int
+	Limit  int
+}
+
+// Result is the result of a query
+type Result struct {
+	// Total is the number of records matching the query, regardless of offset and limit
+	Total   uint64
+	Records []Record
+}
+
+// document is the representation of a record in the index
+type document struct {
+	Time          time.Time
+	TimeEstimated bool
+	Action        string
+	User          string
+	ResourceID    string
+	SpaceID       string
+	Message       string
+	Event         string
+}
+
+// Store indexes audit events in a bleve index
+type Store struct {
+	index bleve.Index
+	seq   uint64
+}
+
+// New opens the store in the given directory, it is created if it doesn't exist
+func New(root string) (*Store, error) {
+	destination := filepath.Join(root, "bleve")
+	index, err := bleve.Open(destination)
+	if errors.Is(err, bleve.ErrorIndexPathDoesNotExist) {
+		if err := os.MkdirAll(root, 0700); err != nil {
+			return nil, err
+		}
+		m, err := buildMapping()
+		if err != nil {
+			return nil, err
+		}
+		index, err = bleve.New(destination, m)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Store{index: index}, nil
+}
+
+// NewMemOnly returns a store which is not persisted
+func NewMemOnly() (*Store, error) {
+	m, err := buildMapping()
+	if err != nil {
+		return nil, err
+	}
+	index, err := bleve.NewMemOnly(m)
+	if err != nil {
+		return nil, err
+	}
+	return &Store{index: index}, nil
+}
+
+// Close closes the store
+func (s *Store) Close() error {
+	return s.index.Close()
+}
+
+func buildMapping() (mapping.IndexMapping, error) {
+	keywordMapping := bleve.NewTextFieldMapping()
+	keywordMapping.Analyzer = keyword.Name
+	keywordMapping.IncludeInAll = false
+
+	storedOnlyMapping := bleve.NewTextFieldMapping()
+	storedOnlyMapping.Index = false
+	storedOnlyMapping.IncludeInAll = false
+
+	timeMapping := bleve.NewDateTimeFieldMapping()
+	timeMapping.IncludeInAll = false
+
+	boolMapping := bleve.NewBooleanFieldMapping()
+	boolMapping.IncludeInAll = false
+
+	docMapping := bleve.NewDocumentStaticMapping()
+	docMapping.AddFieldMappingsAt("Time", timeMapping)
+	docMapping.AddFieldMappingsAt("TimeEstimated", boolMapping)
+	for _, f := range []string{"Action", "User", "ResourceID", "SpaceID"} {
+		docMapping.AddFieldMappingsAt(f, keywordMapping)
+	}
+	docMapping.AddFieldMappingsAt("Message", storedOnlyMapping)
+	docMapping.AddFieldMappingsAt("Event", storedOnlyMapping)
+
+	indexMapping := bleve.NewIndexMapping()
+	indexMapping.DefaultAnalyzer = keyword.Name
+	indexMapping.DefaultMapping = docMapping
+	return indexMapping, nil
+}
+
+// Index adds an audit event to the store
+func (s *Store) Index(ev interface{}) error {
+	b, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+
+	var fields struct {
+		Time    string
+		Action  string
+		User    string
+		Message string
+		FileID  string
+		SpaceID string
+	}
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return err
+	}
+
+	doc := document{
+		Action:     fields.Action,
+		User:       fields.User,
+		ResourceID: fields.FileID,
+		SpaceID:    spaceID(fields.SpaceID),
+		Message:    fields.Message,
+		Event:      string(b),
+	}
+	switch t, err := time.Parse(time.RFC3339, fields.Time); {
+	case fields.Time == "":
+		// most events don't carry a time, they are stored with the time of indexing and marked as such
+		doc.Time, doc.TimeEstimated = time.Now().UTC(), true
+	case err != nil:
+		return fmt.Errorf("invalid time '%s': %w", fields.Time, err)
+	default:
+		doc.Time = t.UTC()
+	}
+	if doc.SpaceID == "" {
+		doc.SpaceID = spaceID(fields.FileID)
+	}
+
+	return s.index.Index(s.nextID(doc.Time), doc)
+}
+
+// nextID returns an id which sorts in the order the events were indexed
+func (s *Store) nextID(t time.Time) string {
+	return fmt.Sprintf("%020d-%06d", t.UnixNano(), atomic.AddUint64(&s.seq, 1)%1000000)
+}
+
+// Query returns the records matching the query, the most recent first
+func (s *Store) Query(q Query) (Result, error) {
+	limit := q.Limit
+	if limit <= 0 || limit > MaxLimit {
+		limit = MaxLimit
+	}
+
+	req := bleve.NewSearchRequestOptions(buildQuery(q), limit, q.Offset, false)
+	req.SortBy([]string{"-Time", "-_id"})
+	req.Fields = []string{"*"}
+
+	res, err := s.index.Search(req)
+	if err != nil {
+		return Result{}, err
+	}
+
+	result := Result{
+		Total:   res.Total,
+		Records: make([]Record, 0, len(res.Hits)),
+	}
+	for _, hit := range res.Hits {
+		r := Record{
+			ID:         hit.ID,
+			Action:     fieldString(hit.Fields, "Action"),
+			User:       fieldString(hit.Fields, "User"),
+			ResourceID: fieldString(hit.Fields, "ResourceID"),
+			SpaceID:    fieldString(hit.Fields, "SpaceID"),
+			Message:    fieldString(hit.Fields, "Message"),
+			Event:      json.RawMessage(fieldString(hit.Fields, "Event")),
+		}
+		r.TimeEstimated, _ = hit.Fields["TimeEstimated"].(bool)
+		if t, err := time.Parse(time.RFC3339Nano, fieldString(hit.Fields, "Time")); err == nil {
+			r.Time = t
+		}
+		result.Records = append(result.Records, r)
+	}
+	return result, nil
+}
+
+// Purge removes all records older than the given time and returns the number of removed records
+func (s *Store) Purge(before time.Time) (int, error) {
+	q := bleve.NewDateRangeQuery(time.Time{}, before)
+	q.SetField("Time")
+
+	purged := 0
+	for {
+		res, err := s.index.Search(bleve.NewSearchRequestOptions(q, purgeBatchSize, 0, false))
+		if err != nil {
+			return purged, err
+		}
+		if len(res.Hits) == 0 {
+			return purged, nil
+		}
+
+		batch := s.index.NewBatch()
+		for _, hit := range res.Hits {
+			batch.Delete(hit.ID)
+		}
+		if err := s.index.Batch(batch); err != nil {
+			return purged, err
+		}
+		purged += len(res.Hits)
+	}
+}
+
+func buildQuery(q Query) query.Query {
+	var conjuncts []query.Query
+	for field, value := range map[string]string{
+		"User":       q.User,
+		"ResourceID": q.ResourceID,
+		"SpaceID":    spaceID(q.SpaceID),
+		"Action":     q.Action,
+	} {
+		if value == "" {
+			continue
+		}
+		tq := bleve.NewTermQuery(value)
+		tq.SetField(field)
+		conjuncts = append(conjuncts, tq)
+	}
+
+	if !q.Start.IsZero() || !q.End.IsZero() {
+		dq := bleve.NewDateRangeQuery(q.Start, q.End)
+		dq.SetField("Time")
+		conjuncts = append(conjuncts, dq)
+	}
+
+	if len(conjuncts) == 0 {
+		return bleve.NewMatchAllQuery()
+	}
+	return bleve.NewConjunctionQuery(conjuncts...)
+}
+
+// spaceID returns the space part of a space or resource id, so that spaces can be filtered
+// regardless of the form their id is given in
+func spaceID(id string) string {
+	if id == "" {
+		return ""
+	}
+	_, spaceID, _, err := storagespace.SplitID(id)
+	if err != nil {
+		return id
+	}
+	return spaceID
+}
+
+func fieldString(fields map[string]interface{}, name string) string {
+	if s, ok := fields[name].(string); ok {
+		return s
+	}
+	return ""
+}
@@ -0,0 +1,105 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/owncloud/ocis/v2/services/audit/pkg/types"
+	"github.com/test-go/testify/require"
+)
+
+func fileEvent(user, action, fileID, t string) types.AuditEventFiles {
+	return types.AuditEventFiles{
+		AuditEvent: types.BasicAuditEvent(user, t, "message", action),
+		FileID:     fileID,
+	}
+}
+
+func TestQuery(t *testing.T) {
+	s, err := NewMemOnly()
+	require.NoError(t, err)
+	defer s.Close()
+
+	for _, ev := range []interface{}{
+		fileEvent("einstein", types.ActionFileRead, "pro-1$space-1!file-1", "2023-01-01T10:00:00Z"),
+		fileEvent("marie", types.ActionFileRead, "pro-1$space-1!file-1", "2023-01-02T10:00:00Z"),
+		fileEvent("einstein", types.ActionFileCreated, "pro-1$space-2!file-2", "2023-01-03T10:00:00Z"),
+		types.AuditEventSpaces{
+			AuditEvent: types.BasicAuditEvent("einstein", "2023-01-04T10:00:00Z", "message", types.ActionSpaceDisabled),
+			SpaceID:    "pro-1$space-1",
+		},
+	} {
+		require.NoError(t, s.Index(ev))
+	}
+
+	res, err := s.Query(Query{})
+	require.NoError(t, err)
+	require.Equal(t, uint64(4), res.Total)
+	require.Equal(t, types.ActionSpaceDisabled, res.Records[0].Action, "the most recent record comes first")
+	require.Equal(t, time.Date(2023, 1, 4, 10, 0, 0, 0, time.UTC), res.Records[0].Time.UTC())
+	require.Contains(t, string(res.Records[0].Event), `"SpaceID":"pro-1$space-1"`)
+
+	res, err = s.Query(Query{User: "einstein"})
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), res.Total)
+
+	res, err = s.Query(Query{ResourceID: "pro-1$space-1!file-1"})
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), res.Total)
+
+	res, err = s.Query(Query{SpaceID: "pro-1$space-1"})
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), res.Total, "file events are found by the space of the file")
+
+	res, err = s.Query(Query{User: "einstein", Action: types.ActionFileRead})
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), res.Total)
+
+	res, err = s.Query(Query{
+		Start: time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2023, 1, 4, 0, 0, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), res.Total)
+
+	res, err = s.Query(Query{Offset: 1, Limit: 2})
+	require.NoError(t, err)
+	require.Equal(t, uint64(4), res.Total)
+	require.Len(t, res.Records, 2)
+	require.Equal(t, types.ActionFileCreated, res.Records[0].Action)
+}
+
+func TestIndexMarksEventsWithoutTime(t *testing.T) {
+	s, err := NewMemOnly()
+	require.NoError(t, err)
+	defer s.Close()
+
+	before := time.Now()
+	require.NoError(t, s.Index(fileEvent("einstein", types.ActionFileRead, "pro-1$space-1!file-1", "")))
+	require.NoError(t, s.Index(fileEvent("einstein", types.ActionFileRead, "pro-1$space-1!file-1", "2023-01-01T10:00:00Z")))
+	require.Error(t, s.Index(fileEvent("einstein", types.ActionFileRead, "pro-1$space-1!file-1", "yesterday")))
+
+	res, err := s.Query(Query{})
+	require.NoError(t, err)
+	require.Len(t, res.Records, 2)
+	require.True(t, res.Records[0].TimeEstimated)
+	require.False(t, res.Records[0].Time.Before(before.Truncate(time.Second)))
+	require.False(t, res.Records[1].TimeEstimated)
+}
+
+func TestPurge(t *testing.T) {
+	s, err := NewMemOnly()
+	require.NoError(t, err)
+	defer s.Close()
+
+	require.NoError(t, s.Index(fileEvent("einstein", types.ActionFileRead, "pro-1$space-1!file-1", "2023-01-01T10:00:00Z")))
+	require.NoError(t, s.Index(fileEvent("einstein", types.ActionFileRead, "pro-1$space-1!file-1", "2023-01-03T10:00:00Z")))
+
+	purged, err := s.Purge(time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Equal(t, 1, purged)
+
+	res, err := s.Query(Query{})
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), res.Total)
+}
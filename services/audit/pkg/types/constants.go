@@ -41,9 +41,16 @@ const (
 	ActionSpaceUpdated  = "space_updated"
 
 	// Users
-	ActionUserCreated        = "user_created"
-	ActionUserDeleted        = "user_deleted"
-	ActionUserFeatureChanged = "user_feature_changed"
+	ActionUserCreated         = "user_created"
+	ActionUserDeleted         = "user_deleted"
+	ActionUserFeatureChanged  = "user_feature_changed"
+	ActionUserPasswordChanged = "user_password_changed"
+	ActionUserRoleAssigned    = "user_role_assigned"
+	ActionUserRoleUnassigned  = "user_role_unassigned"
+
+	// Authentication
+	ActionUserLogin       = "user_login"
+	ActionUserLoginFailed = "user_login_failed"
 
 	// Groups
 	ActionGroupCreated       = "group_created"
@@ -206,6 +213,31 @@ func MessageUserFeatureChanged(executant, userID string, features []events.UserF
 	return sb.String()
 }
 
+// MessageUserPasswordChanged returns the human readable string that describes the action
+func MessageUserPasswordChanged(executant, userID string) string {
+	return fmt.Sprintf("user '%s' changed the password of user '%s'", executant, userID)
+}
+
+// MessageUserRoleAssigned returns the human readable string that describes the action
+func MessageUserRoleAssigned(executant, userID, roleID string) string {
+	return fmt.Sprintf("user '%s' assigned role '%s' to user '%s'", executant, roleID, userID)
+}
+
+// MessageUserRoleUnassigned returns the human readable string that describes the action
+func MessageUserRoleUnassigned(executant, userID, roleID string) string {
+	return fmt.Sprintf("user '%s' removed role '%s' from user '%s'", executant, roleID, userID)
+}
+
+// MessageUserLogin returns the human readable string that describes the action
+func MessageUserLogin(username, method, remoteAddr string) string {
+	return fmt.Sprintf("user '%s' logged in with '%s' from '%s'", username, method, remoteAddr)
+}
+
+// MessageUserLoginFailed returns the human readable string that describes the action
+func MessageUserLoginFailed(username, method, remoteAddr string) string {
+	return fmt.Sprintf("failed login of user '%s' with '%s' from '%s'", username, method, remoteAddr)
+}
+
 // MessageGroupCreated returns the human readable string that describes the action
 func MessageGroupCreated(executant, groupID string) string {
 	return fmt.Sprintf("user '%s' created group '%s'", executant, groupID)
@@ -6,6 +6,7 @@ import (
 
 	"github.com/cs3org/reva/v2/pkg/events"
 	"github.com/cs3org/reva/v2/pkg/storagespace"
+	ocisevents "github.com/owncloud/ocis/v2/ocis-pkg/events"
 
 	group "github.com/cs3org/go-cs3apis/cs3/identity/group/v1beta1"
 	user "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
@@ -463,6 +464,66 @@ func UserFeatureChanged(ev events.UserFeatureChanged) AuditEventUserFeatureChang
 	}
 }
 
+// UserPasswordChanged converts a UserPasswordChanged event to an AuditEventUserPasswordChanged
+func UserPasswordChanged(ev ocisevents.UserPasswordChanged) AuditEventUserPasswordChanged {
+	uid := ev.Executant.GetOpaqueId()
+	base := BasicAuditEvent(uid, formatGoTime(ev.Timestamp), MessageUserPasswordChanged(uid, ev.UserID), ActionUserPasswordChanged)
+	return AuditEventUserPasswordChanged{
+		AuditEvent: base,
+		UserID:     ev.UserID,
+	}
+}
+
+// RoleAssigned converts a RoleAssigned event to an AuditEventUserRoleAssigned
+func RoleAssigned(ev ocisevents.RoleAssigned) AuditEventUserRoleAssigned {
+	uid := ev.Executant.GetOpaqueId()
+	base := BasicAuditEvent(uid, formatGoTime(ev.Timestamp), MessageUserRoleAssigned(uid, ev.UserID, ev.RoleID), ActionUserRoleAssigned)
+	return AuditEventUserRoleAssigned{
+		AuditEvent:   base,
+		UserID:       ev.UserID,
+		RoleID:       ev.RoleID,
+		AssignmentID: ev.AssignmentID,
+	}
+}
+
+// RoleUnassigned converts a RoleUnassigned event to an AuditEventUserRoleUnassigned
+func RoleUnassigned(ev ocisevents.RoleUnassigned) AuditEventUserRoleUnassigned {
+	uid := ev.Executant.GetOpaqueId()
+	base := BasicAuditEvent(uid, formatGoTime(ev.Timestamp), MessageUserRoleUnassigned(uid, ev.UserID, ev.RoleID), ActionUserRoleUnassigned)
+	return AuditEventUserRoleUnassigned{
+		AuditEvent:   base,
+		UserID:       ev.UserID,
+		RoleID:       ev.RoleID,
+		AssignmentID: ev.AssignmentID,
+	}
+}
+
+// UserSignedIn converts a UserSignedIn event to an AuditEventUserLogin
+func UserSignedIn(ev ocisevents.UserSignedIn) AuditEventUserLogin {
+	base := BasicAuditEvent(ev.UserID, formatGoTime(ev.Timestamp), MessageUserLogin(ev.Username, ev.Method, ev.RemoteAddr), ActionUserLogin)
+	base.RemoteAddr = ev.RemoteAddr
+	base.UserAgent = ev.UserAgent
+	return AuditEventUserLogin{
+		AuditEvent: base,
+		UserID:     ev.UserID,
+		Username:   ev.Username,
+		AuthMethod: ev.Method,
+	}
+}
+
+// UserSignInFailed converts a UserSignInFailed event to an AuditEventUserLoginFailed
+func UserSignInFailed(ev ocisevents.UserSignInFailed) AuditEventUserLoginFailed {
+	base := BasicAuditEvent("", formatGoTime(ev.Timestamp), MessageUserLoginFailed(ev.Username, ev.Method, ev.RemoteAddr), ActionUserLoginFailed)
+	base.RemoteAddr = ev.RemoteAddr
+	base.UserAgent = ev.UserAgent
+	return AuditEventUserLoginFailed{
+		AuditEvent: base,
+		Username:   ev.Username,
+		AuthMethod: ev.Method,
+		Reason:     ev.Reason,
+	}
+}
+
 // GroupCreated converts a GroupCreated event to an AuditEventGroupCreated
 func GroupCreated(ev events.GroupCreated) AuditEventGroupCreated {
 	base := BasicAuditEvent("", "", MessageGroupCreated(ev.Executant.GetOpaqueId(), ev.GroupID), ActionGroupCreated)
@@ -535,6 +596,13 @@ func formatTime(t *types.Timestamp) string {
 	return time.Unix(int64(t.Seconds), int64(t.Nanos)).UTC().Format(time.RFC3339)
 }
 
+func formatGoTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
 func updateType(u string) string {
 	switch u {
 	case "permissions":
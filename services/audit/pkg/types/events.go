@@ -2,6 +2,7 @@ package types
 
 import (
 	"github.com/cs3org/reva/v2/pkg/events"
+	ocisevents "github.com/owncloud/ocis/v2/ocis-pkg/events"
 )
 
 // RegisteredEvents returns the events the service is registered for
@@ -39,5 +40,10 @@ func RegisteredEvents() []events.Unmarshaller {
 		events.GroupDeleted{},
 		events.GroupMemberAdded{},
 		events.GroupMemberRemoved{},
+		ocisevents.UserSignedIn{},
+		ocisevents.UserSignInFailed{},
+		ocisevents.UserPasswordChanged{},
+		ocisevents.RoleAssigned{},
+		ocisevents.RoleUnassigned{},
 	}
 }
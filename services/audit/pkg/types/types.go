@@ -249,6 +249,48 @@ type AuditEventUserFeatureChanged struct {
 	Features []events.UserFeature
 }
 
+// AuditEventUserPasswordChanged is the event logged when a user changed their password
+type AuditEventUserPasswordChanged struct {
+	AuditEvent
+	UserID string
+}
+
+// AuditEventUserRoleAssigned is the event logged when a role is assigned to a user
+type AuditEventUserRoleAssigned struct {
+	AuditEvent
+	UserID       string
+	RoleID       string
+	AssignmentID string
+}
+
+// AuditEventUserRoleUnassigned is the event logged when a role assignment of a user is removed
+type AuditEventUserRoleUnassigned struct {
+	AuditEvent
+	UserID       string
+	RoleID       string
+	AssignmentID string
+}
+
+/*
+   Authentication
+*/
+
+// AuditEventUserLogin is the event logged when a user logged in
+type AuditEventUserLogin struct {
+	AuditEvent
+	UserID     string
+	Username   string
+	AuthMethod string // the authentication method, eg: oidc or basic
+}
+
+// AuditEventUserLoginFailed is the event logged when the credentials of a user were rejected
+type AuditEventUserLoginFailed struct {
+	AuditEvent
+	Username   string
+	AuthMethod string // the authentication method, eg: oidc or basic
+	Reason     string
+}
+
 // AuditEventGroupCreated is the event logged when a group is created
 type AuditEventGroupCreated struct {
 	AuditEvent
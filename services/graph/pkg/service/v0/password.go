@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/CiscoM31/godata"
 	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
@@ -12,6 +13,7 @@ import (
 	"github.com/cs3org/reva/v2/pkg/events"
 	"github.com/go-chi/render"
 	libregraph "github.com/owncloud/libre-graph-api-go"
+	ocisevents "github.com/owncloud/ocis/v2/ocis-pkg/events"
 	"github.com/owncloud/ocis/v2/services/graph/pkg/service/v0/errorcode"
 )
 
@@ -100,6 +102,13 @@ func (g Graph) ChangeOwnPassword(w http.ResponseWriter, r *http.Request) {
 			},
 		},
 	)
+	g.publishEvent(
+		ocisevents.UserPasswordChanged{
+			Executant: currentUser.Id,
+			UserID:    u.Id.OpaqueId,
+			Timestamp: time.Now(),
+		},
+	)
 
 	render.Status(r, http.StatusNoContent)
 	render.NoContent(w, r)
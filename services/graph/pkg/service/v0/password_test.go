@@ -11,11 +11,13 @@ import (
 	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
 	userv1beta1 "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
 	revactx "github.com/cs3org/reva/v2/pkg/ctx"
+	"github.com/cs3org/reva/v2/pkg/events"
 	"github.com/cs3org/reva/v2/pkg/rgrpc/status"
 	"github.com/go-ldap/ldap/v3"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	libregraph "github.com/owncloud/libre-graph-api-go"
+	ocisevents "github.com/owncloud/ocis/v2/ocis-pkg/events"
 	"github.com/owncloud/ocis/v2/ocis-pkg/log"
 	"github.com/owncloud/ocis/v2/services/graph/mocks"
 	"github.com/owncloud/ocis/v2/services/graph/pkg/config"
@@ -118,6 +120,25 @@ var _ = Describe("Users changing their own password", func() {
 		Entry("fails when current password is wrong", "currentpassword", "newpassword", "deny", http.StatusBadRequest),
 		Entry("succeeds when current password is correct", "currentpassword", "newpassword", "", http.StatusNoContent),
 	)
+
+	It("publishes the password change as a feature change and a password change", func() {
+		gatewayClient.On("Authenticate", mock.Anything, mock.Anything).Return(&gateway.AuthenticateResponse{
+			Status: status.NewOK(ctx),
+			Token:  "authtoken",
+		}, nil)
+		cpw := libregraph.NewPasswordChangeWithDefaults()
+		cpw.SetCurrentPassword("currentpassword")
+		cpw.SetNewPassword("newpassword")
+		body, _ := json.Marshal(cpw)
+		r := httptest.NewRequest(http.MethodPost, "/graph/v1.0/me/changePassword", bytes.NewBuffer(body)).WithContext(ctx)
+		rr := httptest.NewRecorder()
+		svc.ChangeOwnPassword(rr, r)
+		Expect(rr.Code).To(Equal(http.StatusNoContent))
+
+		eventsPublisher.AssertNumberOfCalls(GinkgoT(), "Publish", 2)
+		Expect(eventsPublisher.Calls[0].Arguments.Get(1)).To(BeAssignableToTypeOf(events.UserFeatureChanged{}))
+		Expect(eventsPublisher.Calls[1].Arguments.Get(1)).To(BeAssignableToTypeOf(ocisevents.UserPasswordChanged{}))
+	})
 })
 
 func mockedLDAPClient() *mocks.Client {
@@ -25,6 +25,31 @@ type Channel interface {
 	SendMessageToGroup(ctx context.Context, groupdID *groups.GroupId, msg, subject, senderDisplayName string) error
 }
 
+// RecipientsError is returned by channels which could send a message to some of the users only.
+type RecipientsError struct {
+	// Failed are the users who didn't get the message
+	Failed []string
+	Err    error
+}
+
+func (e *RecipientsError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RecipientsError) Unwrap() error {
+	return e.Err
+}
+
+// FailedRecipients returns the users who didn't get a message because of err. Unless the channel
+// returned a RecipientsError none of the users got it.
+func FailedRecipients(err error, userIDs []string) []string {
+	var re *RecipientsError
+	if errors.As(err, &re) {
+		return re.Failed
+	}
+	return userIDs
+}
+
 // NewMailChannel instantiates a new mail communication channel.
 func NewMailChannel(cfg config.Config, logger log.Logger) (Channel, error) {
 	tm, err := pool.StringToTLSMode(cfg.Notifications.GRPCClientTLS.Mode)
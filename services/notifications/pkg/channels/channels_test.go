@@ -0,0 +1,22 @@
+package channels_test
+
+import (
+	"errors"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/owncloud/ocis/v2/services/notifications/pkg/channels"
+)
+
+var _ = Describe("FailedRecipients", func() {
+	It("returns the users the channel couldn't send the message to", func() {
+		err := fmt.Errorf("wrapped: %w", &channels.RecipientsError{Failed: []string{"einstein"}, Err: errors.New("down")})
+		Expect(channels.FailedRecipients(err, []string{"marie", "einstein"})).To(Equal([]string{"einstein"}))
+		Expect(err.Error()).To(Equal("wrapped: down"))
+	})
+
+	It("returns all users for other errors", func() {
+		Expect(channels.FailedRecipients(errors.New("down"), []string{"marie", "einstein"})).To(Equal([]string{"marie", "einstein"}))
+	})
+})
@@ -0,0 +1,144 @@
+package channels
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	groups "github.com/cs3org/go-cs3apis/cs3/identity/group/v1beta1"
+	"github.com/cs3org/reva/v2/pkg/rgrpc/todo/pool"
+	"github.com/owncloud/ocis/v2/ocis-pkg/log"
+	settingssvc "github.com/owncloud/ocis/v2/protogen/gen/ocis/services/settings/v0"
+	"github.com/owncloud/ocis/v2/services/notifications/pkg/config"
+	"github.com/owncloud/ocis/v2/services/settings/pkg/store/defaults"
+)
+
+// chatMessage is understood by the incoming webhooks of Slack and Mattermost and by the generic webhooks of
+// the Matrix hookshot bridge
+type chatMessage struct {
+	Text string `json:"text"`
+}
+
+// NewChatChannel instantiates a new chat communication channel.
+func NewChatChannel(cfg config.Config, logger log.Logger, vs settingssvc.ValueService) (Channel, error) {
+	tm, err := pool.StringToTLSMode(cfg.Notifications.GRPCClientTLS.Mode)
+	if err != nil {
+		logger.Error().Err(err).Msg("could not get gateway client tls mode")
+		return nil, err
+	}
+	gc, err := pool.GetGatewayServiceClient(cfg.Notifications.RevaGateway,
+		pool.WithTLSCACert(cfg.Notifications.GRPCClientTLS.CACert),
+		pool.WithTLSMode(tm),
+	)
+	if err != nil {
+		logger.Error().Err(err).Msg("could not get gateway client")
+		return nil, err
+	}
+
+	return Chat{
+		gatewayClient: gc,
+		valueService:  vs,
+		allowedHosts:  cfg.Notifications.ChatWebhook.AllowedHosts,
+		client: &http.Client{
+			Timeout: _webhookTimeout,
+			// redirects could lead to hosts which are not allowed
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		logger: logger,
+	}, nil
+}
+
+// Chat is the communication channel posting to the chat webhooks the users configured in their settings.
+type Chat struct {
+	gatewayClient gateway.GatewayAPIClient
+	valueService  settingssvc.ValueService
+	allowedHosts  []string
+	client        *http.Client
+	logger        log.Logger
+}
+
+// SendMessage posts a message to the chat webhooks of all given users.
+func (c Chat) SendMessage(ctx context.Context, userIDs []string, msg, subject, senderDisplayName string) error {
+	body, err := json.Marshal(chatMessage{Text: subject + "\n\n" + msg})
+	if err != nil {
+		return err
+	}
+
+	var failed []string
+	for _, id := range userIDs {
+		webhook, err := userSetting(c.valueService, id, defaults.SettingUUIDNotificationChatWebhookURL)
+		if err != nil {
+			c.logger.Error().Err(err).Str("userid", id).Msg("could not get chat webhook")
+			failed = append(failed, id)
+			continue
+		}
+		if webhook == "" {
+			c.logger.Debug().Str("userid", id).Msg("user has no chat webhook")
+			continue
+		}
+		if err := c.post(ctx, webhook, body); err != nil {
+			c.logger.Error().Err(err).Str("userid", id).Msg("could not post to chat webhook")
+			failed = append(failed, id)
+		}
+	}
+
+	if len(failed) > 0 {
+		return &RecipientsError{
+			Failed: failed,
+			Err:    fmt.Errorf("could not post to the chat webhooks of %s", strings.Join(failed, ", ")),
+		}
+	}
+	return nil
+}
+
+// SendMessageToGroup posts a message to the chat webhooks of all members of the given group.
+func (c Chat) SendMessageToGroup(ctx context.Context, groupID *groups.GroupId, msg, subject, senderDisplayName string) error {
+	members, err := groupMembers(ctx, c.gatewayClient, groupID)
+	if err != nil {
+		return err
+	}
+	return c.SendMessage(ctx, members, msg, subject, senderDisplayName)
+}
+
+func (c Chat) post(ctx context.Context, webhook string, body []byte) error {
+	u, err := url.Parse(webhook)
+	if err != nil {
+		return err
+	}
+	// the URLs are chosen by the users, they must not make the server talk to anything else
+	if u.Scheme != "https" || !c.allowed(u.Hostname()) {
+		return fmt.Errorf("chat webhook host '%s' is not allowed", u.Host)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("chat webhook responded with status %d", res.StatusCode)
+	}
+	return nil
+}
+
+func (c Chat) allowed(host string) bool {
+	for _, h := range c.allowedHosts {
+		if strings.EqualFold(strings.TrimSpace(h), host) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package channels
+
+import (
+	"context"
+	"errors"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	groups "github.com/cs3org/go-cs3apis/cs3/identity/group/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	"github.com/owncloud/ocis/v2/ocis-pkg/middleware"
+	settingssvc "github.com/owncloud/ocis/v2/protogen/gen/ocis/services/settings/v0"
+	"github.com/owncloud/ocis/v2/services/settings/pkg/store/defaults"
+	"go-micro.dev/v4/metadata"
+)
+
+// userSetting returns the string value a user saved for a setting of the notifications bundle
+func userSetting(vs settingssvc.ValueService, userID, settingID string) (string, error) {
+	// the settings service only hands out the values of the user asking for them
+	ctx := metadata.Set(context.Background(), middleware.AccountID, userID)
+	res, err := vs.ListValues(ctx, &settingssvc.ListValuesRequest{
+		BundleId:    defaults.BundleUUIDNotifications,
+		AccountUuid: userID,
+	})
+	if err != nil {
+		return "", err
+	}
+	for _, v := range res.GetValues() {
+		if v.GetValue().GetSettingId() == settingID {
+			return v.GetValue().GetStringValue(), nil
+		}
+	}
+	return "", nil
+}
+
+// groupMembers returns the ids of the members of a group
+func groupMembers(ctx context.Context, gc gateway.GatewayAPIClient, groupID *groups.GroupId) ([]string, error) {
+	res, err := gc.GetGroup(ctx, &groups.GetGroupRequest{GroupId: groupID})
+	if err != nil {
+		return nil, err
+	}
+	if res.GetStatus().GetCode() != rpc.Code_CODE_OK {
+		return nil, errors.New("could not get group")
+	}
+
+	members := make([]string, 0, len(res.GetGroup().GetMembers()))
+	for _, id := range res.GetGroup().GetMembers() {
+		members = append(members, id.GetOpaqueId())
+	}
+	return members, nil
+}
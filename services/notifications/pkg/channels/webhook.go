@@ -0,0 +1,136 @@
+package channels
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	groups "github.com/cs3org/go-cs3apis/cs3/identity/group/v1beta1"
+	"github.com/owncloud/ocis/v2/ocis-pkg/log"
+	"github.com/owncloud/ocis/v2/services/notifications/pkg/config"
+)
+
+const (
+	// SignatureHeader holds the signature of the payload of a webhook, like `sha256=<hex encoded hmac>`.
+	// The hmac is computed over the timestamp header, a dot and the body.
+	SignatureHeader = "X-OCIS-Signature"
+	// TimestampHeader holds the unix time a webhook was sent at
+	TimestampHeader = "X-OCIS-Timestamp"
+
+	_webhookTimeout = 10 * time.Second
+)
+
+type eventKey struct{}
+
+// ContextWithEvent returns a context carrying the name of the event a message is sent for
+func ContextWithEvent(ctx context.Context, event string) context.Context {
+	return context.WithValue(ctx, eventKey{}, event)
+}
+
+// EventFromContext returns the name of the event a message is sent for
+func EventFromContext(ctx context.Context) string {
+	event, _ := ctx.Value(eventKey{}).(string)
+	return event
+}
+
+// WebhookPayload is the json posted to the webhook
+type WebhookPayload struct {
+	Event      string    `json:"event,omitempty"`
+	Recipients []string  `json:"recipients,omitempty"`
+	Group      string    `json:"group,omitempty"`
+	Sender     string    `json:"sender,omitempty"`
+	Subject    string    `json:"subject"`
+	Message    string    `json:"message"`
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+// NewWebhookChannel instantiates a new webhook communication channel.
+func NewWebhookChannel(cfg config.Config, logger log.Logger) Channel {
+	return Webhook{
+		conf: cfg.Notifications.Webhook,
+		client: &http.Client{
+			Timeout: _webhookTimeout,
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: cfg.Notifications.Webhook.Insecure}, //nolint:gosec
+			},
+		},
+		logger: logger,
+	}
+}
+
+// Webhook is the communication channel posting signed json to a URL, to hook notifications into other systems.
+type Webhook struct {
+	conf   config.Webhook
+	client *http.Client
+	logger log.Logger
+}
+
+// SendMessage posts a message to the webhook.
+func (w Webhook) SendMessage(ctx context.Context, userIDs []string, msg, subject, senderDisplayName string) error {
+	return w.post(ctx, WebhookPayload{
+		Event:      EventFromContext(ctx),
+		Recipients: userIDs,
+		Sender:     senderDisplayName,
+		Subject:    subject,
+		Message:    msg,
+		Timestamp:  time.Now().UTC(),
+	})
+}
+
+// SendMessageToGroup posts a message for a group to the webhook.
+func (w Webhook) SendMessageToGroup(ctx context.Context, groupID *groups.GroupId, msg, subject, senderDisplayName string) error {
+	return w.post(ctx, WebhookPayload{
+		Event:     EventFromContext(ctx),
+		Group:     groupID.GetOpaqueId(),
+		Sender:    senderDisplayName,
+		Subject:   subject,
+		Message:   msg,
+		Timestamp: time.Now().UTC(),
+	})
+}
+
+func (w Webhook) post(ctx context.Context, p WebhookPayload) error {
+	body, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.conf.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(p.Timestamp.Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(TimestampHeader, timestamp)
+	if w.conf.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(w.conf.Secret, timestamp, body))
+	}
+
+	res, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with status %d", res.StatusCode)
+	}
+	return nil
+}
+
+// Sign returns the signature of a webhook payload, receivers compare it to the SignatureHeader
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,68 @@
+package channels_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/owncloud/ocis/v2/ocis-pkg/log"
+	"github.com/owncloud/ocis/v2/services/notifications/pkg/channels"
+	"github.com/owncloud/ocis/v2/services/notifications/pkg/config"
+)
+
+var _ = Describe("Webhook", func() {
+	var (
+		received chan *http.Request
+		bodies   chan []byte
+		status   int
+		srv      *httptest.Server
+		cfg      config.Config
+	)
+
+	BeforeEach(func() {
+		received = make(chan *http.Request, 1)
+		bodies = make(chan []byte, 1)
+		status = http.StatusNoContent
+		srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			b, _ := io.ReadAll(r.Body)
+			received <- r
+			bodies <- b
+			w.WriteHeader(status)
+		}))
+		cfg = config.Config{}
+		cfg.Notifications.Webhook = config.Webhook{URL: srv.URL, Secret: "secret"}
+	})
+
+	AfterEach(func() {
+		srv.Close()
+	})
+
+	It("posts signed notifications", func() {
+		wh := channels.NewWebhookChannel(cfg, log.NewLogger())
+		ctx := channels.ContextWithEvent(context.Background(), "shareCreated")
+		Expect(wh.SendMessage(ctx, []string{"einstein"}, "message", "subject", "marie")).To(Succeed())
+
+		r := <-received
+		body := <-bodies
+		Expect(r.Header.Get("Content-Type")).To(Equal("application/json"))
+		Expect(r.Header.Get(channels.SignatureHeader)).To(Equal(channels.Sign("secret", r.Header.Get(channels.TimestampHeader), body)))
+
+		var p channels.WebhookPayload
+		Expect(json.Unmarshal(body, &p)).To(Succeed())
+		Expect(p.Event).To(Equal("shareCreated"))
+		Expect(p.Recipients).To(Equal([]string{"einstein"}))
+		Expect(p.Sender).To(Equal("marie"))
+		Expect(p.Subject).To(Equal("subject"))
+		Expect(p.Message).To(Equal("message"))
+	})
+
+	It("fails if the webhook does not accept the notification", func() {
+		status = http.StatusInternalServerError
+		wh := channels.NewWebhookChannel(cfg, log.NewLogger())
+		Expect(wh.SendMessage(context.Background(), []string{"einstein"}, "message", "subject", "")).ToNot(Succeed())
+	})
+})
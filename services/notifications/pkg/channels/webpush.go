@@ -0,0 +1,309 @@
+package channels
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	groups "github.com/cs3org/go-cs3apis/cs3/identity/group/v1beta1"
+	"github.com/cs3org/reva/v2/pkg/rgrpc/todo/pool"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/owncloud/ocis/v2/ocis-pkg/log"
+	settingssvc "github.com/owncloud/ocis/v2/protogen/gen/ocis/services/settings/v0"
+	"github.com/owncloud/ocis/v2/services/notifications/pkg/config"
+	"github.com/owncloud/ocis/v2/services/settings/pkg/store/defaults"
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	// _pushRecordSize is the record size of the encrypted content, messages have to fit into one record
+	_pushRecordSize = 4096
+	// _pushMaxPayload leaves room for the padding delimiter and the authentication tag in the record
+	_pushMaxPayload = _pushRecordSize - 17
+)
+
+// PushSubscription is the subscription of a browser as returned by PushSubscription.toJSON() in the browser
+type PushSubscription struct {
+	Endpoint string `json:"endpoint"`
+	Keys     struct {
+		P256dh string `json:"p256dh"`
+		Auth   string `json:"auth"`
+	} `json:"keys"`
+}
+
+// pushMessage is the payload the service worker of the browser receives
+type pushMessage struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+// NewWebPushChannel instantiates a new Web Push communication channel.
+func NewWebPushChannel(cfg config.Config, logger log.Logger, vs settingssvc.ValueService) (Channel, error) {
+	key, err := ParseVAPIDPrivateKey(cfg.Notifications.WebPush.VAPIDPrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	tm, err := pool.StringToTLSMode(cfg.Notifications.GRPCClientTLS.Mode)
+	if err != nil {
+		logger.Error().Err(err).Msg("could not get gateway client tls mode")
+		return nil, err
+	}
+	gc, err := pool.GetGatewayServiceClient(cfg.Notifications.RevaGateway,
+		pool.WithTLSCACert(cfg.Notifications.GRPCClientTLS.CACert),
+		pool.WithTLSMode(tm),
+	)
+	if err != nil {
+		logger.Error().Err(err).Msg("could not get gateway client")
+		return nil, err
+	}
+
+	return WebPush{
+		gatewayClient: gc,
+		valueService:  vs,
+		key:           key,
+		conf:          cfg.Notifications.WebPush,
+		client:        &http.Client{Timeout: _webhookTimeout},
+		logger:        logger,
+	}, nil
+}
+
+// WebPush is the communication channel sending notifications to the browsers the users subscribed with.
+type WebPush struct {
+	gatewayClient gateway.GatewayAPIClient
+	valueService  settingssvc.ValueService
+	key           *ecdsa.PrivateKey
+	conf          config.WebPush
+	client        *http.Client
+	logger        log.Logger
+}
+
+// SendMessage sends a message to the subscribed browsers of all given users.
+func (p WebPush) SendMessage(ctx context.Context, userIDs []string, msg, subject, senderDisplayName string) error {
+	payload, err := json.Marshal(pushMessage{Title: subject, Body: msg})
+	if err != nil {
+		return err
+	}
+	if len(payload) > _pushMaxPayload {
+		return fmt.Errorf("push message of %d bytes exceeds the maximum of %d bytes", len(payload), _pushMaxPayload)
+	}
+
+	var failed []string
+	for _, id := range userIDs {
+		value, err := userSetting(p.valueService, id, defaults.SettingUUIDNotificationPushSubscriptions)
+		if err != nil {
+			p.logger.Error().Err(err).Str("userid", id).Msg("could not get push subscriptions")
+			failed = append(failed, id)
+			continue
+		}
+		if value == "" {
+			p.logger.Debug().Str("userid", id).Msg("user has no push subscriptions")
+			continue
+		}
+
+		var subscriptions []PushSubscription
+		if err := json.Unmarshal([]byte(value), &subscriptions); err != nil {
+			p.logger.Error().Err(err).Str("userid", id).Msg("could not parse push subscriptions")
+			failed = append(failed, id)
+			continue
+		}
+		ok := true
+		for _, s := range subscriptions {
+			if err := p.push(ctx, s, payload); err != nil {
+				p.logger.Error().Err(err).Str("userid", id).Str("endpoint", s.Endpoint).Msg("could not send push message")
+				ok = false
+			}
+		}
+		if !ok {
+			failed = append(failed, id)
+		}
+	}
+
+	if len(failed) > 0 {
+		return &RecipientsError{
+			Failed: failed,
+			Err:    fmt.Errorf("could not send push messages to %s", strings.Join(failed, ", ")),
+		}
+	}
+	return nil
+}
+
+// SendMessageToGroup sends a message to the subscribed browsers of all members of the given group.
+func (p WebPush) SendMessageToGroup(ctx context.Context, groupID *groups.GroupId, msg, subject, senderDisplayName string) error {
+	members, err := groupMembers(ctx, p.gatewayClient, groupID)
+	if err != nil {
+		return err
+	}
+	return p.SendMessage(ctx, members, msg, subject, senderDisplayName)
+}
+
+// push sends an encrypted message to the push service of a browser, see RFC 8030
+func (p WebPush) push(ctx context.Context, s PushSubscription, payload []byte) error {
+	endpoint, err := url.Parse(s.Endpoint)
+	if err != nil {
+		return err
+	}
+	if endpoint.Scheme != "https" {
+		return errors.New("push endpoints must use https")
+	}
+
+	uaPublic, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s.Keys.P256dh, "="))
+	if err != nil {
+		return err
+	}
+	authSecret, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s.Keys.Auth, "="))
+	if err != nil {
+		return err
+	}
+	body, err := EncryptPushMessage(payload, uaPublic, authSecret)
+	if err != nil {
+		return err
+	}
+
+	authorization, err := p.vapidAuthorization(endpoint)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("TTL", strconv.Itoa(p.conf.TTL))
+	req.Header.Set("Authorization", authorization)
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	switch {
+	case res.StatusCode == http.StatusNotFound || res.StatusCode == http.StatusGone:
+		// the browser unsubscribed, the web ui removes the subscription from the settings
+		p.logger.Debug().Str("endpoint", s.Endpoint).Msg("push subscription expired")
+		return nil
+	case res.StatusCode < 200 || res.StatusCode > 299:
+		return fmt.Errorf("push service responded with status %d", res.StatusCode)
+	}
+	return nil
+}
+
+// vapidAuthorization returns the authorization header identifying the server to a push service, see RFC 8292
+func (p WebPush) vapidAuthorization(endpoint *url.URL) (string, error) {
+	claims := jwt.MapClaims{
+		"aud": endpoint.Scheme + "://" + endpoint.Host,
+		"exp": time.Now().Add(12 * time.Hour).Unix(),
+	}
+	if p.conf.Subject != "" {
+		claims["sub"] = p.conf.Subject
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodES256, claims).SignedString(p.key)
+	if err != nil {
+		return "", err
+	}
+	public := elliptic.Marshal(elliptic.P256(), p.key.X, p.key.Y)
+	return fmt.Sprintf("vapid t=%s, k=%s", token, base64.RawURLEncoding.EncodeToString(public)), nil
+}
+
+// ParseVAPIDPrivateKey parses a base64url encoded P-256 private key as generated by the common Web Push tools
+func ParseVAPIDPrivateKey(s string) (*ecdsa.PrivateKey, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+	if len(raw) != 32 {
+		return nil, fmt.Errorf("invalid VAPID private key: expected 32 bytes, got %d", len(raw))
+	}
+
+	curve := elliptic.P256()
+	key := &ecdsa.PrivateKey{D: new(big.Int).SetBytes(raw)}
+	key.PublicKey.Curve = curve
+	key.PublicKey.X, key.PublicKey.Y = curve.ScalarBaseMult(raw)
+	return key, nil
+}
+
+// EncryptPushMessage encrypts a message for the browser holding the given keys, see RFC 8291
+func EncryptPushMessage(payload, uaPublic, authSecret []byte) ([]byte, error) {
+	curve := elliptic.P256()
+	uaX, uaY := elliptic.Unmarshal(curve, uaPublic)
+	if uaX == nil {
+		return nil, errors.New("invalid p256dh key of the push subscription")
+	}
+
+	asPrivate, asX, asY, err := elliptic.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	asPublic := elliptic.Marshal(curve, asX, asY)
+
+	sharedX, _ := curve.ScalarMult(uaX, uaY, asPrivate)
+	ecdhSecret := make([]byte, 32)
+	sharedX.FillBytes(ecdhSecret)
+
+	keyInfo := append(append([]byte("WebPush: info\x00"), uaPublic...), asPublic...)
+	ikm, err := hkdfExpand(ecdhSecret, authSecret, keyInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	cek, err := hkdfExpand(ikm, salt, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hkdfExpand(ikm, salt, []byte("Content-Encoding: nonce\x00"), 12)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// the header of the single record: salt, record size, key id length and the public key of the server as key id
+	header := make([]byte, 16+4+1, 16+4+1+len(asPublic))
+	copy(header, salt)
+	binary.BigEndian.PutUint32(header[16:], _pushRecordSize)
+	header[20] = byte(len(asPublic))
+	header = append(header, asPublic...)
+
+	// 0x02 marks the last record
+	record := append(append([]byte{}, payload...), 0x02)
+	return gcm.Seal(header, nonce, record, nil), nil
+}
+
+func hkdfExpand(secret, salt, info []byte, length int) ([]byte, error) {
+	out := make([]byte, length)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, secret, salt, info), out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,82 @@
+package channels_test
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/owncloud/ocis/v2/services/notifications/pkg/channels"
+	"golang.org/x/crypto/hkdf"
+)
+
+var _ = Describe("Web Push", func() {
+	It("encrypts messages the browser can decrypt", func() {
+		curve := elliptic.P256()
+		uaPrivate, x, y, err := elliptic.GenerateKey(curve, rand.Reader)
+		Expect(err).ToNot(HaveOccurred())
+		uaPublic := elliptic.Marshal(curve, x, y)
+		authSecret := make([]byte, 16)
+		_, err = rand.Read(authSecret)
+		Expect(err).ToNot(HaveOccurred())
+
+		body, err := channels.EncryptPushMessage([]byte(`{"title":"hello"}`), uaPublic, authSecret)
+		Expect(err).ToNot(HaveOccurred())
+
+		// decrypt like a browser, see RFC 8291
+		salt := body[:16]
+		Expect(binary.BigEndian.Uint32(body[16:20])).To(Equal(uint32(4096)))
+		keyIDLen := int(body[20])
+		asPublic := body[21 : 21+keyIDLen]
+		ciphertext := body[21+keyIDLen:]
+
+		asX, asY := elliptic.Unmarshal(curve, asPublic)
+		Expect(asX).ToNot(BeNil())
+		sharedX, _ := curve.ScalarMult(asX, asY, uaPrivate)
+		ecdhSecret := make([]byte, 32)
+		sharedX.FillBytes(ecdhSecret)
+
+		keyInfo := append(append([]byte("WebPush: info\x00"), uaPublic...), asPublic...)
+		ikm := expand(ecdhSecret, authSecret, keyInfo, 32)
+		cek := expand(ikm, salt, []byte("Content-Encoding: aes128gcm\x00"), 16)
+		nonce := expand(ikm, salt, []byte("Content-Encoding: nonce\x00"), 12)
+
+		block, err := aes.NewCipher(cek)
+		Expect(err).ToNot(HaveOccurred())
+		gcm, err := cipher.NewGCM(block)
+		Expect(err).ToNot(HaveOccurred())
+		plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(plaintext)).To(Equal("{\"title\":\"hello\"}\x02"))
+	})
+
+	It("rejects invalid subscription keys", func() {
+		_, err := channels.EncryptPushMessage([]byte("hello"), []byte("not a key"), make([]byte, 16))
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("parses VAPID private keys", func() {
+		key, err := channels.ParseVAPIDPrivateKey(base64.RawURLEncoding.EncodeToString(make([]byte, 31)))
+		Expect(err).To(HaveOccurred())
+		Expect(key).To(BeNil())
+
+		raw := make([]byte, 32)
+		raw[31] = 1
+		key, err = channels.ParseVAPIDPrivateKey(base64.RawURLEncoding.EncodeToString(raw))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(key.X).To(Equal(elliptic.P256().Params().Gx), "the public key of 1 is the base point")
+	})
+})
+
+func expand(secret, salt, info []byte, length int) []byte {
+	out := make([]byte, length)
+	_, err := io.ReadFull(hkdf.New(sha256.New, secret, salt, info), out)
+	Expect(err).ToNot(HaveOccurred())
+	return out
+}
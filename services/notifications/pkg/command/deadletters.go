@@ -0,0 +1,168 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/owncloud/ocis/v2/ocis-pkg/config/configlog"
+	"github.com/owncloud/ocis/v2/ocis-pkg/store"
+	"github.com/owncloud/ocis/v2/services/notifications/pkg/config"
+	"github.com/owncloud/ocis/v2/services/notifications/pkg/config/parser"
+	"github.com/owncloud/ocis/v2/services/notifications/pkg/outbox"
+	"github.com/urfave/cli/v2"
+)
+
+// DeadLetters is the entrypoint for the dead-letters command.
+func DeadLetters(cfg *config.Config) *cli.Command {
+	return &cli.Command{
+		Name:  "dead-letters",
+		Usage: "manage notifications which could not be delivered",
+		Subcommands: []*cli.Command{
+			ListDeadLetters(cfg),
+			ReplayDeadLetters(cfg),
+			DiscardDeadLetters(cfg),
+		},
+	}
+}
+
+// ListDeadLetters prints the notifications which could not be delivered
+func ListDeadLetters(cfg *config.Config) *cli.Command {
+	return &cli.Command{
+		Name:  "list",
+		Usage: "Print a list of the notifications which could not be delivered",
+		Before: func(c *cli.Context) error {
+			return configlog.ReturnFatal(parser.ParseConfig(cfg))
+		},
+		Action: func(c *cli.Context) error {
+			ob, err := getOutbox(cfg)
+			if err != nil {
+				return err
+			}
+			msgs, err := ob.DeadLetters()
+			if err != nil {
+				return err
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tCREATED\tCHANNEL\tEVENT\tRECIPIENTS\tATTEMPTS\tLAST ERROR")
+			for _, m := range msgs {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\t%s\n", m.ID, m.Created.Format(time.RFC3339), m.Channel, m.Event, strings.Join(m.Recipients, ","), m.Attempts, m.LastError)
+			}
+			return w.Flush()
+		},
+	}
+}
+
+// ReplayDeadLetters moves notifications which could not be delivered back to the outbox
+func ReplayDeadLetters(cfg *config.Config) *cli.Command {
+	return &cli.Command{
+		Name:      "replay",
+		Usage:     "Move notifications which could not be delivered back to the outbox to retry them",
+		ArgsUsage: "[id...]",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "all",
+				Usage: "replay all notifications which could not be delivered",
+			},
+		},
+		Before: func(c *cli.Context) error {
+			return configlog.ReturnFatal(parser.ParseConfig(cfg))
+		},
+		Action: func(c *cli.Context) error {
+			ob, err := getOutbox(cfg)
+			if err != nil {
+				return err
+			}
+			ids, err := deadLetterIDs(c, ob)
+			if err != nil {
+				return err
+			}
+
+			now := time.Now()
+			for _, id := range ids {
+				if err := ob.Replay(id, now); err != nil {
+					return fmt.Errorf("could not replay %s: %w", id, err)
+				}
+				fmt.Printf("Replayed %s\n", id)
+			}
+			return nil
+		},
+	}
+}
+
+// DiscardDeadLetters removes notifications which could not be delivered for good
+func DiscardDeadLetters(cfg *config.Config) *cli.Command {
+	return &cli.Command{
+		Name:      "discard",
+		Usage:     "Remove notifications which could not be delivered for good",
+		ArgsUsage: "[id...]",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "all",
+				Usage: "discard all notifications which could not be delivered",
+			},
+		},
+		Before: func(c *cli.Context) error {
+			return configlog.ReturnFatal(parser.ParseConfig(cfg))
+		},
+		Action: func(c *cli.Context) error {
+			ob, err := getOutbox(cfg)
+			if err != nil {
+				return err
+			}
+			ids, err := deadLetterIDs(c, ob)
+			if err != nil {
+				return err
+			}
+
+			for _, id := range ids {
+				if err := ob.Discard(id); err != nil {
+					return fmt.Errorf("could not discard %s: %w", id, err)
+				}
+				fmt.Printf("Discarded %s\n", id)
+			}
+			return nil
+		},
+	}
+}
+
+// getOutbox returns the outbox of the notifications service
+func getOutbox(cfg *config.Config) (*outbox.Outbox, error) {
+	// the memory stores live in the process of the service
+	switch cfg.Notifications.Store.Type {
+	case "nats-js", "etcd":
+	default:
+		return nil, fmt.Errorf("the outbox is not accessible with the store type '%s', NOTIFICATIONS_STORE_TYPE must be 'nats-js' or 'etcd'", cfg.Notifications.Store.Type)
+	}
+
+	st := store.GetStore(store.OcisStoreOptions{
+		Type:     cfg.Notifications.Store.Type,
+		Address:  cfg.Notifications.Store.Address,
+		Database: cfg.Notifications.Store.Database,
+	})
+	return outbox.New(st, cfg.Notifications.Store, cfg.Notifications.Outbox), nil
+}
+
+// deadLetterIDs returns the ids given as arguments or the ids of all dead letters if the all flag is set
+func deadLetterIDs(c *cli.Context, ob *outbox.Outbox) ([]string, error) {
+	if !c.Bool("all") {
+		if c.NArg() == 0 {
+			return nil, errors.New("either pass the ids of the notifications or --all")
+		}
+		return c.Args().Slice(), nil
+	}
+
+	msgs, err := ob.DeadLetters()
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(msgs))
+	for _, m := range msgs {
+		ids = append(ids, m.ID)
+	}
+	return ids, nil
+}
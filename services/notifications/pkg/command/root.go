@@ -18,6 +18,7 @@ func GetCommands(cfg *config.Config) cli.Commands {
 		Server(cfg),
 
 		// interaction with this service
+		DeadLetters(cfg),
 
 		// infos about this service
 		Health(cfg),
@@ -12,11 +12,17 @@ import (
 	"github.com/go-micro/plugins/v4/events/natsjs"
 	"github.com/owncloud/ocis/v2/ocis-pkg/config/configlog"
 	"github.com/owncloud/ocis/v2/ocis-pkg/crypto"
+	"github.com/owncloud/ocis/v2/ocis-pkg/log"
+	"github.com/owncloud/ocis/v2/ocis-pkg/service/grpc"
+	"github.com/owncloud/ocis/v2/ocis-pkg/store"
+	settingssvc "github.com/owncloud/ocis/v2/protogen/gen/ocis/services/settings/v0"
 	"github.com/owncloud/ocis/v2/services/notifications/pkg/channels"
 	"github.com/owncloud/ocis/v2/services/notifications/pkg/config"
 	"github.com/owncloud/ocis/v2/services/notifications/pkg/config/parser"
 	"github.com/owncloud/ocis/v2/services/notifications/pkg/logging"
+	"github.com/owncloud/ocis/v2/services/notifications/pkg/outbox"
 	"github.com/owncloud/ocis/v2/services/notifications/pkg/service"
+	"github.com/owncloud/ocis/v2/services/settings/pkg/store/defaults"
 	"github.com/urfave/cli/v2"
 )
 
@@ -40,6 +46,15 @@ func Server(cfg *config.Config) *cli.Command {
 				events.SpaceUnshared{},
 				events.SpaceMembershipExpired{},
 			}
+			if cfg.Notifications.Triggers.VirusFound || cfg.Notifications.Triggers.UploadAborted {
+				evs = append(evs, events.PostprocessingFinished{})
+			}
+			if cfg.Notifications.Triggers.SpaceQuota {
+				evs = append(evs, events.UploadReady{})
+			}
+			if cfg.Notifications.Triggers.ShareExpiring {
+				evs = append(evs, events.ShareUpdated{}, events.ShareRemoved{})
+			}
 
 			evtsCfg := cfg.Notifications.Events
 
@@ -77,10 +92,6 @@ func Server(cfg *config.Config) *cli.Command {
 			if err != nil {
 				return err
 			}
-			channel, err := channels.NewMailChannel(*cfg, logger)
-			if err != nil {
-				return err
-			}
 			tm, err := pool.StringToTLSMode(cfg.Notifications.GRPCClientTLS.Mode)
 			if err != nil {
 				return err
@@ -94,8 +105,68 @@ func Server(cfg *config.Config) *cli.Command {
 				logger.Fatal().Err(err).Str("addr", cfg.Notifications.RevaGateway).Msg("could not get reva client")
 			}
 
-			svc := service.NewEventsNotifier(evts, channel, logger, gwclient, cfg.Notifications.MachineAuthAPIKey, cfg.Notifications.EmailTemplatePath, cfg.WebUIURL)
+			if err := grpc.Configure(grpc.GetClientOptions(cfg.Notifications.GRPCClientTLS)...); err != nil {
+				return err
+			}
+			valueService := settingssvc.NewValueService("com.owncloud.api.settings", grpc.DefaultClient())
+
+			chs, err := getChannels(cfg, logger, valueService)
+			if err != nil {
+				return err
+			}
+
+			st := store.GetStore(store.OcisStoreOptions{
+				Type:     cfg.Notifications.Store.Type,
+				Address:  cfg.Notifications.Store.Address,
+				Database: cfg.Notifications.Store.Database,
+			})
+
+			ob := outbox.New(st, cfg.Notifications.Store, cfg.Notifications.Outbox)
+
+			svc := service.NewEventsNotifier(
+				service.Logger(logger),
+				service.Config(cfg),
+				service.Events(evts),
+				service.Channels(chs),
+				service.GatewayClient(gwclient),
+				service.ValueService(valueService),
+				service.Store(st),
+				service.Outbox(ob),
+			)
 			return svc.Run()
 		},
 	}
 }
+
+// getChannels returns the configured channels by their name
+func getChannels(cfg *config.Config, logger log.Logger, vs settingssvc.ValueService) (map[string]channels.Channel, error) {
+	mail, err := channels.NewMailChannel(*cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+	chs := map[string]channels.Channel{
+		defaults.NotificationChannelMail: mail,
+	}
+
+	if cfg.Notifications.Webhook.URL != "" {
+		chs[defaults.NotificationChannelWebhook] = channels.NewWebhookChannel(*cfg, logger)
+	}
+
+	if len(cfg.Notifications.ChatWebhook.AllowedHosts) > 0 {
+		chat, err := channels.NewChatChannel(*cfg, logger, vs)
+		if err != nil {
+			return nil, err
+		}
+		chs[defaults.NotificationChannelChat] = chat
+	}
+
+	if cfg.Notifications.WebPush.VAPIDPrivateKey != "" {
+		push, err := channels.NewWebPushChannel(*cfg, logger, vs)
+		if err != nil {
+			return nil, err
+		}
+		chs[defaults.NotificationChannelPush] = push
+	}
+
+	return chs, nil
+}
@@ -2,6 +2,9 @@ package config
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/owncloud/ocis/v2/ocis-pkg/shared"
 )
@@ -27,9 +30,78 @@ type Notifications struct {
 	SMTP              SMTP                  `yaml:"SMTP"`
 	Events            Events                `yaml:"events"`
 	MachineAuthAPIKey string                `yaml:"machine_auth_api_key" env:"OCIS_MACHINE_AUTH_API_KEY;NOTIFICATIONS_MACHINE_AUTH_API_KEY" desc:"Machine auth API key used to validate internal requests necessary to access resources from other services."`
+	ServiceUserID     string                `yaml:"service_user_id" env:"OCIS_ADMIN_USER_ID;NOTIFICATIONS_SERVICE_USER_ID" desc:"ID of the user that is used to list all spaces when looking up the existing shares with an expiration date. The user needs the permission to list all spaces. Defaults to the admin user."`
 	EmailTemplatePath string                `yaml:"email_template_path" env:"OCIS_EMAIL_TEMPLATE_PATH;NOTIFICATIONS_EMAIL_TEMPLATE_PATH" desc:"Path to Email notification templates overriding embedded ones."`
+	DefaultLanguage   string                `yaml:"default_language" env:"NOTIFICATIONS_DEFAULT_LANGUAGE" desc:"The language of the notifications sent to users who didn't choose a language, like 'de'. Notifications are sent in English if there are no templates for the language."`
 	RevaGateway       string                `yaml:"reva_gateway" env:"REVA_GATEWAY" desc:"CS3 gateway used to look up user metadata"`
 	GRPCClientTLS     *shared.GRPCClientTLS `yaml:"grpc_client_tls"`
+	Digest            Digest                `yaml:"digest"`
+	Store             Store                 `yaml:"store"`
+	Outbox            Outbox                `yaml:"outbox"`
+	Triggers          Triggers              `yaml:"triggers"`
+	Channels          []string              `yaml:"channels" env:"NOTIFICATIONS_CHANNELS" desc:"A comma-separated list of the channels notifications are sent through to users who didn't choose any. Supported channels are 'mail', 'webhook', 'chat' and 'push'."`
+	Webhook           Webhook               `yaml:"webhook"`
+	ChatWebhook       ChatWebhook           `yaml:"chat_webhook"`
+	WebPush           WebPush               `yaml:"web_push"`
+}
+
+// Webhook defines the webhook channel, which posts notifications as signed json to a single URL.
+type Webhook struct {
+	URL      string `yaml:"url" env:"NOTIFICATIONS_WEBHOOK_URL" desc:"The URL notifications are posted to. The webhook channel is only available if this is set."`
+	Secret   string `yaml:"secret" env:"NOTIFICATIONS_WEBHOOK_SECRET" desc:"The secret the payload of the webhook is signed with. The signature is sent in the 'X-OCIS-Signature' header."`
+	Insecure bool   `yaml:"insecure" env:"NOTIFICATIONS_WEBHOOK_INSECURE" desc:"Allow insecure connections to the webhook."`
+}
+
+// ChatWebhook defines the chat channel, which posts notifications to the Slack, Mattermost or Matrix webhooks of the users.
+type ChatWebhook struct {
+	AllowedHosts []string `yaml:"allowed_hosts" env:"NOTIFICATIONS_CHAT_WEBHOOK_ALLOWED_HOSTS" desc:"A comma-separated list of the hosts users may post notifications to, like 'hooks.slack.com'. The chat channel is only available if this is set."`
+}
+
+// WebPush defines the Web Push channel, which sends notifications to the browsers of the users.
+type WebPush struct {
+	VAPIDPrivateKey string `yaml:"vapid_private_key" env:"NOTIFICATIONS_WEB_PUSH_VAPID_PRIVATE_KEY" desc:"The base64url encoded private VAPID key identifying oCIS to the push services. The Web Push channel is only available if this is set."`
+	Subject         string `yaml:"subject" env:"NOTIFICATIONS_WEB_PUSH_SUBJECT" desc:"A 'mailto:' or 'https:' URL the push services can contact the admin with."`
+	TTL             int    `yaml:"ttl" env:"NOTIFICATIONS_WEB_PUSH_TTL" desc:"The number of seconds a push service keeps a notification for a browser which is offline."`
+}
+
+// Digest defines when the notifications collected for users who chose a daily or weekly digest are sent.
+type Digest struct {
+	Hour    int    `yaml:"hour" env:"NOTIFICATIONS_DIGEST_HOUR" desc:"The hour of the day, from 0 to 23 in the local time of the server, at which daily and weekly digests are sent."`
+	Weekday string `yaml:"weekday" env:"NOTIFICATIONS_DIGEST_WEEKDAY" desc:"The day of the week weekly digests are sent on, like 'monday'."`
+}
+
+// ParseWeekday returns the day of the week weekly digests are sent on
+func (d Digest) ParseWeekday() (time.Weekday, error) {
+	for wd := time.Sunday; wd <= time.Saturday; wd++ {
+		if strings.EqualFold(d.Weekday, wd.String()) {
+			return wd, nil
+		}
+	}
+	return time.Sunday, fmt.Errorf("NOTIFICATIONS_DIGEST_WEEKDAY must be a day of the week like 'monday', got '%s'", d.Weekday)
+}
+
+// Store defines the store holding the notifications collected for digests and the outbox
+type Store struct {
+	Type     string `yaml:"type" env:"NOTIFICATIONS_STORE_TYPE" desc:"The type of the store holding the notifications collected for digests and the notifications waiting in the outbox. Valid options are \"nats-js\", \"etcd\", \"memory\" and \"ocmem\". Only \"nats-js\" and \"etcd\" keep the notifications across restarts of the notifications service and make the outbox available to the dead-letters command."`
+	Address  string `yaml:"address" env:"NOTIFICATIONS_STORE_ADDRESS" desc:"A comma-separated list of addresses to connect to. Only valid if the above setting is set to \"nats-js\" or \"etcd\"."`
+	Database string `yaml:"database" env:"NOTIFICATIONS_STORE_DATABASE" desc:"The database name the store should use. With \"nats-js\" it is the name of the bucket."`
+	Table    string `yaml:"table" env:"NOTIFICATIONS_STORE_TABLE" desc:"The table name the store should use."`
+}
+
+// Triggers defines which events besides shares and spaces users are notified about.
+type Triggers struct {
+	VirusFound        bool `yaml:"virus_found" env:"NOTIFICATIONS_TRIGGER_VIRUS_FOUND" desc:"Notify users when a virus was found in their upload."`
+	UploadAborted     bool `yaml:"upload_aborted" env:"NOTIFICATIONS_TRIGGER_UPLOAD_ABORTED" desc:"Notify users when the postprocessing of their upload was aborted."`
+	SpaceQuota        bool `yaml:"space_quota" env:"NOTIFICATIONS_TRIGGER_SPACE_QUOTA" desc:"Notify the managers of a space when it used 80%, 90% or 100% of its quota."`
+	ShareExpiring     bool `yaml:"share_expiring" env:"NOTIFICATIONS_TRIGGER_SHARE_EXPIRING" desc:"Notify users when a share with them is about to expire."`
+	ShareExpiringDays int  `yaml:"share_expiring_days" env:"NOTIFICATIONS_SHARE_EXPIRING_DAYS" desc:"The number of days before the expiration of a share the users it is shared with are notified."`
+}
+
+// Outbox defines how notifications which could not be delivered are retried.
+type Outbox struct {
+	MaxAttempts    int           `yaml:"max_attempts" env:"NOTIFICATIONS_OUTBOX_MAX_ATTEMPTS" desc:"The maximum number of attempts to deliver a notification through a channel. Notifications which could not be delivered are moved to the dead letters."`
+	InitialBackoff time.Duration `yaml:"initial_backoff" env:"NOTIFICATIONS_OUTBOX_INITIAL_BACKOFF" desc:"The time to wait before the first retry. The time doubles with every further attempt. The duration can be set as number followed by a unit identifier like s, m or h."`
+	MaxBackoff     time.Duration `yaml:"max_backoff" env:"NOTIFICATIONS_OUTBOX_MAX_BACKOFF" desc:"The maximum time to wait between two attempts. The duration can be set as number followed by a unit identifier like s, m or h."`
 }
 
 // SMTP combines the smtp configuration options.
@@ -1,6 +1,8 @@
 package defaults
 
 import (
+	"time"
+
 	"github.com/owncloud/ocis/v2/ocis-pkg/shared"
 	"github.com/owncloud/ocis/v2/services/notifications/pkg/config"
 )
@@ -39,6 +41,33 @@ func DefaultConfig() *config.Config {
 				EnableTLS:     false,
 			},
 			RevaGateway: shared.DefaultRevaConfig().Address,
+			Digest: config.Digest{
+				Hour:    7,
+				Weekday: "monday",
+			},
+			Store: config.Store{
+				Type:     "nats-js",
+				Address:  "127.0.0.1:9233",
+				Database: "services",
+				Table:    "services/notifications/",
+			},
+			Triggers: config.Triggers{
+				VirusFound:        true,
+				UploadAborted:     true,
+				SpaceQuota:        true,
+				ShareExpiring:     true,
+				ShareExpiringDays: 3,
+			},
+			Outbox: config.Outbox{
+				MaxAttempts:    8,
+				InitialBackoff: time.Minute,
+				MaxBackoff:     6 * time.Hour,
+			},
+			Channels:        []string{"mail"},
+			DefaultLanguage: "en",
+			WebPush: config.WebPush{
+				TTL: 86400,
+			},
 		},
 	}
 }
@@ -59,6 +88,9 @@ func EnsureDefaults(cfg *config.Config) {
 	if cfg.Notifications.MachineAuthAPIKey == "" && cfg.Commons != nil && cfg.Commons.MachineAuthAPIKey != "" {
 		cfg.Notifications.MachineAuthAPIKey = cfg.Commons.MachineAuthAPIKey
 	}
+	if cfg.Notifications.ServiceUserID == "" && cfg.Commons != nil {
+		cfg.Notifications.ServiceUserID = cfg.Commons.AdminUserID
+	}
 	if cfg.Notifications.GRPCClientTLS == nil {
 		cfg.Notifications.GRPCClientTLS = &shared.GRPCClientTLS{}
 		if cfg.Commons != nil && cfg.Commons.GRPCClientTLS != nil {
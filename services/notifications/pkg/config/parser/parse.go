@@ -2,6 +2,7 @@ package parser
 
 import (
 	"errors"
+	"fmt"
 
 	ociscfg "github.com/owncloud/ocis/v2/ocis-pkg/config"
 	"github.com/owncloud/ocis/v2/ocis-pkg/shared"
@@ -38,5 +39,43 @@ func Validate(cfg *config.Config) error {
 		return shared.MissingMachineAuthApiKeyError(cfg.Service.Name)
 	}
 
+	if cfg.Notifications.Digest.Hour < 0 || cfg.Notifications.Digest.Hour > 23 {
+		return fmt.Errorf("NOTIFICATIONS_DIGEST_HOUR must be between 0 and 23, got %d", cfg.Notifications.Digest.Hour)
+	}
+	if _, err := cfg.Notifications.Digest.ParseWeekday(); err != nil {
+		return err
+	}
+
+	if cfg.Notifications.Outbox.MaxAttempts < 1 {
+		return fmt.Errorf("NOTIFICATIONS_OUTBOX_MAX_ATTEMPTS must be at least 1, got %d", cfg.Notifications.Outbox.MaxAttempts)
+	}
+	if cfg.Notifications.Outbox.InitialBackoff <= 0 || cfg.Notifications.Outbox.MaxBackoff < cfg.Notifications.Outbox.InitialBackoff {
+		return errors.New("NOTIFICATIONS_OUTBOX_INITIAL_BACKOFF must be positive and must not exceed NOTIFICATIONS_OUTBOX_MAX_BACKOFF")
+	}
+
+	if cfg.Notifications.Triggers.ShareExpiring && cfg.Notifications.Triggers.ShareExpiringDays < 1 {
+		return fmt.Errorf("NOTIFICATIONS_SHARE_EXPIRING_DAYS must be at least 1, got %d", cfg.Notifications.Triggers.ShareExpiringDays)
+	}
+
+	for _, ch := range cfg.Notifications.Channels {
+		switch ch {
+		case "mail":
+		case "webhook":
+			if cfg.Notifications.Webhook.URL == "" {
+				return errors.New("the webhook notification channel needs NOTIFICATIONS_WEBHOOK_URL to be set")
+			}
+		case "chat":
+			if len(cfg.Notifications.ChatWebhook.AllowedHosts) == 0 {
+				return errors.New("the chat notification channel needs NOTIFICATIONS_CHAT_WEBHOOK_ALLOWED_HOSTS to be set")
+			}
+		case "push":
+			if cfg.Notifications.WebPush.VAPIDPrivateKey == "" {
+				return errors.New("the push notification channel needs NOTIFICATIONS_WEB_PUSH_VAPID_PRIVATE_KEY to be set")
+			}
+		default:
+			return fmt.Errorf("unknown notification channel '%s' in NOTIFICATIONS_CHANNELS", ch)
+		}
+	}
+
 	return nil
 }
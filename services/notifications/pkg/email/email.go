@@ -4,26 +4,50 @@ import (
 	"bytes"
 	"embed"
 	"html/template"
+	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"strings"
+	texttemplate "text/template"
 )
 
 var (
 	//go:embed templates
 	templatesFS embed.FS
+
+	// languagePattern matches language tags like "de" or "pt_BR", which are safe to use as directory names
+	languagePattern = regexp.MustCompile(`^[a-zA-Z]{2,3}([_-][a-zA-Z0-9]{2,8})?$`)
 )
 
-// RenderEmailTemplate renders the email template for a new share
-func RenderEmailTemplate(templateName string, templateVariables map[string]string, emailTemplatePath string) (string, error) {
-	var err error
-	var tpl *template.Template
-	// try to lookup the files in the filesystem
-	tpl, err = template.ParseFiles(filepath.Join(emailTemplatePath, templateName))
+// RenderEmailTemplate renders an email template in the given language with the given variables
+func RenderEmailTemplate(templateName, language string, templateVariables interface{}, emailTemplatePath string) (string, error) {
+	content, err := readTemplate(templateName, language, emailTemplatePath)
 	if err != nil {
-		// template has not been found in the fs, or path has not been specified => use embed templates
-		tpl, err = template.ParseFS(templatesFS, filepath.Join("templates/", templateName))
-		if err != nil {
-			return "", err
-		}
+		return "", err
+	}
+	tpl, err := template.New(templateName).Parse(content)
+	if err != nil {
+		return "", err
+	}
+	var writer bytes.Buffer
+	err = tpl.Execute(&writer, templateVariables)
+	if err != nil {
+		return "", err
+	}
+	return writer.String(), nil
+}
+
+// RenderTextTemplate renders a template of a channel which isn't email, like a chat message. Unlike email
+// templates the values are not escaped.
+func RenderTextTemplate(templateName, language string, templateVariables interface{}, templatePath string) (string, error) {
+	content, err := readTemplate(templateName, language, templatePath)
+	if err != nil {
+		return "", err
+	}
+	tpl, err := texttemplate.New(templateName).Parse(content)
+	if err != nil {
+		return "", err
 	}
 	var writer bytes.Buffer
 	err = tpl.Execute(&writer, templateVariables)
@@ -32,3 +56,36 @@ func RenderEmailTemplate(templateName string, templateVariables map[string]strin
 	}
 	return writer.String(), nil
 }
+
+// readTemplate returns the template translated to the language, or the default template if there is no
+// translation. Templates in the filesystem take precedence over the embedded ones, so that the default
+// templates can be replaced without translating them.
+func readTemplate(templateName, language, templatePath string) (string, error) {
+	candidates := []string{templateName}
+	if languagePattern.MatchString(language) {
+		candidates = []string{path.Join(language, templateName), templateName}
+		// fall back from regional languages like "de_CH" to "de"
+		if i := strings.IndexAny(language, "_-"); i > 0 {
+			candidates = []string{path.Join(language, templateName), path.Join(language[:i], templateName), templateName}
+		}
+	}
+
+	// try to lookup the files in the filesystem
+	if templatePath != "" {
+		for _, c := range candidates {
+			if b, err := os.ReadFile(filepath.Join(templatePath, filepath.FromSlash(c))); err == nil {
+				return string(b), nil
+			}
+		}
+	}
+
+	// template has not been found in the fs, or path has not been specified => use embed templates
+	var err error
+	for _, c := range candidates {
+		var b []byte
+		if b, err = templatesFS.ReadFile(path.Join("templates", c)); err == nil {
+			return string(b), nil
+		}
+	}
+	return "", err
+}
@@ -0,0 +1,203 @@
+// Package outbox keeps the notifications which could not be delivered until they are retried.
+package outbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/owncloud/ocis/v2/services/notifications/pkg/config"
+	"go-micro.dev/v4/store"
+)
+
+const (
+	outboxPrefix     = "outbox/"
+	deadLetterPrefix = "dead-letter/"
+)
+
+// Message is a rendered notification waiting to be delivered through a channel
+type Message struct {
+	ID          string    `json:"id"`
+	Channel     string    `json:"channel"`
+	Event       string    `json:"event"`
+	Recipients  []string  `json:"recipients"`
+	Subject     string    `json:"subject"`
+	Body        string    `json:"body"`
+	Sender      string    `json:"sender"`
+	Attempts    int       `json:"attempts"`
+	LastError   string    `json:"last_error"`
+	Created     time.Time `json:"created"`
+	NextAttempt time.Time `json:"next_attempt"`
+}
+
+// Outbox keeps the messages which could not be delivered in a store. Messages are retried with an
+// exponential backoff and moved to the dead letters after the maximum number of attempts.
+type Outbox struct {
+	store          store.Store
+	database       string
+	table          string
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+}
+
+// New returns an outbox keeping the messages in the given store
+func New(st store.Store, storeConfig config.Store, cfg config.Outbox) *Outbox {
+	return &Outbox{
+		store:          st,
+		database:       storeConfig.Database,
+		table:          storeConfig.Table,
+		maxAttempts:    cfg.MaxAttempts,
+		initialBackoff: cfg.InitialBackoff,
+		maxBackoff:     cfg.MaxBackoff,
+	}
+}
+
+// Add adds a message whose first attempt failed with the given error to the outbox
+func (o *Outbox) Add(m Message, cause error, now time.Time) error {
+	m.ID = fmt.Sprintf("%020d-%s", now.UnixNano(), uuid.Must(uuid.NewV4()).String())
+	m.Created = now
+	m.Attempts = 0
+	_, err := o.Failed(m, cause, now)
+	return err
+}
+
+// Due returns the messages whose next attempt is due, the oldest first
+func (o *Outbox) Due(now time.Time) ([]Message, error) {
+	msgs, err := o.list(outboxPrefix)
+	if err != nil {
+		return nil, err
+	}
+	due := msgs[:0]
+	for _, m := range msgs {
+		if !m.NextAttempt.After(now) {
+			due = append(due, m)
+		}
+	}
+	return due, nil
+}
+
+// Delivered removes a message which has been delivered from the outbox
+func (o *Outbox) Delivered(m Message) error {
+	return o.delete(outboxPrefix + m.ID)
+}
+
+// Failed records a failed attempt to deliver a message. It schedules the next attempt or moves the
+// message to the dead letters if it has reached the maximum number of attempts, which is reported
+// by the returned bool.
+func (o *Outbox) Failed(m Message, cause error, now time.Time) (bool, error) {
+	m.Attempts++
+	if cause != nil {
+		m.LastError = cause.Error()
+	}
+
+	if m.Attempts >= o.maxAttempts {
+		m.NextAttempt = time.Time{}
+		if err := o.write(deadLetterPrefix+m.ID, m); err != nil {
+			return false, err
+		}
+		return true, o.delete(outboxPrefix + m.ID)
+	}
+
+	m.NextAttempt = now.Add(o.backoff(m.Attempts))
+	return false, o.write(outboxPrefix+m.ID, m)
+}
+
+// DeadLetters returns the messages which could not be delivered, the oldest first
+func (o *Outbox) DeadLetters() ([]Message, error) {
+	return o.list(deadLetterPrefix)
+}
+
+// Replay moves a dead letter back to the outbox, where it is retried as if it had never been attempted
+func (o *Outbox) Replay(id string, now time.Time) error {
+	m, err := o.read(deadLetterPrefix + id)
+	if err != nil {
+		return err
+	}
+	m.Attempts = 0
+	m.NextAttempt = now
+	if err := o.write(outboxPrefix+m.ID, m); err != nil {
+		return err
+	}
+	return o.delete(deadLetterPrefix + id)
+}
+
+// Discard removes a dead letter for good
+func (o *Outbox) Discard(id string) error {
+	if _, err := o.read(deadLetterPrefix + id); err != nil {
+		return err
+	}
+	return o.delete(deadLetterPrefix + id)
+}
+
+// backoff returns the time to wait after the given number of failed attempts
+func (o *Outbox) backoff(attempts int) time.Duration {
+	d := o.initialBackoff
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if d >= o.maxBackoff {
+			return o.maxBackoff
+		}
+	}
+	return d
+}
+
+func (o *Outbox) list(prefix string) ([]Message, error) {
+	keys, err := o.store.List(store.ListPrefix(prefix), store.ListFrom(o.database, o.table))
+	if err != nil {
+		return nil, err
+	}
+	// keys sort by the time the message was added
+	sort.Strings(keys)
+
+	msgs := make([]Message, 0, len(keys))
+	for _, k := range keys {
+		if !strings.HasPrefix(k, prefix) {
+			continue
+		}
+		m, err := o.read(k)
+		if err == store.ErrNotFound {
+			// delivered or replayed in the meantime
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, m)
+	}
+	return msgs, nil
+}
+
+func (o *Outbox) read(key string) (Message, error) {
+	recs, err := o.store.Read(key, store.ReadFrom(o.database, o.table))
+	if err != nil {
+		return Message{}, err
+	}
+	if len(recs) == 0 {
+		return Message{}, store.ErrNotFound
+	}
+	var m Message
+	err = json.Unmarshal(recs[0].Value, &m)
+	return m, err
+}
+
+func (o *Outbox) write(key string, m Message) error {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return o.store.Write(&store.Record{
+		Key:   key,
+		Value: b,
+	}, store.WriteTo(o.database, o.table))
+}
+
+func (o *Outbox) delete(key string) error {
+	if err := o.store.Delete(key, store.DeleteFrom(o.database, o.table)); err != nil && err != store.ErrNotFound {
+		return err
+	}
+	return nil
+}
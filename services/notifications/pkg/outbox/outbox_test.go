@@ -0,0 +1,104 @@
+package outbox_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/owncloud/ocis/v2/services/notifications/pkg/config"
+	"github.com/owncloud/ocis/v2/services/notifications/pkg/outbox"
+	"go-micro.dev/v4/store"
+)
+
+var _ = Describe("Outbox", func() {
+	var (
+		ob  *outbox.Outbox
+		now = time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+		msg = outbox.Message{
+			Channel:    "mail",
+			Event:      "shareCreated",
+			Recipients: []string{"einstein"},
+			Subject:    "subject",
+			Body:       "body",
+		}
+		down = errors.New("smtp server is down")
+	)
+
+	BeforeEach(func() {
+		ob = outbox.New(store.NewMemoryStore(), config.Store{Database: "services", Table: "services/notifications/"}, config.Outbox{
+			MaxAttempts:    5,
+			InitialBackoff: time.Minute,
+			MaxBackoff:     3 * time.Minute,
+		})
+	})
+
+	It("backs off exponentially up to the maximum backoff", func() {
+		Expect(ob.Add(msg, down, now)).To(Succeed())
+
+		expected := []time.Duration{time.Minute, 2 * time.Minute, 3 * time.Minute, 3 * time.Minute}
+		at := now
+		for i, backoff := range expected {
+			due, err := ob.Due(at.Add(backoff - time.Second))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(due).To(BeEmpty(), "attempt %d is not due yet", i+2)
+
+			at = at.Add(backoff)
+			due, err = ob.Due(at)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(due).To(HaveLen(1))
+			Expect(due[0].Attempts).To(Equal(i + 1))
+			Expect(due[0].LastError).To(Equal("smtp server is down"))
+
+			dead, err := ob.Failed(due[0], down, at)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(dead).To(Equal(i == len(expected)-1))
+		}
+
+		due, err := ob.Due(at.Add(24 * time.Hour))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(due).To(BeEmpty())
+
+		dead, err := ob.DeadLetters()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(dead).To(HaveLen(1))
+		Expect(dead[0].Attempts).To(Equal(5))
+		Expect(dead[0].Recipients).To(Equal([]string{"einstein"}))
+	})
+
+	It("removes delivered messages", func() {
+		Expect(ob.Add(msg, down, now)).To(Succeed())
+		due, err := ob.Due(now.Add(time.Minute))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(due).To(HaveLen(1))
+
+		Expect(ob.Delivered(due[0])).To(Succeed())
+		due, err = ob.Due(now.Add(time.Hour))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(due).To(BeEmpty())
+	})
+
+	It("replays and discards dead letters", func() {
+		ob = outbox.New(store.NewMemoryStore(), config.Store{}, config.Outbox{MaxAttempts: 1, InitialBackoff: time.Minute, MaxBackoff: time.Minute})
+		Expect(ob.Add(msg, down, now)).To(Succeed())
+		Expect(ob.Add(msg, down, now.Add(time.Second))).To(Succeed())
+
+		dead, err := ob.DeadLetters()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(dead).To(HaveLen(2))
+
+		Expect(ob.Replay(dead[0].ID, now)).To(Succeed())
+		Expect(ob.Discard(dead[1].ID)).To(Succeed())
+		Expect(ob.Replay("unknown", now)).To(MatchError(store.ErrNotFound))
+
+		due, err := ob.Due(now)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(due).To(HaveLen(1))
+		Expect(due[0].ID).To(Equal(dead[0].ID))
+		Expect(due[0].Attempts).To(BeZero())
+
+		dead, err = ob.DeadLetters()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(dead).To(BeEmpty())
+	})
+})
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/owncloud/ocis/v2/services/settings/pkg/store/defaults"
+	"go-micro.dev/v4/store"
+)
+
+// lastSentPrefix prefixes the keys remembering when the digests of a delivery were sent last
+const lastSentPrefix = "last-sent/"
+
+// digestEntry is a notification collected for a digest
+type digestEntry struct {
+	Subject string
+	Sender  string
+	Time    time.Time
+}
+
+// collect queues a notification for the next digest of the user
+func (s eventsNotifier) collect(delivery, userID, subject, sender string) error {
+	b, err := json.Marshal(digestEntry{
+		Subject: subject,
+		Sender:  sender,
+		Time:    time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	// keys sort by the time the notification was collected
+	key := path.Join(delivery, userID, fmt.Sprintf("%020d-%s", time.Now().UnixNano(), uuid.Must(uuid.NewV4()).String()))
+	return s.store.Write(&store.Record{
+		Key:   key,
+		Value: b,
+	}, store.WriteTo(s.storeConfig.Database, s.storeConfig.Table))
+}
+
+// lastDue returns the last time at or before now the digests of the given delivery were due
+func (s eventsNotifier) lastDue(delivery string, now time.Time) time.Time {
+	due := time.Date(now.Year(), now.Month(), now.Day(), s.digestHour, 0, 0, 0, now.Location())
+	if due.After(now) {
+		due = due.AddDate(0, 0, -1)
+	}
+	if delivery == defaults.NotificationDeliveryWeekly {
+		for due.Weekday() != s.digestWeekday {
+			due = due.AddDate(0, 0, -1)
+		}
+	}
+	return due
+}
+
+// sendDueDigests sends the daily and weekly digests which became due since they were sent last
+func (s eventsNotifier) sendDueDigests(now time.Time) {
+	// digests which take longer than the interval of the checks must not be sent twice
+	if !s.digestLock.TryLock() {
+		return
+	}
+	defer s.digestLock.Unlock()
+
+	for _, delivery := range []string{defaults.NotificationDeliveryDaily, defaults.NotificationDeliveryWeekly} {
+		logger := s.logger.With().Str("delivery", delivery).Logger()
+		due := s.lastDue(delivery, now)
+
+		last, err := s.readLastSent(delivery)
+		switch {
+		case err == store.ErrNotFound:
+			// the first start, the notifications collected from now on go into the next digest
+			if err := s.writeLastSent(delivery, due); err != nil {
+				logger.Error().Err(err).Msg("could not remember when digests were sent")
+			}
+			continue
+		case err != nil:
+			logger.Error().Err(err).Msg("could not read when digests were sent")
+			continue
+		case !last.Before(due):
+			continue
+		}
+
+		s.sendDigests(delivery)
+		if err := s.writeLastSent(delivery, due); err != nil {
+			logger.Error().Err(err).Msg("could not remember when digests were sent")
+		}
+	}
+}
+
+// sendDigests sends a digest of the collected notifications to every user of the given delivery
+func (s eventsNotifier) sendDigests(delivery string) {
+	keys, err := s.store.List(store.ListPrefix(delivery+"/"), store.ListFrom(s.storeConfig.Database, s.storeConfig.Table))
+	if err != nil {
+		s.logger.Error().Err(err).Str("delivery", delivery).Msg("could not list collected notifications")
+		return
+	}
+
+	byUser := map[string][]string{}
+	for _, k := range keys {
+		parts := strings.Split(k, "/")
+		if len(parts) != 3 {
+			continue
+		}
+		byUser[parts[1]] = append(byUser[parts[1]], k)
+	}
+
+	for userID, userKeys := range byUser {
+		logger := s.logger.With().Str("delivery", delivery).Str("userid", userID).Logger()
+		sort.Strings(userKeys)
+
+		entries := make([]digestEntry, 0, len(userKeys))
+		for _, k := range userKeys {
+			recs, err := s.store.Read(k, store.ReadFrom(s.storeConfig.Database, s.storeConfig.Table))
+			if err != nil || len(recs) == 0 {
+				logger.Error().Err(err).Str("key", k).Msg("could not read collected notification")
+				continue
+			}
+			var e digestEntry
+			if err := json.Unmarshal(recs[0].Value, &e); err != nil {
+				logger.Error().Err(err).Str("key", k).Msg("could not unmarshal collected notification")
+				continue
+			}
+			entries = append(entries, e)
+		}
+		if len(entries) == 0 {
+			continue
+		}
+
+		values := map[string]interface{}{
+			"Delivery":      delivery,
+			"Count":         len(entries),
+			"Notifications": entries,
+			"Link":          s.ocisURL,
+		}
+		p := s.getPreferences(userID)
+		recipients := map[recipientGroup][]string{}
+		for _, ch := range s.channelsOf(p) {
+			recipients[recipientGroup{channel: ch, language: p.language}] = []string{userID}
+		}
+		if err := s.deliver(context.Background(), recipients, "digest/digest", values, ""); err != nil {
+			// the notifications stay collected and go into the next digest
+			logger.Error().Err(err).Msg("could not send digest")
+			continue
+		}
+
+		for _, k := range userKeys {
+			if err := s.store.Delete(k, store.DeleteFrom(s.storeConfig.Database, s.storeConfig.Table)); err != nil && err != store.ErrNotFound {
+				logger.Error().Err(err).Str("key", k).Msg("could not delete collected notification")
+			}
+		}
+	}
+}
+
+func (s eventsNotifier) readLastSent(delivery string) (time.Time, error) {
+	recs, err := s.store.Read(lastSentPrefix+delivery, store.ReadFrom(s.storeConfig.Database, s.storeConfig.Table))
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(recs) == 0 {
+		return time.Time{}, store.ErrNotFound
+	}
+	var t time.Time
+	err = t.UnmarshalText(recs[0].Value)
+	return t, err
+}
+
+func (s eventsNotifier) writeLastSent(delivery string, t time.Time) error {
+	b, err := t.MarshalText()
+	if err != nil {
+		return err
+	}
+	return s.store.Write(&store.Record{
+		Key:   lastSentPrefix + delivery,
+		Value: b,
+	}, store.WriteTo(s.storeConfig.Database, s.storeConfig.Table))
+}
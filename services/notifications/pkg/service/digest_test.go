@@ -0,0 +1,344 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	group "github.com/cs3org/go-cs3apis/cs3/identity/group/v1beta1"
+	user "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/owncloud/ocis/v2/ocis-pkg/log"
+	"github.com/owncloud/ocis/v2/ocis-pkg/middleware"
+	settingsmsg "github.com/owncloud/ocis/v2/protogen/gen/ocis/messages/settings/v0"
+	settingssvc "github.com/owncloud/ocis/v2/protogen/gen/ocis/services/settings/v0"
+	"github.com/owncloud/ocis/v2/services/notifications/pkg/channels"
+	"github.com/owncloud/ocis/v2/services/notifications/pkg/config"
+	"github.com/owncloud/ocis/v2/services/notifications/pkg/outbox"
+	"github.com/owncloud/ocis/v2/services/settings/pkg/store/defaults"
+	"go-micro.dev/v4/client"
+	"go-micro.dev/v4/metadata"
+	"go-micro.dev/v4/store"
+)
+
+var _ = Describe("Digests", func() {
+	var (
+		ch       *recordingChannel
+		chat     *recordingChannel
+		notifier eventsNotifier
+		ob       *outbox.Outbox
+		prefs    map[string][]*settingsmsg.ValueWithIdentifier
+		langs    map[string]string
+		cfg      *config.Config
+		grantee  = &user.UserId{OpaqueId: "sharee"}
+
+		shareValues = map[string]string{
+			"ShareGrantee": "Eric Expireling",
+			"ShareSharer":  "Dr. S. Harer",
+			"ShareFolder":  "secrets",
+			"ShareLink":    "https://ocis.example.org/files/shares/with-me",
+			"ExpiredAt":    "2023-04-17 16:42:00",
+		}
+	)
+
+	BeforeEach(func() {
+		ch = &recordingChannel{}
+		chat = &recordingChannel{}
+		prefs = map[string][]*settingsmsg.ValueWithIdentifier{}
+		langs = map[string]string{}
+		vs := &settingssvc.MockValueService{
+			ListValuesFunc: func(ctx context.Context, req *settingssvc.ListValuesRequest, opts ...client.CallOption) (*settingssvc.ListValuesResponse, error) {
+				defer GinkgoRecover()
+				accountID, _ := metadata.Get(ctx, middleware.AccountID)
+				Expect(accountID).To(Equal(req.GetAccountUuid()))
+				return &settingssvc.ListValuesResponse{Values: prefs[req.GetAccountUuid()]}, nil
+			},
+			GetValueByUniqueIdentifiersFunc: func(ctx context.Context, req *settingssvc.GetValueByUniqueIdentifiersRequest, opts ...client.CallOption) (*settingssvc.GetValueResponse, error) {
+				defer GinkgoRecover()
+				accountID, _ := metadata.Get(ctx, middleware.AccountID)
+				Expect(accountID).To(Equal(req.GetAccountUuid()))
+				Expect(req.GetSettingId()).To(Equal(defaults.SettingUUIDProfileLanguage))
+				lang, ok := langs[req.GetAccountUuid()]
+				if !ok {
+					return nil, errors.New("value not found")
+				}
+				return &settingssvc.GetValueResponse{Value: listValue(defaults.SettingUUIDProfileLanguage, lang)}, nil
+			},
+		}
+		chs := map[string]channels.Channel{"mail": ch, "chat": chat}
+		st := store.NewMemoryStore()
+		storeConfig := config.Store{
+			Database: "services",
+			Table:    "services/notifications/",
+		}
+		ob = outbox.New(st, storeConfig, config.Outbox{MaxAttempts: 3, InitialBackoff: time.Minute, MaxBackoff: time.Hour})
+		cfg = &config.Config{
+			WebUIURL: "https://ocis.example.org",
+			Notifications: config.Notifications{
+				Channels:        []string{"mail"},
+				Store:           storeConfig,
+				Digest:          config.Digest{Hour: 7, Weekday: "monday"},
+				DefaultLanguage: "en",
+			},
+		}
+		notifier = NewEventsNotifier(
+			Logger(log.NewLogger()),
+			Config(cfg),
+			Channels(chs),
+			ValueService(vs),
+			Store(st),
+			Outbox(ob),
+		).(eventsNotifier)
+	})
+
+	It("sends notifications instantly by default", func() {
+		Expect(notifier.send(context.Background(), defaults.SettingUUIDNotifyShareCreated, "shares/shareCreated", shareValues, grantee, nil, "sharer")).To(Succeed())
+		Expect(ch.subjects).To(Equal([]string{"Dr. S. Harer shared 'secrets' with you"}))
+		Expect(chat.subjects).To(BeEmpty())
+	})
+
+	It("sends notifications through the channels the user chose", func() {
+		prefs["sharee"] = []*settingsmsg.ValueWithIdentifier{channelsValue("chat", "unavailable")}
+
+		Expect(notifier.send(context.Background(), defaults.SettingUUIDNotifyShareCreated, "shares/shareCreated", shareValues, grantee, nil, "sharer")).To(Succeed())
+		Expect(ch.subjects).To(BeEmpty())
+		Expect(chat.subjects).To(Equal([]string{"Dr. S. Harer shared 'secrets' with you"}))
+		Expect(chat.messages[0]).To(ContainSubstring("https://ocis.example.org/files/shares/with-me"))
+	})
+
+	It("sends notifications in the language of the user", func() {
+		langs["sharee"] = "de"
+		langs["partage"] = "fr"
+		langs["compartir"] = "es"
+
+		for _, id := range []string{"sharee", "partage", "compartir"} {
+			Expect(notifier.send(context.Background(), defaults.SettingUUIDNotifyShareCreated, "shares/shareCreated", shareValues, &user.UserId{OpaqueId: id}, nil, "sharer")).To(Succeed())
+		}
+		Expect(ch.subjects).To(Equal([]string{
+			"Dr. S. Harer hat 'secrets' mit dir geteilt",
+			"Dr. S. Harer a partagé « secrets » avec vous",
+			"Dr. S. Harer shared 'secrets' with you",
+		}))
+		Expect(ch.messages[0]).To(HavePrefix("Hallo Eric Expireling,"))
+	})
+
+	It("skips notifications the user opted out of", func() {
+		prefs["sharee"] = []*settingsmsg.ValueWithIdentifier{boolValue(defaults.SettingUUIDNotifyShareCreated, false)}
+
+		Expect(notifier.send(context.Background(), defaults.SettingUUIDNotifyShareCreated, "shares/shareCreated", shareValues, grantee, nil, "sharer")).To(Succeed())
+		Expect(notifier.send(context.Background(), defaults.SettingUUIDNotifyShareExpired, "shares/shareExpired", shareValues, grantee, nil, "sharer")).To(Succeed())
+		Expect(ch.subjects).To(Equal([]string{"Share to 'secrets' expired at 2023-04-17 16:42:00"}))
+	})
+
+	It("collects notifications for the daily digest", func() {
+		prefs["sharee"] = []*settingsmsg.ValueWithIdentifier{deliveryValue(defaults.NotificationDeliveryDaily)}
+		monday := time.Date(2023, 5, 1, 7, 30, 0, 0, time.Local)
+
+		// the first check only remembers when the digest was due
+		notifier.sendDueDigests(monday.Add(-24 * time.Hour))
+
+		Expect(notifier.send(context.Background(), defaults.SettingUUIDNotifyShareCreated, "shares/shareCreated", shareValues, grantee, nil, "sharer")).To(Succeed())
+		Expect(notifier.send(context.Background(), defaults.SettingUUIDNotifySpaceShared, "spaces/sharedSpace", map[string]string{
+			"SpaceSharer": "Dr. S. Harer",
+			"SpaceName":   "secret space",
+		}, grantee, nil, "sharer")).To(Succeed())
+		Expect(ch.subjects).To(BeEmpty())
+
+		notifier.sendDueDigests(monday.Add(-time.Hour))
+		Expect(ch.subjects).To(BeEmpty(), "the digest is not due yet")
+
+		notifier.sendDueDigests(monday)
+		Expect(ch.subjects).To(Equal([]string{"Your daily digest: 2 new notifications"}))
+		Expect(ch.recipients).To(Equal([][]string{{"sharee"}}))
+		Expect(ch.messages[0]).To(ContainSubstring("Dr. S. Harer shared"))
+		Expect(ch.messages[0]).To(ContainSubstring("Dr. S. Harer invited you to join secret space"))
+
+		notifier.sendDueDigests(monday.Add(24 * time.Hour))
+		Expect(ch.subjects).To(HaveLen(1), "sent notifications are removed from the digest")
+	})
+
+	It("keeps the collected notifications when the service restarts", func() {
+		prefs["sharee"] = []*settingsmsg.ValueWithIdentifier{deliveryValue(defaults.NotificationDeliveryDaily)}
+		monday := time.Date(2023, 5, 1, 7, 30, 0, 0, time.Local)
+		notifier.sendDueDigests(monday.Add(-24 * time.Hour))
+
+		Expect(notifier.send(context.Background(), defaults.SettingUUIDNotifyShareCreated, "shares/shareCreated", shareValues, grantee, nil, "sharer")).To(Succeed())
+
+		restarted := NewEventsNotifier(
+			Logger(log.NewLogger()),
+			Config(cfg),
+			Channels(map[string]channels.Channel{"mail": ch}),
+			ValueService(notifier.valueService),
+			Store(notifier.store),
+		).(eventsNotifier)
+		restarted.sendDueDigests(monday)
+		Expect(ch.subjects).To(Equal([]string{"Your daily digest: 1 new notification"}))
+	})
+
+	It("retries messages which could not be sent", func() {
+		ch.failures = 1
+		now := time.Now()
+
+		Expect(notifier.send(context.Background(), defaults.SettingUUIDNotifyShareCreated, "shares/shareCreated", shareValues, grantee, nil, "sharer")).To(Succeed())
+		Expect(ch.subjects).To(BeEmpty())
+
+		notifier.retryOutbox(now.Add(30 * time.Second))
+		Expect(ch.subjects).To(BeEmpty(), "the retry is not due yet")
+
+		notifier.retryOutbox(now.Add(2 * time.Minute))
+		Expect(ch.subjects).To(Equal([]string{"Dr. S. Harer shared 'secrets' with you"}))
+		Expect(ch.recipients).To(Equal([][]string{{"sharee"}}))
+
+		due, err := ob.Due(now.Add(24 * time.Hour))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(due).To(BeEmpty())
+	})
+
+	It("retries messages only for the recipients who didn't get them", func() {
+		ch.failing = map[string]int{"einstein": 1}
+		now := time.Now()
+
+		Expect(notifier.sendTo(context.Background(), defaults.SettingUUIDNotifyShareCreated, "shares/shareCreated", shareValues, []string{"sharee", "einstein"}, "sharer")).To(Succeed())
+		Expect(ch.recipients).To(Equal([][]string{{"sharee"}}))
+
+		due, err := ob.Due(now.Add(2 * time.Minute))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(due).To(HaveLen(1))
+		Expect(due[0].Recipients).To(Equal([]string{"einstein"}))
+
+		notifier.retryOutbox(now.Add(2 * time.Minute))
+		Expect(ch.recipients).To(Equal([][]string{{"sharee"}, {"einstein"}}))
+	})
+
+	It("keeps retrying the recipients who still didn't get a message", func() {
+		now := time.Now()
+
+		ch.failures = 1
+		Expect(notifier.sendTo(context.Background(), defaults.SettingUUIDNotifyShareCreated, "shares/shareCreated", shareValues, []string{"sharee", "einstein"}, "sharer")).To(Succeed())
+		Expect(ch.recipients).To(BeEmpty())
+
+		ch.failing = map[string]int{"einstein": 1}
+		notifier.retryOutbox(now.Add(2 * time.Minute))
+		Expect(ch.recipients).To(Equal([][]string{{"sharee"}}))
+
+		due, err := ob.Due(now.Add(time.Hour))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(due).To(HaveLen(1))
+		Expect(due[0].Recipients).To(Equal([]string{"einstein"}))
+		Expect(due[0].Attempts).To(Equal(2))
+	})
+
+	It("moves messages to the dead letters after the maximum number of attempts", func() {
+		ch.failures = 3
+		now := time.Now()
+
+		Expect(notifier.send(context.Background(), defaults.SettingUUIDNotifyShareCreated, "shares/shareCreated", shareValues, grantee, nil, "sharer")).To(Succeed())
+		notifier.retryOutbox(now.Add(2 * time.Minute))
+		notifier.retryOutbox(now.Add(10 * time.Minute))
+		Expect(ch.subjects).To(BeEmpty())
+
+		dead, err := ob.DeadLetters()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(dead).To(HaveLen(1))
+		Expect(dead[0].Attempts).To(Equal(3))
+		Expect(dead[0].Event).To(Equal("shareCreated"))
+		Expect(dead[0].LastError).To(Equal("channel is down"))
+
+		Expect(ob.Replay(dead[0].ID, now.Add(time.Hour))).To(Succeed())
+		notifier.retryOutbox(now.Add(time.Hour))
+		Expect(ch.subjects).To(Equal([]string{"Dr. S. Harer shared 'secrets' with you"}))
+	})
+
+	It("sends weekly digests on the configured weekday", func() {
+		sunday := time.Date(2023, 4, 30, 12, 0, 0, 0, time.Local)
+		Expect(notifier.lastDue(defaults.NotificationDeliveryDaily, sunday)).To(Equal(time.Date(2023, 4, 30, 7, 0, 0, 0, time.Local)))
+		Expect(notifier.lastDue(defaults.NotificationDeliveryWeekly, sunday)).To(Equal(time.Date(2023, 4, 24, 7, 0, 0, 0, time.Local)))
+
+		monday := time.Date(2023, 5, 1, 6, 0, 0, 0, time.Local)
+		Expect(notifier.lastDue(defaults.NotificationDeliveryDaily, monday)).To(Equal(time.Date(2023, 4, 30, 7, 0, 0, 0, time.Local)))
+		Expect(notifier.lastDue(defaults.NotificationDeliveryWeekly, monday)).To(Equal(time.Date(2023, 4, 24, 7, 0, 0, 0, time.Local)))
+	})
+})
+
+func boolValue(settingID string, v bool) *settingsmsg.ValueWithIdentifier {
+	return &settingsmsg.ValueWithIdentifier{
+		Value: &settingsmsg.Value{
+			SettingId: settingID,
+			Value:     &settingsmsg.Value_BoolValue{BoolValue: v},
+		},
+	}
+}
+
+func channelsValue(chs ...string) *settingsmsg.ValueWithIdentifier {
+	values := make([]*settingsmsg.ListOptionValue, 0, len(chs))
+	for _, c := range chs {
+		values = append(values, &settingsmsg.ListOptionValue{Option: &settingsmsg.ListOptionValue_StringValue{StringValue: c}})
+	}
+	return &settingsmsg.ValueWithIdentifier{
+		Value: &settingsmsg.Value{
+			SettingId: defaults.SettingUUIDNotificationChannels,
+			Value:     &settingsmsg.Value_ListValue{ListValue: &settingsmsg.ListValue{Values: values}},
+		},
+	}
+}
+
+func deliveryValue(delivery string) *settingsmsg.ValueWithIdentifier {
+	return listValue(defaults.SettingUUIDNotificationDelivery, delivery)
+}
+
+func listValue(settingID, option string) *settingsmsg.ValueWithIdentifier {
+	return &settingsmsg.ValueWithIdentifier{
+		Value: &settingsmsg.Value{
+			SettingId: settingID,
+			Value: &settingsmsg.Value_ListValue{ListValue: &settingsmsg.ListValue{
+				Values: []*settingsmsg.ListOptionValue{{Option: &settingsmsg.ListOptionValue_StringValue{StringValue: option}}},
+			}},
+		},
+	}
+}
+
+// recordingChannel remembers the messages sent through it
+type recordingChannel struct {
+	lock       sync.Mutex
+	recipients [][]string
+	subjects   []string
+	messages   []string
+	// failures is the number of messages the channel fails to send before it works again
+	failures int
+	// failing is the number of messages the channel fails to send to a user before it works again
+	failing map[string]int
+}
+
+func (c *recordingChannel) SendMessage(_ context.Context, userIDs []string, msg, subject, _ string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.failures > 0 {
+		c.failures--
+		return errors.New("channel is down")
+	}
+
+	var sent, failed []string
+	for _, id := range userIDs {
+		if c.failing[id] > 0 {
+			c.failing[id]--
+			failed = append(failed, id)
+			continue
+		}
+		sent = append(sent, id)
+	}
+	if len(sent) > 0 {
+		c.recipients = append(c.recipients, sent)
+		c.subjects = append(c.subjects, subject)
+		c.messages = append(c.messages, msg)
+	}
+	if len(failed) > 0 {
+		return &channels.RecipientsError{Failed: failed, Err: errors.New("recipient is down")}
+	}
+	return nil
+}
+
+func (c *recordingChannel) SendMessageToGroup(ctx context.Context, groupID *group.GroupId, msg, subject, senderDisplayName string) error {
+	return c.SendMessage(ctx, []string{groupID.GetOpaqueId()}, msg, subject, senderDisplayName)
+}
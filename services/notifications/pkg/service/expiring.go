@@ -0,0 +1,330 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	user "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	collaboration "github.com/cs3org/go-cs3apis/cs3/sharing/collaboration/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/v2/pkg/events"
+	"github.com/cs3org/reva/v2/pkg/share"
+	"github.com/cs3org/reva/v2/pkg/utils"
+	"github.com/owncloud/ocis/v2/services/settings/pkg/store/defaults"
+	"go-micro.dev/v4/store"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+const (
+	// expiringPrefix prefixes the keys of the shares with an expiration date
+	expiringPrefix = "expiring/"
+	// expiringSeededKey marks that the shares which existed before the notifier tracked them have been looked up
+	expiringSeededKey = "expiring-seeded"
+)
+
+// expiringShare is a share with an expiration date whose grantees haven't been notified yet
+type expiringShare struct {
+	ShareID    string
+	Sharer     *user.UserId
+	Expiration time.Time
+}
+
+// tracksExpiringShares tells if the notifier remembers the shares with an expiration date. There is no event
+// for shares which expire soon, so the shares are remembered when they are created or updated.
+func (s eventsNotifier) tracksExpiringShares() bool {
+	return s.triggers.ShareExpiring && s.store != nil
+}
+
+// seedExpiringShares remembers the shares with an expiration date which were created before the notifier tracked
+// them. The shares are looked up once, later changes are tracked through the events.
+func (s eventsNotifier) seedExpiringShares() {
+	if !s.tracksExpiringShares() {
+		return
+	}
+	if _, err := s.store.Read(expiringSeededKey, store.ReadFrom(s.storeConfig.Database, s.storeConfig.Table)); err == nil {
+		return
+	}
+	if s.serviceUserID == "" {
+		s.logger.Info().Msg("no service user configured, only shares created or updated from now on are known to expire")
+		return
+	}
+
+	ctx, _, err := utils.Impersonate(&user.UserId{OpaqueId: s.serviceUserID}, s.gwClient, s.machineAuthAPIKey)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("could not impersonate service user")
+		return
+	}
+
+	// every user who created shares has a personal space, use the unrestricted flag to get the spaces of all users
+	res, err := s.gwClient.ListStorageSpaces(ctx, &provider.ListStorageSpacesRequest{
+		Opaque: utils.AppendPlainToOpaque(nil, "unrestricted", "T"),
+		Filters: []*provider.ListStorageSpacesRequest_Filter{{
+			Type: provider.ListStorageSpacesRequest_Filter_TYPE_SPACE_TYPE,
+			Term: &provider.ListStorageSpacesRequest_Filter_SpaceType{SpaceType: "personal"},
+		}},
+	})
+	if err != nil || res.GetStatus().GetCode() != rpc.Code_CODE_OK {
+		s.logger.Error().Err(err).Str("status", res.GetStatus().GetMessage()).Msg("could not list spaces")
+		return
+	}
+
+	failed := 0
+	for _, space := range res.GetStorageSpaces() {
+		owner := space.GetOwner().GetId()
+		if err := s.seedSharesOf(owner); err != nil {
+			s.logger.Error().Err(err).Str("userid", owner.GetOpaqueId()).Msg("could not look up expiring shares")
+			failed++
+		}
+	}
+	if failed > 0 {
+		s.logger.Error().Int("failed", failed).Msg("could not look up the expiring shares of all users, trying again on the next start")
+		return
+	}
+
+	if err := s.store.Write(&store.Record{
+		Key:   expiringSeededKey,
+		Value: []byte(time.Now().Format(time.RFC3339)),
+	}, store.WriteTo(s.storeConfig.Database, s.storeConfig.Table)); err != nil {
+		s.logger.Error().Err(err).Msg("could not remember that the expiring shares were looked up")
+	}
+}
+
+// seedSharesOf remembers the shares with an expiration date a user created
+func (s eventsNotifier) seedSharesOf(creator *user.UserId) error {
+	ctx, _, err := utils.Impersonate(creator, s.gwClient, s.machineAuthAPIKey)
+	if err != nil {
+		return err
+	}
+	res, err := s.gwClient.ListShares(ctx, &collaboration.ListSharesRequest{})
+	if err != nil {
+		return err
+	}
+	if res.GetStatus().GetCode() != rpc.Code_CODE_OK {
+		return fmt.Errorf("unexpected status code from gateway client: %d", res.GetStatus().GetCode())
+	}
+
+	for _, sh := range res.GetShares() {
+		if sh.GetExpiration() == nil {
+			continue
+		}
+		if err := s.trackShare(sh); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s eventsNotifier) trackShareCreated(e events.ShareCreated) {
+	if !s.tracksExpiringShares() {
+		return
+	}
+	logger := s.logger.With().
+		Str("event", "ShareCreated").
+		Str("itemid", e.ItemID.GetOpaqueId()).
+		Logger()
+
+	// the event doesn't contain the share, look it up
+	ctx, _, err := utils.Impersonate(e.Sharer, s.gwClient, s.machineAuthAPIKey)
+	if err != nil {
+		logger.Error().Err(err).Msg("could not impersonate sharer")
+		return
+	}
+	res, err := s.gwClient.ListShares(ctx, &collaboration.ListSharesRequest{
+		Filters: []*collaboration.Filter{share.ResourceIDFilter(e.ItemID)},
+	})
+	if err != nil || res.GetStatus().GetCode() != rpc.Code_CODE_OK {
+		logger.Error().Err(err).Str("status", res.GetStatus().GetMessage()).Msg("could not list shares")
+		return
+	}
+
+	for _, sh := range res.GetShares() {
+		g := sh.GetGrantee()
+		if (e.GranteeUserID != nil && g.GetUserId().GetOpaqueId() == e.GranteeUserID.GetOpaqueId()) ||
+			(e.GranteeGroupID != nil && g.GetGroupId().GetOpaqueId() == e.GranteeGroupID.GetOpaqueId()) {
+			if err := s.trackShare(sh); err != nil {
+				logger.Error().Err(err).Msg("could not remember expiring share")
+			}
+		}
+	}
+}
+
+func (s eventsNotifier) trackShareUpdated(e events.ShareUpdated) {
+	if !s.tracksExpiringShares() {
+		return
+	}
+	logger := s.logger.With().
+		Str("event", "ShareUpdated").
+		Str("shareid", e.ShareID.GetOpaqueId()).
+		Logger()
+
+	ctx, _, err := utils.Impersonate(e.Executant, s.gwClient, s.machineAuthAPIKey)
+	if err != nil {
+		logger.Error().Err(err).Msg("could not impersonate executant")
+		return
+	}
+	sh, err := s.getShare(ctx, e.ShareID)
+	switch {
+	case err != nil:
+		logger.Error().Err(err).Msg("could not get share")
+		return
+	case sh == nil:
+		err = s.deleteExpiringShare(e.ShareID.GetOpaqueId())
+	default:
+		err = s.trackShare(sh)
+	}
+	if err != nil {
+		logger.Error().Err(err).Msg("could not update expiring share")
+	}
+}
+
+func (s eventsNotifier) untrackShare(e events.ShareRemoved) {
+	if !s.tracksExpiringShares() || e.ShareID == nil {
+		return
+	}
+	if err := s.deleteExpiringShare(e.ShareID.GetOpaqueId()); err != nil {
+		s.logger.Error().Err(err).Str("event", "ShareRemoved").Str("shareid", e.ShareID.GetOpaqueId()).Msg("could not forget expiring share")
+	}
+}
+
+// trackShare remembers a share if it has an expiration date
+func (s eventsNotifier) trackShare(sh *collaboration.Share) error {
+	if sh.GetExpiration() == nil {
+		return s.deleteExpiringShare(sh.GetId().GetOpaqueId())
+	}
+	b, err := json.Marshal(expiringShare{
+		ShareID:    sh.GetId().GetOpaqueId(),
+		Sharer:     sh.GetCreator(),
+		Expiration: utils.TSToTime(sh.GetExpiration()),
+	})
+	if err != nil {
+		return err
+	}
+	return s.store.Write(&store.Record{
+		Key:   expiringPrefix + sh.GetId().GetOpaqueId(),
+		Value: b,
+	}, store.WriteTo(s.storeConfig.Database, s.storeConfig.Table))
+}
+
+// notifyExpiringShares notifies the grantees of the shares which expire within the configured number of days
+func (s eventsNotifier) notifyExpiringShares(now time.Time) {
+	if !s.expiringLock.TryLock() {
+		return
+	}
+	defer s.expiringLock.Unlock()
+
+	keys, err := s.store.List(store.ListPrefix(expiringPrefix), store.ListFrom(s.storeConfig.Database, s.storeConfig.Table))
+	if err != nil {
+		s.logger.Error().Err(err).Msg("could not list expiring shares")
+		return
+	}
+
+	soon := now.AddDate(0, 0, s.triggers.ShareExpiringDays)
+	for _, k := range keys {
+		recs, err := s.store.Read(k, store.ReadFrom(s.storeConfig.Database, s.storeConfig.Table))
+		if err != nil || len(recs) == 0 {
+			continue
+		}
+		var es expiringShare
+		if err := json.Unmarshal(recs[0].Value, &es); err != nil {
+			s.logger.Error().Err(err).Str("key", k).Msg("could not unmarshal expiring share")
+			continue
+		}
+		if es.Expiration.After(soon) {
+			continue
+		}
+
+		// the grantees are notified once, expired shares are taken care of by the ShareExpired event
+		if err := s.deleteExpiringShare(es.ShareID); err != nil {
+			s.logger.Error().Err(err).Str("shareid", es.ShareID).Msg("could not forget expiring share")
+			continue
+		}
+		if es.Expiration.After(now) {
+			s.notifyExpiringShare(es)
+		}
+	}
+}
+
+func (s eventsNotifier) notifyExpiringShare(es expiringShare) {
+	logger := s.logger.With().
+		Str("shareid", es.ShareID).
+		Logger()
+
+	ctx, sharer, err := utils.Impersonate(es.Sharer, s.gwClient, s.machineAuthAPIKey)
+	if err != nil {
+		logger.Error().Err(err).Msg("could not impersonate sharer")
+		return
+	}
+
+	// the share might have changed without an event reaching us
+	sh, err := s.getShare(ctx, &collaboration.ShareId{OpaqueId: es.ShareID})
+	switch {
+	case err != nil:
+		logger.Error().Err(err).Msg("could not get share")
+		return
+	case sh == nil:
+		return
+	case sh.GetExpiration() == nil || !utils.TSToTime(sh.GetExpiration()).Equal(es.Expiration):
+		if err := s.trackShare(sh); err != nil {
+			logger.Error().Err(err).Msg("could not update expiring share")
+		}
+		return
+	}
+
+	resourceInfo, err := s.getResourceInfo(ctx, sh.GetResourceId(), &fieldmaskpb.FieldMask{Paths: []string{"name"}})
+	if err != nil {
+		logger.Error().Err(err).Msg("could not stat resource")
+		return
+	}
+
+	shareLink, err := urlJoinPath(s.ocisURL, "files/shares/with-me")
+	if err != nil {
+		logger.Error().Err(err).Msg("could not create link to the share")
+		return
+	}
+
+	granteeUserID, granteeGroupID := sh.GetGrantee().GetUserId(), sh.GetGrantee().GetGroupId()
+	shareGrantee, err := s.getGranteeName(ctx, granteeUserID, granteeGroupID)
+	if err != nil {
+		logger.Error().Err(err).Msg("could not get grantee name")
+		return
+	}
+
+	values := map[string]string{
+		"ShareGrantee": shareGrantee,
+		"ShareSharer":  sharer.GetDisplayName(),
+		"ShareFolder":  resourceInfo.GetName(),
+		"ShareLink":    shareLink,
+		"ExpiresAt":    es.Expiration.Format("2006-01-02 15:04:05"),
+	}
+	if err := s.send(ctx, defaults.SettingUUIDNotifyShareExpiring, "shares/shareExpiring", values, granteeUserID, granteeGroupID, sharer.GetDisplayName()); err != nil {
+		logger.Error().Err(err).Msg("failed to send a message")
+	}
+}
+
+// getShare returns a share, or nil if it doesn't exist anymore
+func (s eventsNotifier) getShare(ctx context.Context, id *collaboration.ShareId) (*collaboration.Share, error) {
+	res, err := s.gwClient.GetShare(ctx, &collaboration.GetShareRequest{
+		Ref: &collaboration.ShareReference{
+			Spec: &collaboration.ShareReference_Id{Id: id},
+		},
+	})
+	switch {
+	case err != nil:
+		return nil, err
+	case res.GetStatus().GetCode() == rpc.Code_CODE_NOT_FOUND:
+		return nil, nil
+	case res.GetStatus().GetCode() != rpc.Code_CODE_OK:
+		return nil, fmt.Errorf("unexpected status code from gateway client: %d", res.GetStatus().GetCode())
+	}
+	return res.GetShare(), nil
+}
+
+func (s eventsNotifier) deleteExpiringShare(shareID string) error {
+	if err := s.store.Delete(expiringPrefix+shareID, store.DeleteFrom(s.storeConfig.Database, s.storeConfig.Table)); err != nil && err != store.ErrNotFound {
+		return err
+	}
+	return nil
+}
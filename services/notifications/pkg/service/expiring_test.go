@@ -0,0 +1,255 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	user "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	collaboration "github.com/cs3org/go-cs3apis/cs3/sharing/collaboration/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	revactx "github.com/cs3org/reva/v2/pkg/ctx"
+	"github.com/cs3org/reva/v2/pkg/events"
+	"github.com/cs3org/reva/v2/pkg/utils"
+	cs3mocks "github.com/cs3org/reva/v2/tests/cs3mocks/mocks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/owncloud/ocis/v2/ocis-pkg/log"
+	"github.com/owncloud/ocis/v2/services/notifications/pkg/channels"
+	"github.com/owncloud/ocis/v2/services/notifications/pkg/config"
+	"github.com/test-go/testify/mock"
+	"go-micro.dev/v4/store"
+	"google.golang.org/grpc"
+)
+
+var _ = Describe("Expiring shares", func() {
+	var (
+		ch       *recordingChannel
+		gwc      *cs3mocks.GatewayAPIClient
+		st       store.Store
+		cfg      *config.Config
+		notifier eventsNotifier
+		// shares are the shares known to the gateway, keyed by their creator
+		shares map[string][]*collaboration.Share
+		now    = time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+		sharer = &user.UserId{OpaqueId: "sharer"}
+	)
+
+	newShare := func(id string, expiration time.Time) *collaboration.Share {
+		sh := &collaboration.Share{
+			Id:         &collaboration.ShareId{OpaqueId: id},
+			ResourceId: &provider.ResourceId{SpaceId: "space", OpaqueId: "item-" + id},
+			Grantee: &provider.Grantee{
+				Type: provider.GranteeType_GRANTEE_TYPE_USER,
+				Id:   &provider.Grantee_UserId{UserId: &user.UserId{OpaqueId: "sharee"}},
+			},
+			Creator: sharer,
+		}
+		if !expiration.IsZero() {
+			sh.Expiration = utils.TimeToTS(expiration)
+		}
+		return sh
+	}
+	tracked := func() []string {
+		keys, err := st.List(store.ListPrefix(expiringPrefix), store.ListFrom(cfg.Notifications.Store.Database, cfg.Notifications.Store.Table))
+		Expect(err).ToNot(HaveOccurred())
+		return keys
+	}
+	newNotifier := func() eventsNotifier {
+		return NewEventsNotifier(
+			Logger(log.NewLogger()),
+			Config(cfg),
+			Channels(map[string]channels.Channel{"mail": ch}),
+			GatewayClient(gwc),
+			Store(st),
+		).(eventsNotifier)
+	}
+
+	BeforeEach(func() {
+		ch = &recordingChannel{}
+		st = store.NewMemoryStore()
+		shares = map[string][]*collaboration.Share{}
+		cfg = &config.Config{
+			WebUIURL: "https://ocis.example.org",
+			Notifications: config.Notifications{
+				Channels: []string{"mail"},
+				Store: config.Store{
+					Database: "services",
+					Table:    "services/notifications/",
+				},
+				Triggers:        config.Triggers{ShareExpiring: true, ShareExpiringDays: 3},
+				DefaultLanguage: "en",
+				ServiceUserID:   "admin",
+			},
+		}
+
+		gwc = &cs3mocks.GatewayAPIClient{}
+		gwc.On("GetUser", mock.Anything, mock.Anything).Return(func(_ context.Context, req *user.GetUserRequest, _ ...grpc.CallOption) *user.GetUserResponse {
+			return &user.GetUserResponse{Status: &rpc.Status{Code: rpc.Code_CODE_OK}, User: &user.User{Id: req.GetUserId(), DisplayName: "Dr. " + req.GetUserId().GetOpaqueId()}}
+		}, nil)
+		gwc.On("Authenticate", mock.Anything, mock.Anything).Return(func(_ context.Context, req *gateway.AuthenticateRequest, _ ...grpc.CallOption) *gateway.AuthenticateResponse {
+			return &gateway.AuthenticateResponse{Status: &rpc.Status{Code: rpc.Code_CODE_OK}, User: &user.User{Id: &user.UserId{OpaqueId: strings.TrimPrefix(req.GetClientId(), "userid:")}}, Token: "token"}
+		}, nil)
+		gwc.On("Stat", mock.Anything, mock.Anything).Return(&provider.StatResponse{Status: &rpc.Status{Code: rpc.Code_CODE_OK}, Info: &provider.ResourceInfo{Name: "secrets"}}, nil)
+		gwc.On("ListShares", mock.Anything, mock.Anything).Return(func(ctx context.Context, _ *collaboration.ListSharesRequest, _ ...grpc.CallOption) *collaboration.ListSharesResponse {
+			// the shares of the impersonated user are returned
+			u, _ := revactx.ContextGetUser(ctx)
+			return &collaboration.ListSharesResponse{Status: &rpc.Status{Code: rpc.Code_CODE_OK}, Shares: shares[u.GetId().GetOpaqueId()]}
+		}, nil)
+		gwc.On("GetShare", mock.Anything, mock.Anything).Return(func(_ context.Context, req *collaboration.GetShareRequest, _ ...grpc.CallOption) *collaboration.GetShareResponse {
+			for _, shs := range shares {
+				for _, sh := range shs {
+					if sh.GetId().GetOpaqueId() == req.GetRef().GetId().GetOpaqueId() {
+						return &collaboration.GetShareResponse{Status: &rpc.Status{Code: rpc.Code_CODE_OK}, Share: sh}
+					}
+				}
+			}
+			return &collaboration.GetShareResponse{Status: &rpc.Status{Code: rpc.Code_CODE_NOT_FOUND}}
+		}, nil)
+
+		notifier = newNotifier()
+	})
+
+	Describe("tracking", func() {
+		It("remembers created shares with an expiration date", func() {
+			shares["sharer"] = []*collaboration.Share{newShare("expiring", now.Add(48*time.Hour))}
+			notifier.trackShareCreated(events.ShareCreated{
+				Sharer:        sharer,
+				GranteeUserID: &user.UserId{OpaqueId: "sharee"},
+				ItemID:        &provider.ResourceId{SpaceId: "space", OpaqueId: "item-expiring"},
+			})
+			Expect(tracked()).To(ConsistOf(expiringPrefix + "expiring"))
+		})
+
+		It("ignores created shares without an expiration date", func() {
+			shares["sharer"] = []*collaboration.Share{newShare("forever", time.Time{})}
+			notifier.trackShareCreated(events.ShareCreated{
+				Sharer:        sharer,
+				GranteeUserID: &user.UserId{OpaqueId: "sharee"},
+				ItemID:        &provider.ResourceId{SpaceId: "space", OpaqueId: "item-forever"},
+			})
+			Expect(tracked()).To(BeEmpty())
+		})
+
+		It("forgets shares whose expiration date was removed or which were removed", func() {
+			sh := newShare("expiring", now.Add(48*time.Hour))
+			Expect(notifier.trackShare(sh)).To(Succeed())
+			Expect(notifier.trackShare(newShare("other", now.Add(48*time.Hour)))).To(Succeed())
+			Expect(tracked()).To(HaveLen(2))
+
+			sh.Expiration = nil
+			shares["sharer"] = []*collaboration.Share{sh}
+			notifier.trackShareUpdated(events.ShareUpdated{Executant: sharer, ShareID: sh.GetId()})
+			Expect(tracked()).To(ConsistOf(expiringPrefix + "other"))
+
+			notifier.untrackShare(events.ShareRemoved{ShareID: &collaboration.ShareId{OpaqueId: "other"}})
+			Expect(tracked()).To(BeEmpty())
+		})
+
+		It("doesn't track shares if the trigger is disabled", func() {
+			cfg.Notifications.Triggers.ShareExpiring = false
+			notifier = newNotifier()
+			shares["sharer"] = []*collaboration.Share{newShare("expiring", now.Add(48*time.Hour))}
+			notifier.trackShareCreated(events.ShareCreated{
+				Sharer:        sharer,
+				GranteeUserID: &user.UserId{OpaqueId: "sharee"},
+				ItemID:        &provider.ResourceId{SpaceId: "space", OpaqueId: "item-expiring"},
+			})
+			Expect(tracked()).To(BeEmpty())
+		})
+	})
+
+	Describe("notifyExpiringShares", func() {
+		It("notifies the grantees once when a share expires within the configured days", func() {
+			later := newShare("later", now.Add(7*24*time.Hour))
+			soon := newShare("soon", now.Add(48*time.Hour))
+			shares["sharer"] = []*collaboration.Share{later, soon}
+			Expect(notifier.trackShare(later)).To(Succeed())
+			Expect(notifier.trackShare(soon)).To(Succeed())
+
+			notifier.notifyExpiringShares(now)
+			Expect(ch.subjects).To(Equal([]string{"Share to 'secrets' expires at 2023-05-03 12:00:00"}))
+			Expect(ch.recipients).To(Equal([][]string{{"sharee"}}))
+			Expect(tracked()).To(ConsistOf(expiringPrefix + "later"))
+
+			notifier.notifyExpiringShares(now)
+			Expect(ch.subjects).To(HaveLen(1))
+		})
+
+		It("forgets expired shares without a notification", func() {
+			expired := newShare("expired", now.Add(-time.Hour))
+			shares["sharer"] = []*collaboration.Share{expired}
+			Expect(notifier.trackShare(expired)).To(Succeed())
+
+			notifier.notifyExpiringShares(now)
+			Expect(ch.subjects).To(BeEmpty())
+			Expect(tracked()).To(BeEmpty())
+		})
+
+		It("tracks the new expiration date of shares which changed without an event", func() {
+			sh := newShare("changed", now.Add(48*time.Hour))
+			Expect(notifier.trackShare(sh)).To(Succeed())
+			changed := newShare("changed", now.Add(7*24*time.Hour))
+			shares["sharer"] = []*collaboration.Share{changed}
+
+			notifier.notifyExpiringShares(now)
+			Expect(ch.subjects).To(BeEmpty())
+			Expect(tracked()).To(ConsistOf(expiringPrefix + "changed"))
+
+			notifier.notifyExpiringShares(now.Add(5 * 24 * time.Hour))
+			Expect(ch.subjects).To(HaveLen(1))
+		})
+
+		It("doesn't notify about shares which don't exist anymore", func() {
+			Expect(notifier.trackShare(newShare("gone", now.Add(48*time.Hour)))).To(Succeed())
+
+			notifier.notifyExpiringShares(now)
+			Expect(ch.subjects).To(BeEmpty())
+			Expect(tracked()).To(BeEmpty())
+		})
+	})
+
+	Describe("seedExpiringShares", func() {
+		var spaces []*provider.StorageSpace
+
+		BeforeEach(func() {
+			spaces = []*provider.StorageSpace{
+				{SpaceType: "personal", Owner: &user.User{Id: &user.UserId{OpaqueId: "sharer"}}},
+				{SpaceType: "personal", Owner: &user.User{Id: &user.UserId{OpaqueId: "other"}}},
+			}
+			gwc.On("ListStorageSpaces", mock.Anything, mock.Anything).Return(func(ctx context.Context, req *provider.ListStorageSpacesRequest, _ ...grpc.CallOption) *provider.ListStorageSpacesResponse {
+				defer GinkgoRecover()
+				u, _ := revactx.ContextGetUser(ctx)
+				Expect(u.GetId().GetOpaqueId()).To(Equal("admin"))
+				Expect(utils.ReadPlainFromOpaque(req.GetOpaque(), "unrestricted")).To(Equal("T"))
+				return &provider.ListStorageSpacesResponse{Status: &rpc.Status{Code: rpc.Code_CODE_OK}, StorageSpaces: spaces}
+			}, nil)
+		})
+
+		It("remembers the existing shares with an expiration date of all users once", func() {
+			shares["sharer"] = []*collaboration.Share{newShare("expiring", now.Add(48*time.Hour)), newShare("forever", time.Time{})}
+			shares["other"] = []*collaboration.Share{newShare("also-expiring", now.Add(72*time.Hour))}
+
+			notifier.seedExpiringShares()
+			Expect(tracked()).To(ConsistOf(expiringPrefix+"expiring", expiringPrefix+"also-expiring"))
+			gwc.AssertNumberOfCalls(GinkgoT(), "ListStorageSpaces", 1)
+
+			// a restarted service doesn't look the shares up again
+			notifier = newNotifier()
+			notifier.seedExpiringShares()
+			gwc.AssertNumberOfCalls(GinkgoT(), "ListStorageSpaces", 1)
+		})
+
+		It("doesn't look up the shares without a service user", func() {
+			cfg.Notifications.ServiceUserID = ""
+			notifier = newNotifier()
+			shares["sharer"] = []*collaboration.Share{newShare("expiring", now.Add(48*time.Hour))}
+
+			notifier.seedExpiringShares()
+			Expect(tracked()).To(BeEmpty())
+			gwc.AssertNotCalled(GinkgoT(), "ListStorageSpaces", mock.Anything, mock.Anything)
+		})
+	})
+})
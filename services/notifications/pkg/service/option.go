@@ -0,0 +1,97 @@
+package service
+
+import (
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	"github.com/owncloud/ocis/v2/ocis-pkg/log"
+	settingssvc "github.com/owncloud/ocis/v2/protogen/gen/ocis/services/settings/v0"
+	"github.com/owncloud/ocis/v2/services/notifications/pkg/channels"
+	"github.com/owncloud/ocis/v2/services/notifications/pkg/config"
+	"github.com/owncloud/ocis/v2/services/notifications/pkg/outbox"
+	"go-micro.dev/v4/store"
+)
+
+// Option defines a single option function.
+type Option func(o *Options)
+
+// Options defines the available options for this package.
+type Options struct {
+	Logger        log.Logger
+	Config        *config.Config
+	Events        <-chan interface{}
+	Channels      map[string]channels.Channel
+	GatewayClient gateway.GatewayAPIClient
+	ValueService  settingssvc.ValueService
+	Store         store.Store
+	Outbox        *outbox.Outbox
+}
+
+// newOptions initializes the available default options.
+func newOptions(opts ...Option) Options {
+	opt := Options{
+		Config: &config.Config{},
+	}
+
+	for _, o := range opts {
+		o(&opt)
+	}
+
+	return opt
+}
+
+// Logger provides a function to set the logger option.
+func Logger(val log.Logger) Option {
+	return func(o *Options) {
+		o.Logger = val
+	}
+}
+
+// Config provides a function to set the config option.
+func Config(val *config.Config) Option {
+	return func(o *Options) {
+		o.Config = val
+	}
+}
+
+// Events provides a function to set the events option.
+func Events(val <-chan interface{}) Option {
+	return func(o *Options) {
+		o.Events = val
+	}
+}
+
+// Channels provides a function to set the channels option. The channels are keyed by their name.
+func Channels(val map[string]channels.Channel) Option {
+	return func(o *Options) {
+		o.Channels = val
+	}
+}
+
+// GatewayClient provides a function to set the gateway client option.
+func GatewayClient(val gateway.GatewayAPIClient) Option {
+	return func(o *Options) {
+		o.GatewayClient = val
+	}
+}
+
+// ValueService provides a function to set the value service option. It provides the notification
+// preferences of the users, without it every notification is sent instantly through the default channels.
+func ValueService(val settingssvc.ValueService) Option {
+	return func(o *Options) {
+		o.ValueService = val
+	}
+}
+
+// Store provides a function to set the store option. It holds the notifications collected for digests
+// and the expiring shares, without it digests are not available.
+func Store(val store.Store) Option {
+	return func(o *Options) {
+		o.Store = val
+	}
+}
+
+// Outbox provides a function to set the outbox option. Without it messages which could not be delivered are lost.
+func Outbox(val *outbox.Outbox) Option {
+	return func(o *Options) {
+		o.Outbox = val
+	}
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+
+	"github.com/owncloud/ocis/v2/ocis-pkg/middleware"
+	settingsmsg "github.com/owncloud/ocis/v2/protogen/gen/ocis/messages/settings/v0"
+	settingssvc "github.com/owncloud/ocis/v2/protogen/gen/ocis/services/settings/v0"
+	"github.com/owncloud/ocis/v2/services/settings/pkg/store/defaults"
+	"go-micro.dev/v4/metadata"
+)
+
+// preferences are the notification settings a user chose in the settings service
+type preferences struct {
+	delivery string
+	// language is the language the user chose for the user interface
+	language string
+	// channels are the names of the channels the user chose, empty if the user didn't choose any
+	channels []string
+	// disabled holds the ids of the settings of the events the user opted out of
+	disabled map[string]bool
+}
+
+// wants tells if the user wants to be notified about the event of the given setting
+func (p preferences) wants(settingID string) bool {
+	return !p.disabled[settingID]
+}
+
+// getPreferences returns the notification preferences of a user. Users who didn't change
+// anything, or whose settings can't be read, get every notification instantly in the default language.
+func (s eventsNotifier) getPreferences(userID string) preferences {
+	p := preferences{
+		delivery: defaults.NotificationDeliveryInstant,
+		language: s.defaultLanguage,
+		disabled: map[string]bool{},
+	}
+	if s.valueService == nil {
+		return p
+	}
+
+	// the settings service only hands out the values of the user asking for them
+	ctx := metadata.Set(context.Background(), middleware.AccountID, userID)
+	if lang := s.getLanguage(ctx, userID); lang != "" {
+		p.language = lang
+	}
+
+	res, err := s.valueService.ListValues(ctx, &settingssvc.ListValuesRequest{
+		BundleId:    defaults.BundleUUIDNotifications,
+		AccountUuid: userID,
+	})
+	if err != nil {
+		s.logger.Debug().Err(err).Str("userid", userID).Msg("could not read notification preferences, using defaults")
+		return p
+	}
+
+	for _, v := range res.GetValues() {
+		value := v.GetValue()
+		switch id := value.GetSettingId(); id {
+		case defaults.SettingUUIDNotificationDelivery:
+			if options := value.GetListValue().GetValues(); len(options) > 0 {
+				p.delivery = options[0].GetStringValue()
+			}
+		case defaults.SettingUUIDNotificationChannels:
+			for _, o := range value.GetListValue().GetValues() {
+				p.channels = append(p.channels, o.GetStringValue())
+			}
+		default:
+			if b, ok := value.GetValue().(*settingsmsg.Value_BoolValue); ok && !b.BoolValue {
+				p.disabled[id] = true
+			}
+		}
+	}
+	return p
+}
+
+// getLanguage returns the language a user chose in the profile, or an empty string if the user didn't choose any
+func (s eventsNotifier) getLanguage(ctx context.Context, userID string) string {
+	res, err := s.valueService.GetValueByUniqueIdentifiers(ctx, &settingssvc.GetValueByUniqueIdentifiersRequest{
+		AccountUuid: userID,
+		SettingId:   defaults.SettingUUIDProfileLanguage,
+	})
+	if err != nil {
+		// users who never chose a language don't have a value
+		s.logger.Debug().Err(err).Str("userid", userID).Msg("could not read language, using the default language")
+		return ""
+	}
+	if options := res.GetValue().GetValue().GetListValue().GetValues(); len(options) > 0 {
+		return options[0].GetStringValue()
+	}
+	return ""
+}
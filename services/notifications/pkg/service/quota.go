@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	group "github.com/cs3org/go-cs3apis/cs3/identity/group/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/v2/pkg/events"
+	"github.com/cs3org/reva/v2/pkg/storagespace"
+	"github.com/cs3org/reva/v2/pkg/utils"
+	"github.com/owncloud/ocis/v2/services/settings/pkg/store/defaults"
+	"go-micro.dev/v4/store"
+)
+
+// quotaPrefix prefixes the keys remembering the last quota threshold the managers of a space were notified about
+const quotaPrefix = "quota/"
+
+// quotaThresholds are the percentages of the quota of a space its managers are notified about, the highest first
+var quotaThresholds = []int{100, 90, 80}
+
+func (s eventsNotifier) handleUploadReady(e events.UploadReady) {
+	if !s.triggers.SpaceQuota || s.store == nil || e.Failed {
+		return
+	}
+
+	logger := s.logger.With().
+		Str("event", "UploadReady").
+		Str("uploadid", e.UploadID).
+		Logger()
+
+	ctx, _, err := utils.Impersonate(e.ExecutingUser.GetId(), s.gwClient, s.machineAuthAPIKey)
+	if err != nil {
+		logger.Error().Err(err).Msg("could not impersonate uploader")
+		return
+	}
+
+	rid := e.FileRef.GetResourceId()
+	spaceID := storagespace.FormatStorageID(rid.GetStorageId(), rid.GetSpaceId())
+	space, err := s.getSpace(ctx, spaceID)
+	if err != nil {
+		logger.Error().Err(err).Msg("could not get space")
+		return
+	}
+
+	res, err := s.gwClient.GetQuota(ctx, &gateway.GetQuotaRequest{
+		Ref: &provider.Reference{ResourceId: space.GetRoot(), Path: "."},
+	})
+	switch {
+	case err != nil:
+		logger.Error().Err(err).Msg("could not get quota")
+		return
+	case res.GetStatus().GetCode() != rpc.Code_CODE_OK:
+		logger.Debug().Str("status", res.GetStatus().GetMessage()).Msg("could not get quota")
+		return
+	case res.GetTotalBytes() == 0:
+		// the space has no quota
+		return
+	}
+
+	reached := 0
+	percent := int(res.GetUsedBytes() * 100 / res.GetTotalBytes())
+	for _, t := range quotaThresholds {
+		if percent >= t {
+			reached = t
+			break
+		}
+	}
+
+	notified := s.readQuotaThreshold(spaceID)
+	if reached == notified {
+		return
+	}
+	// also remember lower thresholds, so that the managers are notified again when the space fills up again
+	if err := s.writeQuotaThreshold(spaceID, reached); err != nil {
+		logger.Error().Err(err).Msg("could not remember the quota threshold")
+		return
+	}
+	if reached < notified {
+		return
+	}
+
+	managers, err := s.getSpaceManagers(ctx, space)
+	if err != nil {
+		logger.Error().Err(err).Msg("could not get space managers")
+		return
+	}
+
+	spaceLink, err := urlJoinPath(s.ocisURL, "f", spaceID)
+	if err != nil {
+		logger.Error().Err(err).Msg("could not create link to the space")
+		return
+	}
+
+	values := map[string]string{
+		"SpaceName":    space.GetName(),
+		"QuotaPercent": strconv.Itoa(reached),
+		"SpaceLink":    spaceLink,
+	}
+	if err := s.sendTo(ctx, defaults.SettingUUIDNotifySpaceQuota, "spaces/spaceQuota", values, managers, ""); err != nil {
+		logger.Error().Err(err).Msg("failed to send a message")
+	}
+}
+
+// getSpace returns the storage space with the given id
+func (s eventsNotifier) getSpace(ctx context.Context, spaceID string) (*provider.StorageSpace, error) {
+	res, err := s.gwClient.ListStorageSpaces(ctx, &provider.ListStorageSpacesRequest{
+		Filters: []*provider.ListStorageSpacesRequest_Filter{{
+			Type: provider.ListStorageSpacesRequest_Filter_TYPE_ID,
+			Term: &provider.ListStorageSpacesRequest_Filter_Id{
+				Id: &provider.StorageSpaceId{OpaqueId: spaceID},
+			},
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if res.GetStatus().GetCode() != rpc.Code_CODE_OK {
+		return nil, fmt.Errorf("unexpected status code from gateway client: %d", res.GetStatus().GetCode())
+	}
+	if len(res.GetStorageSpaces()) == 0 {
+		return nil, errors.New("space not found")
+	}
+	return res.GetStorageSpaces()[0], nil
+}
+
+// getSpaceManagers returns the ids of the users who manage a space, the members of groups are returned individually
+func (s eventsNotifier) getSpaceManagers(ctx context.Context, space *provider.StorageSpace) ([]string, error) {
+	// the storage providers list the grants of a space in its opaque
+	var grants map[string]*provider.ResourcePermissions
+	_ = utils.ReadJSONFromOpaque(space.GetOpaque(), "grants", &grants)
+	var groups map[string]struct{}
+	_ = utils.ReadJSONFromOpaque(space.GetOpaque(), "groups", &groups)
+
+	seen := map[string]bool{}
+	var managers []string
+	add := func(ids ...string) {
+		for _, id := range ids {
+			if !seen[id] {
+				seen[id] = true
+				managers = append(managers, id)
+			}
+		}
+	}
+
+	for id, p := range grants {
+		// only managers can change the members of a space
+		if !p.GetAddGrant() || !p.GetRemoveGrant() {
+			continue
+		}
+		if _, ok := groups[id]; !ok {
+			add(id)
+			continue
+		}
+		members, err := s.getRecipients(ctx, nil, &group.GroupId{OpaqueId: id})
+		if err != nil {
+			return nil, err
+		}
+		add(members...)
+	}
+
+	if len(managers) == 0 && space.GetSpaceType() == "personal" {
+		add(space.GetOwner().GetId().GetOpaqueId())
+	}
+	return managers, nil
+}
+
+// readQuotaThreshold returns the last quota threshold the managers of a space were notified about, 0 if there is none
+func (s eventsNotifier) readQuotaThreshold(spaceID string) int {
+	recs, err := s.store.Read(quotaPrefix+spaceID, store.ReadFrom(s.storeConfig.Database, s.storeConfig.Table))
+	if err != nil || len(recs) == 0 {
+		return 0
+	}
+	t, _ := strconv.Atoi(string(recs[0].Value))
+	return t
+}
+
+func (s eventsNotifier) writeQuotaThreshold(spaceID string, threshold int) error {
+	if threshold == 0 {
+		if err := s.store.Delete(quotaPrefix+spaceID, store.DeleteFrom(s.storeConfig.Database, s.storeConfig.Table)); err != nil && err != store.ErrNotFound {
+			return err
+		}
+		return nil
+	}
+	return s.store.Write(&store.Record{
+		Key:   quotaPrefix + spaceID,
+		Value: []byte(strconv.Itoa(threshold)),
+	}, store.WriteTo(s.storeConfig.Database, s.storeConfig.Table))
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	group "github.com/cs3org/go-cs3apis/cs3/identity/group/v1beta1"
+	user "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/v2/pkg/events"
+	"github.com/cs3org/reva/v2/pkg/utils"
+	cs3mocks "github.com/cs3org/reva/v2/tests/cs3mocks/mocks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/owncloud/ocis/v2/ocis-pkg/log"
+	"github.com/owncloud/ocis/v2/services/notifications/pkg/channels"
+	"github.com/owncloud/ocis/v2/services/notifications/pkg/config"
+	"github.com/test-go/testify/mock"
+	"go-micro.dev/v4/store"
+	"google.golang.org/grpc"
+)
+
+var _ = Describe("Space quota", func() {
+	var (
+		ch       *recordingChannel
+		gwc      *cs3mocks.GatewayAPIClient
+		notifier eventsNotifier
+		space    *provider.StorageSpace
+		used     uint64
+		total    uint64
+
+		manager = &provider.ResourcePermissions{AddGrant: true, RemoveGrant: true}
+		editor  = &provider.ResourcePermissions{InitiateFileUpload: true}
+	)
+
+	upload := func() {
+		notifier.handleUploadReady(events.UploadReady{
+			UploadID:      "upload",
+			ExecutingUser: &user.User{Id: &user.UserId{OpaqueId: "uploader"}},
+			FileRef:       &provider.Reference{ResourceId: &provider.ResourceId{StorageId: "storage", SpaceId: "space", OpaqueId: "file"}},
+		})
+	}
+
+	BeforeEach(func() {
+		ch = &recordingChannel{}
+		used, total = 0, 100
+		space = &provider.StorageSpace{
+			Id:        &provider.StorageSpaceId{OpaqueId: "storage$space"},
+			Name:      "Marketing",
+			SpaceType: "project",
+			Root:      &provider.ResourceId{StorageId: "storage", SpaceId: "space", OpaqueId: "space"},
+			Owner:     &user.User{Id: &user.UserId{OpaqueId: "owner"}},
+		}
+		space.Opaque = utils.AppendJSONToOpaque(nil, "grants", map[string]*provider.ResourcePermissions{"manager": manager, "editor": editor})
+
+		gwc = &cs3mocks.GatewayAPIClient{}
+		gwc.On("GetUser", mock.Anything, mock.Anything).Return(&user.GetUserResponse{Status: &rpc.Status{Code: rpc.Code_CODE_OK}, User: &user.User{Id: &user.UserId{OpaqueId: "uploader"}}}, nil)
+		gwc.On("Authenticate", mock.Anything, mock.Anything).Return(&gateway.AuthenticateResponse{Status: &rpc.Status{Code: rpc.Code_CODE_OK}, User: &user.User{Id: &user.UserId{OpaqueId: "uploader"}}}, nil)
+		gwc.On("ListStorageSpaces", mock.Anything, mock.Anything).Return(func(_ context.Context, req *provider.ListStorageSpacesRequest, _ ...grpc.CallOption) *provider.ListStorageSpacesResponse {
+			defer GinkgoRecover()
+			Expect(req.GetFilters()[0].GetId().GetOpaqueId()).To(Equal("storage$space"))
+			return &provider.ListStorageSpacesResponse{Status: &rpc.Status{Code: rpc.Code_CODE_OK}, StorageSpaces: []*provider.StorageSpace{space}}
+		}, nil)
+		gwc.On("GetQuota", mock.Anything, mock.Anything).Return(func(_ context.Context, _ *gateway.GetQuotaRequest, _ ...grpc.CallOption) *provider.GetQuotaResponse {
+			return &provider.GetQuotaResponse{Status: &rpc.Status{Code: rpc.Code_CODE_OK}, UsedBytes: used, TotalBytes: total}
+		}, nil)
+		gwc.On("GetGroup", mock.Anything, mock.Anything).Return(&group.GetGroupResponse{
+			Status: &rpc.Status{Code: rpc.Code_CODE_OK},
+			Group:  &group.Group{Members: []*user.UserId{{OpaqueId: "manager"}, {OpaqueId: "member"}}},
+		}, nil)
+
+		notifier = NewEventsNotifier(
+			Logger(log.NewLogger()),
+			Config(&config.Config{
+				WebUIURL: "https://ocis.example.org",
+				Notifications: config.Notifications{
+					Channels: []string{"mail"},
+					Store: config.Store{
+						Database: "services",
+						Table:    "services/notifications/",
+					},
+					Triggers:        config.Triggers{SpaceQuota: true},
+					DefaultLanguage: "en",
+				},
+			}),
+			Channels(map[string]channels.Channel{"mail": ch}),
+			GatewayClient(gwc),
+			Store(store.NewMemoryStore()),
+		).(eventsNotifier)
+	})
+
+	It("notifies the space managers once per reached threshold", func() {
+		used = 50
+		upload()
+		Expect(ch.subjects).To(BeEmpty())
+
+		used = 85
+		upload()
+		Expect(ch.subjects).To(Equal([]string{"Marketing has used 80% of its quota"}))
+		Expect(ch.recipients).To(Equal([][]string{{"manager"}}))
+
+		used = 87
+		upload()
+		Expect(ch.subjects).To(HaveLen(1))
+
+		used = 100
+		upload()
+		Expect(ch.subjects).To(Equal([]string{"Marketing has used 80% of its quota", "Marketing has used 100% of its quota"}))
+		Expect(ch.messages[1]).To(ContainSubstring("https://ocis.example.org/f/storage$space"))
+	})
+
+	It("notifies the managers again when the space fills up again", func() {
+		used = 92
+		upload()
+		Expect(ch.subjects).To(Equal([]string{"Marketing has used 90% of its quota"}))
+
+		// freeing space only lowers the remembered threshold
+		used = 85
+		upload()
+		Expect(ch.subjects).To(HaveLen(1))
+
+		used = 10
+		upload()
+		used = 81
+		upload()
+		Expect(ch.subjects).To(Equal([]string{"Marketing has used 90% of its quota", "Marketing has used 80% of its quota"}))
+	})
+
+	It("ignores spaces without a quota and failed uploads", func() {
+		used, total = 100, 0
+		upload()
+
+		total = 100
+		notifier.handleUploadReady(events.UploadReady{
+			UploadID:      "upload",
+			ExecutingUser: &user.User{Id: &user.UserId{OpaqueId: "uploader"}},
+			FileRef:       &provider.Reference{ResourceId: &provider.ResourceId{StorageId: "storage", SpaceId: "space", OpaqueId: "file"}},
+			Failed:        true,
+		})
+		Expect(ch.subjects).To(BeEmpty())
+	})
+
+	Describe("getSpaceManagers", func() {
+		It("returns the members of groups managing the space once", func() {
+			space.Opaque = utils.AppendJSONToOpaque(nil, "grants", map[string]*provider.ResourcePermissions{"manager": manager, "managers": manager, "editor": editor})
+			space.Opaque = utils.AppendJSONToOpaque(space.Opaque, "groups", map[string]struct{}{"managers": {}})
+
+			managers, err := notifier.getSpaceManagers(context.Background(), space)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(managers).To(ConsistOf("manager", "member"))
+		})
+
+		It("falls back to the owner of personal spaces", func() {
+			space.SpaceType = "personal"
+			space.Opaque = nil
+
+			managers, err := notifier.getSpaceManagers(context.Background(), space)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(managers).To(ConsistOf("owner"))
+
+			space.SpaceType = "project"
+			managers, err = notifier.getSpaceManagers(context.Background(), space)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(managers).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/owncloud/ocis/v2/services/notifications/pkg/channels"
+)
+
+// retryOutbox sends the messages in the outbox whose next attempt is due
+func (s eventsNotifier) retryOutbox(now time.Time) {
+	// a slow channel must not lead to messages being sent twice
+	if !s.outboxLock.TryLock() {
+		return
+	}
+	defer s.outboxLock.Unlock()
+
+	msgs, err := s.outbox.Due(now)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("could not read the outbox")
+		return
+	}
+
+	for _, m := range msgs {
+		logger := s.logger.With().Str("id", m.ID).Str("channel", m.Channel).Int("attempts", m.Attempts+1).Logger()
+
+		err := errors.New("channel is not configured")
+		if ch, ok := s.channels[m.Channel]; ok {
+			ctx := channels.ContextWithEvent(context.Background(), m.Event)
+			err = ch.SendMessage(ctx, m.Recipients, m.Body, m.Subject, m.Sender)
+		}
+		if err == nil {
+			if err := s.outbox.Delivered(m); err != nil {
+				logger.Error().Err(err).Msg("could not remove delivered message from the outbox")
+			}
+			continue
+		}
+
+		// only retry the recipients who didn't get the message
+		m.Recipients = channels.FailedRecipients(err, m.Recipients)
+		dead, oerr := s.outbox.Failed(m, err, now)
+		switch {
+		case oerr != nil:
+			logger.Error().Err(oerr).Msg("could not update message in the outbox")
+		case dead:
+			logger.Error().Err(err).Msg("could not send message, giving up and moving it to the dead letters")
+		default:
+			logger.Warn().Err(err).Msg("could not send message, retrying later")
+		}
+	}
+}
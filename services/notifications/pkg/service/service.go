@@ -8,7 +8,10 @@ import (
 	"os"
 	"os/signal"
 	"path"
+	"strings"
+	"sync"
 	"syscall"
+	"time"
 
 	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
 	group "github.com/cs3org/go-cs3apis/cs3/identity/group/v1beta1"
@@ -17,8 +20,13 @@ import (
 	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
 	"github.com/cs3org/reva/v2/pkg/events"
 	"github.com/owncloud/ocis/v2/ocis-pkg/log"
+	settingssvc "github.com/owncloud/ocis/v2/protogen/gen/ocis/services/settings/v0"
 	"github.com/owncloud/ocis/v2/services/notifications/pkg/channels"
+	"github.com/owncloud/ocis/v2/services/notifications/pkg/config"
 	"github.com/owncloud/ocis/v2/services/notifications/pkg/email"
+	"github.com/owncloud/ocis/v2/services/notifications/pkg/outbox"
+	"github.com/owncloud/ocis/v2/services/settings/pkg/store/defaults"
+	"go-micro.dev/v4/store"
 	"google.golang.org/protobuf/types/known/fieldmaskpb"
 )
 
@@ -27,33 +35,69 @@ type Service interface {
 	Run() error
 }
 
-// NewEventsNotifier provides a new eventsNotifier
-func NewEventsNotifier(
-	events <-chan interface{},
-	channel channels.Channel,
-	logger log.Logger,
-	gwClient gateway.GatewayAPIClient,
-	machineAuthAPIKey, emailTemplatePath, ocisURL string) Service {
+const (
+	// digestInterval is the interval in which the notifier checks for due digests
+	digestInterval = time.Minute
+	// outboxInterval is the interval in which the notifier retries the messages in the outbox
+	outboxInterval = 30 * time.Second
+	// expiringInterval is the interval in which the notifier checks for shares which expire soon
+	expiringInterval = 10 * time.Minute
+)
+
+// NewEventsNotifier provides a new eventsNotifier. Users who didn't choose any channel get notified
+// through the channels of the config.
+func NewEventsNotifier(opts ...Option) Service {
+	options := newOptions(opts...)
+	cfg := options.Config.Notifications
+
+	// the weekday has been validated on startup
+	weekday, _ := cfg.Digest.ParseWeekday()
 	return eventsNotifier{
-		logger:            logger,
-		channel:           channel,
-		events:            events,
+		logger:            options.Logger,
+		channels:          options.Channels,
+		defaultChannels:   cfg.Channels,
+		events:            options.Events,
 		signals:           make(chan os.Signal, 1),
-		gwClient:          gwClient,
-		machineAuthAPIKey: machineAuthAPIKey,
-		emailTemplatePath: emailTemplatePath,
-		ocisURL:           ocisURL,
+		gwClient:          options.GatewayClient,
+		valueService:      options.ValueService,
+		store:             options.Store,
+		storeConfig:       cfg.Store,
+		outbox:            options.Outbox,
+		outboxLock:        &sync.Mutex{},
+		digestHour:        cfg.Digest.Hour,
+		digestWeekday:     weekday,
+		digestLock:        &sync.Mutex{},
+		triggers:          cfg.Triggers,
+		expiringLock:      &sync.Mutex{},
+		machineAuthAPIKey: cfg.MachineAuthAPIKey,
+		serviceUserID:     cfg.ServiceUserID,
+		emailTemplatePath: cfg.EmailTemplatePath,
+		defaultLanguage:   cfg.DefaultLanguage,
+		ocisURL:           options.Config.WebUIURL,
 	}
 }
 
 type eventsNotifier struct {
 	logger            log.Logger
-	channel           channels.Channel
+	channels          map[string]channels.Channel
+	defaultChannels   []string
 	events            <-chan interface{}
 	signals           chan os.Signal
 	gwClient          gateway.GatewayAPIClient
+	valueService      settingssvc.ValueService
+	store             store.Store
+	storeConfig       config.Store
+	outbox            *outbox.Outbox
+	outboxLock        *sync.Mutex
+	digestHour        int
+	digestWeekday     time.Weekday
+	digestLock        *sync.Mutex
+	triggers          config.Triggers
+	expiringLock      *sync.Mutex
 	machineAuthAPIKey string
+	serviceUserID     string
 	emailTemplatePath string
+	defaultLanguage   string
 	ocisURL           string
 }
 
@@ -61,6 +105,27 @@ func (s eventsNotifier) Run() error {
 	signal.Notify(s.signals, syscall.SIGINT, syscall.SIGTERM)
 	s.logger.Debug().
 		Msg("eventsNotifier started")
+
+	var digests <-chan time.Time
+	if s.store != nil {
+		ticker := time.NewTicker(digestInterval)
+		defer ticker.Stop()
+		digests = ticker.C
+	}
+	var expiring <-chan time.Time
+	if s.store != nil && s.triggers.ShareExpiring {
+		ticker := time.NewTicker(expiringInterval)
+		defer ticker.Stop()
+		expiring = ticker.C
+	}
+	var retries <-chan time.Time
+	if s.outbox != nil {
+		ticker := time.NewTicker(outboxInterval)
+		defer ticker.Stop()
+		retries = ticker.C
+	}
+	go s.seedExpiringShares()
+
 	for {
 		select {
 		case evt := <-s.events:
@@ -74,10 +139,25 @@ func (s eventsNotifier) Run() error {
 					s.handleSpaceMembershipExpired(e)
 				case events.ShareCreated:
 					s.handleShareCreated(e)
+					s.trackShareCreated(e)
+				case events.ShareUpdated:
+					s.trackShareUpdated(e)
+				case events.ShareRemoved:
+					s.untrackShare(e)
 				case events.ShareExpired:
 					s.handleShareExpired(e)
+				case events.PostprocessingFinished:
+					s.handlePostprocessingFinished(e)
+				case events.UploadReady:
+					s.handleUploadReady(e)
 				}
 			}()
+		case now := <-digests:
+			go s.sendDueDigests(now)
+		case now := <-expiring:
+			go s.notifyExpiringShares(now)
+		case now := <-retries:
+			go s.retryOutbox(now)
 		case <-s.signals:
 			s.logger.Debug().
 				Msg("eventsNotifier stopped")
@@ -86,13 +166,20 @@ func (s eventsNotifier) Run() error {
 	}
 }
 
-func (s eventsNotifier) render(bodyTemplate string, subjTemplate string, values map[string]string) (string, string, error) {
-	msg, err := email.RenderEmailTemplate(bodyTemplate, values, s.emailTemplatePath)
+// render renders the body and the subject of a template for a channel in a language, like "shares/shareCreated"
+// for the chat in German
+func (s eventsNotifier) render(channel, language, tmpl string, values interface{}) (string, string, error) {
+	kind, renderTemplate := channel, email.RenderTextTemplate
+	if channel == defaults.NotificationChannelMail {
+		kind, renderTemplate = "email", email.RenderEmailTemplate
+	}
+
+	msg, err := renderTemplate(fmt.Sprintf("%s.%s.body.tmpl", tmpl, kind), language, values, s.emailTemplatePath)
 	if err != nil {
 		return "", "", err
 	}
 
-	sub, err := email.RenderEmailTemplate(subjTemplate, values, s.emailTemplatePath)
+	sub, err := renderTemplate(fmt.Sprintf("%s.%s.subject.tmpl", tmpl, kind), language, values, s.emailTemplatePath)
 	if err != nil {
 		return "", "", err
 	}
@@ -100,19 +187,130 @@ func (s eventsNotifier) render(bodyTemplate string, subjTemplate string, values
 	return msg, sub, nil
 }
 
-func (s eventsNotifier) send(ctx context.Context, u *user.UserId, g *group.GroupId, msg, subj, sender string) error {
-	if u != nil {
-		return s.channel.SendMessage(ctx, []string{u.GetOpaqueId()}, msg, subj, sender)
+// send notifies the grantee about an event. The event is identified by the id of the setting users
+// opt out of it with. Recipients who chose a digest get the notification with their next digest.
+func (s eventsNotifier) send(ctx context.Context, settingID, tmpl string, values map[string]string, u *user.UserId, g *group.GroupId, sender string) error {
+	recipients, err := s.getRecipients(ctx, u, g)
+	if err != nil {
+		return err
+	}
+	return s.sendTo(ctx, settingID, tmpl, values, recipients, sender)
+}
+
+// sendTo notifies the given users about an event like send does
+func (s eventsNotifier) sendTo(ctx context.Context, settingID, tmpl string, values map[string]string, recipients []string, sender string) error {
+	instant := map[recipientGroup][]string{}
+	for _, userID := range recipients {
+		p := s.getPreferences(userID)
+		switch {
+		case !p.wants(settingID):
+			continue
+		case s.store != nil && (p.delivery == defaults.NotificationDeliveryDaily || p.delivery == defaults.NotificationDeliveryWeekly):
+			subj, err := email.RenderTextTemplate(tmpl+".email.subject.tmpl", p.language, values, s.emailTemplatePath)
+			if err == nil {
+				err = s.collect(p.delivery, userID, subj, sender)
+			}
+			if err == nil {
+				continue
+			}
+			s.logger.Error().Err(err).Str("userid", userID).Msg("could not collect notification for digest, sending it instantly")
+		}
+		for _, ch := range s.channelsOf(p) {
+			rg := recipientGroup{channel: ch, language: p.language}
+			instant[rg] = append(instant[rg], userID)
+		}
+	}
+
+	return s.deliver(ctx, instant, tmpl, values, sender)
+}
+
+// channelsOf returns the available channels a user receives notifications through
+func (s eventsNotifier) channelsOf(p preferences) []string {
+	available := func(names []string) []string {
+		chs := make([]string, 0, len(names))
+		for _, n := range names {
+			if _, ok := s.channels[n]; ok {
+				chs = append(chs, n)
+			}
+		}
+		return chs
+	}
 
+	if chs := available(p.channels); len(chs) > 0 {
+		return chs
 	}
+	return available(s.defaultChannels)
+}
+
+// recipientGroup groups the users receiving a notification through the same channel in the same language
+type recipientGroup struct {
+	channel  string
+	language string
+}
 
-	if g != nil {
-		return s.channel.SendMessageToGroup(ctx, g, msg, subj, sender)
+// deliver renders a template for every channel and language and sends it to the users of the group.
+// Messages which could not be sent are added to the outbox to be retried later for the users who didn't get them.
+func (s eventsNotifier) deliver(ctx context.Context, recipients map[recipientGroup][]string, tmpl string, values interface{}, sender string) error {
+	ctx = channels.ContextWithEvent(ctx, path.Base(tmpl))
+
+	var errs []string
+	for rg, userIDs := range recipients {
+		msg, subj, err := s.render(rg.channel, rg.language, tmpl, values)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("could not render %s template for %s: %s", tmpl, rg.channel, err))
+			continue
+		}
+		if err := s.channels[rg.channel].SendMessage(ctx, userIDs, msg, subj, sender); err != nil {
+			if s.outbox == nil {
+				errs = append(errs, fmt.Sprintf("could not send %s message: %s", rg.channel, err))
+				continue
+			}
+			m := outbox.Message{
+				Channel:    rg.channel,
+				Event:      path.Base(tmpl),
+				Recipients: channels.FailedRecipients(err, userIDs),
+				Subject:    subj,
+				Body:       msg,
+				Sender:     sender,
+			}
+			if oerr := s.outbox.Add(m, err, time.Now()); oerr != nil {
+				errs = append(errs, fmt.Sprintf("could not send %s message: %s, could not add it to the outbox: %s", rg.channel, err, oerr))
+				continue
+			}
+			s.logger.Warn().Err(err).Str("channel", rg.channel).Msg("could not send message, added it to the outbox")
+		}
 	}
 
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
 	return nil
 }
 
+// getRecipients returns the ids of the users to notify, the members of a group are notified individually
+func (s eventsNotifier) getRecipients(ctx context.Context, u *user.UserId, g *group.GroupId) ([]string, error) {
+	switch {
+	case u != nil:
+		return []string{u.GetOpaqueId()}, nil
+	case g != nil:
+		r, err := s.gwClient.GetGroup(ctx, &group.GetGroupRequest{GroupId: g})
+		if err != nil {
+			return nil, err
+		}
+		if r.GetStatus().GetCode() != rpc.Code_CODE_OK {
+			return nil, fmt.Errorf("unexpected status code from gateway client: %d", r.GetStatus().GetCode())
+		}
+
+		members := make([]string, 0, len(r.GetGroup().GetMembers()))
+		for _, m := range r.GetGroup().GetMembers() {
+			members = append(members, m.GetOpaqueId())
+		}
+		return members, nil
+	default:
+		return nil, nil
+	}
+}
+
 func (s eventsNotifier) getGranteeName(ctx context.Context, u *user.UserId, g *group.GroupId) (string, error) {
 	switch {
 	case u != nil:
@@ -2,6 +2,7 @@ package service_test
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
@@ -15,6 +16,8 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/owncloud/ocis/v2/ocis-pkg/log"
+	"github.com/owncloud/ocis/v2/services/notifications/pkg/channels"
+	"github.com/owncloud/ocis/v2/services/notifications/pkg/config"
 	"github.com/owncloud/ocis/v2/services/notifications/pkg/service"
 	"github.com/test-go/testify/mock"
 )
@@ -51,7 +54,19 @@ var _ = Describe("Notifications", func() {
 	DescribeTable("Sending notifications",
 		func(tc testChannel, ev interface{}) {
 			ch := make(chan interface{})
-			evts := service.NewEventsNotifier(ch, tc, log.NewLogger(), gwc, "", "", "")
+			cfg := &config.Config{
+				Notifications: config.Notifications{
+					Channels: []string{"mail"},
+					Triggers: config.Triggers{VirusFound: true, UploadAborted: true},
+				},
+			}
+			evts := service.NewEventsNotifier(
+				service.Logger(log.NewLogger()),
+				service.Config(cfg),
+				service.Events(ch),
+				service.Channels(map[string]channels.Channel{"mail": tc}),
+				service.GatewayClient(gwc),
+			)
 			go evts.Run()
 
 			ch <- ev
@@ -122,6 +137,39 @@ var _ = Describe("Notifications", func() {
 			SpaceName:     "secret space",
 			ExpiredAt:     time.Date(2023, 4, 17, 16, 42, 0, 0, time.UTC),
 		}),
+
+		Entry("Virus Found", testChannel{
+			expectedReceipients: map[string]bool{sharer.GetId().GetOpaqueId(): true},
+			expectedSubject:     "Virus found in 'eicar.com'",
+			expectedSender:      "",
+			done:                make(chan struct{}),
+		}, events.PostprocessingFinished{
+			UploadID:      "uploadid",
+			Filename:      "eicar.com",
+			ExecutingUser: sharer,
+			Outcome:       events.PPOutcomeDelete,
+			Result: map[events.Postprocessingstep]interface{}{
+				events.PPStepAntivirus: events.PostprocessingStepFinished{
+					UploadID:     "uploadid",
+					FinishedStep: events.PPStepAntivirus,
+					Outcome:      events.PPOutcomeDelete,
+					Error:        errors.New("infected"),
+					Result:       events.VirusscanFinished{Infected: true, Description: "Eicar-Signature"},
+				},
+			},
+		}),
+
+		Entry("Upload Aborted", testChannel{
+			expectedReceipients: map[string]bool{sharer.GetId().GetOpaqueId(): true},
+			expectedSubject:     "Upload of 'secrets.txt' failed",
+			expectedSender:      "",
+			done:                make(chan struct{}),
+		}, events.PostprocessingFinished{
+			UploadID:      "uploadid",
+			Filename:      "secrets.txt",
+			ExecutingUser: sharer,
+			Outcome:       events.PPOutcomeAbort,
+		}),
 	)
 })
 
@@ -3,6 +3,7 @@ package service
 import (
 	"github.com/cs3org/reva/v2/pkg/events"
 	"github.com/cs3org/reva/v2/pkg/utils"
+	"github.com/owncloud/ocis/v2/services/settings/pkg/store/defaults"
 	"google.golang.org/protobuf/types/known/fieldmaskpb"
 )
 
@@ -41,18 +42,13 @@ func (s eventsNotifier) handleShareCreated(e events.ShareCreated) {
 	}
 
 	sharerDisplayName := owner.GetDisplayName()
-	msg, subj, err := s.render("shares/shareCreated.email.body.tmpl", "shares/shareCreated.email.subject.tmpl", map[string]string{
+	values := map[string]string{
 		"ShareGrantee": shareGrantee,
 		"ShareSharer":  sharerDisplayName,
 		"ShareFolder":  resourceInfo.Name,
 		"ShareLink":    shareLink,
-	})
-
-	if err != nil {
-		s.logger.Error().Err(err).Str("event", "ShareCreated").Msg("Could not render E-Mail body template for shares")
 	}
-
-	if err := s.send(ownerCtx, e.GranteeUserID, e.GranteeGroupID, msg, subj, sharerDisplayName); err != nil {
+	if err := s.send(ownerCtx, defaults.SettingUUIDNotifyShareCreated, "shares/shareCreated", values, e.GranteeUserID, e.GranteeGroupID, sharerDisplayName); err != nil {
 		s.logger.Error().Err(err).Str("event", "ShareCreated").Msg("failed to send a message")
 	}
 
@@ -84,17 +80,12 @@ func (s eventsNotifier) handleShareExpired(e events.ShareExpired) {
 		return
 	}
 
-	msg, subj, err := s.render("shares/shareExpired.email.body.tmpl", "shares/shareExpired.email.subject.tmpl", map[string]string{
+	values := map[string]string{
 		"ShareGrantee": shareGrantee,
 		"ShareFolder":  resourceInfo.GetName(),
 		"ExpiredAt":    e.ExpiredAt.Format("2006-01-02 15:04:05"),
-	})
-
-	if err != nil {
-		s.logger.Error().Err(err).Str("event", "ShareCreated").Msg("Could not render E-Mail body template for shares")
 	}
-
-	if err := s.send(ctx, e.GranteeUserID, e.GranteeGroupID, msg, subj, owner.GetDisplayName()); err != nil {
+	if err := s.send(ctx, defaults.SettingUUIDNotifyShareExpired, "shares/shareExpired", values, e.GranteeUserID, e.GranteeGroupID, owner.GetDisplayName()); err != nil {
 		s.logger.Error().Err(err).Str("event", "ShareCreated").Msg("failed to send a message")
 	}
 
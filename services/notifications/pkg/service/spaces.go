@@ -4,6 +4,7 @@ import (
 	"github.com/cs3org/reva/v2/pkg/events"
 	"github.com/cs3org/reva/v2/pkg/storagespace"
 	"github.com/cs3org/reva/v2/pkg/utils"
+	"github.com/owncloud/ocis/v2/services/settings/pkg/store/defaults"
 )
 
 func (s eventsNotifier) handleSpaceShared(e events.SpaceShared) {
@@ -54,18 +55,13 @@ func (s eventsNotifier) handleSpaceShared(e events.SpaceShared) {
 	}
 
 	sharerDisplayName := owner.GetDisplayName()
-	msg, subj, err := s.render("spaces/sharedSpace.email.body.tmpl", "spaces/sharedSpace.email.subject.tmpl", map[string]string{
+	values := map[string]string{
 		"SpaceGrantee": spaceGrantee,
 		"SpaceSharer":  sharerDisplayName,
 		"SpaceName":    resourceInfo.GetSpace().GetName(),
 		"ShareLink":    shareLink,
-	})
-	if err != nil {
-		logger.Error().Err(err).Msg("Could not render E-Mail template for spaces")
-		return
 	}
-
-	if err := s.send(ownerCtx, e.GranteeUserID, e.GranteeGroupID, msg, subj, sharerDisplayName); err != nil {
+	if err := s.send(ownerCtx, defaults.SettingUUIDNotifySpaceShared, "spaces/sharedSpace", values, e.GranteeUserID, e.GranteeGroupID, sharerDisplayName); err != nil {
 		logger.Error().Err(err).Msg("failed to send a message")
 	}
 }
@@ -116,19 +112,13 @@ func (s eventsNotifier) handleSpaceUnshared(e events.SpaceUnshared) {
 	}
 
 	sharerDisplayName := owner.GetDisplayName()
-	msg, subj, err := s.render("spaces/unsharedSpace.email.body.tmpl", "spaces/unsharedSpace.email.subject.tmpl", map[string]string{
+	values := map[string]string{
 		"SpaceGrantee": spaceGrantee,
 		"SpaceSharer":  sharerDisplayName,
 		"SpaceName":    resourceInfo.GetSpace().Name,
 		"ShareLink":    shareLink,
-	})
-
-	if err != nil {
-		logger.Error().Err(err).Msg("Could not render E-Mail template for spaces")
-		return
 	}
-
-	if err := s.send(ownerCtx, e.GranteeUserID, e.GranteeGroupID, msg, subj, sharerDisplayName); err != nil {
+	if err := s.send(ownerCtx, defaults.SettingUUIDNotifySpaceUnshared, "spaces/unsharedSpace", values, e.GranteeUserID, e.GranteeGroupID, sharerDisplayName); err != nil {
 		logger.Error().Err(err).Msg("failed to send a message")
 	}
 }
@@ -151,17 +141,12 @@ func (s eventsNotifier) handleSpaceMembershipExpired(e events.SpaceMembershipExp
 		return
 	}
 
-	msg, subj, err := s.render("spaces/membershipExpired.email.body.tmpl", "spaces/membershipExpired.email.subject.tmpl", map[string]string{
+	values := map[string]string{
 		"SpaceGrantee": shareGrantee,
 		"SpaceName":    e.SpaceName,
 		"ExpiredAt":    e.ExpiredAt.Format("2006-01-02 15:04:05"),
-	})
-
-	if err != nil {
-		s.logger.Error().Err(err).Str("event", "ShareCreated").Msg("Could not render E-Mail body template for shares")
 	}
-
-	if err := s.send(ctx, e.GranteeUserID, e.GranteeGroupID, msg, subj, owner.GetDisplayName()); err != nil {
+	if err := s.send(ctx, defaults.SettingUUIDNotifySpaceMembershipExpired, "spaces/membershipExpired", values, e.GranteeUserID, e.GranteeGroupID, owner.GetDisplayName()); err != nil {
 		s.logger.Error().Err(err).Str("event", "ShareCreated").Msg("failed to send a message")
 	}
 
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/cs3org/reva/v2/pkg/events"
+	"github.com/owncloud/ocis/v2/services/settings/pkg/store/defaults"
+)
+
+func (s eventsNotifier) handlePostprocessingFinished(e events.PostprocessingFinished) {
+	logger := s.logger.With().
+		Str("event", "PostprocessingFinished").
+		Str("uploadid", e.UploadID).
+		Logger()
+
+	scan, scanned := virusscanResult(e)
+	var tmpl string
+	switch {
+	case scanned && scan.Infected:
+		if !s.triggers.VirusFound {
+			return
+		}
+		tmpl = "uploads/virusFound"
+	case e.Outcome == events.PPOutcomeAbort || e.Outcome == events.PPOutcomeDelete:
+		if !s.triggers.UploadAborted {
+			return
+		}
+		tmpl = "uploads/uploadAborted"
+	default:
+		return
+	}
+
+	if e.ExecutingUser.GetId() == nil {
+		logger.Debug().Msg("upload has no executing user, not sending a notification")
+		return
+	}
+
+	values := map[string]string{
+		"UploadUser":       e.ExecutingUser.GetDisplayName(),
+		"UploadFilename":   e.Filename,
+		"VirusDescription": scan.Description,
+	}
+	if err := s.send(context.Background(), defaults.SettingUUIDNotifyUploadFailed, tmpl, values, e.ExecutingUser.GetId(), nil, ""); err != nil {
+		logger.Error().Err(err).Msg("failed to send a message")
+	}
+}
+
+// virusscanResult returns the result of the virus scan of an upload, if it has been scanned. Postprocessing keeps
+// the PostprocessingStepFinished event of every step, the result of the scan is nested in it.
+func virusscanResult(e events.PostprocessingFinished) (events.VirusscanFinished, bool) {
+	var scan events.VirusscanFinished
+	r, ok := e.Result[events.PPStepAntivirus]
+	if !ok {
+		return scan, false
+	}
+
+	// the results are only typed before they went through the event system. Only the result of the step is
+	// decoded, its error can't be unmarshalled.
+	b, err := json.Marshal(r)
+	if err != nil {
+		return scan, false
+	}
+	var step struct {
+		Result *events.VirusscanFinished
+	}
+	if err := json.Unmarshal(b, &step); err != nil || step.Result == nil {
+		return scan, false
+	}
+	return *step.Result, true
+}
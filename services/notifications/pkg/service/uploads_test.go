@@ -0,0 +1,60 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/cs3org/reva/v2/pkg/events"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("virusscanResult", func() {
+	// viaEventSystem returns the event as received by the notifications service
+	viaEventSystem := func(e events.PostprocessingFinished) events.PostprocessingFinished {
+		b, err := json.Marshal(e)
+		Expect(err).ToNot(HaveOccurred())
+		ev, err := events.PostprocessingFinished{}.Unmarshal(b)
+		Expect(err).ToNot(HaveOccurred())
+		return ev.(events.PostprocessingFinished)
+	}
+
+	finished := func(step events.PostprocessingStepFinished) events.PostprocessingFinished {
+		return events.PostprocessingFinished{
+			UploadID: "uploadid",
+			Result:   map[events.Postprocessingstep]interface{}{events.PPStepAntivirus: step},
+		}
+	}
+
+	It("returns the scan nested in the finished step", func() {
+		e := viaEventSystem(finished(events.PostprocessingStepFinished{
+			FinishedStep: events.PPStepAntivirus,
+			Outcome:      events.PPOutcomeDelete,
+			Error:        errors.New("infected"),
+			Result:       events.VirusscanFinished{Infected: true, Description: "Eicar-Signature"},
+		}))
+
+		scan, scanned := virusscanResult(e)
+		Expect(scanned).To(BeTrue())
+		Expect(scan.Infected).To(BeTrue())
+		Expect(scan.Description).To(Equal("Eicar-Signature"))
+	})
+
+	It("returns clean scans", func() {
+		scan, scanned := virusscanResult(viaEventSystem(finished(events.PostprocessingStepFinished{
+			FinishedStep: events.PPStepAntivirus,
+			Outcome:      events.PPOutcomeContinue,
+			Result:       events.VirusscanFinished{},
+		})))
+		Expect(scanned).To(BeTrue())
+		Expect(scan.Infected).To(BeFalse())
+	})
+
+	It("ignores uploads which were not scanned", func() {
+		_, scanned := virusscanResult(viaEventSystem(events.PostprocessingFinished{UploadID: "uploadid"}))
+		Expect(scanned).To(BeFalse())
+
+		_, scanned = virusscanResult(viaEventSystem(finished(events.PostprocessingStepFinished{FinishedStep: events.PPStepAntivirus})))
+		Expect(scanned).To(BeFalse())
+	})
+})
@@ -0,0 +1,201 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/owncloud/ocis/v2/ocis-pkg/config/configlog"
+	"github.com/owncloud/ocis/v2/ocis-pkg/service/grpc"
+	ppmsg "github.com/owncloud/ocis/v2/protogen/gen/ocis/messages/postprocessing/v0"
+	ppsvc "github.com/owncloud/ocis/v2/protogen/gen/ocis/services/postprocessing/v0"
+	"github.com/owncloud/ocis/v2/services/postprocessing/pkg/config"
+	"github.com/owncloud/ocis/v2/services/postprocessing/pkg/config/parser"
+	"github.com/urfave/cli/v2"
+)
+
+// List is the entrypoint for the list command.
+func List(cfg *config.Config) *cli.Command {
+	return &cli.Command{
+		Name:     "list",
+		Usage:    "list all uploads which are currently postprocessed",
+		Category: "postprocessing management",
+		Aliases:  []string{"ls"},
+		Before: func(c *cli.Context) error {
+			return configlog.ReturnFatal(parser.ParseConfig(cfg))
+		},
+		Action: func(c *cli.Context) error {
+			client, err := postprocessingClient(cfg)
+			if err != nil {
+				return err
+			}
+
+			res, err := client.List(context.Background(), &ppsvc.ListRequest{})
+			if err != nil {
+				fmt.Println("failed to list postprocessings: " + err.Error())
+				return err
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "UPLOAD ID\tFILENAME\tSTEP\tATTEMPTS\tWAITING\tOUTCOME")
+			for _, pp := range res.GetPostprocessings() {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\n", pp.GetUploadId(), pp.GetFilename(), pp.GetCurrentStep(), pp.GetAttempts()+1, waiting(pp), pp.GetOutcome())
+			}
+			return w.Flush()
+		},
+	}
+}
+
+// Show is the entrypoint for the show command.
+func Show(cfg *config.Config) *cli.Command {
+	return &cli.Command{
+		Name:      "show",
+		Usage:     "show the postprocessing of an upload",
+		Category:  "postprocessing management",
+		ArgsUsage: "<upload-id>",
+		Before: func(c *cli.Context) error {
+			return configlog.ReturnFatal(parser.ParseConfig(cfg))
+		},
+		Action: func(c *cli.Context) error {
+			id, err := uploadID(c)
+			if err != nil {
+				return err
+			}
+
+			client, err := postprocessingClient(cfg)
+			if err != nil {
+				return err
+			}
+
+			res, err := client.Get(context.Background(), &ppsvc.GetRequest{UploadId: id})
+			if err != nil {
+				fmt.Println("failed to get postprocessing: " + err.Error())
+				return err
+			}
+
+			return printPostprocessing(res.GetPostprocessing())
+		},
+	}
+}
+
+// Restart is the entrypoint for the restart command.
+func Restart(cfg *config.Config) *cli.Command {
+	return &cli.Command{
+		Name:      "restart",
+		Usage:     "start the current postprocessing step of an upload again",
+		Category:  "postprocessing management",
+		ArgsUsage: "<upload-id>",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "skip",
+				Usage: "skip the current step and continue with the next one",
+			},
+		},
+		Before: func(c *cli.Context) error {
+			return configlog.ReturnFatal(parser.ParseConfig(cfg))
+		},
+		Action: func(c *cli.Context) error {
+			id, err := uploadID(c)
+			if err != nil {
+				return err
+			}
+
+			client, err := postprocessingClient(cfg)
+			if err != nil {
+				return err
+			}
+
+			res, err := client.Restart(context.Background(), &ppsvc.RestartRequest{UploadId: id, Skip: c.Bool("skip")})
+			if err != nil {
+				fmt.Println("failed to restart postprocessing: " + err.Error())
+				return err
+			}
+
+			return printPostprocessing(res.GetPostprocessing())
+		},
+	}
+}
+
+// Abort is the entrypoint for the abort command.
+func Abort(cfg *config.Config) *cli.Command {
+	return &cli.Command{
+		Name:      "abort",
+		Usage:     "abort the postprocessing of an upload",
+		Category:  "postprocessing management",
+		ArgsUsage: "<upload-id>",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "delete",
+				Usage: "delete the upload instead of keeping it",
+			},
+		},
+		Before: func(c *cli.Context) error {
+			return configlog.ReturnFatal(parser.ParseConfig(cfg))
+		},
+		Action: func(c *cli.Context) error {
+			id, err := uploadID(c)
+			if err != nil {
+				return err
+			}
+
+			client, err := postprocessingClient(cfg)
+			if err != nil {
+				return err
+			}
+
+			res, err := client.Abort(context.Background(), &ppsvc.AbortRequest{UploadId: id, Delete: c.Bool("delete")})
+			if err != nil {
+				fmt.Println("failed to abort postprocessing: " + err.Error())
+				return err
+			}
+
+			return printPostprocessing(res.GetPostprocessing())
+		},
+	}
+}
+
+func postprocessingClient(cfg *config.Config) (ppsvc.PostprocessingService, error) {
+	if err := grpc.Configure(grpc.GetClientOptions(cfg.GRPCClientTLS)...); err != nil {
+		return nil, err
+	}
+	return ppsvc.NewPostprocessingService(cfg.GRPC.Namespace+"."+cfg.Service.Name, grpc.DefaultClient()), nil
+}
+
+func uploadID(c *cli.Context) (string, error) {
+	if c.NArg() != 1 {
+		return "", fmt.Errorf("expected exactly one upload id, got %d arguments", c.NArg())
+	}
+	return c.Args().First(), nil
+}
+
+// waiting returns how long the upload waits for its current step
+func waiting(pp *ppmsg.Postprocessing) string {
+	if pp.GetFinished() || pp.GetStepStarted() == nil {
+		return "-"
+	}
+	return time.Since(pp.GetStepStarted().AsTime()).Round(time.Second).String()
+}
+
+func printPostprocessing(pp *ppmsg.Postprocessing) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Upload ID:\t%s\n", pp.GetUploadId())
+	fmt.Fprintf(w, "Filename:\t%s\n", pp.GetFilename())
+	fmt.Fprintf(w, "Filesize:\t%d\n", pp.GetFilesize())
+	fmt.Fprintf(w, "Space ID:\t%s\n", pp.GetResourceId().GetSpaceId())
+	fmt.Fprintf(w, "User ID:\t%s\n", pp.GetUserId())
+	fmt.Fprintf(w, "Steps:\t%v\n", pp.GetSteps())
+	fmt.Fprintf(w, "Current step:\t%s\n", pp.GetCurrentStep())
+	if pp.GetStepStarted() != nil {
+		fmt.Fprintf(w, "Step started:\t%s\n", pp.GetStepStarted().AsTime().Local().Format(time.RFC3339))
+	}
+	fmt.Fprintf(w, "Waiting:\t%s\n", waiting(pp))
+	fmt.Fprintf(w, "Attempts:\t%d\n", pp.GetAttempts()+1)
+	fmt.Fprintf(w, "Finished:\t%t\n", pp.GetFinished())
+	fmt.Fprintf(w, "Outcome:\t%s\n", pp.GetOutcome())
+	for _, r := range pp.GetResults() {
+		fmt.Fprintf(w, "Result %s:\t%s %s\n", r.GetStep(), r.GetOutcome(), r.GetResult())
+	}
+	return w.Flush()
+}
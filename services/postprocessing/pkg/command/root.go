@@ -18,6 +18,10 @@ func GetCommands(cfg *config.Config) cli.Commands {
 		Server(cfg),
 
 		// interaction with this service
+		List(cfg),
+		Show(cfg),
+		Restart(cfg),
+		Abort(cfg),
 
 		// infos about this service
 		Health(cfg),
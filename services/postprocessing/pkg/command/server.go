@@ -1,6 +1,7 @@
 package command
 
 import (
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
@@ -8,10 +9,13 @@ import (
 
 	"github.com/cs3org/reva/v2/pkg/events/stream"
 	"github.com/go-micro/plugins/v4/events/natsjs"
+	"github.com/oklog/run"
 	ociscrypto "github.com/owncloud/ocis/v2/ocis-pkg/crypto"
+	"github.com/owncloud/ocis/v2/ocis-pkg/store"
 	"github.com/owncloud/ocis/v2/services/postprocessing/pkg/config"
 	"github.com/owncloud/ocis/v2/services/postprocessing/pkg/config/parser"
 	"github.com/owncloud/ocis/v2/services/postprocessing/pkg/logging"
+	"github.com/owncloud/ocis/v2/services/postprocessing/pkg/server/grpc"
 	"github.com/owncloud/ocis/v2/services/postprocessing/pkg/service"
 	"github.com/urfave/cli/v2"
 )
@@ -65,11 +69,52 @@ func Server(cfg *config.Config) *cli.Command {
 				return err
 			}
 
-			svc, err := service.NewPostprocessingService(bus, logger, cfg.Postprocessing)
+			st := store.GetStore(store.OcisStoreOptions{
+				Type:     cfg.Store.Type,
+				Address:  cfg.Store.Address,
+				Database: cfg.Store.Database,
+			})
+
+			svc, err := service.NewPostprocessingService(bus, logger, st, cfg.Postprocessing, cfg.Store)
 			if err != nil {
 				return err
 			}
-			return svc.Run()
+
+			gr := run.Group{}
+			ctx, cancel := func() (context.Context, context.CancelFunc) {
+				if cfg.Context == nil {
+					return context.WithCancel(context.Background())
+				}
+				return context.WithCancel(cfg.Context)
+			}()
+			defer cancel()
+
+			gr.Add(svc.Run, func(_ error) {
+				svc.Close()
+				cancel()
+			})
+
+			grpcServer, err := grpc.Server(
+				grpc.Config(cfg),
+				grpc.Logger(logger),
+				grpc.Name(cfg.Service.Name),
+				grpc.Context(ctx),
+				grpc.Postprocessing(svc),
+			)
+			if err != nil {
+				logger.Info().Err(err).Str("transport", "grpc").Msg("Failed to initialize server")
+				return err
+			}
+
+			gr.Add(grpcServer.Run, func(_ error) {
+				logger.Error().
+					Err(err).
+					Str("server", "grpc").
+					Msg("shutting down server")
+				cancel()
+			})
+
+			return gr.Run()
 		},
 	}
 }
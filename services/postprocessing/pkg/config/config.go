@@ -15,17 +15,38 @@ type Config struct {
 
 	Log *Log `yaml:"log"`
 
+	GRPC          GRPCConfig            `yaml:"grpc"`
+	GRPCClientTLS *shared.GRPCClientTLS `yaml:"grpc_client_tls"`
+
 	Postprocessing Postprocessing `yaml:"postprocessing"`
 
+	Store Store `yaml:"store"`
+
 	Context context.Context `yaml:"-"`
 }
 
 // Postprocessing defines the config options for the postprocessing service.
 type Postprocessing struct {
-	Events          Events        `yaml:"events"`
-	Steps           []string      `yaml:"steps" env:"POSTPROCESSING_STEPS" desc:"A comma separated list of postprocessing steps, processed in order of their appearance. Currently supported values by the system are: 'virusscan' and 'delay'. Custom steps are allowed. See the documentation for instructions."`
-	Virusscan       bool          `yaml:"virusscan" env:"POSTPROCESSING_VIRUSSCAN" desc:"After uploading a file but before making it available for download, virus scanning the file can be enabled. Needs as prerequisite the antivirus service to be enabled and configured." deprecationVersion:"master" removalVersion:"master" deprecationInfo:"POSTPROCESSING_VIRUSSCAN is not longer necessary and is replaced by POSTPROCESSING_STEPS which also holds information about the order of steps" deprecationReplacement:"POSTPROCESSING_STEPS"`
-	Delayprocessing time.Duration `yaml:"delayprocessing" env:"POSTPROCESSING_DELAY" desc:"After uploading a file but before making it available for download, a delay step can be added. Intended for developing purposes only. The duration can be set as number followed by a unit identifier like s, m or h. If a duration is set but the keyword 'delay' is not explicitely added to 'POSTPROCESSING_STEPS', the delay step will be processed as last step. In such a case, a log entry will be written on service startup to remind the admin about that situation."`
+	Events             Events        `yaml:"events"`
+	Steps              []string      `yaml:"steps" env:"POSTPROCESSING_STEPS" desc:"A comma separated list of postprocessing steps, processed in order of their appearance. Currently supported values by the system are: 'virusscan' and 'delay'. Custom steps are allowed. See the documentation for instructions."`
+	Virusscan          bool          `yaml:"virusscan" env:"POSTPROCESSING_VIRUSSCAN" desc:"After uploading a file but before making it available for download, virus scanning the file can be enabled. Needs as prerequisite the antivirus service to be enabled and configured." deprecationVersion:"master" removalVersion:"master" deprecationInfo:"POSTPROCESSING_VIRUSSCAN is not longer necessary and is replaced by POSTPROCESSING_STEPS which also holds information about the order of steps" deprecationReplacement:"POSTPROCESSING_STEPS"`
+	Rules              []Rule        `yaml:"rules"`
+	Delayprocessing    time.Duration `yaml:"delayprocessing" env:"POSTPROCESSING_DELAY" desc:"After uploading a file but before making it available for download, a delay step can be added. Intended for developing purposes only. The duration can be set as number followed by a unit identifier like s, m or h. If a duration is set but the keyword 'delay' is not explicitely added to 'POSTPROCESSING_STEPS', the delay step will be processed as last step. In such a case, a log entry will be written on service startup to remind the admin about that situation."`
+	StepTimeout        time.Duration `yaml:"step_timeout" env:"POSTPROCESSING_STEP_TIMEOUT" desc:"The time to wait for a postprocessing step to finish before it is started again. The duration can be set as number followed by a unit identifier like s, m or h. A duration of 0 disables the timeout and the postprocessing service waits forever."`
+	StepRetries        int           `yaml:"step_retries" env:"POSTPROCESSING_STEP_RETRIES" desc:"The number of times a timed out postprocessing step is started again before the postprocessing is finished with the outcome configured in POSTPROCESSING_STEP_FAILURE_OUTCOME."`
+	StepFailureOutcome string        `yaml:"step_failure_outcome" env:"POSTPROCESSING_STEP_FAILURE_OUTCOME" desc:"The outcome of a postprocessing step that timed out more often than allowed by POSTPROCESSING_STEP_RETRIES. Valid values are: 'abort' (abort postprocessing, keep the file), 'delete' (abort postprocessing, delete the file) and 'continue' (skip the step and continue postprocessing)."`
+}
+
+// Rule defines the postprocessing steps for all uploads matching the rule. Rules are evaluated in order of their
+// appearance, the first matching rule wins. All conditions of a rule must match, empty conditions match every upload.
+// Uploads not matching any rule are processed with the default steps.
+type Rule struct {
+	SpaceType string   `yaml:"space_type"` // "personal" or "project"
+	SpaceIDs  []string `yaml:"space_ids"`
+	MimeTypes []string `yaml:"mime_types"` // a trailing "/*" matches all subtypes, e.g. "image/*"
+	MinSize   uint64   `yaml:"min_size"`   // in bytes, inclusive
+	MaxSize   uint64   `yaml:"max_size"`   // in bytes, inclusive, 0 means no limit
+	Steps     []string `yaml:"steps"`
 }
 
 // Events combines the configuration options for the event bus.
@@ -1,6 +1,7 @@
 package defaults
 
 import (
+	"github.com/owncloud/ocis/v2/ocis-pkg/shared"
 	"github.com/owncloud/ocis/v2/services/postprocessing/pkg/config"
 )
 
@@ -18,11 +19,23 @@ func DefaultConfig() *config.Config {
 		Service: config.Service{
 			Name: "postprocessing",
 		},
+		GRPC: config.GRPCConfig{
+			Addr:      "127.0.0.1:9255",
+			Namespace: "com.owncloud.api",
+		},
 		Postprocessing: config.Postprocessing{
 			Events: config.Events{
 				Endpoint: "127.0.0.1:9233",
 				Cluster:  "ocis-cluster",
 			},
+			StepRetries:        3,
+			StepFailureOutcome: "abort",
+		},
+		Store: config.Store{
+			Type:     "nats-js",
+			Address:  "127.0.0.1:9233",
+			Database: "services",
+			Table:    "services/postprocessing/",
 		},
 	}
 }
@@ -40,6 +53,22 @@ func EnsureDefaults(cfg *config.Config) {
 	} else if cfg.Log == nil {
 		cfg.Log = &config.Log{}
 	}
+
+	if cfg.GRPCClientTLS == nil {
+		cfg.GRPCClientTLS = &shared.GRPCClientTLS{}
+		if cfg.Commons != nil && cfg.Commons.GRPCClientTLS != nil {
+			cfg.GRPCClientTLS.Mode = cfg.Commons.GRPCClientTLS.Mode
+			cfg.GRPCClientTLS.CACert = cfg.Commons.GRPCClientTLS.CACert
+		}
+	}
+	if cfg.GRPC.TLS == nil {
+		cfg.GRPC.TLS = &shared.GRPCServiceTLS{}
+		if cfg.Commons != nil && cfg.Commons.GRPCServiceTLS != nil {
+			cfg.GRPC.TLS.Enabled = cfg.Commons.GRPCServiceTLS.Enabled
+			cfg.GRPC.TLS.Cert = cfg.Commons.GRPCServiceTLS.Cert
+			cfg.GRPC.TLS.Key = cfg.Commons.GRPCServiceTLS.Key
+		}
+	}
 }
 
 // Sanitize does nothing atm
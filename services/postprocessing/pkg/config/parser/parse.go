@@ -54,6 +54,28 @@ func Validate(cfg *config.Config) error {
 			cfg.Postprocessing.Steps = append(cfg.Postprocessing.Steps, string(events.PPStepDelay))
 		}
 	}
+
+	switch events.PostprocessingOutcome(cfg.Postprocessing.StepFailureOutcome) {
+	case events.PPOutcomeAbort, events.PPOutcomeDelete, events.PPOutcomeContinue:
+	default:
+		return fmt.Errorf("unknown postprocessing step failure outcome '%s'", cfg.Postprocessing.StepFailureOutcome)
+	}
+
+	if cfg.Postprocessing.StepRetries < 0 {
+		return fmt.Errorf("postprocessing step retries must not be negative")
+	}
+
+	for i, r := range cfg.Postprocessing.Rules {
+		switch r.SpaceType {
+		case "", "personal", "project":
+		default:
+			return fmt.Errorf("postprocessing rule %d: unknown space type '%s'", i, r.SpaceType)
+		}
+
+		if r.MaxSize != 0 && r.MaxSize < r.MinSize {
+			return fmt.Errorf("postprocessing rule %d: max_size must not be smaller than min_size", i)
+		}
+	}
 	return nil
 }
 
@@ -0,0 +1,9 @@
+package config
+
+// Store defines the available configuration for the store holding the state of running postprocessings
+type Store struct {
+	Type     string `yaml:"type" env:"POSTPROCESSING_STORE_TYPE" desc:"The type of the store holding the state of running postprocessings. Valid options are \"nats-js\", \"etcd\", \"memory\" and \"ocmem\". Only \"nats-js\" and \"etcd\" persist the state across restarts of the postprocessing service."`
+	Address  string `yaml:"address" env:"POSTPROCESSING_STORE_ADDRESS" desc:"A comma-separated list of addresses to connect to. Only valid if the above setting is set to \"nats-js\" or \"etcd\"."`
+	Database string `yaml:"database" env:"POSTPROCESSING_STORE_DATABASE" desc:"The database name the store should use. With \"nats-js\" it is the name of the bucket."`
+	Table    string `yaml:"table" env:"POSTPROCESSING_STORE_TABLE" desc:"The table name the store should use."`
+}
@@ -1,6 +1,7 @@
 package postprocessing
 
 import (
+	"fmt"
 	"time"
 
 	user "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
@@ -9,47 +10,64 @@ import (
 )
 
 // Postprocessing handles postprocessing of a file
+// NOTE: all fields are exported so the postprocessing can be persisted in a store
 type Postprocessing struct {
-	id         string
-	url        string
-	u          *user.User
-	m          map[events.Postprocessingstep]interface{}
-	filename   string
-	filesize   uint64
-	resourceID *provider.ResourceId
-	steps      []events.Postprocessingstep
-	delay      time.Duration
+	ID         string
+	URL        string
+	User       *user.User
+	Results    map[events.Postprocessingstep]interface{}
+	Filename   string
+	Filesize   uint64
+	ResourceID *provider.ResourceId
+	Steps      []events.Postprocessingstep
+	PPDelay    time.Duration
+
+	// CurrentStep is the step that was started last. It is empty if the postprocessing has not been initialized yet
+	CurrentStep events.Postprocessingstep
+	// StepStarted is the time the current step was started last
+	StepStarted time.Time
+	// Attempts is the number of times the current step was started again after timing out
+	Attempts int
+	// Finished is true as soon as the PostprocessingFinished event was sent
+	Finished bool
+	// Outcome is the outcome of a finished postprocessing
+	Outcome events.PostprocessingOutcome
 }
 
 // New returns a new postprocessing instance
 func New(uploadID string, uploadURL string, user *user.User, filename string, filesize uint64, resourceID *provider.ResourceId, steps []events.Postprocessingstep, delay time.Duration) *Postprocessing {
 	return &Postprocessing{
-		id:         uploadID,
-		url:        uploadURL,
-		u:          user,
-		m:          make(map[events.Postprocessingstep]interface{}),
-		filename:   filename,
-		filesize:   filesize,
-		resourceID: resourceID,
-		steps:      steps,
-		delay:      delay,
+		ID:         uploadID,
+		URL:        uploadURL,
+		User:       user,
+		Results:    make(map[events.Postprocessingstep]interface{}),
+		Filename:   filename,
+		Filesize:   filesize,
+		ResourceID: resourceID,
+		Steps:      steps,
+		PPDelay:    delay,
 	}
 }
 
 // Init is the first step of the postprocessing
 func (pp *Postprocessing) Init(ev events.BytesReceived) interface{} {
-	pp.m["init"] = ev
+	pp.Results["init"] = ev
 
-	if len(pp.steps) == 0 {
+	if len(pp.Steps) == 0 {
 		return pp.finished(events.PPOutcomeContinue)
 	}
 
-	return pp.nextStep(pp.steps[0])
+	return pp.nextStep(pp.Steps[0])
 }
 
 // NextStep returns the next postprocessing step
 func (pp *Postprocessing) NextStep(ev events.PostprocessingStepFinished) interface{} {
-	pp.m[ev.FinishedStep] = ev
+	if pp.Finished || ev.FinishedStep != pp.CurrentStep {
+		// the step was started again after a timeout and both runs finished - ignore the late one
+		return nil
+	}
+
+	pp.Results[ev.FinishedStep] = ev
 
 	switch ev.Outcome {
 	case events.PPOutcomeContinue:
@@ -62,39 +80,133 @@ func (pp *Postprocessing) NextStep(ev events.PostprocessingStepFinished) interfa
 
 // Delay will sleep the configured time then continue
 func (pp *Postprocessing) Delay(ev events.StartPostprocessingStep) interface{} {
-	pp.m[events.PPStepDelay] = ev
-	time.Sleep(pp.delay)
+	if pp.Finished || pp.CurrentStep != events.PPStepDelay {
+		return nil
+	}
+
+	pp.Results[events.PPStepDelay] = ev
+	time.Sleep(pp.PPDelay)
 	return pp.next(events.PPStepDelay)
 }
 
+// TimedOut returns true if the current step is running longer than the given timeout
+func (pp *Postprocessing) TimedOut(timeout time.Duration) bool {
+	if timeout <= 0 || pp.Finished || pp.CurrentStep == "" {
+		return false
+	}
+	return time.Since(pp.StepStarted) > timeout
+}
+
+// Timeout handles a timed out step. The step is started again as long as there are retries left.
+// Afterwards the step is finished with the given outcome and the reason is recorded in the results.
+func (pp *Postprocessing) Timeout(retries int, outcome events.PostprocessingOutcome) interface{} {
+	if pp.Attempts < retries {
+		attempts := pp.Attempts + 1
+		next := pp.nextStep(pp.CurrentStep)
+		pp.Attempts = attempts
+		return next
+	}
+
+	step := pp.CurrentStep
+	pp.Results[step] = pp.stepFinished(step, fmt.Sprintf("step '%s' did not finish after %d attempts", step, pp.Attempts+1), outcome)
+
+	if outcome == events.PPOutcomeContinue {
+		return pp.next(step)
+	}
+	return pp.finished(outcome)
+}
+
+// Resume returns the event that needs to be sent again to continue an interrupted postprocessing
+func (pp *Postprocessing) Resume() interface{} {
+	switch {
+	case pp.Finished:
+		return pp.finished(pp.Outcome)
+	case pp.CurrentStep == "":
+		if len(pp.Steps) == 0 {
+			return pp.finished(events.PPOutcomeContinue)
+		}
+		return pp.nextStep(pp.Steps[0])
+	default:
+		attempts := pp.Attempts
+		next := pp.nextStep(pp.CurrentStep)
+		pp.Attempts = attempts
+		return next
+	}
+}
+
+// Restart starts the current step again. Other than on Resume the attempts are reset.
+// A finished postprocessing sends its finished event again.
+func (pp *Postprocessing) Restart() interface{} {
+	if pp.Finished || pp.CurrentStep == "" {
+		return pp.Resume()
+	}
+	return pp.nextStep(pp.CurrentStep)
+}
+
+// Skip finishes the current step with the given reason and continues with the next step
+func (pp *Postprocessing) Skip(reason string) interface{} {
+	if pp.CurrentStep == "" {
+		return pp.Resume()
+	}
+
+	step := pp.CurrentStep
+	pp.Results[step] = pp.stepFinished(step, reason, events.PPOutcomeContinue)
+	return pp.next(step)
+}
+
+// Abort finishes the postprocessing with the given outcome and records the reason for the current step
+func (pp *Postprocessing) Abort(reason string, outcome events.PostprocessingOutcome) interface{} {
+	if pp.CurrentStep != "" {
+		pp.Results[pp.CurrentStep] = pp.stepFinished(pp.CurrentStep, reason, outcome)
+	}
+	return pp.finished(outcome)
+}
+
 func (pp *Postprocessing) next(current events.Postprocessingstep) interface{} {
-	l := len(pp.steps)
-	for i, s := range pp.steps {
+	l := len(pp.Steps)
+	for i, s := range pp.Steps {
 		if s == current && i+1 < l {
-			return pp.nextStep(pp.steps[i+1])
+			return pp.nextStep(pp.Steps[i+1])
 		}
 	}
 	return pp.finished(events.PPOutcomeContinue)
 }
 
 func (pp *Postprocessing) nextStep(next events.Postprocessingstep) events.StartPostprocessingStep {
+	pp.CurrentStep = next
+	pp.StepStarted = time.Now()
+	pp.Attempts = 0
 	return events.StartPostprocessingStep{
-		UploadID:      pp.id,
-		URL:           pp.url,
-		ExecutingUser: pp.u,
-		Filename:      pp.filename,
-		Filesize:      pp.filesize,
-		ResourceID:    pp.resourceID,
+		UploadID:      pp.ID,
+		URL:           pp.URL,
+		ExecutingUser: pp.User,
+		Filename:      pp.Filename,
+		Filesize:      pp.Filesize,
+		ResourceID:    pp.ResourceID,
 		StepToStart:   next,
 	}
 }
 
+// stepFinished returns the result of a step which was finished by the postprocessing service itself
+func (pp *Postprocessing) stepFinished(step events.Postprocessingstep, result string, outcome events.PostprocessingOutcome) events.PostprocessingStepFinished {
+	return events.PostprocessingStepFinished{
+		UploadID:      pp.ID,
+		ExecutingUser: pp.User,
+		Filename:      pp.Filename,
+		FinishedStep:  step,
+		Result:        result,
+		Outcome:       outcome,
+	}
+}
+
 func (pp *Postprocessing) finished(outcome events.PostprocessingOutcome) events.PostprocessingFinished {
+	pp.Finished = true
+	pp.Outcome = outcome
 	return events.PostprocessingFinished{
-		UploadID:      pp.id,
-		Result:        pp.m,
-		ExecutingUser: pp.u,
-		Filename:      pp.filename,
+		UploadID:      pp.ID,
+		Result:        pp.Results,
+		ExecutingUser: pp.User,
+		Filename:      pp.Filename,
 		Outcome:       outcome,
 	}
 }
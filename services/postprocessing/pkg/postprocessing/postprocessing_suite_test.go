@@ -0,0 +1,13 @@
+package postprocessing_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestPostprocessing(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Postprocessing Suite")
+}
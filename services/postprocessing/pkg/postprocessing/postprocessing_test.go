@@ -0,0 +1,121 @@
+package postprocessing_test
+
+import (
+	"time"
+
+	user "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/cs3org/reva/v2/pkg/events"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/owncloud/ocis/v2/services/postprocessing/pkg/postprocessing"
+)
+
+var _ = Describe("Postprocessing", func() {
+	var pp *postprocessing.Postprocessing
+
+	BeforeEach(func() {
+		pp = postprocessing.New("upload-1", "https://localhost/upload-1", &user.User{}, "foo.pdf", 42, nil, []events.Postprocessingstep{"virusscan", "policies"}, 0)
+	})
+
+	Describe("Resume", func() {
+		It("starts the first step of a postprocessing which was not initialized", func() {
+			ev := pp.Resume()
+			Expect(ev).To(BeAssignableToTypeOf(events.StartPostprocessingStep{}))
+			Expect(ev.(events.StartPostprocessingStep).StepToStart).To(Equal(events.Postprocessingstep("virusscan")))
+			Expect(pp.CurrentStep).To(Equal(events.Postprocessingstep("virusscan")))
+		})
+
+		It("finishes a postprocessing without steps", func() {
+			pp.Steps = nil
+			ev := pp.Resume()
+			Expect(ev).To(BeAssignableToTypeOf(events.PostprocessingFinished{}))
+			Expect(ev.(events.PostprocessingFinished).Outcome).To(Equal(events.PPOutcomeContinue))
+		})
+
+		It("starts the current step again and keeps its attempts", func() {
+			pp.Init(events.BytesReceived{})
+			pp.NextStep(events.PostprocessingStepFinished{FinishedStep: "virusscan", Outcome: events.PPOutcomeContinue})
+			pp.Attempts = 2
+			started := pp.StepStarted
+
+			time.Sleep(time.Millisecond)
+			ev := pp.Resume()
+			Expect(ev).To(BeAssignableToTypeOf(events.StartPostprocessingStep{}))
+			Expect(ev.(events.StartPostprocessingStep).StepToStart).To(Equal(events.Postprocessingstep("policies")))
+			Expect(pp.Attempts).To(Equal(2))
+			Expect(pp.StepStarted).To(BeTemporally(">", started))
+		})
+
+		It("sends the finished event of a finished postprocessing again", func() {
+			pp.Init(events.BytesReceived{})
+			pp.NextStep(events.PostprocessingStepFinished{FinishedStep: "virusscan", Outcome: events.PPOutcomeDelete})
+
+			ev := pp.Resume()
+			Expect(ev).To(BeAssignableToTypeOf(events.PostprocessingFinished{}))
+			Expect(ev.(events.PostprocessingFinished).Outcome).To(Equal(events.PPOutcomeDelete))
+			Expect(pp.Finished).To(BeTrue())
+		})
+	})
+
+	Describe("TimedOut", func() {
+		It("is false without a timeout, a current step or after finishing", func() {
+			Expect(pp.TimedOut(time.Nanosecond)).To(BeFalse())
+
+			pp.Init(events.BytesReceived{})
+			pp.StepStarted = time.Now().Add(-time.Hour)
+			Expect(pp.TimedOut(0)).To(BeFalse())
+			Expect(pp.TimedOut(2 * time.Hour)).To(BeFalse())
+			Expect(pp.TimedOut(time.Minute)).To(BeTrue())
+
+			pp.Abort("aborted", events.PPOutcomeAbort)
+			Expect(pp.TimedOut(time.Minute)).To(BeFalse())
+		})
+	})
+
+	Describe("Timeout", func() {
+		BeforeEach(func() {
+			pp.Init(events.BytesReceived{})
+		})
+
+		It("starts the step again as long as there are retries left", func() {
+			for attempt := 1; attempt <= 2; attempt++ {
+				ev := pp.Timeout(2, events.PPOutcomeAbort)
+				Expect(ev).To(BeAssignableToTypeOf(events.StartPostprocessingStep{}))
+				Expect(ev.(events.StartPostprocessingStep).StepToStart).To(Equal(events.Postprocessingstep("virusscan")))
+				Expect(pp.Attempts).To(Equal(attempt))
+				Expect(pp.Finished).To(BeFalse())
+			}
+		})
+
+		It("finishes with the failure outcome when the retries are used up", func() {
+			pp.Timeout(1, events.PPOutcomeAbort)
+
+			ev := pp.Timeout(1, events.PPOutcomeAbort)
+			Expect(ev).To(BeAssignableToTypeOf(events.PostprocessingFinished{}))
+			finished := ev.(events.PostprocessingFinished)
+			Expect(finished.Outcome).To(Equal(events.PPOutcomeAbort))
+			Expect(finished.Result).To(HaveKey(events.Postprocessingstep("virusscan")))
+
+			result := finished.Result["virusscan"].(events.PostprocessingStepFinished)
+			Expect(result.Outcome).To(Equal(events.PPOutcomeAbort))
+			Expect(result.Result).To(Equal("step 'virusscan' did not finish after 2 attempts"))
+			Expect(pp.Finished).To(BeTrue())
+		})
+
+		It("continues with the next step if the failure outcome is continue", func() {
+			ev := pp.Timeout(0, events.PPOutcomeContinue)
+			Expect(ev).To(BeAssignableToTypeOf(events.StartPostprocessingStep{}))
+			Expect(ev.(events.StartPostprocessingStep).StepToStart).To(Equal(events.Postprocessingstep("policies")))
+			Expect(pp.Attempts).To(Equal(0))
+			Expect(pp.Results).To(HaveKey(events.Postprocessingstep("virusscan")))
+		})
+
+		It("ignores a late result of a step which was started again", func() {
+			pp.Timeout(0, events.PPOutcomeContinue)
+
+			Expect(pp.NextStep(events.PostprocessingStepFinished{FinishedStep: "virusscan", Outcome: events.PPOutcomeDelete})).To(BeNil())
+			Expect(pp.Finished).To(BeFalse())
+			Expect(pp.CurrentStep).To(Equal(events.Postprocessingstep("policies")))
+		})
+	})
+})
@@ -0,0 +1,55 @@
+package grpc
+
+import (
+	"github.com/owncloud/ocis/v2/ocis-pkg/service/grpc"
+	"github.com/owncloud/ocis/v2/ocis-pkg/version"
+	ppsvc "github.com/owncloud/ocis/v2/protogen/gen/ocis/services/postprocessing/v0"
+	svc "github.com/owncloud/ocis/v2/services/postprocessing/pkg/service/grpc/v0"
+)
+
+// Server initializes a new go-micro service ready to run
+func Server(opts ...Option) (grpc.Service, error) {
+	options := newOptions(opts...)
+
+	service, err := grpc.NewService(
+		grpc.TLSEnabled(options.Config.GRPC.TLS.Enabled),
+		grpc.TLSCert(
+			options.Config.GRPC.TLS.Cert,
+			options.Config.GRPC.TLS.Key,
+		),
+		grpc.Name(options.Config.Service.Name),
+		grpc.Context(options.Context),
+		grpc.Address(options.Config.GRPC.Addr),
+		grpc.Namespace(options.Config.GRPC.Namespace),
+		grpc.Logger(options.Logger),
+		grpc.Version(version.GetString()),
+	)
+	if err != nil {
+		options.Logger.Fatal().Err(err).Msg("Error creating postprocessing service")
+		return grpc.Service{}, err
+	}
+
+	handle, err := svc.NewHandler(
+		svc.Config(options.Config),
+		svc.Logger(options.Logger),
+		svc.Postprocessing(options.Postprocessing),
+	)
+	if err != nil {
+		options.Logger.Error().
+			Err(err).
+			Msg("Error initializing postprocessing service")
+		return grpc.Service{}, err
+	}
+
+	if err := ppsvc.RegisterPostprocessingServiceHandler(
+		service.Server(),
+		handle,
+	); err != nil {
+		options.Logger.Error().
+			Err(err).
+			Msg("Error registering postprocessing handler")
+		return grpc.Service{}, err
+	}
+
+	return service, nil
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/cs3org/reva/v2/pkg/events"
+	"github.com/owncloud/ocis/v2/services/postprocessing/pkg/postprocessing"
+)
+
+var (
+	// ErrNotFound is returned when there is no running postprocessing for an upload
+	ErrNotFound = errors.New("postprocessing not found")
+	// ErrFinished is returned when a finished postprocessing should be changed
+	ErrFinished = errors.New("postprocessing already finished")
+)
+
+// command is executed by the event loop, so it can safely access the running postprocessings
+type command func(current map[string]*postprocessing.Postprocessing)
+
+// List returns a copy of all running postprocessings ordered by upload id
+func (pps *PostprocessingService) List(ctx context.Context) ([]*postprocessing.Postprocessing, error) {
+	var list []*postprocessing.Postprocessing
+	err := pps.exec(ctx, func(current map[string]*postprocessing.Postprocessing) error {
+		for _, pp := range current {
+			list = append(list, snapshot(pp))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list, nil
+}
+
+// Get returns a copy of the running postprocessing of an upload
+func (pps *PostprocessingService) Get(ctx context.Context, uploadID string) (*postprocessing.Postprocessing, error) {
+	var res *postprocessing.Postprocessing
+	err := pps.exec(ctx, func(current map[string]*postprocessing.Postprocessing) error {
+		pp, ok := current[uploadID]
+		if !ok {
+			return ErrNotFound
+		}
+		res = snapshot(pp)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Restart starts the current step of an upload again. If skip is true the current step is skipped instead.
+func (pps *PostprocessingService) Restart(ctx context.Context, uploadID string, skip bool) (*postprocessing.Postprocessing, error) {
+	return pps.control(ctx, uploadID, func(pp *postprocessing.Postprocessing) (interface{}, error) {
+		if !skip {
+			return pp.Restart(), nil
+		}
+		if pp.Finished {
+			return nil, ErrFinished
+		}
+		return pp.Skip("skipped by admin"), nil
+	})
+}
+
+// Abort finishes the postprocessing of an upload. If del is true the upload is deleted.
+func (pps *PostprocessingService) Abort(ctx context.Context, uploadID string, del bool) (*postprocessing.Postprocessing, error) {
+	return pps.control(ctx, uploadID, func(pp *postprocessing.Postprocessing) (interface{}, error) {
+		if pp.Finished {
+			return nil, ErrFinished
+		}
+
+		outcome := events.PPOutcomeAbort
+		if del {
+			outcome = events.PPOutcomeDelete
+		}
+		return pp.Abort("aborted by admin", outcome), nil
+	})
+}
+
+// control changes a running postprocessing and publishes the resulting event
+func (pps *PostprocessingService) control(ctx context.Context, uploadID string, f func(pp *postprocessing.Postprocessing) (interface{}, error)) (*postprocessing.Postprocessing, error) {
+	var res *postprocessing.Postprocessing
+	err := pps.exec(ctx, func(current map[string]*postprocessing.Postprocessing) error {
+		pp, ok := current[uploadID]
+		if !ok {
+			return ErrNotFound
+		}
+
+		next, err := f(pp)
+		if err != nil {
+			return err
+		}
+
+		pps.log.Info().Str("uploadID", pp.ID).Str("step", string(pp.CurrentStep)).Bool("finished", pp.Finished).Msg("postprocessing changed by admin")
+		if err := pps.publish(pp, next); err != nil {
+			return err
+		}
+		res = snapshot(pp)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// exec runs f in the event loop and waits for its result.
+// Values set by f must only be used if exec returns without an error, f might still be running otherwise.
+func (pps *PostprocessingService) exec(ctx context.Context, f func(current map[string]*postprocessing.Postprocessing) error) error {
+	done := make(chan error, 1)
+	cmd := func(current map[string]*postprocessing.Postprocessing) {
+		done <- f(current)
+	}
+
+	select {
+	case pps.cmds <- cmd:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// snapshot copies a postprocessing so it can be used outside of the event loop
+func snapshot(pp *postprocessing.Postprocessing) *postprocessing.Postprocessing {
+	c := *pp
+	c.Steps = append([]events.Postprocessingstep(nil), pp.Steps...)
+	c.Results = make(map[events.Postprocessingstep]interface{}, len(pp.Results))
+	for k, v := range pp.Results {
+		c.Results[k] = v
+	}
+	return &c
+}
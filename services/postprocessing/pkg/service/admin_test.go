@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/v2/pkg/events"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/owncloud/ocis/v2/services/postprocessing/pkg/config"
+	"go-micro.dev/v4/store"
+)
+
+var _ = Describe("Admin", func() {
+	var (
+		ctx  context.Context
+		pps  *PostprocessingService
+		evs  chan interface{}
+		pub  *publisher
+		stop func()
+	)
+
+	upload := func(id string) {
+		n := len(pub.published())
+		evs <- events.BytesReceived{UploadID: id, Filename: id + ".txt", ResourceID: &provider.ResourceId{SpaceId: "space"}}
+		Eventually(pub.published).Should(HaveLen(n + 1))
+	}
+	last := func() interface{} {
+		p := pub.published()
+		return p[len(p)-1]
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		pps, evs, pub = newTestService(config.Postprocessing{Steps: []string{"virusscan", "policies"}}, store.NewMemoryStore())
+		stop = run(pps)
+	})
+
+	AfterEach(func() {
+		stop()
+	})
+
+	Describe("List", func() {
+		It("returns the running postprocessings ordered by upload id", func() {
+			list, err := pps.List(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(list).To(BeEmpty())
+
+			upload("upload-2")
+			upload("upload-1")
+
+			list, err = pps.List(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(list).To(HaveLen(2))
+			Expect(list[0].ID).To(Equal("upload-1"))
+			Expect(list[1].ID).To(Equal("upload-2"))
+			Expect(list[0].CurrentStep).To(Equal(events.Postprocessingstep("virusscan")))
+		})
+
+		It("returns copies which can't change the running postprocessings", func() {
+			upload("upload-1")
+
+			list, err := pps.List(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			list[0].Steps[0] = "changed"
+			list[0].Finished = true
+
+			pp, err := pps.Get(ctx, "upload-1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pp.Steps[0]).To(Equal(events.Postprocessingstep("virusscan")))
+			Expect(pp.Finished).To(BeFalse())
+		})
+	})
+
+	Describe("Get", func() {
+		It("returns the postprocessing of an upload", func() {
+			upload("upload-1")
+
+			pp, err := pps.Get(ctx, "upload-1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pp.Filename).To(Equal("upload-1.txt"))
+		})
+
+		It("returns ErrNotFound for unknown uploads", func() {
+			_, err := pps.Get(ctx, "unknown")
+			Expect(err).To(MatchError(ErrNotFound))
+		})
+
+		It("returns the error of the context when the event loop doesn't answer", func() {
+			stop()
+			stop = func() {}
+
+			cctx, cancel := context.WithCancel(ctx)
+			cancel()
+			_, err := pps.Get(cctx, "upload-1")
+			Expect(err).To(MatchError(context.Canceled))
+		})
+	})
+
+	Describe("Restart", func() {
+		BeforeEach(func() {
+			upload("upload-1")
+		})
+
+		It("starts the current step again and resets its attempts", func() {
+			evs <- events.PostprocessingStepFinished{UploadID: "upload-1", FinishedStep: "virusscan", Outcome: events.PPOutcomeContinue}
+			Eventually(pub.published).Should(HaveLen(2))
+
+			pp, err := pps.Restart(ctx, "upload-1", false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pp.CurrentStep).To(Equal(events.Postprocessingstep("policies")))
+			Expect(pp.Attempts).To(Equal(0))
+
+			Expect(pub.published()).To(HaveLen(3))
+			Expect(last()).To(BeAssignableToTypeOf(events.StartPostprocessingStep{}))
+			Expect(last().(events.StartPostprocessingStep).StepToStart).To(Equal(events.Postprocessingstep("policies")))
+		})
+
+		It("skips the current step", func() {
+			pp, err := pps.Restart(ctx, "upload-1", true)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pp.CurrentStep).To(Equal(events.Postprocessingstep("policies")))
+			Expect(pp.Results).To(HaveKey(events.Postprocessingstep("virusscan")))
+			Expect(pp.Results["virusscan"].(events.PostprocessingStepFinished).Outcome).To(Equal(events.PPOutcomeContinue))
+			Expect(last().(events.StartPostprocessingStep).StepToStart).To(Equal(events.Postprocessingstep("policies")))
+
+			pp, err = pps.Restart(ctx, "upload-1", true)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pp.Finished).To(BeTrue())
+			Expect(pp.Outcome).To(Equal(events.PPOutcomeContinue))
+			Expect(last().(events.PostprocessingFinished).Outcome).To(Equal(events.PPOutcomeContinue))
+		})
+
+		It("sends the finished event of a finished postprocessing again", func() {
+			_, err := pps.Abort(ctx, "upload-1", true)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pub.published()).To(HaveLen(2))
+
+			pp, err := pps.Restart(ctx, "upload-1", false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pp.Finished).To(BeTrue())
+			Expect(pub.published()).To(HaveLen(3))
+			Expect(last().(events.PostprocessingFinished).Outcome).To(Equal(events.PPOutcomeDelete))
+		})
+
+		It("doesn't skip steps of a finished postprocessing", func() {
+			_, err := pps.Abort(ctx, "upload-1", false)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = pps.Restart(ctx, "upload-1", true)
+			Expect(err).To(MatchError(ErrFinished))
+			Expect(pub.published()).To(HaveLen(2))
+		})
+
+		It("stores the changed postprocessing", func() {
+			_, err := pps.Restart(ctx, "upload-1", true)
+			Expect(err).ToNot(HaveOccurred())
+
+			loaded, err := pps.load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(loaded["upload-1"].CurrentStep).To(Equal(events.Postprocessingstep("policies")))
+		})
+
+		It("returns ErrNotFound for unknown uploads", func() {
+			_, err := pps.Restart(ctx, "unknown", false)
+			Expect(err).To(MatchError(ErrNotFound))
+			Expect(pub.published()).To(HaveLen(1))
+		})
+	})
+
+	Describe("Abort", func() {
+		BeforeEach(func() {
+			upload("upload-1")
+		})
+
+		It("finishes the postprocessing with the abort outcome", func() {
+			pp, err := pps.Abort(ctx, "upload-1", false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pp.Finished).To(BeTrue())
+			Expect(pp.Outcome).To(Equal(events.PPOutcomeAbort))
+
+			finished := last().(events.PostprocessingFinished)
+			Expect(finished.UploadID).To(Equal("upload-1"))
+			Expect(finished.Outcome).To(Equal(events.PPOutcomeAbort))
+			Expect(finished.Result).To(HaveKey(events.Postprocessingstep("virusscan")))
+			Expect(finished.Result["virusscan"].(events.PostprocessingStepFinished).Result).To(Equal("aborted by admin"))
+		})
+
+		It("finishes the postprocessing with the delete outcome", func() {
+			pp, err := pps.Abort(ctx, "upload-1", true)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pp.Outcome).To(Equal(events.PPOutcomeDelete))
+			Expect(last().(events.PostprocessingFinished).Outcome).To(Equal(events.PPOutcomeDelete))
+		})
+
+		It("keeps the postprocessing until the upload is ready", func() {
+			_, err := pps.Abort(ctx, "upload-1", false)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = pps.Get(ctx, "upload-1")
+			Expect(err).ToNot(HaveOccurred())
+
+			evs <- events.UploadReady{UploadID: "upload-1"}
+			Eventually(func() error {
+				_, err := pps.Get(ctx, "upload-1")
+				return err
+			}).Should(MatchError(ErrNotFound))
+		})
+
+		It("doesn't abort a finished postprocessing again", func() {
+			_, err := pps.Abort(ctx, "upload-1", false)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = pps.Abort(ctx, "upload-1", true)
+			Expect(err).To(MatchError(ErrFinished))
+			Expect(pub.published()).To(HaveLen(2))
+			Expect(last().(events.PostprocessingFinished).Outcome).To(Equal(events.PPOutcomeAbort))
+		})
+
+		It("returns ErrNotFound for unknown uploads", func() {
+			_, err := pps.Abort(ctx, "unknown", false)
+			Expect(err).To(MatchError(ErrNotFound))
+		})
+	})
+})
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/cs3org/reva/v2/pkg/events"
+	"github.com/owncloud/ocis/v2/ocis-pkg/log"
+	ppmsg "github.com/owncloud/ocis/v2/protogen/gen/ocis/messages/postprocessing/v0"
+	ppsvc "github.com/owncloud/ocis/v2/protogen/gen/ocis/services/postprocessing/v0"
+	"github.com/owncloud/ocis/v2/services/postprocessing/pkg/postprocessing"
+	"github.com/owncloud/ocis/v2/services/postprocessing/pkg/service"
+	merrors "go-micro.dev/v4/errors"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// NewHandler returns a service implementation for Service.
+func NewHandler(opts ...Option) (ppsvc.PostprocessingServiceHandler, error) {
+	options := newOptions(opts...)
+	cfg := options.Config
+
+	if options.Postprocessing == nil {
+		return nil, errors.New("missing postprocessing service")
+	}
+
+	return &Service{
+		id:  cfg.GRPC.Namespace + "." + cfg.Service.Name,
+		log: options.Logger,
+		pps: options.Postprocessing,
+	}, nil
+}
+
+// Service implements the PostprocessingServiceHandler interface
+type Service struct {
+	id  string
+	log log.Logger
+	pps *service.PostprocessingService
+}
+
+// List returns all running postprocessings
+func (s Service) List(ctx context.Context, _ *ppsvc.ListRequest, out *ppsvc.ListResponse) error {
+	list, err := s.pps.List(ctx)
+	if err != nil {
+		return s.error(err)
+	}
+
+	out.Postprocessings = make([]*ppmsg.Postprocessing, 0, len(list))
+	for _, pp := range list {
+		out.Postprocessings = append(out.Postprocessings, toMessage(pp))
+	}
+	return nil
+}
+
+// Get returns the running postprocessing of an upload
+func (s Service) Get(ctx context.Context, in *ppsvc.GetRequest, out *ppsvc.GetResponse) error {
+	pp, err := s.pps.Get(ctx, in.UploadId)
+	if err != nil {
+		return s.error(err)
+	}
+
+	out.Postprocessing = toMessage(pp)
+	return nil
+}
+
+// Restart starts the current step of an upload again or skips it
+func (s Service) Restart(ctx context.Context, in *ppsvc.RestartRequest, out *ppsvc.RestartResponse) error {
+	pp, err := s.pps.Restart(ctx, in.UploadId, in.Skip)
+	if err != nil {
+		return s.error(err)
+	}
+
+	out.Postprocessing = toMessage(pp)
+	return nil
+}
+
+// Abort finishes the postprocessing of an upload
+func (s Service) Abort(ctx context.Context, in *ppsvc.AbortRequest, out *ppsvc.AbortResponse) error {
+	pp, err := s.pps.Abort(ctx, in.UploadId, in.Delete)
+	if err != nil {
+		return s.error(err)
+	}
+
+	out.Postprocessing = toMessage(pp)
+	return nil
+}
+
+func (s Service) error(err error) error {
+	switch {
+	case errors.Is(err, service.ErrNotFound):
+		return merrors.NotFound(s.id, err.Error())
+	case errors.Is(err, service.ErrFinished):
+		return merrors.BadRequest(s.id, err.Error())
+	default:
+		s.log.Error().Err(err).Msg("postprocessing request failed")
+		return merrors.InternalServerError(s.id, err.Error())
+	}
+}
+
+func toMessage(pp *postprocessing.Postprocessing) *ppmsg.Postprocessing {
+	m := &ppmsg.Postprocessing{
+		UploadId:    pp.ID,
+		Filename:    pp.Filename,
+		Filesize:    pp.Filesize,
+		UserId:      pp.User.GetId().GetOpaqueId(),
+		CurrentStep: string(pp.CurrentStep),
+		Attempts:    int32(pp.Attempts),
+		Finished:    pp.Finished,
+		Outcome:     string(pp.Outcome),
+	}
+
+	if pp.ResourceID != nil {
+		m.ResourceId = &ppmsg.ResourceID{
+			StorageId: pp.ResourceID.GetStorageId(),
+			OpaqueId:  pp.ResourceID.GetOpaqueId(),
+			SpaceId:   pp.ResourceID.GetSpaceId(),
+		}
+	}
+
+	if !pp.StepStarted.IsZero() {
+		m.StepStarted = timestamppb.New(pp.StepStarted)
+	}
+
+	for _, step := range pp.Steps {
+		m.Steps = append(m.Steps, string(step))
+		if r, ok := pp.Results[step]; ok {
+			m.Results = append(m.Results, toStepResult(step, r))
+		}
+	}
+	return m
+}
+
+func toStepResult(step events.Postprocessingstep, r interface{}) *ppmsg.StepResult {
+	res := &ppmsg.StepResult{Step: string(step)}
+
+	switch v := r.(type) {
+	case events.PostprocessingStepFinished:
+		res.Outcome = string(v.Outcome)
+		if v.Result != nil {
+			res.Result = fmt.Sprint(v.Result)
+		}
+	case map[string]interface{}:
+		// results restored from the store are plain json objects
+		res.Outcome, _ = v["Outcome"].(string)
+		if v["Result"] != nil {
+			res.Result = fmt.Sprint(v["Result"])
+		}
+	}
+	return res
+}
@@ -0,0 +1,196 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	user "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/v2/pkg/events"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/owncloud/ocis/v2/ocis-pkg/log"
+	ppsvc "github.com/owncloud/ocis/v2/protogen/gen/ocis/services/postprocessing/v0"
+	"github.com/owncloud/ocis/v2/services/postprocessing/pkg/config"
+	"github.com/owncloud/ocis/v2/services/postprocessing/pkg/config/defaults"
+	"github.com/owncloud/ocis/v2/services/postprocessing/pkg/postprocessing"
+	"github.com/owncloud/ocis/v2/services/postprocessing/pkg/service"
+	svc "github.com/owncloud/ocis/v2/services/postprocessing/pkg/service/grpc/v0"
+	merrors "go-micro.dev/v4/errors"
+	mevents "go-micro.dev/v4/events"
+	"go-micro.dev/v4/store"
+)
+
+// loopback is a stream which delivers the published events to its consumer
+type loopback chan mevents.Event
+
+func (l loopback) Publish(_ string, ev interface{}, opts ...mevents.PublishOption) error {
+	o := mevents.PublishOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	b, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	l <- mevents.Event{Payload: b, Metadata: o.Metadata}
+	return nil
+}
+
+func (l loopback) Consume(string, ...mevents.ConsumeOption) (<-chan mevents.Event, error) {
+	return l, nil
+}
+
+var _ = Describe("Service", func() {
+	var (
+		ctx     context.Context
+		stream  loopback
+		st      store.Store
+		sc      config.Store
+		handler ppsvc.PostprocessingServiceHandler
+		stop    func()
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		stream = make(loopback, 100)
+		st = store.NewMemoryStore()
+		sc = config.Store{Database: "services", Table: "services/postprocessing/"}
+	})
+
+	start := func() {
+		cfg := defaults.DefaultConfig()
+		cfg.Postprocessing.Steps = []string{"virusscan", "policies"}
+
+		pps, err := service.NewPostprocessingService(stream, log.NewLogger(), st, cfg.Postprocessing, sc)
+		Expect(err).ToNot(HaveOccurred())
+
+		handler, err = svc.NewHandler(svc.Config(cfg), svc.Logger(log.NewLogger()), svc.Postprocessing(pps))
+		Expect(err).ToNot(HaveOccurred())
+
+		done := make(chan error, 1)
+		go func() { done <- pps.Run() }()
+		stop = func() {
+			pps.Close()
+			Eventually(done).Should(Receive(BeNil()))
+		}
+	}
+	upload := func(id string) {
+		Expect(events.Publish(stream, events.BytesReceived{
+			UploadID:      id,
+			Filename:      id + ".txt",
+			Filesize:      42,
+			ExecutingUser: &user.User{Id: &user.UserId{OpaqueId: "einstein"}},
+			ResourceID:    &provider.ResourceId{StorageId: "storage", SpaceId: "space", OpaqueId: id},
+		})).To(Succeed())
+		Eventually(func() error {
+			return handler.Get(ctx, &ppsvc.GetRequest{UploadId: id}, &ppsvc.GetResponse{})
+		}).Should(Succeed())
+	}
+	code := func(err error) int32 {
+		return merrors.FromError(err).Code
+	}
+
+	It("needs a postprocessing service", func() {
+		_, err := svc.NewHandler(svc.Config(defaults.DefaultConfig()))
+		Expect(err).To(HaveOccurred())
+	})
+
+	Context("with a running postprocessing service", func() {
+		BeforeEach(func() {
+			start()
+		})
+
+		AfterEach(func() {
+			stop()
+		})
+
+		It("lists the running postprocessings", func() {
+			upload("upload-2")
+			upload("upload-1")
+
+			out := &ppsvc.ListResponse{}
+			Expect(handler.List(ctx, &ppsvc.ListRequest{}, out)).To(Succeed())
+			Expect(out.Postprocessings).To(HaveLen(2))
+
+			pp := out.Postprocessings[0]
+			Expect(pp.UploadId).To(Equal("upload-1"))
+			Expect(pp.Filename).To(Equal("upload-1.txt"))
+			Expect(pp.Filesize).To(Equal(uint64(42)))
+			Expect(pp.UserId).To(Equal("einstein"))
+			Expect(pp.ResourceId.StorageId).To(Equal("storage"))
+			Expect(pp.ResourceId.SpaceId).To(Equal("space"))
+			Expect(pp.ResourceId.OpaqueId).To(Equal("upload-1"))
+			Expect(pp.Steps).To(Equal([]string{"virusscan", "policies"}))
+			Expect(pp.CurrentStep).To(Equal("virusscan"))
+			Expect(pp.StepStarted).ToNot(BeNil())
+			Expect(pp.Finished).To(BeFalse())
+		})
+
+		It("returns not found for unknown uploads", func() {
+			err := handler.Get(ctx, &ppsvc.GetRequest{UploadId: "unknown"}, &ppsvc.GetResponse{})
+			Expect(code(err)).To(Equal(int32(http.StatusNotFound)))
+
+			err = handler.Restart(ctx, &ppsvc.RestartRequest{UploadId: "unknown"}, &ppsvc.RestartResponse{})
+			Expect(code(err)).To(Equal(int32(http.StatusNotFound)))
+
+			err = handler.Abort(ctx, &ppsvc.AbortRequest{UploadId: "unknown"}, &ppsvc.AbortResponse{})
+			Expect(code(err)).To(Equal(int32(http.StatusNotFound)))
+		})
+
+		It("restarts and skips steps", func() {
+			upload("upload-1")
+
+			out := &ppsvc.RestartResponse{}
+			Expect(handler.Restart(ctx, &ppsvc.RestartRequest{UploadId: "upload-1"}, out)).To(Succeed())
+			Expect(out.Postprocessing.CurrentStep).To(Equal("virusscan"))
+			Expect(out.Postprocessing.Attempts).To(Equal(int32(0)))
+
+			out = &ppsvc.RestartResponse{}
+			Expect(handler.Restart(ctx, &ppsvc.RestartRequest{UploadId: "upload-1", Skip: true}, out)).To(Succeed())
+			Expect(out.Postprocessing.CurrentStep).To(Equal("policies"))
+			Expect(out.Postprocessing.Results).To(HaveLen(1))
+			Expect(out.Postprocessing.Results[0].Step).To(Equal("virusscan"))
+			Expect(out.Postprocessing.Results[0].Outcome).To(Equal(string(events.PPOutcomeContinue)))
+			Expect(out.Postprocessing.Results[0].Result).To(Equal("skipped by admin"))
+		})
+
+		It("aborts postprocessings", func() {
+			upload("upload-1")
+
+			out := &ppsvc.AbortResponse{}
+			Expect(handler.Abort(ctx, &ppsvc.AbortRequest{UploadId: "upload-1", Delete: true}, out)).To(Succeed())
+			Expect(out.Postprocessing.Finished).To(BeTrue())
+			Expect(out.Postprocessing.Outcome).To(Equal(string(events.PPOutcomeDelete)))
+
+			err := handler.Abort(ctx, &ppsvc.AbortRequest{UploadId: "upload-1"}, &ppsvc.AbortResponse{})
+			Expect(code(err)).To(Equal(int32(http.StatusBadRequest)))
+
+			err = handler.Restart(ctx, &ppsvc.RestartRequest{UploadId: "upload-1", Skip: true}, &ppsvc.RestartResponse{})
+			Expect(code(err)).To(Equal(int32(http.StatusBadRequest)))
+		})
+	})
+
+	It("shows the results of postprocessings restored from the store", func() {
+		pp := postprocessing.New("upload-1", "", &user.User{}, "foo.pdf", 42, nil, []events.Postprocessingstep{"virusscan", "policies"}, 0)
+		pp.Init(events.BytesReceived{UploadID: pp.ID})
+		pp.NextStep(events.PostprocessingStepFinished{UploadID: pp.ID, FinishedStep: "virusscan", Outcome: events.PPOutcomeContinue, Result: "clean"})
+		b, err := json.Marshal(pp)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(st.Write(&store.Record{Key: pp.ID, Value: b}, store.WriteTo(sc.Database, sc.Table))).To(Succeed())
+
+		start()
+		defer stop()
+
+		out := &ppsvc.GetResponse{}
+		Expect(handler.Get(ctx, &ppsvc.GetRequest{UploadId: "upload-1"}, out)).To(Succeed())
+		Expect(out.Postprocessing.CurrentStep).To(Equal("policies"))
+		Expect(out.Postprocessing.ResourceId).To(BeNil())
+		Expect(out.Postprocessing.Results).To(HaveLen(1))
+		Expect(out.Postprocessing.Results[0].Step).To(Equal("virusscan"))
+		Expect(out.Postprocessing.Results[0].Outcome).To(Equal(string(events.PPOutcomeContinue)))
+		Expect(out.Postprocessing.Results[0].Result).To(Equal("clean"))
+	})
+})
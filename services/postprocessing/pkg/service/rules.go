@@ -0,0 +1,123 @@
+package service
+
+import (
+	"mime"
+	"path/filepath"
+	"strings"
+
+	"github.com/cs3org/reva/v2/pkg/events"
+	"github.com/owncloud/ocis/v2/services/postprocessing/pkg/config"
+)
+
+const (
+	_spaceTypePersonal = "personal"
+	_spaceTypeProject  = "project"
+)
+
+// rule is a config.Rule prepared for matching
+type rule struct {
+	spaceType string
+	spaceIDs  map[string]struct{}
+	mimeTypes []string
+	minSize   uint64
+	maxSize   uint64
+	steps     []events.Postprocessingstep
+}
+
+func getRules(c config.Postprocessing) []rule {
+	rules := make([]rule, 0, len(c.Rules))
+	for _, r := range c.Rules {
+		ids := make(map[string]struct{}, len(r.SpaceIDs))
+		for _, id := range r.SpaceIDs {
+			ids[id] = struct{}{}
+		}
+
+		// an empty list of steps is valid and means that no postprocessing steps are executed
+		steps := make([]events.Postprocessingstep, 0, len(r.Steps))
+		for _, s := range r.Steps {
+			steps = append(steps, events.Postprocessingstep(s))
+		}
+
+		rules = append(rules, rule{
+			spaceType: r.SpaceType,
+			spaceIDs:  ids,
+			mimeTypes: r.MimeTypes,
+			minSize:   r.MinSize,
+			maxSize:   r.MaxSize,
+			steps:     steps,
+		})
+	}
+	return rules
+}
+
+// stepsFor returns the steps of the first rule matching the upload or the default steps if no rule matches
+func (pps *PostprocessingService) stepsFor(ev events.BytesReceived) []events.Postprocessingstep {
+	for _, r := range pps.rules {
+		if r.matches(ev) {
+			return r.steps
+		}
+	}
+	return pps.steps
+}
+
+func (r rule) matches(ev events.BytesReceived) bool {
+	spaceID := ev.ResourceID.GetSpaceId()
+
+	if r.spaceType != "" && r.spaceType != spaceType(ev) {
+		return false
+	}
+
+	if len(r.spaceIDs) > 0 {
+		if _, ok := r.spaceIDs[spaceID]; !ok {
+			return false
+		}
+	}
+
+	if len(r.mimeTypes) > 0 && !matchesMimeType(r.mimeTypes, mimeType(ev.Filename)) {
+		return false
+	}
+
+	if ev.Filesize < r.minSize {
+		return false
+	}
+
+	if r.maxSize != 0 && ev.Filesize > r.maxSize {
+		return false
+	}
+
+	return true
+}
+
+// spaceType guesses the type of the space the upload belongs to.
+// Personal spaces use the id of their owner as space id, so every other space is considered a project space.
+func spaceType(ev events.BytesReceived) string {
+	if ev.SpaceOwner != nil && ev.SpaceOwner.GetOpaqueId() == ev.ResourceID.GetSpaceId() {
+		return _spaceTypePersonal
+	}
+	return _spaceTypeProject
+}
+
+func mimeType(filename string) string {
+	mt := mime.TypeByExtension(filepath.Ext(filename))
+	if mt == "" {
+		return "application/octet-stream"
+	}
+
+	// strip parameters like "; charset=utf-8"
+	if i := strings.Index(mt, ";"); i >= 0 {
+		mt = mt[:i]
+	}
+	return strings.TrimSpace(mt)
+}
+
+func matchesMimeType(patterns []string, mt string) bool {
+	for _, p := range patterns {
+		switch {
+		case p == mt:
+			return true
+		case strings.HasSuffix(p, "/*") && strings.HasPrefix(mt, strings.TrimSuffix(p, "*")):
+			return true
+		}
+	}
+	return false
+}
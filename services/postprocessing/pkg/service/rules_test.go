@@ -0,0 +1,100 @@
+package service
+
+import (
+	user "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/v2/pkg/events"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/owncloud/ocis/v2/services/postprocessing/pkg/config"
+	"go-micro.dev/v4/store"
+)
+
+var _ = Describe("Rules", func() {
+	upload := func(spaceID, filename string, size uint64) events.BytesReceived {
+		return events.BytesReceived{
+			UploadID:   "upload",
+			Filename:   filename,
+			Filesize:   size,
+			ResourceID: &provider.ResourceId{SpaceId: spaceID},
+			SpaceOwner: &user.UserId{OpaqueId: "einstein"},
+		}
+	}
+	stepsFor := func(c config.Postprocessing, ev events.BytesReceived) []events.Postprocessingstep {
+		pps, _, _ := newTestService(c, store.NewMemoryStore())
+		return pps.stepsFor(ev)
+	}
+	steps := func(s ...string) []events.Postprocessingstep {
+		res := make([]events.Postprocessingstep, 0, len(s))
+		for _, step := range s {
+			res = append(res, events.Postprocessingstep(step))
+		}
+		return res
+	}
+
+	It("uses the default steps if no rule matches", func() {
+		c := config.Postprocessing{
+			Steps: []string{"virusscan"},
+			Rules: []config.Rule{{SpaceType: "project", Steps: []string{"policies"}}},
+		}
+		Expect(stepsFor(c, upload("einstein", "foo.pdf", 1))).To(Equal(steps("virusscan")))
+	})
+
+	It("uses the default steps without rules", func() {
+		c := config.Postprocessing{Steps: []string{"virusscan", "delay"}}
+		Expect(stepsFor(c, upload("einstein", "foo.pdf", 1))).To(Equal(steps("virusscan", "delay")))
+	})
+
+	It("uses the steps of the first matching rule", func() {
+		c := config.Postprocessing{
+			Steps: []string{"virusscan"},
+			Rules: []config.Rule{
+				{SpaceType: "personal", MaxSize: 10, Steps: []string{"policies"}},
+				{SpaceType: "personal", Steps: []string{"virusscan", "policies"}},
+				{Steps: []string{"delay"}},
+			},
+		}
+		Expect(stepsFor(c, upload("einstein", "foo.pdf", 5))).To(Equal(steps("policies")))
+		Expect(stepsFor(c, upload("einstein", "foo.pdf", 50))).To(Equal(steps("virusscan", "policies")))
+		Expect(stepsFor(c, upload("project", "foo.pdf", 5))).To(Equal(steps("delay")))
+	})
+
+	It("skips all steps for rules without steps", func() {
+		c := config.Postprocessing{
+			Steps: []string{"virusscan"},
+			Rules: []config.Rule{{SpaceIDs: []string{"trusted"}}},
+		}
+		Expect(stepsFor(c, upload("trusted", "foo.pdf", 1))).To(BeEmpty())
+		Expect(stepsFor(c, upload("other", "foo.pdf", 1))).To(Equal(steps("virusscan")))
+	})
+
+	It("derives the mime type from the file extension", func() {
+		c := config.Postprocessing{
+			Steps: []string{"virusscan"},
+			Rules: []config.Rule{
+				{MimeTypes: []string{"image/*"}, Steps: []string{"thumbnails"}},
+				{MimeTypes: []string{"application/pdf"}, Steps: []string{"policies"}},
+				{MimeTypes: []string{"application/octet-stream"}, Steps: []string{"delay"}},
+			},
+		}
+		Expect(stepsFor(c, upload("einstein", "photo.PNG", 1))).To(Equal(steps("thumbnails")))
+		Expect(stepsFor(c, upload("einstein", "foo.pdf", 1))).To(Equal(steps("policies")))
+		Expect(stepsFor(c, upload("einstein", "unknown.extension-xyz", 1))).To(Equal(steps("delay")))
+		Expect(stepsFor(c, upload("einstein", "noextension", 1))).To(Equal(steps("delay")))
+	})
+
+	It("strips the parameters of the mime type", func() {
+		Expect(mimeType("foo.txt")).To(Equal("text/plain"))
+	})
+
+	It("matches the size limits inclusively", func() {
+		c := config.Postprocessing{
+			Steps: []string{"virusscan"},
+			Rules: []config.Rule{{MinSize: 10, MaxSize: 20, Steps: []string{"delay"}}},
+		}
+		Expect(stepsFor(c, upload("einstein", "foo", 9))).To(Equal(steps("virusscan")))
+		Expect(stepsFor(c, upload("einstein", "foo", 10))).To(Equal(steps("delay")))
+		Expect(stepsFor(c, upload("einstein", "foo", 20))).To(Equal(steps("delay")))
+		Expect(stepsFor(c, upload("einstein", "foo", 21))).To(Equal(steps("virusscan")))
+	})
+})
@@ -1,10 +1,15 @@
 package service
 
 import (
+	"encoding/json"
+	"sync"
+	"time"
+
 	"github.com/cs3org/reva/v2/pkg/events"
 	"github.com/owncloud/ocis/v2/ocis-pkg/log"
 	"github.com/owncloud/ocis/v2/services/postprocessing/pkg/config"
 	"github.com/owncloud/ocis/v2/services/postprocessing/pkg/postprocessing"
+	"go-micro.dev/v4/store"
 )
 
 // PostprocessingService is an instance of the service handling postprocessing of files
@@ -13,14 +18,21 @@ type PostprocessingService struct {
 	events <-chan interface{}
 	pub    events.Publisher
 	steps  []events.Postprocessingstep
+	rules  []rule
+	store  store.Store
 	c      config.Postprocessing
+	sc     config.Store
+	cmds   chan command
+	quit   chan struct{}
+	once   sync.Once
 }
 
 // NewPostprocessingService returns a new instance of a postprocessing service
-func NewPostprocessingService(stream events.Stream, logger log.Logger, c config.Postprocessing) (*PostprocessingService, error) {
+func NewPostprocessingService(stream events.Stream, logger log.Logger, st store.Store, c config.Postprocessing, sc config.Store) (*PostprocessingService, error) {
 	evs, err := events.Consume(stream, "postprocessing",
 		events.BytesReceived{},
 		events.StartPostprocessingStep{},
+		events.PostprocessingStepFinished{},
 		events.VirusscanFinished{},
 		events.UploadReady{},
 	)
@@ -33,22 +45,70 @@ func NewPostprocessingService(stream events.Stream, logger log.Logger, c config.
 		events: evs,
 		pub:    stream,
 		steps:  getSteps(c),
+		rules:  getRules(c),
+		store:  st,
 		c:      c,
+		sc:     sc,
+		cmds:   make(chan command),
+		quit:   make(chan struct{}),
 	}, nil
 }
 
 // Run to fulfil Runner interface
 func (pps *PostprocessingService) Run() error {
-	current := make(map[string]*postprocessing.Postprocessing)
-	for e := range pps.events {
-		var next interface{}
+	current, err := pps.load()
+	if err != nil {
+		pps.log.Error().Err(err).Msg("unable to load running postprocessings from store")
+		return err
+	}
+
+	// resume all postprocessings which were interrupted by a restart of the service
+	for _, pp := range current {
+		pps.log.Info().Str("uploadID", pp.ID).Str("step", string(pp.CurrentStep)).Msg("resuming postprocessing")
+		if err := pps.publish(pp, pp.Resume()); err != nil {
+			return err
+		}
+	}
+
+	// without a step timeout the ticker never fires
+	var timeouts <-chan time.Time
+	if pps.c.StepTimeout > 0 {
+		ticker := time.NewTicker(checkInterval(pps.c.StepTimeout))
+		defer ticker.Stop()
+		timeouts = ticker.C
+	}
+
+	for {
+		var e interface{}
+		select {
+		case ev, ok := <-pps.events:
+			if !ok {
+				return nil
+			}
+			e = ev
+		case <-timeouts:
+			if err := pps.handleTimeouts(current); err != nil {
+				return err
+			}
+			continue
+		case <-pps.quit:
+			return nil
+		case cmd := <-pps.cmds:
+			cmd(current)
+			continue
+		}
+
+		var (
+			next interface{}
+			pp   *postprocessing.Postprocessing
+		)
 		switch ev := e.(type) {
 		case events.BytesReceived:
-			pp := postprocessing.New(ev.UploadID, ev.URL, ev.ExecutingUser, ev.Filename, ev.Filesize, ev.ResourceID, pps.steps, pps.c.Delayprocessing)
+			pp = postprocessing.New(ev.UploadID, ev.URL, ev.ExecutingUser, ev.Filename, ev.Filesize, ev.ResourceID, pps.stepsFor(ev), pps.c.Delayprocessing)
 			current[ev.UploadID] = pp
 			next = pp.Init(ev)
 		case events.PostprocessingStepFinished:
-			pp := current[ev.UploadID]
+			pp = current[ev.UploadID]
 			if pp == nil {
 				// no current upload - this was an on demand scan
 				continue
@@ -58,28 +118,130 @@ func (pps *PostprocessingService) Run() error {
 			if ev.StepToStart != events.PPStepDelay {
 				continue
 			}
-			pp := current[ev.UploadID]
+			pp = current[ev.UploadID]
+			if pp == nil {
+				continue
+			}
 			next = pp.Delay(ev)
 		case events.UploadReady:
 			// the storage provider thinks the upload is done - so no need to keep it any more
 			delete(current, ev.UploadID)
+			if err := pps.delete(ev.UploadID); err != nil {
+				pps.log.Error().Err(err).Str("uploadID", ev.UploadID).Msg("unable to delete postprocessing from store")
+			}
 		}
 
 		if next != nil {
-			if err := events.Publish(pps.pub, next); err != nil {
-				pps.log.Error().Err(err).Msg("unable to publish event")
+			if err := pps.publish(pp, next); err != nil {
 				return err // we can't publish -> we are screwed
 			}
 		}
+	}
+}
+
+// Close stops the event loop started by Run
+func (pps *PostprocessingService) Close() {
+	pps.once.Do(func() { close(pps.quit) })
+}
+
+// handleTimeouts restarts or fails all steps which did not finish in time
+func (pps *PostprocessingService) handleTimeouts(current map[string]*postprocessing.Postprocessing) error {
+	for _, pp := range current {
+		if !pp.TimedOut(pps.c.StepTimeout) {
+			continue
+		}
+
+		pps.log.Warn().Str("uploadID", pp.ID).Str("step", string(pp.CurrentStep)).Int("attempts", pp.Attempts+1).Msg("postprocessing step timed out")
+		next := pp.Timeout(pps.c.StepRetries, events.PostprocessingOutcome(pps.c.StepFailureOutcome))
+		if err := pps.publish(pp, next); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkInterval returns how often running steps are checked for timeouts
+func checkInterval(timeout time.Duration) time.Duration {
+	if i := timeout / 4; i > time.Second {
+		return i
+	}
+	return time.Second
+}
+
+// publish stores the state of the postprocessing before publishing its next event.
+// This way a restarted service will send the event again if publishing failed.
+func (pps *PostprocessingService) publish(pp *postprocessing.Postprocessing, next interface{}) error {
+	if err := pps.save(pp); err != nil {
+		pps.log.Error().Err(err).Str("uploadID", pp.ID).Msg("unable to store postprocessing")
+	}
 
+	if err := events.Publish(pps.pub, next); err != nil {
+		pps.log.Error().Err(err).Msg("unable to publish event")
+		return err
 	}
 	return nil
 }
 
+func (pps *PostprocessingService) save(pp *postprocessing.Postprocessing) error {
+	b, err := json.Marshal(pp)
+	if err != nil {
+		return err
+	}
+
+	return pps.store.Write(&store.Record{
+		Key:   pp.ID,
+		Value: b,
+	}, store.WriteTo(pps.sc.Database, pps.sc.Table))
+}
+
+func (pps *PostprocessingService) delete(uploadID string) error {
+	err := pps.store.Delete(uploadID, store.DeleteFrom(pps.sc.Database, pps.sc.Table))
+	if err == store.ErrNotFound {
+		return nil
+	}
+	return err
+}
+
+// load reads all running postprocessings from the store
+func (pps *PostprocessingService) load() (map[string]*postprocessing.Postprocessing, error) {
+	current := make(map[string]*postprocessing.Postprocessing)
+
+	keys, err := pps.store.List(store.ListFrom(pps.sc.Database, pps.sc.Table))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, k := range keys {
+		recs, err := pps.store.Read(k, store.ReadFrom(pps.sc.Database, pps.sc.Table))
+		if err != nil {
+			pps.log.Error().Err(err).Str("uploadID", k).Msg("unable to read postprocessing from store")
+			continue
+		}
+
+		for _, r := range recs {
+			if r.Key != k {
+				continue
+			}
+
+			pp := &postprocessing.Postprocessing{}
+			if err := json.Unmarshal(r.Value, pp); err != nil {
+				pps.log.Error().Err(err).Str("uploadID", k).Msg("unable to unmarshal postprocessing")
+				continue
+			}
+
+			if pp.Results == nil {
+				pp.Results = make(map[events.Postprocessingstep]interface{})
+			}
+			current[pp.ID] = pp
+		}
+	}
+
+	return current, nil
+}
+
 func getSteps(c config.Postprocessing) []events.Postprocessingstep {
-	// NOTE: improved version only allows configuring order of postprocessing steps
-	// But we aim for a system where postprocessing steps can be configured per space, ideally by the spaceadmin itself
-	// We need to iterate over configuring PP service when we see fit
+	// NOTE: these are the default steps. Steps for specific spaces, mimetypes or file sizes can be configured with rules.
+	// We still aim for a system where postprocessing steps can be configured by the spaceadmin itself
 	var steps []events.Postprocessingstep
 	for _, s := range c.Steps {
 		steps = append(steps, events.Postprocessingstep(s))
@@ -0,0 +1,227 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	user "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/v2/pkg/events"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/owncloud/ocis/v2/ocis-pkg/log"
+	"github.com/owncloud/ocis/v2/services/postprocessing/pkg/config"
+	"github.com/owncloud/ocis/v2/services/postprocessing/pkg/postprocessing"
+	mevents "go-micro.dev/v4/events"
+	"go-micro.dev/v4/store"
+)
+
+// publisher records the published events
+type publisher struct {
+	mu     sync.Mutex
+	events []interface{}
+}
+
+func (p *publisher) Publish(_ string, ev interface{}, _ ...mevents.PublishOption) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, ev)
+	return nil
+}
+
+func (p *publisher) published() []interface{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]interface{}(nil), p.events...)
+}
+
+// newTestService returns a service which reads its events from the returned channel instead of a stream
+func newTestService(c config.Postprocessing, st store.Store) (*PostprocessingService, chan interface{}, *publisher) {
+	evs := make(chan interface{})
+	pub := &publisher{}
+	return &PostprocessingService{
+		log:    log.NewLogger(),
+		events: evs,
+		pub:    pub,
+		steps:  getSteps(c),
+		rules:  getRules(c),
+		store:  st,
+		c:      c,
+		sc:     config.Store{Database: "services", Table: "services/postprocessing/"},
+		cmds:   make(chan command),
+		quit:   make(chan struct{}),
+	}, evs, pub
+}
+
+// run starts the event loop of the service and returns a function stopping it
+func run(pps *PostprocessingService) func() {
+	done := make(chan error, 1)
+	go func() { done <- pps.Run() }()
+	return func() {
+		pps.Close()
+		Eventually(done).Should(Receive(BeNil()))
+	}
+}
+
+var _ = Describe("PostprocessingService", func() {
+	var (
+		st  store.Store
+		pps *PostprocessingService
+		evs chan interface{}
+		pub *publisher
+		pp  *postprocessing.Postprocessing
+	)
+
+	BeforeEach(func() {
+		st = store.NewMemoryStore()
+		pps, evs, pub = newTestService(config.Postprocessing{Steps: []string{"virusscan", "policies"}}, st)
+		pp = postprocessing.New("upload-1", "https://localhost/upload-1", &user.User{Id: &user.UserId{OpaqueId: "einstein"}}, "foo.pdf", 42, &provider.ResourceId{SpaceId: "space"}, pps.steps, 0)
+	})
+
+	Describe("the store", func() {
+		It("saves and loads the running postprocessings", func() {
+			pp.Init(events.BytesReceived{UploadID: pp.ID})
+			Expect(pps.save(pp)).To(Succeed())
+
+			current, err := pps.load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(current).To(HaveKey("upload-1"))
+
+			loaded := current["upload-1"]
+			Expect(loaded.URL).To(Equal(pp.URL))
+			Expect(loaded.User.GetId().GetOpaqueId()).To(Equal("einstein"))
+			Expect(loaded.Filename).To(Equal("foo.pdf"))
+			Expect(loaded.Filesize).To(Equal(uint64(42)))
+			Expect(loaded.ResourceID.GetSpaceId()).To(Equal("space"))
+			Expect(loaded.Steps).To(Equal([]events.Postprocessingstep{"virusscan", "policies"}))
+			Expect(loaded.CurrentStep).To(Equal(events.Postprocessingstep("virusscan")))
+			Expect(loaded.StepStarted).To(BeTemporally("==", pp.StepStarted))
+			Expect(loaded.Results).To(HaveKey(events.Postprocessingstep("init")))
+		})
+
+		It("loads nothing from an empty store", func() {
+			current, err := pps.load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(current).To(BeEmpty())
+		})
+
+		It("skips records which can't be read", func() {
+			Expect(st.Write(&store.Record{Key: "broken", Value: []byte("{")}, store.WriteTo(pps.sc.Database, pps.sc.Table))).To(Succeed())
+			Expect(pps.save(pp)).To(Succeed())
+
+			current, err := pps.load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(current).To(HaveLen(1))
+			Expect(current).To(HaveKey("upload-1"))
+		})
+
+		It("deletes postprocessings", func() {
+			Expect(pps.save(pp)).To(Succeed())
+			Expect(pps.delete(pp.ID)).To(Succeed())
+			Expect(pps.delete("unknown")).To(Succeed())
+
+			current, err := pps.load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(current).To(BeEmpty())
+		})
+	})
+
+	Describe("Run", func() {
+		It("resumes the postprocessings which were interrupted by a restart", func() {
+			pp.Init(events.BytesReceived{UploadID: pp.ID})
+			pp.NextStep(events.PostprocessingStepFinished{UploadID: pp.ID, FinishedStep: "virusscan", Outcome: events.PPOutcomeContinue})
+			Expect(pps.save(pp)).To(Succeed())
+
+			stop := run(pps)
+			defer stop()
+
+			Eventually(pub.published).Should(ConsistOf(
+				BeAssignableToTypeOf(events.StartPostprocessingStep{}),
+			))
+			ev := pub.published()[0].(events.StartPostprocessingStep)
+			Expect(ev.UploadID).To(Equal("upload-1"))
+			Expect(ev.StepToStart).To(Equal(events.Postprocessingstep("policies")))
+			Expect(ev.ExecutingUser.GetId().GetOpaqueId()).To(Equal("einstein"))
+		})
+
+		It("continues with the next step when a step finished", func() {
+			stop := run(pps)
+			defer stop()
+
+			evs <- events.BytesReceived{UploadID: "upload-2", Filename: "bar.txt", ResourceID: &provider.ResourceId{SpaceId: "space"}}
+			Eventually(pub.published).Should(HaveLen(1))
+
+			evs <- events.PostprocessingStepFinished{UploadID: "upload-2", FinishedStep: "virusscan", Outcome: events.PPOutcomeContinue}
+			Eventually(pub.published).Should(HaveLen(2))
+			Expect(pub.published()[1].(events.StartPostprocessingStep).StepToStart).To(Equal(events.Postprocessingstep("policies")))
+
+			evs <- events.PostprocessingStepFinished{UploadID: "upload-2", FinishedStep: "policies", Outcome: events.PPOutcomeContinue}
+			Eventually(pub.published).Should(HaveLen(3))
+			Expect(pub.published()[2].(events.PostprocessingFinished).Outcome).To(Equal(events.PPOutcomeContinue))
+		})
+
+		It("stores new postprocessings and forgets them when the upload is ready", func() {
+			stop := run(pps)
+			defer stop()
+
+			evs <- events.BytesReceived{UploadID: "upload-2", Filename: "bar.txt", ResourceID: &provider.ResourceId{SpaceId: "space"}}
+			Eventually(pub.published).Should(HaveLen(1))
+			keys, err := st.List(store.ListFrom(pps.sc.Database, pps.sc.Table))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(keys).To(ConsistOf("upload-2"))
+
+			evs <- events.UploadReady{UploadID: "upload-2"}
+			Eventually(func() []string {
+				keys, _ := st.List(store.ListFrom(pps.sc.Database, pps.sc.Table))
+				return keys
+			}).Should(BeEmpty())
+		})
+	})
+
+	Describe("handleTimeouts", func() {
+		BeforeEach(func() {
+			pps.c.StepTimeout = time.Minute
+			pps.c.StepRetries = 1
+			pps.c.StepFailureOutcome = string(events.PPOutcomeDelete)
+			pp.Init(events.BytesReceived{UploadID: pp.ID})
+		})
+
+		It("leaves steps alone which are running shorter than the timeout", func() {
+			current := map[string]*postprocessing.Postprocessing{pp.ID: pp}
+			Expect(pps.handleTimeouts(current)).To(Succeed())
+			Expect(pub.published()).To(BeEmpty())
+		})
+
+		It("starts timed out steps again and finishes them when the retries are used up", func() {
+			current := map[string]*postprocessing.Postprocessing{pp.ID: pp}
+
+			pp.StepStarted = time.Now().Add(-time.Hour)
+			Expect(pps.handleTimeouts(current)).To(Succeed())
+			Expect(pub.published()).To(HaveLen(1))
+			Expect(pub.published()[0].(events.StartPostprocessingStep).StepToStart).To(Equal(events.Postprocessingstep("virusscan")))
+
+			pp.StepStarted = time.Now().Add(-time.Hour)
+			Expect(pps.handleTimeouts(current)).To(Succeed())
+			Expect(pub.published()).To(HaveLen(2))
+			Expect(pub.published()[1].(events.PostprocessingFinished).Outcome).To(Equal(events.PPOutcomeDelete))
+
+			// the state is stored with every published event
+			loaded, err := pps.load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(loaded[pp.ID].Finished).To(BeTrue())
+			Expect(loaded[pp.ID].Outcome).To(Equal(events.PPOutcomeDelete))
+
+			// finished postprocessings don't time out
+			pp.StepStarted = time.Now().Add(-time.Hour)
+			Expect(pps.handleTimeouts(current)).To(Succeed())
+			Expect(pub.published()).To(HaveLen(2))
+		})
+	})
+
+	Describe("checkInterval", func() {
+		It("checks four times per timeout, but at most every second", func() {
+			Expect(checkInterval(time.Minute)).To(Equal(15 * time.Second))
+			Expect(checkInterval(2 * time.Second)).To(Equal(time.Second))
+		})
+	})
+})
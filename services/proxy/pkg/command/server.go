@@ -3,17 +3,23 @@ package command
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"net/http"
+	"os"
 	"time"
 
 	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/cs3org/reva/v2/pkg/events"
 	"github.com/cs3org/reva/v2/pkg/rgrpc/todo/pool"
 	"github.com/cs3org/reva/v2/pkg/token/manager/jwt"
 	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/go-micro/plugins/v4/events/natsjs"
 	"github.com/justinas/alice"
 	"github.com/oklog/run"
 	"github.com/owncloud/ocis/v2/ocis-pkg/config/configlog"
+	ociscrypto "github.com/owncloud/ocis/v2/ocis-pkg/crypto"
+	ocisevents "github.com/owncloud/ocis/v2/ocis-pkg/events"
 	"github.com/owncloud/ocis/v2/ocis-pkg/log"
 	pkgmiddleware "github.com/owncloud/ocis/v2/ocis-pkg/middleware"
 	"github.com/owncloud/ocis/v2/ocis-pkg/service/grpc"
@@ -79,6 +85,12 @@ func Server(cfg *config.Config) *cli.Command {
 				return fmt.Errorf("Failed to initialize reverse proxy: %w", err)
 			}
 
+			publisher, err := eventsPublisher(cfg.Events)
+			if err != nil {
+				logger.Error().Err(err).Msg("Error initializing events publisher")
+				return fmt.Errorf("could not initialize events publisher: %w", err)
+			}
+
 			{
 				server, err := proxyHTTP.Server(
 					proxyHTTP.Handler(rp),
@@ -86,7 +98,7 @@ func Server(cfg *config.Config) *cli.Command {
 					proxyHTTP.Context(ctx),
 					proxyHTTP.Config(cfg),
 					proxyHTTP.Metrics(metrics.New()),
-					proxyHTTP.Middlewares(loadMiddlewares(ctx, logger, cfg)),
+					proxyHTTP.Middlewares(loadMiddlewares(ctx, logger, cfg, publisher)),
 				)
 
 				if err != nil {
@@ -133,7 +145,45 @@ func Server(cfg *config.Config) *cli.Command {
 	}
 }
 
-func loadMiddlewares(ctx context.Context, logger log.Logger, cfg *config.Config) alice.Chain {
+// eventsPublisher returns a publisher which connects to the event system when the first event is published, so that
+// the service doesn't wait for the event system on startup. It returns nil if no endpoint is configured.
+func eventsPublisher(cfg config.Events) (events.Publisher, error) {
+	if cfg.Endpoint == "" {
+		return nil, nil
+	}
+
+	var tlsConf *tls.Config
+	if cfg.EnableTLS {
+		var rootCAPool *x509.CertPool
+		if cfg.TLSRootCACertificate != "" {
+			rootCrtFile, err := os.Open(cfg.TLSRootCACertificate)
+			if err != nil {
+				return nil, err
+			}
+
+			rootCAPool, err = ociscrypto.NewCertPoolFromPEM(rootCrtFile)
+			if err != nil {
+				return nil, err
+			}
+			cfg.TLSInsecure = false
+		}
+
+		tlsConf = &tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			InsecureSkipVerify: cfg.TLSInsecure, //nolint:gosec
+			RootCAs:            rootCAPool,
+		}
+	}
+	return ocisevents.NewLazyPublisher(func() (events.Publisher, error) {
+		return natsjs.NewStream(
+			natsjs.TLSConfig(tlsConf),
+			natsjs.Address(cfg.Endpoint),
+			natsjs.ClusterID(cfg.Cluster),
+		)
+	}), nil
+}
+
+func loadMiddlewares(ctx context.Context, logger log.Logger, cfg *config.Config, publisher events.Publisher) alice.Chain {
 	rolesClient := settingssvc.NewRoleService("com.owncloud.api.settings", grpc.DefaultClient())
 	revaClient, err := pool.GetGatewayServiceClient(cfg.Reva.Address, cfg.Reva.GetRevaOptions()...)
 	var userProvider backend.UserBackend
@@ -170,15 +220,18 @@ func loadMiddlewares(ctx context.Context, logger log.Logger, cfg *config.Config)
 		Timeout: time.Second * 10,
 	}
 
+	signInEvents := middleware.NewSignInEvents(publisher, cfg.Events.SignInInterval, cfg.OIDC.UserinfoCache.Size, logger)
+
 	var authenticators []middleware.Authenticator
 	if cfg.EnableBasicAuth {
 		logger.Warn().Msg("basic auth enabled, use only for testing or development")
 		authenticators = append(authenticators, middleware.BasicAuthenticator{
 			Logger:       logger,
 			UserProvider: userProvider,
+			SignInEvents: signInEvents,
 		})
 	}
-	authenticators = append(authenticators, middleware.NewOIDCAuthenticator(
+	oidcAuthenticator := middleware.NewOIDCAuthenticator(
 		logger,
 		cfg.OIDC.UserinfoCache.TTL,
 		oidcHTTPClient,
@@ -194,7 +247,9 @@ func loadMiddlewares(ctx context.Context, logger log.Logger, cfg *config.Config)
 		},
 		cfg.OIDC.JWKS,
 		cfg.OIDC.AccessTokenVerifyMethod,
-	))
+	)
+	oidcAuthenticator.SignInEvents = signInEvents
+	authenticators = append(authenticators, oidcAuthenticator)
 	authenticators = append(authenticators, middleware.PublicShareAuthenticator{
 		Logger:            logger,
 		RevaGatewayClient: revaClient,
@@ -2,6 +2,7 @@ package config
 
 import (
 	"context"
+	"time"
 
 	"github.com/owncloud/ocis/v2/ocis-pkg/shared"
 )
@@ -35,10 +36,21 @@ type Config struct {
 	InsecureBackends      bool            `yaml:"insecure_backends" env:"PROXY_INSECURE_BACKENDS" desc:"Disable TLS certificate validation for all HTTP backend connections."`
 	BackendHTTPSCACert    string          `yaml:"backend_https_cacert" env:"PROXY_HTTPS_CACERT" desc:"Path/File for the root CA certificate used to validate the server’s TLS certificate for https enabled backend services."`
 	AuthMiddleware        AuthMiddleware  `yaml:"auth_middleware"`
+	Events                Events          `yaml:"events"`
 
 	Context context.Context `yaml:"-" json:"-"`
 }
 
+// Events combines the configuration options for the event bus.
+type Events struct {
+	Endpoint             string        `yaml:"endpoint" env:"PROXY_EVENTS_ENDPOINT" desc:"The address of the event system. The event system is the message queuing service. It is used as message broker for the microservice architecture. Set to a empty string to disable emitting events."`
+	Cluster              string        `yaml:"cluster" env:"PROXY_EVENTS_CLUSTER" desc:"The clusterID of the event system. The event system is the message queuing service. It is used as message broker for the microservice architecture."`
+	TLSInsecure          bool          `yaml:"tls_insecure" env:"OCIS_INSECURE;PROXY_EVENTS_TLS_INSECURE" desc:"Whether to verify the server TLS certificates."`
+	TLSRootCACertificate string        `yaml:"tls_root_ca_certificate" env:"PROXY_EVENTS_TLS_ROOT_CA_CERTIFICATE" desc:"The root CA certificate used to validate the server's TLS certificate. If provided PROXY_EVENTS_TLS_INSECURE will be seen as false."`
+	EnableTLS            bool          `yaml:"enable_tls" env:"OCIS_EVENTS_ENABLE_TLS;PROXY_EVENTS_ENABLE_TLS" desc:"Enable TLS for the connection to the events broker. The events broker is the ocis service which receives and delivers events between the services.."`
+	SignInInterval       time.Duration `yaml:"sign_in_interval" env:"PROXY_EVENTS_SIGN_IN_INTERVAL" desc:"Repeated sign-ins of a user with the same client from the same address are only published once during this interval. The duration can be set as number followed by a unit identifier like s, m or h. 0 publishes every authenticated request."`
+}
+
 // Policy enables us to use multiple directors.
 type Policy struct {
 	Name   string  `yaml:"name"`
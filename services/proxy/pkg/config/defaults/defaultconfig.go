@@ -3,6 +3,7 @@ package defaults
 import (
 	"path"
 	"strings"
+	"time"
 
 	"github.com/owncloud/ocis/v2/ocis-pkg/config/defaults"
 	"github.com/owncloud/ocis/v2/ocis-pkg/shared"
@@ -60,6 +61,12 @@ func DefaultConfig() *config.Config {
 		AutoprovisionAccounts: false,
 		EnableBasicAuth:       false,
 		InsecureBackends:      false,
+		Events: config.Events{
+			Endpoint:       "127.0.0.1:9233",
+			Cluster:        "ocis-cluster",
+			EnableTLS:      false,
+			SignInInterval: time.Hour,
+		},
 	}
 }
 
@@ -193,6 +200,10 @@ func DefaultPolicies() []config.Policy {
 					Endpoint: "/api/v0/settings",
 					Service:  "com.owncloud.web.settings",
 				},
+				{
+					Endpoint: "/api/v0/audit",
+					Service:  "com.owncloud.web.audit",
+				},
 				{
 					Endpoint:    "/settings.js",
 					Service:     "com.owncloud.web.settings",
@@ -14,6 +14,7 @@ type BasicAuthenticator struct {
 	UserProvider  backend.UserBackend
 	UserCS3Claim  string
 	UserOIDCClaim string
+	SignInEvents  *SignInEvents
 }
 
 // Authenticate implements the authenticator interface to authenticate requests via basic auth.
@@ -37,8 +38,10 @@ func (m BasicAuthenticator) Authenticate(r *http.Request) (*http.Request, bool)
 			Str("authenticator", "basic").
 			Str("path", r.URL.Path).
 			Msg("failed to authenticate request")
+		m.SignInEvents.SignInFailed(r, "basic", login, err.Error())
 		return nil, false
 	}
+	m.SignInEvents.SignedIn(r, "basic", user.GetId().GetOpaqueId(), user.GetUsername())
 
 	// fake oidc claims
 	claims := map[string]interface{}{
@@ -58,6 +58,7 @@ type OIDCAuthenticator struct {
 	ProviderFunc            func() (OIDCProvider, error)
 	AccessTokenVerifyMethod string
 	JWKSOptions             config.JWKS
+	SignInEvents            *SignInEvents
 
 	providerLock *sync.Mutex
 	provider     OIDCProvider
@@ -94,6 +95,10 @@ func (m *OIDCAuthenticator) getClaims(token string, req *http.Request) (map[stri
 		m.tokenCache.Store(token, claims, expiration)
 
 		m.Logger.Debug().Interface("claims", claims).Interface("userInfo", userInfo).Time("expiration", expiration.UTC()).Msg("unmarshalled and cached userinfo")
+
+		userID, _ := claims[oidc.OwncloudUUID].(string)
+		username, _ := claims[oidc.PreferredUsername].(string)
+		m.SignInEvents.SignedIn(req, "oidc", userID, username)
 		return claims, nil
 	}
 
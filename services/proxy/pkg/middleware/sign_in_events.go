@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cs3org/reva/v2/pkg/events"
+	ocisevents "github.com/owncloud/ocis/v2/ocis-pkg/events"
+	"github.com/owncloud/ocis/v2/ocis-pkg/log"
+	osync "github.com/owncloud/ocis/v2/ocis-pkg/sync"
+)
+
+// SignInEvents publishes sign-ins of users to the event system. Most clients authenticate every single request,
+// so repeated sign-ins of a user with the same method from the same address and client are published only once
+// per interval. Failed sign-ins are always published. A nil *SignInEvents publishes nothing.
+type SignInEvents struct {
+	publisher events.Publisher
+	logger    log.Logger
+	interval  time.Duration
+	seen      *osync.Cache
+}
+
+// NewSignInEvents returns a SignInEvents publishing to the given publisher. It returns nil if the publisher is nil.
+func NewSignInEvents(publisher events.Publisher, interval time.Duration, cacheSize int, logger log.Logger) *SignInEvents {
+	if publisher == nil {
+		return nil
+	}
+
+	seen := osync.NewCache(cacheSize)
+	return &SignInEvents{
+		publisher: publisher,
+		logger:    logger,
+		interval:  interval,
+		seen:      &seen,
+	}
+}
+
+// SignedIn publishes a UserSignedIn event unless the same sign-in was published during the interval
+func (s *SignInEvents) SignedIn(r *http.Request, method, userID, username string) {
+	if s == nil {
+		return
+	}
+
+	key := strings.Join([]string{method, userID, username, remoteIP(r), r.UserAgent()}, "\x00")
+	if s.interval > 0 {
+		if s.seen.Load(key) != nil {
+			return
+		}
+		s.seen.Store(key, struct{}{}, time.Now().Add(s.interval))
+	}
+
+	s.publish(ocisevents.UserSignedIn{
+		UserID:     userID,
+		Username:   username,
+		Method:     method,
+		RemoteAddr: remoteIP(r),
+		UserAgent:  r.UserAgent(),
+		Timestamp:  time.Now(),
+	})
+}
+
+// SignInFailed publishes a UserSignInFailed event
+func (s *SignInEvents) SignInFailed(r *http.Request, method, username, reason string) {
+	if s == nil {
+		return
+	}
+
+	s.publish(ocisevents.UserSignInFailed{
+		Username:   username,
+		Method:     method,
+		Reason:     reason,
+		RemoteAddr: remoteIP(r),
+		UserAgent:  r.UserAgent(),
+		Timestamp:  time.Now(),
+	})
+}
+
+func (s *SignInEvents) publish(ev interface{}) {
+	if err := events.Publish(s.publisher, ev); err != nil {
+		s.logger.Error().Err(err).Msg("could not publish sign-in event")
+	}
+}
+
+// remoteIP strips the port from the remote address, which changes with every connection
+func remoteIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
@@ -2,7 +2,10 @@ package command
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"text/tabwriter"
 	"time"
 
 	"github.com/urfave/cli/v2"
@@ -24,25 +27,42 @@ func Index(cfg *config.Config) *cli.Command {
 		Aliases:  []string{"i"},
 		Flags: []cli.Flag{
 			&cli.StringFlag{
-				Name:     "space",
-				Aliases:  []string{"s"},
-				Required: true,
-				Usage:    "the id of the space to travers and index the files of",
+				Name:    "space",
+				Aliases: []string{"s"},
+				Usage:   "the id of the space to travers and index the files of",
 			},
 			&cli.StringFlag{
-				Name:     "user",
-				Aliases:  []string{"u"},
-				Required: true,
-				Usage:    "the username of the user that shall be used to access the files",
+				Name:    "user",
+				Aliases: []string{"u"},
+				Usage:   "the username of the user that shall be used to access the files",
 			},
+			&cli.BoolFlag{
+				Name:  "all-spaces",
+				Usage: "index all spaces in the background, personal spaces are accessed as their owner and project spaces as the service user",
+			},
+		},
+		Subcommands: []*cli.Command{
+			IndexVerify(cfg),
+			IndexStats(cfg),
 		},
 		Before: func(c *cli.Context) error {
 			return configlog.ReturnFatal(parser.ParseConfig(cfg))
 		},
 		Action: func(ctx *cli.Context) error {
-			grpcClient := grpc.DefaultClient()
-			grpcClient.Options()
-			c := searchsvc.NewSearchProviderService("com.owncloud.api.search", grpcClient)
+			c := searchClient()
+			if ctx.Bool("all-spaces") {
+				res, err := c.IndexAllSpaces(context.Background(), &searchsvc.IndexAllSpacesRequest{})
+				if err != nil {
+					fmt.Println("failed to index all spaces: " + err.Error())
+					return err
+				}
+				fmt.Printf("indexing %d spaces in the background, the search service logs when it is done\n", len(res.GetSpaceIds()))
+				return nil
+			}
+
+			if ctx.String("space") == "" || ctx.String("user") == "" {
+				return errors.New("either the space and the user or all spaces need to be given")
+			}
 			_, err := c.IndexSpace(context.Background(), &searchsvc.IndexSpaceRequest{
 				SpaceId: ctx.String("space"),
 				UserId:  ctx.String("user"),
@@ -55,3 +75,79 @@ func Index(cfg *config.Config) *cli.Command {
 		},
 	}
 }
+
+// IndexVerify is the entrypoint for the index verify command.
+func IndexVerify(cfg *config.Config) *cli.Command {
+	return &cli.Command{
+		Name:  "verify",
+		Usage: "compare the index of a space with the storage and list stale and missing resources",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "space",
+				Aliases:  []string{"s"},
+				Required: true,
+				Usage:    "the id of the space to verify",
+			},
+			&cli.StringFlag{
+				Name:    "user",
+				Aliases: []string{"u"},
+				Usage:   "the id of the user that shall be used to access the files, defaults to the owner of personal spaces and the service user for project spaces",
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			res, err := searchClient().VerifyIndex(context.Background(), &searchsvc.VerifyIndexRequest{
+				SpaceId: ctx.String("space"),
+				UserId:  ctx.String("user"),
+			}, func(opts *client.CallOptions) { opts.RequestTimeout = 10 * time.Minute })
+			if err != nil {
+				fmt.Println("failed to verify the index: " + err.Error())
+				return err
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "STATE\tID\tPATH")
+			for _, e := range res.GetStale() {
+				fmt.Fprintf(w, "stale\t%s\t%s\n", e.GetId().GetOpaqueId(), e.GetRef().GetPath())
+			}
+			for _, e := range res.GetMissing() {
+				fmt.Fprintf(w, "missing\t%s\t%s\n", e.GetId().GetOpaqueId(), e.GetRef().GetPath())
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+			fmt.Printf("%d stale and %d missing resources\n", len(res.GetStale()), len(res.GetMissing()))
+			return nil
+		},
+	}
+}
+
+// IndexStats is the entrypoint for the index stats command.
+func IndexStats(cfg *config.Config) *cli.Command {
+	return &cli.Command{
+		Name:  "stats",
+		Usage: "show the number of resources per space and the size of the index",
+		Action: func(ctx *cli.Context) error {
+			res, err := searchClient().IndexStats(context.Background(), &searchsvc.IndexStatsRequest{})
+			if err != nil {
+				fmt.Println("failed to get the index stats: " + err.Error())
+				return err
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "SPACE\tDOCUMENTS")
+			for _, s := range res.GetSpaces() {
+				fmt.Fprintf(w, "%s\t%d\n", s.GetSpaceId(), s.GetDocuments())
+			}
+			fmt.Fprintf(w, "total\t%d\n", res.GetDocuments())
+			if err := w.Flush(); err != nil {
+				return err
+			}
+			fmt.Printf("index size: %d bytes\n", res.GetSize())
+			return nil
+		},
+	}
+}
+
+func searchClient() searchsvc.SearchProviderService {
+	return searchsvc.NewSearchProviderService("com.owncloud.api.search", grpc.DefaultClient())
+}
@@ -25,6 +25,7 @@ type Config struct {
 	Extractor     Extractor             `yaml:"extractor"`
 
 	MachineAuthAPIKey string `yaml:"machine_auth_api_key" env:"OCIS_MACHINE_AUTH_API_KEY;SEARCH_MACHINE_AUTH_API_KEY" desc:"Machine auth API key used to validate internal requests necessary for the access to resources from other services."`
+	ServiceUserID     string `yaml:"service_user_id" env:"OCIS_ADMIN_USER_ID;SEARCH_SERVICE_USER_ID" desc:"ID of the user that is used to list all spaces when indexing all spaces and to verify the index of spaces without an owner. The user needs the permission to list all spaces. Defaults to the admin user."`
 
 	Context context.Context `yaml:"-"`
 }
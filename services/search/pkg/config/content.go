@@ -2,12 +2,18 @@ package config
 
 // Extractor defines which extractor to use
 type Extractor struct {
-	Type             string        `yaml:"type" env:"SEARCH_EXTRACTOR_TYPE" desc:"Defines the content extraction engine."`
-	CS3AllowInsecure bool          `yaml:"cs3_allow_insecure" env:"OCIS_INSECURE;SEARCH_EXTRACTOR_CS3SOURCE_INSECURE" desc:"Ignore untrusted SSL certificates when connecting to the CS3 source."`
-	Tika             ExtractorTika `yaml:"tika"`
+	Type             string          `yaml:"type" env:"SEARCH_EXTRACTOR_TYPE" desc:"Defines the content extraction engine. Supported values are 'basic', 'native' and 'tika'."`
+	CS3AllowInsecure bool            `yaml:"cs3_allow_insecure" env:"OCIS_INSECURE;SEARCH_EXTRACTOR_CS3SOURCE_INSECURE" desc:"Ignore untrusted SSL certificates when connecting to the CS3 source."`
+	Tika             ExtractorTika   `yaml:"tika"`
+	Native           ExtractorNative `yaml:"native"`
 }
 
 // ExtractorTika configures the Tika extractor
 type ExtractorTika struct {
 	TikaURL string `yaml:"tika_url" env:"SEARCH_EXTRACTOR_TIKA_TIKA_URL" desc:"URL of the tika server."`
 }
+
+// ExtractorNative configures the native extractor
+type ExtractorNative struct {
+	MaxFileSize uint64 `yaml:"max_file_size" env:"SEARCH_EXTRACTOR_NATIVE_MAX_FILE_SIZE" desc:"The maximum size of a file in bytes the content is extracted from. Only the metadata of bigger files is indexed."`
+}
@@ -35,6 +35,10 @@ func DefaultConfig() *config.Config {
 			Bleve: config.EngineBleve{
 				Datapath: filepath.Join(defaults.BaseDataPath(), "search"),
 			},
+			OpenSearch: config.EngineOpenSearch{
+				Addresses: []string{"http://127.0.0.1:9200"},
+				Index:     "ocis-resources",
+			},
 		},
 		Extractor: config.Extractor{
 			Type:             "basic",
@@ -42,6 +46,9 @@ func DefaultConfig() *config.Config {
 			Tika: config.ExtractorTika{
 				TikaURL: "http://127.0.0.1:9998",
 			},
+			Native: config.ExtractorNative{
+				MaxFileSize: 20 << 20,
+			},
 		},
 		Events: config.Events{
 			Endpoint:         "127.0.0.1:9233",
@@ -83,6 +90,10 @@ func EnsureDefaults(cfg *config.Config) {
 		cfg.MachineAuthAPIKey = cfg.Commons.MachineAuthAPIKey
 	}
 
+	if cfg.ServiceUserID == "" && cfg.Commons != nil {
+		cfg.ServiceUserID = cfg.Commons.AdminUserID
+	}
+
 	if cfg.Reva == nil && cfg.Commons != nil && cfg.Commons.Reva != nil {
 		cfg.Reva = &shared.Reva{
 			Address: cfg.Commons.Reva.Address,
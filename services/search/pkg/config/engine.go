@@ -2,11 +2,21 @@ package config
 
 // Engine defines which search engine to use
 type Engine struct {
-	Type  string      `yaml:"type" env:"SEARCH_ENGINE_TYPE" desc:"Defines which search engine to use."`
-	Bleve EngineBleve `yaml:"bleve"`
+	Type       string           `yaml:"type" env:"SEARCH_ENGINE_TYPE" desc:"Defines which search engine to use. Supported values are 'bleve' and 'opensearch'."`
+	Bleve      EngineBleve      `yaml:"bleve"`
+	OpenSearch EngineOpenSearch `yaml:"opensearch"`
 }
 
 // EngineBleve configures the bleve engine
 type EngineBleve struct {
 	Datapath string `yaml:"data_path" env:"SEARCH_ENGINE_BLEVE_DATA_PATH" desc:"Path for the search persistence directory."`
 }
+
+// EngineOpenSearch configures the OpenSearch engine
+type EngineOpenSearch struct {
+	Addresses []string `yaml:"addresses" env:"SEARCH_ENGINE_OPENSEARCH_ADDRESSES" desc:"A comma-separated list of the URLs of the OpenSearch or Elasticsearch nodes. Requests are distributed over the nodes and retried on the next node if a node can't be reached."`
+	Index     string   `yaml:"index" env:"SEARCH_ENGINE_OPENSEARCH_INDEX" desc:"The name of the index the resources are stored in. It is created with the required mapping if it doesn't exist."`
+	Username  string   `yaml:"username" env:"SEARCH_ENGINE_OPENSEARCH_USERNAME" desc:"The username for the basic authentication at the cluster. Leave empty if the cluster doesn't require authentication."`
+	Password  string   `yaml:"password" env:"SEARCH_ENGINE_OPENSEARCH_PASSWORD" desc:"The password for the basic authentication at the cluster."`
+	Insecure  bool     `yaml:"insecure" env:"OCIS_INSECURE;SEARCH_ENGINE_OPENSEARCH_INSECURE" desc:"Ignore untrusted SSL certificates when connecting to the cluster."`
+}
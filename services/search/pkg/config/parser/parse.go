@@ -2,6 +2,7 @@ package parser
 
 import (
 	"errors"
+	"fmt"
 
 	ociscfg "github.com/owncloud/ocis/v2/ocis-pkg/config"
 	"github.com/owncloud/ocis/v2/ocis-pkg/shared"
@@ -37,5 +38,14 @@ func Validate(cfg *config.Config) error {
 	if cfg.MachineAuthAPIKey == "" {
 		return shared.MissingMachineAuthApiKeyError(cfg.Service.Name)
 	}
+
+	if cfg.Engine.Type == "opensearch" {
+		if len(cfg.Engine.OpenSearch.Addresses) == 0 {
+			return fmt.Errorf("the opensearch engine of the %s service needs at least one address, set SEARCH_ENGINE_OPENSEARCH_ADDRESSES", cfg.Service.Name)
+		}
+		if cfg.Engine.OpenSearch.Index == "" {
+			return fmt.Errorf("the opensearch engine of the %s service needs an index, set SEARCH_ENGINE_OPENSEARCH_INDEX", cfg.Service.Name)
+		}
+	}
 	return nil
 }
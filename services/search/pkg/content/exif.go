@@ -0,0 +1,169 @@
+package content
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"unicode/utf16"
+)
+
+// exifTags are the descriptive exif tags which are worth to be searched for
+var exifTags = map[uint16]string{
+	0x010e: "ImageDescription",
+	0x010f: "Make",
+	0x0110: "Model",
+	0x0131: "Software",
+	0x0132: "DateTime",
+	0x013b: "Artist",
+	0x8298: "Copyright",
+	0x9003: "DateTimeOriginal",
+	0x9286: "UserComment",
+	0x9c9b: "XPTitle",
+	0x9c9c: "XPComment",
+	0x9c9d: "XPAuthor",
+	0x9c9e: "XPKeywords",
+	0x9c9f: "XPSubject",
+	0xa434: "LensModel",
+}
+
+const exifIFDPointer = 0x8769
+
+// parseEXIF returns the descriptive exif metadata of JPEG and TIFF images, the title is the
+// windows title or the image description.
+func parseEXIF(data []byte) (string, string, error) {
+	tiff := data
+	if bytes.HasPrefix(data, []byte{0xff, 0xd8}) {
+		tiff = jpegEXIF(data)
+	}
+
+	values := exifValues(tiff)
+	if len(values) == 0 {
+		return "", "", nil
+	}
+
+	title := values["XPTitle"]
+	if title == "" {
+		title = values["ImageDescription"]
+	}
+
+	var sb strings.Builder
+	for _, tag := range []uint16{0x010e, 0x9c9b, 0x9c9f, 0x9c9c, 0x9286, 0x9c9e, 0x013b, 0x9c9d, 0x8298, 0x010f, 0x0110, 0xa434, 0x0131, 0x9003, 0x0132} {
+		appendText(&sb, values[exifTags[tag]])
+	}
+	return title, sb.String(), nil
+}
+
+// jpegEXIF returns the tiff structure of the exif APP1 segment of a JPEG image
+func jpegEXIF(data []byte) []byte {
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xff {
+			return nil
+		}
+		marker := data[i+1]
+		if marker == 0xda || marker == 0xd9 {
+			// the image data starts, there are no more metadata segments
+			return nil
+		}
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if length < 2 || i+2+length > len(data) {
+			return nil
+		}
+		segment := data[i+4 : i+2+length]
+		if marker == 0xe1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return segment[6:]
+		}
+		i += 2 + length
+	}
+	return nil
+}
+
+// exifValues reads the text values of the exif tags from IFD0 and the exif IFD of a tiff structure
+func exifValues(tiff []byte) map[string]string {
+	if len(tiff) < 8 {
+		return nil
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return nil
+	}
+	if order.Uint16(tiff[2:]) != 42 {
+		return nil
+	}
+
+	values := map[string]string{}
+	ifds := []uint32{order.Uint32(tiff[4:])}
+	visited := map[uint32]bool{}
+	for len(ifds) > 0 {
+		offset := ifds[0]
+		ifds = ifds[1:]
+		if visited[offset] || int(offset)+2 > len(tiff) {
+			continue
+		}
+		visited[offset] = true
+
+		count := int(order.Uint16(tiff[offset:]))
+		for n := 0; n < count; n++ {
+			entry := int(offset) + 2 + n*12
+			if entry+12 > len(tiff) {
+				break
+			}
+			tag := order.Uint16(tiff[entry:])
+			typ := order.Uint16(tiff[entry+2:])
+			size := int(order.Uint32(tiff[entry+4:]))
+
+			if tag == exifIFDPointer {
+				ifds = append(ifds, order.Uint32(tiff[entry+8:]))
+				continue
+			}
+			name, ok := exifTags[tag]
+			if !ok || (typ != 1 && typ != 2 && typ != 7) || size < 0 {
+				// only byte, ascii and undefined values can contain text
+				continue
+			}
+
+			value := tiff[entry+8 : entry+12]
+			if size > 4 {
+				start := int(order.Uint32(tiff[entry+8:]))
+				if start < 0 || start+size > len(tiff) {
+					continue
+				}
+				value = tiff[start : start+size]
+			} else {
+				value = value[:size]
+			}
+			values[name] = exifText(tag, value, order)
+		}
+	}
+	return values
+}
+
+// exifText decodes the value of a tag, windows tags are UTF-16LE and user comments start with their character code
+func exifText(tag uint16, value []byte, order binary.ByteOrder) string {
+	switch {
+	case tag >= 0x9c9b && tag <= 0x9c9f:
+		return decodeUTF16(value, binary.LittleEndian)
+	case tag == 0x9286:
+		if len(value) < 8 {
+			return ""
+		}
+		if bytes.HasPrefix(value, []byte("UNICODE")) {
+			return decodeUTF16(value[8:], order)
+		}
+		value = value[8:]
+	}
+	return strings.TrimSpace(strings.ToValidUTF8(strings.TrimRight(string(value), "\x00"), ""))
+}
+
+func decodeUTF16(b []byte, order binary.ByteOrder) string {
+	u := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		u = append(u, order.Uint16(b[i:]))
+	}
+	return strings.TrimSpace(strings.TrimRight(string(utf16.Decode(u)), "\x00"))
+}
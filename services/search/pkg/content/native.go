@@ -0,0 +1,244 @@
+package content
+
+import (
+	"context"
+	"io"
+	"path"
+	"strings"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/owncloud/ocis/v2/ocis-pkg/log"
+	"github.com/owncloud/ocis/v2/services/search/pkg/config"
+)
+
+// parser extracts the title and the text content of a file.
+type parser func(data []byte) (title string, content string, err error)
+
+// maxDecompressedSize limits the data decompressed from a single file, so that small archives or pdf
+// streams which expand to huge amounts of data don't exhaust the memory.
+const maxDecompressedSize = 16 << 20
+
+// decompressionBudget is the amount of data which may still be decompressed from a file
+type decompressionBudget struct {
+	remaining int64
+}
+
+func newDecompressionBudget() *decompressionBudget {
+	return &decompressionBudget{remaining: maxDecompressedSize}
+}
+
+// limit returns a reader which stops with io.EOF once the budget is used up
+func (b *decompressionBudget) limit(r io.Reader) io.Reader {
+	return &budgetReader{r: r, b: b}
+}
+
+// exhausted tells if the budget is used up
+func (b *decompressionBudget) exhausted() bool {
+	return b.remaining <= 0
+}
+
+type budgetReader struct {
+	r io.Reader
+	b *decompressionBudget
+}
+
+func (br *budgetReader) Read(p []byte) (int, error) {
+	if br.b.exhausted() {
+		return 0, io.EOF
+	}
+	if int64(len(p)) > br.b.remaining {
+		p = p[:br.b.remaining]
+	}
+	n, err := br.r.Read(p)
+	br.b.remaining -= int64(n)
+	return n, err
+}
+
+// nativeParsers maps the supported mime types and file extensions to their parsers,
+// files which match none of them are handled as text if they look like text.
+var nativeParsers = []struct {
+	mimeTypes  []string
+	extensions []string
+	parse      parser
+}{
+	{
+		mimeTypes:  []string{"text/html", "application/xhtml+xml"},
+		extensions: []string{".html", ".htm", ".xhtml"},
+		parse:      parseHTML,
+	},
+	{
+		mimeTypes:  []string{"application/vnd.openxmlformats-officedocument.wordprocessingml.document"},
+		extensions: []string{".docx", ".docm", ".dotx"},
+		parse:      ooxmlParser("t", "word/document.xml"),
+	},
+	{
+		mimeTypes:  []string{"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"},
+		extensions: []string{".xlsx", ".xlsm", ".xltx"},
+		parse:      ooxmlParser("t", "xl/sharedStrings.xml", "xl/worksheets/sheet*.xml"),
+	},
+	{
+		mimeTypes:  []string{"application/vnd.openxmlformats-officedocument.presentationml.presentation"},
+		extensions: []string{".pptx", ".pptm", ".potx"},
+		parse:      ooxmlParser("t", "ppt/slides/slide*.xml"),
+	},
+	{
+		mimeTypes: []string{
+			"application/vnd.oasis.opendocument.text",
+			"application/vnd.oasis.opendocument.spreadsheet",
+			"application/vnd.oasis.opendocument.presentation",
+		},
+		extensions: []string{".odt", ".ods", ".odp", ".ott", ".ots", ".otp"},
+		parse:      parseODF,
+	},
+	{
+		mimeTypes:  []string{"application/pdf"},
+		extensions: []string{".pdf"},
+		parse:      parsePDF,
+	},
+	{
+		mimeTypes:  []string{"image/jpeg", "image/tiff"},
+		extensions: []string{".jpg", ".jpeg", ".tif", ".tiff"},
+		parse:      parseEXIF,
+	},
+}
+
+// textExtensions are the extensions of text files which aren't reported with a text mime type
+var textExtensions = map[string]bool{
+	".txt": true, ".md": true, ".markdown": true, ".rst": true, ".adoc": true, ".csv": true, ".tsv": true,
+	".log": true, ".json": true, ".yaml": true, ".yml": true, ".toml": true, ".ini": true, ".xml": true,
+	".go": true, ".py": true, ".js": true, ".ts": true, ".jsx": true, ".tsx": true, ".java": true,
+	".kt": true, ".c": true, ".h": true, ".cpp": true, ".hpp": true, ".cs": true, ".rb": true, ".php": true,
+	".rs": true, ".swift": true, ".scala": true, ".sh": true, ".bash": true, ".ps1": true, ".sql": true,
+	".css": true, ".scss": true, ".vue": true, ".lua": true, ".pl": true, ".r": true, ".tex": true,
+}
+
+// textMimeTypes are the mime types of text files which don't start with text/
+var textMimeTypes = map[string]bool{
+	"application/json":       true,
+	"application/xml":        true,
+	"application/javascript": true,
+	"application/x-sh":       true,
+	"application/x-yaml":     true,
+	"application/yaml":       true,
+	"application/sql":        true,
+	"application/x-tex":      true,
+}
+
+// Native is used to extract content from a resource without an external service,
+// it understands text, HTML, Office Open XML, OpenDocument and PDF files and the EXIF metadata of images.
+type Native struct {
+	*Basic
+	Retriever
+	maxFileSize uint64
+}
+
+// NewNativeExtractor creates a new Native instance.
+func NewNativeExtractor(gw gateway.GatewayAPIClient, logger log.Logger, cfg *config.Config) (*Native, error) {
+	basic, err := NewBasicExtractor(logger)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Native{
+		Basic:       basic,
+		Retriever:   newCS3Retriever(gw, logger, cfg.Extractor.CS3AllowInsecure),
+		maxFileSize: cfg.Extractor.Native.MaxFileSize,
+	}, nil
+}
+
+// Extract loads a resource from its underlying storage, parses it according to its type and processes the result into a Document.
+func (n Native) Extract(ctx context.Context, ri *provider.ResourceInfo) (Document, error) {
+	doc, err := n.Basic.Extract(ctx, ri)
+	if err != nil {
+		return doc, err
+	}
+
+	if ri.Size == 0 {
+		return doc, nil
+	}
+
+	if ri.Type != provider.ResourceType_RESOURCE_TYPE_FILE {
+		return doc, nil
+	}
+
+	if n.maxFileSize > 0 && ri.Size > n.maxFileSize {
+		n.logger.Debug().Str("name", ri.Name).Uint64("size", ri.Size).Msg("file too big, skipping content extraction")
+		return doc, nil
+	}
+
+	parse, isText := nativeParser(ri)
+	if parse == nil && !isText {
+		return doc, nil
+	}
+
+	data, err := n.Retrieve(ctx, ri.Id)
+	if err != nil {
+		return doc, err
+	}
+	defer data.Close()
+
+	r := io.Reader(data)
+	if n.maxFileSize > 0 {
+		r = io.LimitReader(data, int64(n.maxFileSize))
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return doc, err
+	}
+
+	if parse == nil {
+		parse = parseText
+	}
+	title, content, err := parse(b)
+	if err != nil {
+		return doc, err
+	}
+
+	doc.Title = strings.TrimSpace(title)
+	doc.Content = strings.TrimSpace(content)
+
+	return doc, nil
+}
+
+// nativeParser returns the parser for a resource or whether it is a text file.
+func nativeParser(ri *provider.ResourceInfo) (parser, bool) {
+	name := ri.Name
+	if name == "" {
+		name = ri.Path
+	}
+	ext := strings.ToLower(path.Ext(name))
+	mimeType := strings.ToLower(strings.TrimSpace(strings.Split(ri.MimeType, ";")[0]))
+
+	for _, p := range nativeParsers {
+		for _, m := range p.mimeTypes {
+			if m == mimeType {
+				return p.parse, false
+			}
+		}
+	}
+	for _, p := range nativeParsers {
+		for _, e := range p.extensions {
+			if e == ext {
+				return p.parse, false
+			}
+		}
+	}
+
+	return nil, strings.HasPrefix(mimeType, "text/") || textMimeTypes[mimeType] || textExtensions[ext]
+}
+
+// appendText appends a piece of text to a builder, separated by a space from the previous text
+func appendText(sb *strings.Builder, text string) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+	if sb.Len() > 0 {
+		last := sb.String()[sb.Len()-1]
+		if last != ' ' && last != '\n' {
+			sb.WriteByte(' ')
+		}
+	}
+	sb.WriteString(text)
+}
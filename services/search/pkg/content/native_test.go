@@ -0,0 +1,269 @@
+package content_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/owncloud/ocis/v2/ocis-pkg/log"
+	conf "github.com/owncloud/ocis/v2/services/search/pkg/config/defaults"
+	"github.com/owncloud/ocis/v2/services/search/pkg/content"
+	contentMocks "github.com/owncloud/ocis/v2/services/search/pkg/content/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+var _ = Describe("Native", func() {
+	Describe("extract", func() {
+		var (
+			body   []byte
+			native *content.Native
+		)
+
+		extract := func(name, mimeType string) content.Document {
+			doc, err := native.Extract(context.TODO(), &provider.ResourceInfo{
+				Type:     provider.ResourceType_RESOURCE_TYPE_FILE,
+				Name:     name,
+				MimeType: mimeType,
+				Size:     uint64(len(body)),
+			})
+			Expect(err).ToNot(HaveOccurred())
+			return doc
+		}
+
+		BeforeEach(func() {
+			body = nil
+
+			cfg := conf.DefaultConfig()
+			cfg.Extractor.Native.MaxFileSize = 1 << 20
+
+			var err error
+			native, err = content.NewNativeExtractor(nil, log.NewLogger(), cfg)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(native).ToNot(BeNil())
+
+			retriever := &contentMocks.Retriever{}
+			retriever.On("Retrieve", mock.Anything, mock.Anything).Return(func(context.Context, *provider.ResourceId) io.ReadCloser {
+				return io.NopCloser(bytes.NewReader(body))
+			}, nil)
+
+			native.Retriever = retriever
+		})
+
+		It("skips non file resources", func() {
+			doc, err := native.Extract(context.TODO(), &provider.ResourceInfo{Name: "foo.txt", Size: 1})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(doc.Content).To(Equal(""))
+		})
+
+		It("skips files which are too big", func() {
+			body = []byte("any body")
+
+			doc, err := native.Extract(context.TODO(), &provider.ResourceInfo{
+				Type: provider.ResourceType_RESOURCE_TYPE_FILE,
+				Name: "foo.txt",
+				Size: 2 << 20,
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(doc.Content).To(Equal(""))
+		})
+
+		It("skips unknown formats", func() {
+			body = []byte("any body")
+
+			doc := extract("foo.bin", "application/octet-stream")
+			Expect(doc.Content).To(Equal(""))
+		})
+
+		It("adds the content of text files", func() {
+			body = []byte("# Heading\n\nany body")
+
+			Expect(extract("readme.md", "").Content).To(Equal("# Heading\n\nany body"))
+			Expect(extract("main.go", "text/x-go").Content).To(Equal("# Heading\n\nany body"))
+		})
+
+		It("ignores binary files which claim to be text", func() {
+			body = []byte("any\x00body")
+
+			Expect(extract("foo.txt", "text/plain").Content).To(Equal(""))
+		})
+
+		It("adds the title and the visible text of html files", func() {
+			body = []byte(`<html><head><title>The title</title><style>p { color: red; }</style></head>
+<body><h1>Heading</h1><p>any &amp; body</p><script>alert("hidden")</script></body></html>`)
+
+			doc := extract("index.html", "text/html")
+			Expect(doc.Title).To(Equal("The title"))
+			Expect(doc.Content).To(Equal("Heading any & body"))
+		})
+
+		It("adds the title and the text of docx files", func() {
+			body = zipArchive(map[string]string{
+				"docProps/core.xml": `<cp:coreProperties xmlns:cp="cp" xmlns:dc="dc"><dc:title>The title</dc:title></cp:coreProperties>`,
+				"word/document.xml": `<w:document xmlns:w="w"><w:body><w:p><w:r><w:t>any </w:t></w:r><w:r><w:t>body</w:t></w:r></w:p><w:p><w:r><w:t>second paragraph</w:t></w:r></w:p></w:body></w:document>`,
+			})
+
+			doc := extract("foo.docx", "application/vnd.openxmlformats-officedocument.wordprocessingml.document")
+			Expect(doc.Title).To(Equal("The title"))
+			Expect(doc.Content).To(Equal("any body\nsecond paragraph"))
+		})
+
+		It("adds the text of xlsx and pptx files", func() {
+			body = zipArchive(map[string]string{
+				"xl/sharedStrings.xml":     `<sst><si><t>first cell</t></si><si><t>second cell</t></si></sst>`,
+				"xl/worksheets/sheet1.xml": `<worksheet><sheetData><row><c t="inlineStr"><is><t>inline cell</t></is></c></row></sheetData></worksheet>`,
+			})
+			Expect(extract("foo.xlsx", "").Content).To(Equal("first cell\nsecond cell\ninline cell"))
+
+			body = zipArchive(map[string]string{
+				"ppt/slides/slide1.xml": `<p:sld xmlns:p="p" xmlns:a="a"><a:p><a:r><a:t>slide text</a:t></a:r></a:p></p:sld>`,
+			})
+			Expect(extract("foo.pptx", "").Content).To(Equal("slide text"))
+		})
+
+		It("adds the title and the text of odf files", func() {
+			body = zipArchive(map[string]string{
+				"meta.xml":    `<office:document-meta xmlns:office="office" xmlns:dc="dc"><office:meta><dc:title>The title</dc:title></office:meta></office:document-meta>`,
+				"content.xml": `<office:document-content xmlns:office="office" xmlns:text="text"><office:body><office:text><text:h>Heading</text:h><text:p>any<text:s/>body</text:p></office:text></office:body></office:document-content>`,
+			})
+
+			doc := extract("foo.odt", "application/vnd.oasis.opendocument.text")
+			Expect(doc.Title).To(Equal("The title"))
+			Expect(doc.Content).To(Equal("Heading\nany body"))
+		})
+
+		It("adds the title and the text of pdf files", func() {
+			body = pdfDocument("The title",
+				"BT /F1 12 Tf 72 712 Td (any \\(pdf\\) body) Tj ET",
+				"BT /F1 12 Tf 72 700 Td [(compr) 10 (essed) -250 (text)] TJ ET",
+			)
+
+			doc := extract("foo.pdf", "application/pdf")
+			Expect(doc.Title).To(Equal("The title"))
+			Expect(doc.Content).To(Equal("any (pdf) body\n\ncompressed text"))
+		})
+
+		It("limits the text decompressed from office files", func() {
+			body = zipArchive(map[string]string{
+				"word/document.xml": `<w:document xmlns:w="w"><w:body><w:p><w:r><w:t>` + strings.Repeat("a", 20<<20) + `</w:t></w:r></w:p></w:body></w:document>`,
+			})
+			Expect(len(body)).To(BeNumerically("<", 1<<20))
+
+			doc := extract("foo.docx", "")
+			Expect(doc.Content).ToNot(BeEmpty())
+			Expect(len(doc.Content)).To(BeNumerically("<", 16<<20))
+		})
+
+		It("limits the text decompressed from pdf files", func() {
+			body = pdfDocument("The title", "", "BT ("+strings.Repeat("a", 20<<20)+") Tj ET")
+			Expect(len(body)).To(BeNumerically("<", 1<<20))
+
+			doc := extract("foo.pdf", "application/pdf")
+			Expect(doc.Title).To(Equal("The title"))
+			Expect(len(doc.Content)).To(BeNumerically("<", 16<<20))
+		})
+
+		It("parses pdf files with many streams in linear time", func() {
+			for _, b := range [][]byte{
+				// streams without objects
+				append([]byte("%PDF-1.4\n"), bytes.Repeat([]byte("xstream\nendstream\n"), 50000)...),
+				// streams sharing the same end
+				append(append([]byte("%PDF-1.4\n"), bytes.Repeat([]byte("1 0 obj <<>> stream\n(a) Tj\n"), 30000)...), "endstream\n"...),
+			} {
+				body = b
+				Expect(len(body)).To(BeNumerically("<", 1<<20))
+
+				start := time.Now()
+				extract("foo.pdf", "application/pdf")
+				Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+			}
+		})
+
+		It("adds the exif metadata of jpeg files", func() {
+			body = jpegWithEXIF(map[uint16]string{
+				0x010e: "A lighthouse",
+				0x010f: "Canon",
+				0x0110: "EOS 5D",
+			})
+
+			doc := extract("foo.jpg", "image/jpeg")
+			Expect(doc.Title).To(Equal("A lighthouse"))
+			Expect(doc.Content).To(Equal("A lighthouse Canon EOS 5D"))
+		})
+	})
+})
+
+// zipArchive returns a zip archive with the given files
+func zipArchive(files map[string]string) []byte {
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	for _, name := range []string{"docProps/core.xml", "meta.xml", "word/document.xml", "content.xml", "xl/sharedStrings.xml", "xl/worksheets/sheet1.xml", "ppt/slides/slide1.xml"} {
+		if data, ok := files[name]; ok {
+			w, err := zw.Create(name)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = w.Write([]byte(data))
+			Expect(err).ToNot(HaveOccurred())
+		}
+	}
+	Expect(zw.Close()).To(Succeed())
+	return buf.Bytes()
+}
+
+// pdfDocument returns a pdf with an uncompressed and a flate compressed content stream
+func pdfDocument(title, plain, compressed string) []byte {
+	z := &bytes.Buffer{}
+	zw := zlib.NewWriter(z)
+	_, _ = zw.Write([]byte(compressed))
+	Expect(zw.Close()).To(Succeed())
+
+	buf := &bytes.Buffer{}
+	buf.WriteString("%PDF-1.4\n")
+	fmt.Fprintf(buf, "1 0 obj\n<< /Title (%s) >>\nendobj\n", title)
+	fmt.Fprintf(buf, "2 0 obj\n<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>\nendobj\n")
+	fmt.Fprintf(buf, "3 0 obj\n<< /Length %d >>\nstream\n%s\nendstream\nendobj\n", len(plain), plain)
+	fmt.Fprintf(buf, "4 0 obj\n<< /Length %d /Filter /FlateDecode >>\nstream\n", z.Len())
+	buf.Write(z.Bytes())
+	buf.WriteString("\nendstream\nendobj\ntrailer\n<< /Info 1 0 R >>\n%%EOF\n")
+	return buf.Bytes()
+}
+
+// jpegWithEXIF returns the start of a jpeg with an exif segment which contains the given ascii tags
+func jpegWithEXIF(tags map[uint16]string) []byte {
+	order := binary.BigEndian
+	ids := []uint16{0x010e, 0x010f, 0x0110}
+
+	tiff := &bytes.Buffer{}
+	tiff.WriteString("MM")
+	_ = binary.Write(tiff, order, uint16(42))
+	_ = binary.Write(tiff, order, uint32(8))
+	_ = binary.Write(tiff, order, uint16(len(ids)))
+
+	dataOffset := 8 + 2 + len(ids)*12 + 4
+	data := &bytes.Buffer{}
+	for _, id := range ids {
+		value := tags[id] + "\x00"
+		_ = binary.Write(tiff, order, id)
+		_ = binary.Write(tiff, order, uint16(2))
+		_ = binary.Write(tiff, order, uint32(len(value)))
+		_ = binary.Write(tiff, order, uint32(dataOffset+data.Len()))
+		data.WriteString(value)
+	}
+	_ = binary.Write(tiff, order, uint32(0))
+	tiff.Write(data.Bytes())
+
+	jpeg := &bytes.Buffer{}
+	jpeg.Write([]byte{0xff, 0xd8, 0xff, 0xe1})
+	_ = binary.Write(jpeg, order, uint16(2+6+tiff.Len()))
+	jpeg.WriteString("Exif\x00\x00")
+	jpeg.Write(tiff.Bytes())
+	jpeg.Write([]byte{0xff, 0xda})
+	return jpeg.Bytes()
+}
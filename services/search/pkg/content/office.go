@@ -0,0 +1,118 @@
+package content
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"path"
+	"strings"
+)
+
+// xmlBlockElements are the paragraphs, headings and shared strings of the office formats, a line ends after them
+var xmlBlockElements = map[string]bool{"p": true, "h": true, "si": true}
+
+// ooxmlParser returns a parser for Office Open XML documents (docx, xlsx, pptx) which extracts the
+// text of the text elements in the parts matching the patterns.
+func ooxmlParser(textElement string, patterns ...string) parser {
+	return func(data []byte) (string, string, error) {
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return "", "", err
+		}
+
+		budget := newDecompressionBudget()
+		title, err := zipXMLText(zr, budget, "title", "docProps/core.xml")
+		if err != nil {
+			return "", "", err
+		}
+		content, err := zipXMLText(zr, budget, textElement, patterns...)
+		return title, content, err
+	}
+}
+
+// parseODF returns the title and the text of OpenDocument text documents, spreadsheets and presentations.
+func parseODF(data []byte) (string, string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", "", err
+	}
+
+	budget := newDecompressionBudget()
+	title, err := zipXMLText(zr, budget, "title", "meta.xml")
+	if err != nil {
+		return "", "", err
+	}
+	content, err := zipXMLText(zr, budget, "body", "content.xml")
+	return title, content, err
+}
+
+// zipXMLText returns the text of the xml files in a zip archive which match one of the patterns,
+// in the order of the patterns and the archive. The extraction stops with the text found so far
+// once the decompression budget is used up.
+func zipXMLText(zr *zip.Reader, budget *decompressionBudget, textElement string, patterns ...string) (string, error) {
+	var sb strings.Builder
+	for _, pattern := range patterns {
+		for _, f := range zr.File {
+			if budget.exhausted() {
+				return sb.String(), nil
+			}
+			if ok, _ := path.Match(pattern, f.Name); !ok {
+				continue
+			}
+
+			r, err := f.Open()
+			if err != nil {
+				return "", err
+			}
+			err = xmlText(&sb, budget.limit(r), textElement)
+			r.Close()
+			if err != nil && !budget.exhausted() {
+				return "", err
+			}
+		}
+	}
+	return sb.String(), nil
+}
+
+// xmlText writes the character data inside of the text elements to the builder. Block elements
+// end a line, the space, tab and line break elements of the office formats become spaces.
+func xmlText(sb *strings.Builder, r io.Reader, textElement string) error {
+	d := xml.NewDecoder(r)
+	d.Strict = false
+
+	depth := 0
+	for {
+		t, err := d.Token()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch t := t.(type) {
+		case xml.StartElement:
+			switch {
+			case t.Name.Local == textElement:
+				depth++
+			case depth > 0 && (t.Name.Local == "s" || t.Name.Local == "tab" || t.Name.Local == "br" || t.Name.Local == "line-break"):
+				sb.WriteByte(' ')
+			}
+		case xml.EndElement:
+			switch {
+			case t.Name.Local == textElement && depth > 0:
+				depth--
+			case xmlBlockElements[t.Name.Local] && sb.Len() > 0:
+				if s := sb.String(); s[len(s)-1] != '\n' {
+					sb.WriteByte('\n')
+				}
+			}
+		case xml.CharData:
+			if depth > 0 {
+				sb.Write(t)
+			}
+		}
+	}
+}
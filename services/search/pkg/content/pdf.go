@@ -0,0 +1,251 @@
+package content
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/hex"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+var (
+	pdfTitle = regexp.MustCompile(`/Title\s*(\(|<[0-9A-Fa-f\s]*>)`)
+	// images, fonts, xref and object streams and the xmp metadata don't contain text operators
+	pdfBinaryStream = regexp.MustCompile(`/(Image|XRef|ObjStm|Metadata|Length1|Length2|Type1C|CIDFontType0C|OpenType)\b`)
+)
+
+// pdfMaxDictionary is how far before a stream its dictionary is looked for. Dictionaries of content streams are
+// short, the limit keeps crafted documents with many streams and no objects from being scanned over and over.
+const pdfMaxDictionary = 4 << 10
+
+// parsePDF returns the title and the text of a PDF document. This is best effort, only the text of content
+// streams which are uncompressed or flate compressed and use a simple font encoding can be extracted.
+func parsePDF(data []byte) (string, string, error) {
+	if !bytes.HasPrefix(data, []byte("%PDF-")) || bytes.Contains(data, []byte("/Encrypt")) {
+		return "", "", nil
+	}
+
+	var title string
+	if m := pdfTitle.FindSubmatchIndex(data); m != nil {
+		if data[m[2]] == '(' {
+			title, _ = pdfLiteral(data, m[2])
+		} else {
+			title = pdfHex(data[m[2]+1 : m[3]-1])
+		}
+	}
+
+	var sb strings.Builder
+	budget := newDecompressionBudget()
+	for pos := 0; !budget.exhausted(); {
+		i := bytes.Index(data[pos:], []byte("stream"))
+		if i < 0 {
+			break
+		}
+		i += pos
+		// the dictionary is between the previous keyword and the stream
+		from := pos
+		if i-pdfMaxDictionary > from {
+			from = i - pdfMaxDictionary
+		}
+		pos = i + len("stream")
+		if i > 0 && data[i-1] == 'd' {
+			// endstream
+			continue
+		}
+
+		dict := data[from:i]
+		if obj := bytes.LastIndex(dict, []byte(" obj")); obj >= 0 {
+			dict = dict[obj:]
+		}
+		if pdfBinaryStream.Match(dict) {
+			continue
+		}
+
+		stream := data[pos:]
+		stream = bytes.TrimPrefix(stream, []byte("\r"))
+		stream = bytes.TrimPrefix(stream, []byte("\n"))
+		end := bytes.Index(stream, []byte("endstream"))
+		if end < 0 {
+			break
+		}
+		// the content of the stream is not searched for further streams
+		pos = len(data) - len(stream) + end + len("endstream")
+		stream = stream[:end]
+
+		if bytes.Contains(dict, []byte("/FlateDecode")) {
+			zr, err := zlib.NewReader(bytes.NewReader(stream))
+			if err != nil {
+				continue
+			}
+			// a truncated stream still yields the text decoded so far
+			stream, _ = io.ReadAll(budget.limit(zr))
+		} else if bytes.Contains(dict, []byte("/Filter")) {
+			continue
+		}
+
+		pdfText(&sb, stream)
+	}
+
+	return title, sb.String(), nil
+}
+
+// pdfText writes the strings shown by the text operators of a content stream to the builder
+func pdfText(sb *strings.Builder, stream []byte) {
+	inText, inArray := false, false
+	for i := 0; i < len(stream); {
+		c := stream[i]
+		switch {
+		case c == '%':
+			for i < len(stream) && stream[i] != '\n' && stream[i] != '\r' {
+				i++
+			}
+		case c == '(':
+			var s string
+			s, i = pdfLiteral(stream, i)
+			if inText {
+				sb.WriteString(s)
+			}
+		case c == '<' && i+1 < len(stream) && stream[i+1] == '<':
+			i += 2
+		case c == '<':
+			end := bytes.IndexByte(stream[i:], '>')
+			if end < 0 {
+				return
+			}
+			if inText {
+				sb.WriteString(pdfHex(stream[i+1 : i+end]))
+			}
+			i += end + 1
+		case c == '[':
+			inArray = true
+			i++
+		case c == ']':
+			inArray = false
+			i++
+		case isPDFDelimiter(c) || unicode.IsSpace(rune(c)):
+			i++
+		default:
+			start := i
+			for i < len(stream) && !isPDFDelimiter(stream[i]) && !unicode.IsSpace(rune(stream[i])) {
+				i++
+			}
+			token := string(stream[start:i])
+			switch token {
+			case "BT":
+				inText = true
+			case "ET":
+				inText = false
+				sb.WriteByte('\n')
+			case "Td", "TD", "T*", "'", "\"":
+				if inText {
+					sb.WriteByte('\n')
+				}
+			default:
+				// a big negative offset between the strings of an array moves to the next word
+				if n, err := strconv.ParseFloat(token, 64); err == nil && inText && inArray && n <= -200 {
+					sb.WriteByte(' ')
+				}
+			}
+		}
+	}
+}
+
+// pdfLiteral decodes the literal string starting at the opening parenthesis and returns it with the position after it
+func pdfLiteral(data []byte, start int) (string, int) {
+	var b []byte
+	depth := 0
+	i := start
+	for ; i < len(data); i++ {
+		c := data[i]
+		switch c {
+		case '(':
+			depth++
+			if depth == 1 {
+				continue
+			}
+		case ')':
+			depth--
+			if depth == 0 {
+				return pdfDecode(b), i + 1
+			}
+		case '\\':
+			i++
+			if i >= len(data) {
+				break
+			}
+			switch e := data[i]; e {
+			case 'n':
+				b = append(b, '\n')
+			case 'r':
+				b = append(b, '\r')
+			case 't':
+				b = append(b, '\t')
+			case 'b', 'f':
+			case '\r':
+				if i+1 < len(data) && data[i+1] == '\n' {
+					i++
+				}
+			case '\n':
+			default:
+				if e >= '0' && e <= '7' {
+					n := 0
+					for j := 0; j < 3 && i < len(data) && data[i] >= '0' && data[i] <= '7'; j++ {
+						n = n*8 + int(data[i]-'0')
+						i++
+					}
+					i--
+					b = append(b, byte(n))
+					continue
+				}
+				b = append(b, e)
+			}
+			continue
+		}
+		b = append(b, c)
+	}
+	return pdfDecode(b), i
+}
+
+// pdfHex decodes a hex string, strings which don't decode to text (e.g. glyph ids) are dropped
+func pdfHex(s []byte) string {
+	s = bytes.Join(bytes.Fields(s), nil)
+	if len(s)%2 == 1 {
+		s = append(s, '0')
+	}
+	b, err := hex.DecodeString(string(s))
+	if err != nil {
+		return ""
+	}
+	text := pdfDecode(b)
+	for _, r := range text {
+		if !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+			return ""
+		}
+	}
+	return text
+}
+
+// pdfDecode decodes UTF-16 strings with a byte order mark, everything else is read as latin-1
+func pdfDecode(b []byte) string {
+	if len(b) >= 2 && b[0] == 0xfe && b[1] == 0xff {
+		u := make([]uint16, 0, len(b)/2)
+		for i := 2; i+1 < len(b); i += 2 {
+			u = append(u, uint16(b[i])<<8|uint16(b[i+1]))
+		}
+		return string(utf16.Decode(u))
+	}
+
+	r := make([]rune, len(b))
+	for i, c := range b {
+		r[i] = rune(c)
+	}
+	return string(r)
+}
+
+func isPDFDelimiter(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
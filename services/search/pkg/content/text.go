@@ -0,0 +1,76 @@
+package content
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// parseText returns the content of plain text files like markdown or source code,
+// binary files which only claim to be text are ignored.
+func parseText(data []byte) (string, string, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	head := data
+	if len(head) > 512 {
+		head = head[:512]
+	}
+	if bytes.IndexByte(head, 0) >= 0 {
+		return "", "", nil
+	}
+
+	if utf8.Valid(data) {
+		return "", string(data), nil
+	}
+	return "", strings.ToValidUTF8(string(data), " "), nil
+}
+
+// parseHTML returns the title and the visible text of an HTML document.
+func parseHTML(data []byte) (string, string, error) {
+	var (
+		title, content strings.Builder
+		inTitle        bool
+		hidden         int
+	)
+
+	z := html.NewTokenizer(bytes.NewReader(data))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			if errors.Is(z.Err(), io.EOF) {
+				return title.String(), content.String(), nil
+			}
+			return title.String(), content.String(), z.Err()
+		case html.StartTagToken:
+			name, _ := z.TagName()
+			switch atom.Lookup(name) {
+			case atom.Title:
+				inTitle = true
+			case atom.Script, atom.Style, atom.Noscript, atom.Template:
+				hidden++
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			switch atom.Lookup(name) {
+			case atom.Title:
+				inTitle = false
+			case atom.Script, atom.Style, atom.Noscript, atom.Template:
+				if hidden > 0 {
+					hidden--
+				}
+			}
+		case html.TextToken:
+			switch {
+			case inTitle:
+				appendText(&title, string(z.Text()))
+			case hidden == 0:
+				appendText(&content, string(z.Text()))
+			}
+		}
+	}
+}
@@ -3,6 +3,7 @@ package engine
 import (
 	"context"
 	"errors"
+	"fmt"
 	"math"
 	"path"
 	"path/filepath"
@@ -18,6 +19,7 @@ import (
 	"github.com/blevesearch/bleve/v2/analysis/token/lowercase"
 	"github.com/blevesearch/bleve/v2/analysis/tokenizer/single"
 	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/search"
 	"github.com/blevesearch/bleve/v2/search/query"
 	storageProvider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
 	"github.com/cs3org/reva/v2/pkg/storagespace"
@@ -25,6 +27,8 @@ import (
 	searchMessage "github.com/owncloud/ocis/v2/protogen/gen/ocis/messages/search/v0"
 	searchService "github.com/owncloud/ocis/v2/protogen/gen/ocis/services/search/v0"
 	"github.com/owncloud/ocis/v2/services/search/pkg/content"
+	"github.com/owncloud/ocis/v2/services/search/pkg/query/ast"
+	"github.com/owncloud/ocis/v2/services/search/pkg/query/kql"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -110,20 +114,27 @@ func BuildBleveMapping() (mapping.IndexMapping, error) {
 // Search executes a search request operation within the index.
 // Returns a SearchIndexResponse object or an error.
 func (b *Bleve) Search(_ context.Context, sir *searchService.SearchIndexRequest) (*searchService.SearchIndexResponse, error) {
-	q := bleve.NewConjunctionQuery(
+	tree, err := kql.Parse(sir.Query)
+	if err != nil {
+		return nil, err
+	}
+	q, err := compileBleveQuery(tree)
+	if err != nil {
+		return nil, err
+	}
+
+	bq := bleve.NewConjunctionQuery(
 		// Skip documents that have been marked as deleted
 		&query.BoolFieldQuery{
 			Bool:     false,
 			FieldVal: "Deleted",
 		},
-		&query.QueryStringQuery{
-			Query: formatQuery(sir.Query),
-		},
+		q,
 	)
 
 	if sir.Ref != nil {
-		q.Conjuncts = append(
-			q.Conjuncts,
+		bq.Conjuncts = append(
+			bq.Conjuncts,
 			&query.TermQuery{
 				FieldVal: "RootID",
 				Term: storagespace.FormatResourceID(
@@ -141,7 +152,7 @@ func (b *Bleve) Search(_ context.Context, sir *searchService.SearchIndexRequest)
 		)
 	}
 
-	bleveReq := bleve.NewSearchRequest(q)
+	bleveReq := bleve.NewSearchRequest(bq)
 
 	switch {
 	case sir.PageSize == -1:
@@ -152,6 +163,20 @@ func (b *Bleve) Search(_ context.Context, sir *searchService.SearchIndexRequest)
 		bleveReq.Size = int(sir.PageSize)
 	}
 
+	if err := CheckFacets(sir.Facets); err != nil {
+		return nil, err
+	}
+	facets := uniqueFacets(sir.Facets)
+	now := time.Now()
+	for _, name := range facets {
+		bleveReq.AddFacet(name, bleveFacetRequest(name, now))
+	}
+
+	bleveReq.Highlight = bleve.NewHighlight()
+	for _, field := range highlightFields {
+		bleveReq.Highlight.AddField(field)
+	}
+
 	bleveReq.Fields = []string{"*"}
 	res, err := b.index.Search(bleveReq)
 	if err != nil {
@@ -187,6 +212,7 @@ func (b *Bleve) Search(_ context.Context, sir *searchService.SearchIndexRequest)
 				Deleted:  getValue[bool](hit.Fields, "Deleted"),
 				Tags:     getSliceValue[string](hit.Fields, "Tags"),
 			},
+			Highlights: highlights(hit.Fragments),
 		}
 
 		if mtime, err := time.Parse(time.RFC3339, getValue[string](hit.Fields, "Mtime")); err == nil {
@@ -196,12 +222,73 @@ func (b *Bleve) Search(_ context.Context, sir *searchService.SearchIndexRequest)
 		matches = append(matches, match)
 	}
 
+	counts := facetCounts{}
+	for _, name := range facets {
+		if fr, ok := res.Facets[name]; ok {
+			countBleveFacet(counts, name, fr)
+		}
+	}
+
 	return &searchService.SearchIndexResponse{
 		Matches:      matches,
 		TotalMatches: int32(res.Total),
+		Facets:       counts.facets(facets),
 	}, nil
 }
 
+// bleveFacetRequest returns the request of a facet, relative dates are relative to now
+func bleveFacetRequest(name string, now time.Time) *bleve.FacetRequest {
+	switch name {
+	case FacetMimeType:
+		return bleve.NewFacetRequest("MimeType", maxFacetMimeTypes)
+	case FacetTag:
+		return bleve.NewFacetRequest("Tags", maxFacetTerms)
+	case FacetSpace:
+		return bleve.NewFacetRequest("RootID", maxFacetTerms)
+	case FacetSize:
+		fr := bleve.NewFacetRequest("Size", len(kql.SizeRanges))
+		for _, r := range kql.SizeRanges {
+			min := float64(r.Min)
+			var max *float64
+			if r.Max > 0 {
+				m := float64(r.Max)
+				max = &m
+			}
+			fr.AddNumericRange(r.Name, &min, max)
+		}
+		return fr
+	default:
+		ranges := dateRanges(now)
+		fr := bleve.NewFacetRequest("Mtime", len(ranges))
+		for _, r := range ranges {
+			fr.AddDateTimeRange(r.name, r.from, r.to)
+		}
+		return fr
+	}
+}
+
+// countBleveFacet adds the counts of a facet result, mime types are counted by their group
+func countBleveFacet(counts facetCounts, name string, fr *search.FacetResult) {
+	switch name {
+	case FacetMimeType:
+		for _, t := range fr.Terms.Terms() {
+			counts.add(name, mimeTypeGroup(t.Term), t.Count)
+		}
+	case FacetTag, FacetSpace:
+		for _, t := range fr.Terms.Terms() {
+			counts.add(name, t.Term, t.Count)
+		}
+	case FacetSize:
+		for _, r := range fr.NumericRanges {
+			counts.add(name, r.Name, r.Count)
+		}
+	case FacetMtime:
+		for _, r := range fr.DateRanges {
+			counts.add(name, r.Name, r.Count)
+		}
+	}
+}
+
 // Upsert indexes or stores Resource data fields.
 func (b *Bleve) Upsert(id string, r Resource) error {
 	return b.index.Index(id, r)
@@ -275,6 +362,56 @@ func (b *Bleve) DocCount() (uint64, error) {
 	return b.index.DocCount()
 }
 
+// Stats returns the number of resources per space and the size of the index on disk.
+func (b *Bleve) Stats() (*Stats, error) {
+	req := bleve.NewSearchRequest(bleve.NewMatchAllQuery())
+	req.Size = 0
+	req.AddFacet(FacetSpace, bleve.NewFacetRequest("RootID", math.MaxInt))
+	res, err := b.index.Search(req)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &Stats{Spaces: map[string]uint64{}}
+	if fr, ok := res.Facets[FacetSpace]; ok {
+		for _, t := range fr.Terms.Terms() {
+			stats.Spaces[t.Term] = uint64(t.Count)
+		}
+	}
+
+	// only persisted indexes have a size, in memory indexes don't report one
+	indexStats := getValue[map[string]interface{}](b.index.StatsMap(), "index")
+	stats.Size = getValue[uint64](indexStats, "CurOnDiskBytes")
+	return stats, nil
+}
+
+// Paths returns the paths of the resources of a space by their id. Resources which are marked as deleted
+// are left out.
+func (b *Bleve) Paths(rootID string) (map[string]string, error) {
+	req := bleve.NewSearchRequest(bleve.NewConjunctionQuery(
+		&query.BoolFieldQuery{
+			Bool:     false,
+			FieldVal: "Deleted",
+		},
+		&query.TermQuery{
+			FieldVal: "RootID",
+			Term:     rootID,
+		},
+	))
+	req.Size = math.MaxInt
+	req.Fields = []string{"Path"}
+	res, err := b.index.Search(req)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make(map[string]string, len(res.Hits))
+	for _, hit := range res.Hits {
+		paths[hit.ID] = getValue[string](hit.Fields, "Path")
+	}
+	return paths, nil
+}
+
 func (b *Bleve) getResource(id string) (*Resource, error) {
 	req := bleve.NewSearchRequest(bleve.NewDocIDQuery([]string{id}))
 	req.Fields = []string{"*"}
@@ -352,17 +489,119 @@ func (b *Bleve) setDeleted(id string, deleted bool) error {
 	return nil
 }
 
-func formatQuery(q string) string {
-	cq := q
-	fields := []string{"RootID", "Path", "ID", "Name", "Size", "Mtime", "MimeType", "Type"}
-	for _, field := range fields {
-		cq = strings.ReplaceAll(cq, strings.ToLower(field)+":", field+":")
+// compileBleveQuery translates the syntax tree of a query into a bleve query
+func compileBleveQuery(n ast.Node) (query.Query, error) {
+	switch n := n.(type) {
+	case *ast.And:
+		qs, err := compileBleveQueries(n.Nodes)
+		if err != nil {
+			return nil, err
+		}
+		return bleve.NewConjunctionQuery(qs...), nil
+	case *ast.Or:
+		qs, err := compileBleveQueries(n.Nodes)
+		if err != nil {
+			return nil, err
+		}
+		return bleve.NewDisjunctionQuery(qs...), nil
+	case *ast.Not:
+		q, err := compileBleveQuery(n.Node)
+		if err != nil {
+			return nil, err
+		}
+		bq := bleve.NewBooleanQuery()
+		bq.AddMustNot(q)
+		return bq, nil
+	case *ast.Text:
+		return compileBleveText(n), nil
+	case *ast.Number:
+		v := float64(n.Value)
+		var min, max *float64
+		inclusive := n.Operator != ast.OpGreater && n.Operator != ast.OpLess
+		switch n.Operator {
+		case ast.OpGreater, ast.OpGreaterOrEqual:
+			min = &v
+		case ast.OpLess, ast.OpLessOrEqual:
+			max = &v
+		default:
+			min, max = &v, &v
+		}
+		q := bleve.NewNumericRangeInclusiveQuery(min, max, &inclusive, &inclusive)
+		q.SetField(resourceFields[n.Field])
+		return q, nil
+	case *ast.DateTime:
+		var start, end time.Time
+		inclusive := n.Operator == ast.OpGreaterOrEqual || n.Operator == ast.OpLessOrEqual
+		switch n.Operator {
+		case ast.OpGreater, ast.OpGreaterOrEqual:
+			start = n.Value
+		default:
+			end = n.Value
+		}
+		q := bleve.NewDateRangeInclusiveQuery(start, end, &inclusive, &inclusive)
+		q.SetField(resourceFields[n.Field])
+		return q, nil
+	case *ast.Boolean:
+		q := bleve.NewBoolFieldQuery(n.Value)
+		q.SetField(resourceFields[n.Field])
+		return q, nil
 	}
+	return nil, fmt.Errorf("unsupported query node %T", n)
+}
 
-	if strings.Contains(cq, ":") {
-		return cq // Sophisticated field based search
+func compileBleveQueries(nodes []ast.Node) ([]query.Query, error) {
+	qs := make([]query.Query, 0, len(nodes))
+	for _, n := range nodes {
+		q, err := compileBleveQuery(n)
+		if err != nil {
+			return nil, err
+		}
+		qs = append(qs, q)
 	}
+	return qs, nil
+}
 
-	// this is a basic filename search
-	return "Name:*" + strings.ReplaceAll(strings.ToLower(cq), " ", `\ `) + "*"
+func compileBleveText(t *ast.Text) query.Query {
+	field := resourceFields[t.Field]
+	switch t.Field {
+	case ast.FieldContent:
+		if t.Phrase {
+			q := bleve.NewMatchPhraseQuery(t.Value)
+			q.SetField(field)
+			return q
+		}
+		if t.HasWildcards() {
+			q := bleve.NewWildcardQuery(strings.ToLower(t.Value))
+			q.SetField(field)
+			return q
+		}
+		q := bleve.NewMatchQuery(t.Value)
+		q.SetField(field)
+		return q
+	case ast.FieldID:
+		q := bleve.NewTermQuery(t.Value)
+		q.SetField(field)
+		return q
+	}
+
+	// names, tags and mime types are indexed as lower case keywords
+	if t.Field == "" {
+		// free text matches names containing it
+		value := strings.ToLower(t.Value)
+		if !t.HasWildcards() {
+			value = "*" + value + "*"
+		}
+		q := bleve.NewWildcardQuery(value)
+		q.SetField(resourceFields[ast.FieldName])
+		return q
+	}
+	if t.HasWildcards() {
+		// like with the previous query syntax, patterns are matched against the lower case keywords as given
+		q := bleve.NewWildcardQuery(t.Value)
+		q.SetField(field)
+		return q
+	}
+	q := bleve.NewTermQuery(strings.ToLower(t.Value))
+	q.SetField(field)
+	return q
 }
@@ -3,6 +3,7 @@ package engine_test
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/cs3org/reva/v2/pkg/storagespace"
 
@@ -103,12 +104,13 @@ var _ = Describe("Bleve", func() {
 				err := eng.Upsert(parentResource.ID, parentResource)
 				Expect(err).ToNot(HaveOccurred())
 
-				assertDocCount(rootResource.ID, "Tags:foo", 1)
-				assertDocCount(rootResource.ID, "Tags:bar", 1)
-				assertDocCount(rootResource.ID, "Tags:foo Tags:bar", 1)
+				assertDocCount(rootResource.ID, "tag:foo", 1)
+				assertDocCount(rootResource.ID, "tag:bar", 1)
+				assertDocCount(rootResource.ID, "tag:foo tag:bar", 1)
+				assertDocCount(rootResource.ID, "tag:foo tag:bar tag:baz", 1)
 				assertDocCount(rootResource.ID, "Tags:foo Tags:bar Tags:baz", 1)
-				assertDocCount(rootResource.ID, "Tags:foo Tags:bar Tags:baz", 1)
-				assertDocCount(rootResource.ID, "Tags:baz", 0)
+				assertDocCount(rootResource.ID, "tag:foo AND tag:bar AND tag:baz", 0)
+				assertDocCount(rootResource.ID, "tag:baz", 0)
 			})
 
 			It("finds files by size", func() {
@@ -116,12 +118,150 @@ var _ = Describe("Bleve", func() {
 				err := eng.Upsert(parentResource.ID, parentResource)
 				Expect(err).ToNot(HaveOccurred())
 
-				assertDocCount(rootResource.ID, "Size:12345", 1)
+				assertDocCount(rootResource.ID, "size:12345", 1)
+				assertDocCount(rootResource.ID, "size>1000", 1)
+				assertDocCount(rootResource.ID, "size<100000", 1)
+				assertDocCount(rootResource.ID, "size:10kb..20kb", 1)
+				assertDocCount(rootResource.ID, "size>=12345", 1)
+				assertDocCount(rootResource.ID, "size:12344", 0)
+				assertDocCount(rootResource.ID, "size<1000", 0)
+				assertDocCount(rootResource.ID, "size>100000", 0)
+				assertDocCount(rootResource.ID, "size>12345", 0)
+				assertDocCount(rootResource.ID, "size:tiny", 1)
+				assertDocCount(rootResource.ID, "size:small", 0)
 				assertDocCount(rootResource.ID, "Size:>1000", 1)
-				assertDocCount(rootResource.ID, "Size:<100000", 1)
-				assertDocCount(rootResource.ID, "Size:12344", 0)
 				assertDocCount(rootResource.ID, "Size:<1000", 0)
-				assertDocCount(rootResource.ID, "Size:>100000", 0)
+			})
+
+			It("finds files by modification date", func() {
+				parentResource.Document.Mtime = "2023-04-17T16:42:00Z"
+				err := eng.Upsert(parentResource.ID, parentResource)
+				Expect(err).ToNot(HaveOccurred())
+
+				assertDocCount(rootResource.ID, "mtime:2023-04-17", 1)
+				assertDocCount(rootResource.ID, "mtime>2023-04-16", 1)
+				assertDocCount(rootResource.ID, "mtime:2023-04-01..2023-04-30", 1)
+				assertDocCount(rootResource.ID, `mtime>="2023-04-17T16:42:00Z"`, 1)
+				assertDocCount(rootResource.ID, "mtime>2023-04-17", 0)
+				assertDocCount(rootResource.ID, "mtime<2023-04-17", 0)
+				assertDocCount(rootResource.ID, `Mtime:>="2023-04-17T16:42:00Z"`, 1)
+				assertDocCount(rootResource.ID, `Mtime:>"2023-04-17T16:42:00Z"`, 0)
+			})
+
+			It("finds files by mime type", func() {
+				parentResource.Document.MimeType = "application/pdf"
+				err := eng.Upsert(parentResource.ID, parentResource)
+				Expect(err).ToNot(HaveOccurred())
+
+				assertDocCount(rootResource.ID, "mimetype:application/pdf", 1)
+				assertDocCount(rootResource.ID, "mimetype:application/*", 1)
+				assertDocCount(rootResource.ID, "mimetype:image/*", 0)
+				assertDocCount(rootResource.ID, "mimetype:pdf", 1)
+				assertDocCount(rootResource.ID, "mimetype:document", 0)
+			})
+
+			It("finds files by content", func() {
+				parentResource.Document.Content = "The quick brown fox jumps over the lazy dog"
+				err := eng.Upsert(parentResource.ID, parentResource)
+				Expect(err).ToNot(HaveOccurred())
+
+				assertDocCount(rootResource.ID, "content:fox", 1)
+				assertDocCount(rootResource.ID, "content:jumping", 1)
+				assertDocCount(rootResource.ID, `content:"brown fox"`, 1)
+				assertDocCount(rootResource.ID, `content:"fox brown"`, 0)
+				assertDocCount(rootResource.ID, "content:cat", 0)
+			})
+
+			It("highlights the matched content and name", func() {
+				parentResource.Document.Content = "The quick brown fox jumps over the lazy dog"
+				err := eng.Upsert(parentResource.ID, parentResource)
+				Expect(err).ToNot(HaveOccurred())
+
+				matches := assertDocCount(rootResource.ID, "content:jumping", 1)
+				Expect(matches[0].Highlights).To(Equal([]string{"The quick brown fox <mark>jumps</mark> over the lazy dog"}))
+
+				matches = assertDocCount(rootResource.ID, "content:fox name:parent*", 1)
+				Expect(matches[0].Highlights).To(Equal([]string{
+					"The quick brown <mark>fox</mark> jumps over the lazy dog",
+					"<mark>parent d!r</mark>",
+				}))
+
+				matches = assertDocCount(rootResource.ID, "tag:foo OR name:parent*", 1)
+				Expect(matches[0].Highlights).To(Equal([]string{"<mark>parent d!r</mark>"}))
+			})
+
+			It("escapes the content of the highlights", func() {
+				parentResource.Document.Content = "<script>alert('fox')</script> the fox"
+				err := eng.Upsert(parentResource.ID, parentResource)
+				Expect(err).ToNot(HaveOccurred())
+
+				matches := assertDocCount(rootResource.ID, "content:fox", 1)
+				Expect(matches[0].Highlights).To(HaveLen(1))
+				Expect(matches[0].Highlights[0]).ToNot(ContainSubstring("<script>"))
+				Expect(matches[0].Highlights[0]).To(ContainSubstring("&lt;script&gt;"))
+			})
+
+			It("combines terms with boolean operators", func() {
+				parentResource.Document.Name = "foo.pdf"
+				parentResource.Document.Tags = []string{"important"}
+				err := eng.Upsert(parentResource.ID, parentResource)
+				Expect(err).ToNot(HaveOccurred())
+				err = eng.Upsert(childResource.ID, childResource)
+				Expect(err).ToNot(HaveOccurred())
+
+				assertDocCount(rootResource.ID, "name:foo.pdf OR name:child.pdf", 2)
+				assertDocCount(rootResource.ID, "name:foo.pdf AND name:child.pdf", 0)
+				assertDocCount(rootResource.ID, "NOT name:foo.pdf", 1)
+				assertDocCount(rootResource.ID, "-tag:important", 1)
+				assertDocCount(rootResource.ID, "(name:foo* OR name:bar*) tag:important", 1)
+				assertDocCount(rootResource.ID, "(name:foo* OR name:bar*) NOT tag:important", 0)
+			})
+		})
+
+		Context("with facets", func() {
+			BeforeEach(func() {
+				parentResource.Document.MimeType = "httpd/unix-directory"
+				parentResource.Document.Tags = []string{"foo"}
+				parentResource.Document.Mtime = time.Now().Format(time.RFC3339)
+				childResource.Document.MimeType = "application/pdf"
+				childResource.Document.Tags = []string{"foo", "bar"}
+				childResource.Document.Size = 20 << 10
+				childResource.Document.Mtime = "2020-01-01T00:00:00Z"
+				Expect(eng.Upsert(parentResource.ID, parentResource)).To(Succeed())
+				Expect(eng.Upsert(childResource.ID, childResource)).To(Succeed())
+			})
+
+			It("counts the matches by facet", func() {
+				res, err := eng.Search(ctx, &searchsvc.SearchIndexRequest{
+					Query:  "name:*",
+					Facets: []string{"mimetype", "tag", "space", "size", "mtime"},
+				})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(res.Facets).To(HaveLen(5))
+				Expect(facetValues(res.Facets[0])).To(Equal([]string{"folder=1", "pdf=1"}))
+				Expect(facetValues(res.Facets[1])).To(Equal([]string{"foo=2", "bar=1"}))
+				Expect(facetValues(res.Facets[2])).To(Equal([]string{"1$2!2=2"}))
+				Expect(facetValues(res.Facets[3])).To(Equal([]string{"empty=1", "small=1"}))
+				Expect(facetValues(res.Facets[4])).To(Equal([]string{"today=1", "this week=1", "this month=1", "this year=1"}))
+			})
+
+			It("only counts the matches of the query", func() {
+				res, err := eng.Search(ctx, &searchsvc.SearchIndexRequest{
+					Query:  "tag:bar",
+					Facets: []string{"tag"},
+				})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(res.Facets).To(HaveLen(1))
+				Expect(res.Facets[0].Name).To(Equal("tag"))
+				Expect(facetValues(res.Facets[0])).To(Equal([]string{"bar=1", "foo=1"}))
+			})
+
+			It("rejects unknown facets", func() {
+				_, err := eng.Search(ctx, &searchsvc.SearchIndexRequest{
+					Query:  "name:*",
+					Facets: []string{"owner"},
+				})
+				Expect(err).To(HaveOccurred())
 			})
 		})
 
@@ -132,6 +272,9 @@ var _ = Describe("Bleve", func() {
 				Expect(err).ToNot(HaveOccurred())
 
 				assertDocCount(rootResource.ID, `Name:foo\ o*`, 1)
+				assertDocCount(rootResource.ID, `name:"foo oo.pdf"`, 1)
+				assertDocCount(rootResource.ID, `"foo oo"`, 1)
+				assertDocCount(rootResource.ID, `foo oo`, 1)
 			})
 
 			It("finds files by digits in the filename", func() {
@@ -139,7 +282,7 @@ var _ = Describe("Bleve", func() {
 				err := eng.Upsert(parentResource.ID, parentResource)
 				Expect(err).ToNot(HaveOccurred())
 
-				assertDocCount(rootResource.ID, "Name:1234*", 1)
+				assertDocCount(rootResource.ID, "name:1234*", 1)
 			})
 
 			It("filters hidden files", func() {
@@ -147,8 +290,8 @@ var _ = Describe("Bleve", func() {
 				err := eng.Upsert(childResource.ID, childResource)
 				Expect(err).ToNot(HaveOccurred())
 
-				assertDocCount(rootResource.ID, "Hidden:T", 1)
-				assertDocCount(rootResource.ID, "Hidden:F", 0)
+				assertDocCount(rootResource.ID, "hidden:true", 1)
+				assertDocCount(rootResource.ID, "hidden:false", 0)
 			})
 
 			Context("with a file in the root of the space", func() {
@@ -157,8 +300,8 @@ var _ = Describe("Bleve", func() {
 					err := eng.Upsert(parentResource.ID, parentResource)
 					Expect(err).ToNot(HaveOccurred())
 
-					assertDocCount(rootResource.ID, "Name:foo.pdf", 1)
-					assertDocCount("9$8!7", "Name:foo.pdf", 0)
+					assertDocCount(rootResource.ID, "name:foo.pdf", 1)
+					assertDocCount("9$8!7", "name:foo.pdf", 0)
 				})
 			})
 
@@ -167,7 +310,7 @@ var _ = Describe("Bleve", func() {
 				err := eng.Upsert(parentResource.ID, parentResource)
 				Expect(err).ToNot(HaveOccurred())
 
-				assertDocCount(rootResource.ID, "Name:bar.pdf", 1)
+				assertDocCount(rootResource.ID, "name:bar.pdf", 1)
 				assertDocCount(rootResource.ID, "Unknown:field", 0)
 			})
 
@@ -176,7 +319,7 @@ var _ = Describe("Bleve", func() {
 				err := eng.Upsert(parentResource.ID, parentResource)
 				Expect(err).ToNot(HaveOccurred())
 
-				res, err := doSearch(rootResource.ID, "Name:bar*")
+				res, err := doSearch(rootResource.ID, "name:bar*")
 				Expect(err).ToNot(HaveOccurred())
 				Expect(res.TotalMatches).To(Equal(int32(1)))
 			})
@@ -189,7 +332,7 @@ var _ = Describe("Bleve", func() {
 				err := eng.Upsert(parentResource.ID, parentResource)
 				Expect(err).ToNot(HaveOccurred())
 
-				matches := assertDocCount(rootResource.ID, fmt.Sprintf("Name:%s", parentResource.Name), 1)
+				matches := assertDocCount(rootResource.ID, fmt.Sprintf("name:%s", parentResource.Name), 1)
 				match := matches[0]
 				Expect(match.Entity.Ref.Path).To(Equal(parentResource.Path))
 				Expect(match.Entity.Name).To(Equal(parentResource.Name))
@@ -225,6 +368,26 @@ var _ = Describe("Bleve", func() {
 				assertDocCount(rootResource.ID, "Name:Foo*", 0)
 			})
 
+			It("matches names case-insensitively", func() {
+				parentResource.Document.Name = "Foo.pdf"
+
+				err := eng.Upsert(parentResource.ID, parentResource)
+				Expect(err).ToNot(HaveOccurred())
+
+				assertDocCount(rootResource.ID, "name:FOO.PDF", 1)
+				assertDocCount(rootResource.ID, "FOO", 1)
+			})
+
+			It("treats words with a colon as free text", func() {
+				parentResource.Document.Name = "re: invoice 10:30.pdf"
+
+				err := eng.Upsert(parentResource.ID, parentResource)
+				Expect(err).ToNot(HaveOccurred())
+
+				assertDocCount(rootResource.ID, "re: invoice", 1)
+				assertDocCount(rootResource.ID, "10:30", 1)
+			})
+
 			Context("and an additional file in a subdirectory", func() {
 				BeforeEach(func() {
 					err := eng.Upsert(parentResource.ID, parentResource)
@@ -282,12 +445,12 @@ var _ = Describe("Bleve", func() {
 			err := eng.Upsert(childResource.ID, childResource)
 			Expect(err).ToNot(HaveOccurred())
 
-			assertDocCount(rootResource.ID, "Name:*child*", 1)
+			assertDocCount(rootResource.ID, "name:*child*", 1)
 
 			err = eng.Delete(childResource.ID)
 			Expect(err).ToNot(HaveOccurred())
 
-			assertDocCount(rootResource.ID, "Name:*child*", 0)
+			assertDocCount(rootResource.ID, "name:*child*", 0)
 		})
 
 		It("marks a child resources as deleted", func() {
@@ -344,7 +507,7 @@ var _ = Describe("Bleve", func() {
 
 			assertDocCount(rootResource.ID, parentResource.Name, 0)
 
-			matches := assertDocCount(rootResource.ID, "Name:child.pdf", 1)
+			matches := assertDocCount(rootResource.ID, "name:child.pdf", 1)
 			Expect(matches[0].Entity.ParentId.OpaqueId).To(Equal("3"))
 			Expect(matches[0].Entity.Ref.Path).To(Equal("./my/newname/child.pdf"))
 		})
@@ -363,7 +526,7 @@ var _ = Describe("Bleve", func() {
 			Expect(err).ToNot(HaveOccurred())
 			assertDocCount(rootResource.ID, `parent d!r`, 0)
 
-			matches := assertDocCount(rootResource.ID, "Name:child.pdf", 1)
+			matches := assertDocCount(rootResource.ID, "name:child.pdf", 1)
 			Expect(matches[0].Entity.ParentId.OpaqueId).To(Equal("3"))
 			Expect(matches[0].Entity.Ref.Path).To(Equal("./somewhere/else/newname/child.pdf"))
 
@@ -373,4 +536,31 @@ var _ = Describe("Bleve", func() {
 
 		})
 	})
+
+	Describe("Stats", func() {
+		It("counts the resources per space", func() {
+			Expect(eng.Upsert(parentResource.ID, parentResource)).To(Succeed())
+			Expect(eng.Upsert(childResource.ID, childResource)).To(Succeed())
+			other := engine.Resource{ID: "1$5!6", RootID: "1$5!5", Path: "./other.txt"}
+			Expect(eng.Upsert(other.ID, other)).To(Succeed())
+
+			stats, err := eng.Stats()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stats.Spaces).To(Equal(map[string]uint64{rootResource.ID: 2, other.RootID: 1}))
+		})
+	})
+
+	Describe("Paths", func() {
+		It("returns the paths of the resources of a space which aren't deleted", func() {
+			Expect(eng.Upsert(parentResource.ID, parentResource)).To(Succeed())
+			Expect(eng.Upsert(childResource.ID, childResource)).To(Succeed())
+			other := engine.Resource{ID: "1$5!6", RootID: "1$5!5", Path: "./other.txt"}
+			Expect(eng.Upsert(other.ID, other)).To(Succeed())
+			Expect(eng.Delete(childResource.ID)).To(Succeed())
+
+			paths, err := eng.Paths(rootResource.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(paths).To(Equal(map[string]string{parentResource.ID: parentResource.Path}))
+		})
+	})
 })
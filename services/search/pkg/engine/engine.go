@@ -3,15 +3,39 @@ package engine
 import (
 	"context"
 	"regexp"
+	"strings"
 
 	storageProvider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
 	searchMessage "github.com/owncloud/ocis/v2/protogen/gen/ocis/messages/search/v0"
 	searchService "github.com/owncloud/ocis/v2/protogen/gen/ocis/services/search/v0"
 	"github.com/owncloud/ocis/v2/services/search/pkg/content"
+	"github.com/owncloud/ocis/v2/services/search/pkg/query/ast"
 )
 
 var queryEscape = regexp.MustCompile(`([` + regexp.QuoteMeta(`+=&|><!(){}[]^\"~*?:\/`) + `\-\s])`)
 
+// resourceFields maps the fields of the query syntax tree to the fields of a Resource
+var resourceFields = map[string]string{
+	ast.FieldName:     "Name",
+	ast.FieldTag:      "Tags",
+	ast.FieldMimeType: "MimeType",
+	ast.FieldSize:     "Size",
+	ast.FieldMtime:    "Mtime",
+	ast.FieldContent:  "Content",
+	ast.FieldHidden:   "Hidden",
+	ast.FieldID:       "ID",
+}
+
+// highlightFields are the fields of a Resource whose matches are highlighted, in the order of the
+// highlights of a match
+var highlightFields = []string{"Content", "Name"}
+
+// the matched terms of highlights are wrapped in these tags
+const (
+	highlightPreTag  = "<mark>"
+	highlightPostTag = "</mark>"
+)
+
 //go:generate mockery --name=Engine
 
 // Engine is the interface to the search engine
@@ -23,6 +47,16 @@ type Engine interface {
 	Restore(id string) error
 	Purge(id string) error
 	DocCount() (uint64, error)
+	Stats() (*Stats, error)
+	Paths(rootID string) (map[string]string, error)
+}
+
+// Stats are the statistics of an index
+type Stats struct {
+	// Spaces is the number of resources in the index by the id of their space root
+	Spaces map[string]uint64
+	// Size is the size of the index in bytes
+	Size uint64
 }
 
 // Resource is the entity that is stored in the index.
@@ -45,6 +79,20 @@ func resourceIDtoSearchID(id storageProvider.ResourceId) *searchMessage.Resource
 		OpaqueId:  id.GetOpaqueId()}
 }
 
+// highlights returns the highlighted fragments of a match in the order of the highlight fields.
+// Fragments without a matched term are left out, bleve returns the start of every field.
+func highlights(fragments map[string][]string) []string {
+	var out []string
+	for _, field := range highlightFields {
+		for _, f := range fragments[field] {
+			if strings.Contains(f, highlightPreTag) {
+				out = append(out, f)
+			}
+		}
+	}
+	return out
+}
+
 func escapeQuery(s string) string {
 	return queryEscape.ReplaceAllString(s, "\\$1")
 }
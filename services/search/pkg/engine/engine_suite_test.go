@@ -1,13 +1,24 @@
 package engine_test
 
 import (
+	"fmt"
 	"testing"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	searchmsg "github.com/owncloud/ocis/v2/protogen/gen/ocis/messages/search/v0"
 )
 
 func TestEngine(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "Engine Suite")
 }
+
+// facetValues returns the values of a facet with their counts in the order of the facet
+func facetValues(f *searchmsg.Facet) []string {
+	values := make([]string, 0, len(f.GetValues()))
+	for _, v := range f.GetValues() {
+		values = append(values, fmt.Sprintf("%s=%d", v.GetValue(), v.GetCount()))
+	}
+	return values
+}
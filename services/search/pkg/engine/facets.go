@@ -0,0 +1,166 @@
+package engine
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	searchMessage "github.com/owncloud/ocis/v2/protogen/gen/ocis/messages/search/v0"
+	"github.com/owncloud/ocis/v2/services/search/pkg/query/kql"
+)
+
+// Facets the matches of a search can be counted by
+const (
+	// FacetMimeType counts the matches by groups of mime types like image or document
+	FacetMimeType = "mimetype"
+	// FacetTag counts the matches by tag
+	FacetTag = "tag"
+	// FacetSpace counts the matches by the space they are located in
+	FacetSpace = "space"
+	// FacetMtime counts the matches by relative dates like today or last week
+	FacetMtime = "mtime"
+	// FacetSize counts the matches by named sizes like small or large
+	FacetSize = "size"
+)
+
+// maxFacetTerms limits the number of values of facets which count the values of a field
+const maxFacetTerms = 100
+
+// maxFacetMimeTypes limits the number of mime types which are counted before they are grouped
+const maxFacetMimeTypes = 1000
+
+// otherMimeTypes is the group of the mime types which don't belong to any group
+const otherMimeTypes = "other"
+
+// CheckFacets returns an error if one of the facets is unknown
+func CheckFacets(facets []string) error {
+	for _, f := range facets {
+		switch f {
+		case FacetMimeType, FacetTag, FacetSpace, FacetMtime, FacetSize:
+		default:
+			return fmt.Errorf("unknown facet %q", f)
+		}
+	}
+	return nil
+}
+
+// MergeFacets sums up the counts of the facets of several searches, e.g. of searches in several
+// spaces. The facets are returned in the given order.
+func MergeFacets(names []string, facets ...[]*searchMessage.Facet) []*searchMessage.Facet {
+	counts := facetCounts{}
+	for _, fs := range facets {
+		for _, f := range fs {
+			for _, v := range f.GetValues() {
+				counts.add(f.GetName(), v.GetValue(), int(v.GetCount()))
+			}
+		}
+	}
+	return counts.facets(names)
+}
+
+// facetCounts counts the matches by facet and value
+type facetCounts map[string]map[string]int
+
+func (c facetCounts) add(facet, value string, count int) {
+	if c[facet] == nil {
+		c[facet] = map[string]int{}
+	}
+	c[facet][value] += count
+}
+
+// facets returns the facets in the given order, leaving out values without matches. The values of
+// range facets keep the order of the ranges, other values are sorted by their count.
+func (c facetCounts) facets(names []string) []*searchMessage.Facet {
+	facets := make([]*searchMessage.Facet, 0, len(names))
+	for _, name := range uniqueFacets(names) {
+		f := &searchMessage.Facet{Name: name}
+		for value, count := range c[name] {
+			if count > 0 {
+				f.Values = append(f.Values, &searchMessage.FacetValue{Value: value, Count: int32(count)})
+			}
+		}
+
+		order := rangeOrder(name)
+		sort.Slice(f.Values, func(i, j int) bool {
+			a, b := f.Values[i], f.Values[j]
+			switch {
+			case order != nil:
+				return order[a.Value] < order[b.Value]
+			case a.Count != b.Count:
+				return a.Count > b.Count
+			default:
+				return a.Value < b.Value
+			}
+		})
+		facets = append(facets, f)
+	}
+	return facets
+}
+
+// rangeOrder returns the positions of the ranges of a range facet, or nil for other facets
+func rangeOrder(facet string) map[string]int {
+	var names []string
+	switch facet {
+	case FacetSize:
+		for _, r := range kql.SizeRanges {
+			names = append(names, r.Name)
+		}
+	case FacetMtime:
+		names = kql.RelativeDates
+	default:
+		return nil
+	}
+
+	order := make(map[string]int, len(names))
+	for i, name := range names {
+		order[name] = i
+	}
+	return order
+}
+
+// uniqueFacets removes duplicate facets
+func uniqueFacets(names []string) []string {
+	seen := make(map[string]bool, len(names))
+	unique := make([]string, 0, len(names))
+	for _, name := range names {
+		if !seen[name] {
+			seen[name] = true
+			unique = append(unique, name)
+		}
+	}
+	return unique
+}
+
+// mimeTypeGroup returns the group of a mime type, e.g. image for image/png
+func mimeTypeGroup(mimeType string) string {
+	mimeType = strings.ToLower(mimeType)
+	for group, patterns := range kql.MimeTypeGroups {
+		for _, p := range patterns {
+			if ok, _ := path.Match(p, mimeType); ok {
+				return group
+			}
+		}
+	}
+	return otherMimeTypes
+}
+
+// dateRange is a named period of time, the end is exclusive
+type dateRange struct {
+	name     string
+	from, to time.Time
+}
+
+// dateRanges returns the periods of time of the relative dates
+func dateRanges(now time.Time) []dateRange {
+	ranges := make([]dateRange, 0, len(kql.RelativeDates))
+	for _, name := range kql.RelativeDates {
+		from, to, err := kql.ParseDate(name, now)
+		if err != nil {
+			continue
+		}
+		ranges = append(ranges, dateRange{name: name, from: from, to: to})
+	}
+	return ranges
+}
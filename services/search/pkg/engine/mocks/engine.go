@@ -65,6 +65,29 @@ func (_m *Engine) Move(id string, parentid string, target string) error {
 	return r0
 }
 
+// Paths provides a mock function with given fields: rootID
+func (_m *Engine) Paths(rootID string) (map[string]string, error) {
+	ret := _m.Called(rootID)
+
+	var r0 map[string]string
+	if rf, ok := ret.Get(0).(func(string) map[string]string); ok {
+		r0 = rf(rootID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(rootID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Purge provides a mock function with given fields: id
 func (_m *Engine) Purge(id string) error {
 	ret := _m.Called(id)
@@ -116,6 +139,29 @@ func (_m *Engine) Search(ctx context.Context, req *v0.SearchIndexRequest) (*v0.S
 	return r0, r1
 }
 
+// Stats provides a mock function with given fields:
+func (_m *Engine) Stats() (*engine.Stats, error) {
+	ret := _m.Called()
+
+	var r0 *engine.Stats
+	if rf, ok := ret.Get(0).(func() *engine.Stats); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*engine.Stats)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Upsert provides a mock function with given fields: id, r
 func (_m *Engine) Upsert(id string, r engine.Resource) error {
 	ret := _m.Called(id, r)
@@ -0,0 +1,857 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	storageProvider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/v2/pkg/storagespace"
+	"github.com/cs3org/reva/v2/pkg/utils"
+	searchMessage "github.com/owncloud/ocis/v2/protogen/gen/ocis/messages/search/v0"
+	searchService "github.com/owncloud/ocis/v2/protogen/gen/ocis/services/search/v0"
+	"github.com/owncloud/ocis/v2/services/search/pkg/config"
+	"github.com/owncloud/ocis/v2/services/search/pkg/query/ast"
+	"github.com/owncloud/ocis/v2/services/search/pkg/query/kql"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// openSearchBatchSize is the number of resources updated with a single bulk request
+const openSearchBatchSize = 1000
+
+// openSearchIndex is the definition of the index the resources are stored in. Like with bleve, names
+// and tags are matched case-insensitively as a whole and the content is analyzed as full text.
+var openSearchIndex = map[string]interface{}{
+	"settings": map[string]interface{}{
+		"analysis": map[string]interface{}{
+			"normalizer": map[string]interface{}{
+				"lowercase_keyword": map[string]interface{}{
+					"type":   "custom",
+					"filter": []string{"lowercase"},
+				},
+			},
+			"analyzer": map[string]interface{}{
+				"fulltext": map[string]interface{}{
+					"type":      "custom",
+					"tokenizer": "standard",
+					"filter":    []string{"lowercase", "porter_stem"},
+				},
+			},
+		},
+	},
+	"mappings": map[string]interface{}{
+		"properties": map[string]interface{}{
+			"ID":       map[string]interface{}{"type": "keyword"},
+			"RootID":   map[string]interface{}{"type": "keyword"},
+			"Path":     map[string]interface{}{"type": "keyword"},
+			"ParentID": map[string]interface{}{"type": "keyword"},
+			"Type":     map[string]interface{}{"type": "long"},
+			"Deleted":  map[string]interface{}{"type": "boolean"},
+			"Hidden":   map[string]interface{}{"type": "boolean"},
+			"Name":     map[string]interface{}{"type": "keyword", "normalizer": "lowercase_keyword"},
+			"Tags":     map[string]interface{}{"type": "keyword", "normalizer": "lowercase_keyword"},
+			"Title":    map[string]interface{}{"type": "text", "analyzer": "fulltext"},
+			"Content":  map[string]interface{}{"type": "text", "analyzer": "fulltext"},
+			"Size":     map[string]interface{}{"type": "long"},
+			"Mtime":    map[string]interface{}{"type": "date", "ignore_malformed": true},
+			"MimeType": map[string]interface{}{"type": "keyword"},
+		},
+	},
+}
+
+// OpenSearch represents a search engine which stores the resources in an OpenSearch or Elasticsearch cluster.
+// Unlike bleve, the index can be shared by several instances of the search service.
+type OpenSearch struct {
+	client    *http.Client
+	addresses []string
+	index     string
+	username  string
+	password  string
+	next      uint32
+}
+
+// OpenSearchError is returned when the cluster rejects a request
+type OpenSearchError struct {
+	Status int
+	Type   string
+	Reason string
+}
+
+func (e *OpenSearchError) Error() string {
+	if e.Type == "" {
+		return fmt.Sprintf("opensearch: unexpected status %d", e.Status)
+	}
+	return fmt.Sprintf("opensearch: unexpected status %d: %s: %s", e.Status, e.Type, e.Reason)
+}
+
+// ndjson is a request body of newline delimited json documents as used by the bulk api
+type ndjson []byte
+
+type openSearchHit struct {
+	ID        string              `json:"_id"`
+	Score     float64             `json:"_score"`
+	Source    Resource            `json:"_source"`
+	Sort      []interface{}       `json:"sort"`
+	Highlight map[string][]string `json:"highlight"`
+}
+
+type openSearchSearchResponse struct {
+	Hits struct {
+		Total struct {
+			Value int64 `json:"value"`
+		} `json:"total"`
+		Hits []openSearchHit `json:"hits"`
+	} `json:"hits"`
+	Aggregations map[string]struct {
+		Buckets []struct {
+			Key      string `json:"key"`
+			DocCount int    `json:"doc_count"`
+		} `json:"buckets"`
+	} `json:"aggregations"`
+}
+
+// NewOpenSearchEngine creates a new OpenSearch instance, the index is created if it doesn't exist yet.
+func NewOpenSearchEngine(cfg config.EngineOpenSearch) (*OpenSearch, error) {
+	if len(cfg.Addresses) == 0 {
+		return nil, errors.New("opensearch: no addresses configured")
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: cfg.Insecure, //nolint:gosec
+	}
+
+	o := &OpenSearch{
+		client:    &http.Client{Transport: transport, Timeout: time.Minute},
+		addresses: cfg.Addresses,
+		index:     cfg.Index,
+		username:  cfg.Username,
+		password:  cfg.Password,
+	}
+
+	if err := o.ensureIndex(context.Background()); err != nil {
+		return nil, fmt.Errorf("could not initialize the index %s: %w", cfg.Index, err)
+	}
+	return o, nil
+}
+
+// Search executes a search request operation within the index.
+// Returns a SearchIndexResponse object or an error.
+func (o *OpenSearch) Search(ctx context.Context, sir *searchService.SearchIndexRequest) (*searchService.SearchIndexResponse, error) {
+	tree, err := kql.Parse(sir.Query)
+	if err != nil {
+		return nil, err
+	}
+	q, err := compileOpenSearchQuery(tree)
+	if err != nil {
+		return nil, err
+	}
+
+	filters := []interface{}{
+		// Skip documents that have been marked as deleted
+		termQuery("Deleted", false),
+	}
+
+	if sir.Ref != nil {
+		filters = append(filters,
+			termQuery("RootID", storagespace.FormatResourceID(
+				storageProvider.ResourceId{
+					StorageId: sir.Ref.GetResourceId().GetStorageId(),
+					SpaceId:   sir.Ref.GetResourceId().GetSpaceId(),
+					OpaqueId:  sir.Ref.GetResourceId().GetOpaqueId(),
+				},
+			)),
+			prefixQuery("Path", utils.MakeRelativePath(path.Join(sir.Ref.Path, "/"))),
+		)
+	}
+
+	// like with bleve a page size of -1 returns all matches, they are fetched in batches because the size of
+	// a single page is limited by the max_result_window of the index
+	all := sir.PageSize == -1
+	size := int(sir.PageSize)
+	switch {
+	case all:
+		size = openSearchBatchSize
+	case sir.PageSize == 0:
+		size = 200
+	}
+
+	req := map[string]interface{}{
+		"size":             size,
+		"track_total_hits": true,
+		"query":            boolQuery([]interface{}{q}, filters),
+		"highlight":        openSearchHighlight(),
+	}
+	if all {
+		// search_after needs a unique sort order, the score comes first to keep the order of the relevance
+		req["sort"] = []interface{}{
+			map[string]interface{}{"_score": "desc"},
+			map[string]interface{}{"ID": "asc"},
+		}
+	}
+
+	if err := CheckFacets(sir.Facets); err != nil {
+		return nil, err
+	}
+	facets := uniqueFacets(sir.Facets)
+	if len(facets) > 0 {
+		aggs := map[string]interface{}{}
+		now := time.Now()
+		for _, name := range facets {
+			aggs[name] = openSearchAggregation(name, now)
+		}
+		req["aggs"] = aggs
+	}
+
+	// the first page holds the total and the aggregations
+	var res openSearchSearchResponse
+	if _, err := o.call(ctx, http.MethodPost, o.indexPath("_search"), req, &res); err != nil {
+		return nil, err
+	}
+	hits := res.Hits.Hits
+	for page := res.Hits.Hits; all && len(page) > 0 && int64(len(hits)) < res.Hits.Total.Value; {
+		req["search_after"] = page[len(page)-1].Sort
+		delete(req, "aggs")
+
+		var next openSearchSearchResponse
+		if _, err := o.call(ctx, http.MethodPost, o.indexPath("_search"), req, &next); err != nil {
+			return nil, err
+		}
+		page = next.Hits.Hits
+		hits = append(hits, page...)
+	}
+
+	matches := make([]*searchMessage.Match, 0, len(hits))
+	for _, hit := range hits {
+		match, err := resourceToMatch(hit.Source, float32(hit.Score))
+		if err != nil {
+			return nil, err
+		}
+		match.Highlights = highlights(hit.Highlight)
+		matches = append(matches, match)
+	}
+
+	counts := facetCounts{}
+	for _, name := range facets {
+		for _, b := range res.Aggregations[name].Buckets {
+			value := b.Key
+			if name == FacetMimeType {
+				value = mimeTypeGroup(b.Key)
+			}
+			counts.add(name, value, b.DocCount)
+		}
+	}
+
+	return &searchService.SearchIndexResponse{
+		Matches:      matches,
+		TotalMatches: int32(res.Hits.Total.Value),
+		Facets:       counts.facets(facets),
+	}, nil
+}
+
+// Upsert indexes or stores Resource data fields.
+func (o *OpenSearch) Upsert(id string, r Resource) error {
+	return o.put(context.Background(), id, r)
+}
+
+// Move updates the resource location and all of its necessary fields.
+func (o *OpenSearch) Move(id string, parentid string, target string) error {
+	ctx := context.Background()
+	r, err := o.getResource(ctx, id)
+	if err != nil {
+		return err
+	}
+	currentPath := r.Path
+	nextPath := utils.MakeRelativePath(target)
+
+	r.Path = nextPath
+	r.Name = path.Base(nextPath)
+	r.ParentID = parentid
+	if err := o.put(ctx, id, *r); err != nil {
+		return err
+	}
+
+	if r.Type != uint64(storageProvider.ResourceType_RESOURCE_TYPE_CONTAINER) {
+		return nil
+	}
+	return o.updateDescendants(ctx, r.RootID, currentPath, func(r *Resource) {
+		r.Path = strings.Replace(r.Path, currentPath, nextPath, 1)
+	})
+}
+
+// Delete marks the resource as deleted.
+// The resource object will stay in the index,
+// instead of removing the resource it just marks it as deleted!
+// can be undone
+func (o *OpenSearch) Delete(id string) error {
+	return o.setDeleted(id, true)
+}
+
+// Restore is the counterpart to Delete.
+// It restores the resource which makes it available again.
+func (o *OpenSearch) Restore(id string) error {
+	return o.setDeleted(id, false)
+}
+
+// Purge removes a resource from the index, irreversible operation.
+func (o *OpenSearch) Purge(id string) error {
+	_, err := o.call(context.Background(), http.MethodDelete, o.indexPath("_doc", id)+"?refresh=wait_for", nil, nil, http.StatusNotFound)
+	return err
+}
+
+// DocCount returns the number of resources in the index.
+func (o *OpenSearch) DocCount() (uint64, error) {
+	var res struct {
+		Count uint64 `json:"count"`
+	}
+	if _, err := o.call(context.Background(), http.MethodGet, o.indexPath("_count"), nil, &res); err != nil {
+		return 0, err
+	}
+	return res.Count, nil
+}
+
+// Stats returns the number of resources per space and the size of the primary shards of the index.
+func (o *OpenSearch) Stats() (*Stats, error) {
+	ctx := context.Background()
+	stats := &Stats{Spaces: map[string]uint64{}}
+
+	// a composite aggregation pages through all spaces, a terms aggregation would be limited in size
+	var after map[string]interface{}
+	for {
+		composite := map[string]interface{}{
+			"size":    openSearchBatchSize,
+			"sources": []interface{}{map[string]interface{}{"space": map[string]interface{}{"terms": map[string]interface{}{"field": "RootID"}}}},
+		}
+		if after != nil {
+			composite["after"] = after
+		}
+		req := map[string]interface{}{
+			"size":  0,
+			"query": map[string]interface{}{"match_all": map[string]interface{}{}},
+			"aggs":  map[string]interface{}{"spaces": map[string]interface{}{"composite": composite}},
+		}
+
+		var res struct {
+			Aggregations struct {
+				Spaces struct {
+					AfterKey map[string]interface{} `json:"after_key"`
+					Buckets  []struct {
+						Key struct {
+							Space string `json:"space"`
+						} `json:"key"`
+						DocCount uint64 `json:"doc_count"`
+					} `json:"buckets"`
+				} `json:"spaces"`
+			} `json:"aggregations"`
+		}
+		if _, err := o.call(ctx, http.MethodPost, o.indexPath("_search"), req, &res); err != nil {
+			return nil, err
+		}
+		for _, b := range res.Aggregations.Spaces.Buckets {
+			stats.Spaces[b.Key.Space] = b.DocCount
+		}
+		if len(res.Aggregations.Spaces.Buckets) < openSearchBatchSize || res.Aggregations.Spaces.AfterKey == nil {
+			break
+		}
+		after = res.Aggregations.Spaces.AfterKey
+	}
+
+	var res struct {
+		All struct {
+			Primaries struct {
+				Store struct {
+					SizeInBytes uint64 `json:"size_in_bytes"`
+				} `json:"store"`
+			} `json:"primaries"`
+		} `json:"_all"`
+	}
+	if _, err := o.call(ctx, http.MethodGet, o.indexPath("_stats", "store"), nil, &res); err != nil {
+		return nil, err
+	}
+	stats.Size = res.All.Primaries.Store.SizeInBytes
+	return stats, nil
+}
+
+// Paths returns the paths of the resources of a space by their id. Resources which are marked as deleted
+// are left out.
+func (o *OpenSearch) Paths(rootID string) (map[string]string, error) {
+	ctx := context.Background()
+	paths := map[string]string{}
+	var after []interface{}
+	for {
+		req := map[string]interface{}{
+			"size":    openSearchBatchSize,
+			"sort":    []interface{}{map[string]interface{}{"ID": "asc"}},
+			"_source": []string{"Path"},
+			"query": boolQuery(nil, []interface{}{
+				termQuery("Deleted", false),
+				termQuery("RootID", rootID),
+			}),
+		}
+		if after != nil {
+			req["search_after"] = after
+		}
+
+		var res openSearchSearchResponse
+		if _, err := o.call(ctx, http.MethodPost, o.indexPath("_search"), req, &res); err != nil {
+			return nil, err
+		}
+		hits := res.Hits.Hits
+		for _, h := range hits {
+			paths[h.ID] = h.Source.Path
+		}
+		if len(hits) < openSearchBatchSize {
+			return paths, nil
+		}
+		after = hits[len(hits)-1].Sort
+	}
+}
+
+// ensureIndex creates the index with the mapping of the resources if it doesn't exist
+func (o *OpenSearch) ensureIndex(ctx context.Context) error {
+	status, err := o.call(ctx, http.MethodHead, o.indexPath(), nil, nil, http.StatusNotFound)
+	if err != nil || status != http.StatusNotFound {
+		return err
+	}
+
+	_, err = o.call(ctx, http.MethodPut, o.indexPath(), openSearchIndex, nil)
+	var osErr *OpenSearchError
+	if errors.As(err, &osErr) && osErr.Type == "resource_already_exists_exception" {
+		// another instance was faster
+		return nil
+	}
+	return err
+}
+
+func (o *OpenSearch) getResource(ctx context.Context, id string) (*Resource, error) {
+	var res struct {
+		Found  bool     `json:"found"`
+		Source Resource `json:"_source"`
+	}
+	if _, err := o.call(ctx, http.MethodGet, o.indexPath("_doc", id), nil, &res, http.StatusNotFound); err != nil {
+		return nil, err
+	}
+	if !res.Found {
+		return nil, errors.New("entity not found")
+	}
+	return &res.Source, nil
+}
+
+func (o *OpenSearch) put(ctx context.Context, id string, r Resource) error {
+	_, err := o.call(ctx, http.MethodPut, o.indexPath("_doc", id)+"?refresh=wait_for", r, nil)
+	return err
+}
+
+func (o *OpenSearch) setDeleted(id string, deleted bool) error {
+	ctx := context.Background()
+	r, err := o.getResource(ctx, id)
+	if err != nil {
+		return err
+	}
+	r.Deleted = deleted
+	if err := o.put(ctx, id, *r); err != nil {
+		return err
+	}
+
+	if r.Type != uint64(storageProvider.ResourceType_RESOURCE_TYPE_CONTAINER) {
+		return nil
+	}
+	return o.updateDescendants(ctx, r.RootID, r.Path, func(r *Resource) {
+		r.Deleted = deleted
+	})
+}
+
+// updateDescendants applies mutateFunc to all resources below the given path and stores them in batches
+func (o *OpenSearch) updateDescendants(ctx context.Context, rootID, p string, mutateFunc func(r *Resource)) error {
+	var after []interface{}
+	for {
+		req := map[string]interface{}{
+			"size": openSearchBatchSize,
+			"sort": []interface{}{map[string]interface{}{"ID": "asc"}},
+			"query": boolQuery(nil, []interface{}{
+				termQuery("RootID", rootID),
+				prefixQuery("Path", p+"/"),
+			}),
+		}
+		if after != nil {
+			req["search_after"] = after
+		}
+
+		var res openSearchSearchResponse
+		if _, err := o.call(ctx, http.MethodPost, o.indexPath("_search"), req, &res); err != nil {
+			return err
+		}
+		hits := res.Hits.Hits
+		if len(hits) == 0 {
+			return nil
+		}
+
+		var body bytes.Buffer
+		enc := json.NewEncoder(&body)
+		for _, h := range hits {
+			r := h.Source
+			mutateFunc(&r)
+			if err := enc.Encode(map[string]interface{}{"index": map[string]interface{}{"_id": h.ID}}); err != nil {
+				return err
+			}
+			if err := enc.Encode(r); err != nil {
+				return err
+			}
+		}
+		if err := o.bulk(ctx, ndjson(body.Bytes())); err != nil {
+			return err
+		}
+
+		if len(hits) < openSearchBatchSize {
+			return nil
+		}
+		after = hits[len(hits)-1].Sort
+	}
+}
+
+func (o *OpenSearch) bulk(ctx context.Context, body ndjson) error {
+	var res struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			ID     string `json:"_id"`
+			Status int    `json:"status"`
+			Error  struct {
+				Type   string `json:"type"`
+				Reason string `json:"reason"`
+			} `json:"error"`
+		} `json:"items"`
+	}
+	if _, err := o.call(ctx, http.MethodPost, o.indexPath("_bulk")+"?refresh=wait_for", body, &res); err != nil {
+		return err
+	}
+	if !res.Errors {
+		return nil
+	}
+	for _, item := range res.Items {
+		for _, result := range item {
+			if result.Status >= http.StatusMultipleChoices {
+				return fmt.Errorf("could not update %s: %w", result.ID, &OpenSearchError{Status: result.Status, Type: result.Error.Type, Reason: result.Error.Reason})
+			}
+		}
+	}
+	return nil
+}
+
+func (o *OpenSearch) indexPath(elem ...string) string {
+	p := "/" + url.PathEscape(o.index)
+	for _, e := range elem {
+		p += "/" + url.PathEscape(e)
+	}
+	return p
+}
+
+// call sends a request to the cluster and decodes the response into out. Responses with a status code
+// other than 2xx are returned as an OpenSearchError unless the status code is explicitly accepted.
+func (o *OpenSearch) call(ctx context.Context, method, p string, body interface{}, out interface{}, accept ...int) (int, error) {
+	var (
+		b           []byte
+		contentType = "application/json"
+		err         error
+	)
+	switch v := body.(type) {
+	case nil:
+	case ndjson:
+		b, contentType = v, "application/x-ndjson"
+	default:
+		if b, err = json.Marshal(v); err != nil {
+			return 0, err
+		}
+	}
+
+	res, err := o.send(ctx, method, p, b, contentType)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	accepted := res.StatusCode >= 200 && res.StatusCode < 300
+	for _, s := range accept {
+		accepted = accepted || res.StatusCode == s
+	}
+	if !accepted {
+		return res.StatusCode, readOpenSearchError(res)
+	}
+
+	if out == nil || method == http.MethodHead {
+		return res.StatusCode, nil
+	}
+	return res.StatusCode, json.NewDecoder(res.Body).Decode(out)
+}
+
+// send sends a request to the next node, nodes which can't be reached are skipped
+func (o *OpenSearch) send(ctx context.Context, method, p string, body []byte, contentType string) (*http.Response, error) {
+	var err error
+	for range o.addresses {
+		addr := o.addresses[int(atomic.AddUint32(&o.next, 1)-1)%len(o.addresses)]
+
+		var req *http.Request
+		req, err = http.NewRequestWithContext(ctx, method, strings.TrimSuffix(addr, "/")+p, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		if body != nil {
+			req.Header.Set("Content-Type", contentType)
+		}
+		if o.username != "" {
+			req.SetBasicAuth(o.username, o.password)
+		}
+
+		var res *http.Response
+		res, err = o.client.Do(req)
+		if err == nil {
+			return res, nil
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, err
+}
+
+func readOpenSearchError(res *http.Response) error {
+	osErr := &OpenSearchError{Status: res.StatusCode}
+	var body struct {
+		Error json.RawMessage `json:"error"`
+	}
+	b, _ := io.ReadAll(res.Body)
+	if err := json.Unmarshal(b, &body); err != nil || len(body.Error) == 0 {
+		return osErr
+	}
+
+	var cause struct {
+		Type   string `json:"type"`
+		Reason string `json:"reason"`
+	}
+	if err := json.Unmarshal(body.Error, &cause); err != nil {
+		// some errors are plain strings
+		_ = json.Unmarshal(body.Error, &osErr.Reason)
+		return osErr
+	}
+	osErr.Type, osErr.Reason = cause.Type, cause.Reason
+	return osErr
+}
+
+func resourceToMatch(r Resource, score float32) (*searchMessage.Match, error) {
+	rootID, err := storagespace.ParseID(r.RootID)
+	if err != nil {
+		return nil, err
+	}
+
+	rID, err := storagespace.ParseID(r.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	pID, _ := storagespace.ParseID(r.ParentID)
+	match := &searchMessage.Match{
+		Score: score,
+		Entity: &searchMessage.Entity{
+			Ref: &searchMessage.Reference{
+				ResourceId: resourceIDtoSearchID(rootID),
+				Path:       r.Path,
+			},
+			Id:       resourceIDtoSearchID(rID),
+			Name:     r.Name,
+			ParentId: resourceIDtoSearchID(pID),
+			Size:     r.Size,
+			Type:     r.Type,
+			MimeType: r.MimeType,
+			Deleted:  r.Deleted,
+			Tags:     r.Tags,
+		},
+	}
+
+	if mtime, err := time.Parse(time.RFC3339, r.Mtime); err == nil {
+		match.Entity.LastModifiedTime = &timestamppb.Timestamp{Seconds: mtime.Unix(), Nanos: int32(mtime.Nanosecond())}
+	}
+
+	return match, nil
+}
+
+// compileOpenSearchQuery translates the syntax tree of a query into the query dsl of OpenSearch
+func compileOpenSearchQuery(n ast.Node) (map[string]interface{}, error) {
+	switch n := n.(type) {
+	case *ast.And:
+		qs, err := compileOpenSearchQueries(n.Nodes)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"bool": map[string]interface{}{"must": qs}}, nil
+	case *ast.Or:
+		qs, err := compileOpenSearchQueries(n.Nodes)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"bool": map[string]interface{}{"should": qs, "minimum_should_match": 1}}, nil
+	case *ast.Not:
+		q, err := compileOpenSearchQuery(n.Node)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"bool": map[string]interface{}{"must_not": []interface{}{q}}}, nil
+	case *ast.Text:
+		return compileOpenSearchText(n), nil
+	case *ast.Number:
+		if n.Operator == ast.OpEqual {
+			return termQuery(resourceFields[n.Field], n.Value), nil
+		}
+		return rangeQuery(resourceFields[n.Field], n.Operator, n.Value), nil
+	case *ast.DateTime:
+		return rangeQuery(resourceFields[n.Field], n.Operator, n.Value.Format(time.RFC3339Nano)), nil
+	case *ast.Boolean:
+		return termQuery(resourceFields[n.Field], n.Value), nil
+	}
+	return nil, fmt.Errorf("unsupported query node %T", n)
+}
+
+func compileOpenSearchQueries(nodes []ast.Node) ([]interface{}, error) {
+	qs := make([]interface{}, 0, len(nodes))
+	for _, n := range nodes {
+		q, err := compileOpenSearchQuery(n)
+		if err != nil {
+			return nil, err
+		}
+		qs = append(qs, q)
+	}
+	return qs, nil
+}
+
+func compileOpenSearchText(t *ast.Text) map[string]interface{} {
+	field := resourceFields[t.Field]
+	switch t.Field {
+	case ast.FieldContent:
+		if t.Phrase {
+			return map[string]interface{}{"match_phrase": map[string]interface{}{field: t.Value}}
+		}
+		if t.HasWildcards() {
+			return wildcardQuery(field, strings.ToLower(t.Value), true)
+		}
+		return map[string]interface{}{"match": map[string]interface{}{field: t.Value}}
+	case ast.FieldID:
+		return termQuery(field, t.Value)
+	}
+
+	// names, tags and mime types are indexed as lower case keywords
+	if t.Field == "" {
+		// free text matches names containing it
+		value := strings.ToLower(t.Value)
+		if !t.HasWildcards() {
+			value = "*" + escapeWildcard(value) + "*"
+		}
+		return wildcardQuery(resourceFields[ast.FieldName], value, true)
+	}
+	if t.HasWildcards() {
+		// like with the previous query syntax, patterns are matched against the lower case keywords as given
+		return wildcardQuery(field, t.Value, false)
+	}
+	return termQuery(field, strings.ToLower(t.Value))
+}
+
+// escapeWildcard escapes the wildcards of a value which is to be matched literally
+func escapeWildcard(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`).Replace(s)
+}
+
+func wildcardQuery(field string, value string, caseInsensitive bool) map[string]interface{} {
+	return map[string]interface{}{"wildcard": map[string]interface{}{field: map[string]interface{}{"value": value, "case_insensitive": caseInsensitive}}}
+}
+
+func rangeQuery(field string, op ast.Operator, value interface{}) map[string]interface{} {
+	bound := map[ast.Operator]string{
+		ast.OpGreater:        "gt",
+		ast.OpGreaterOrEqual: "gte",
+		ast.OpLess:           "lt",
+		ast.OpLessOrEqual:    "lte",
+	}[op]
+	return map[string]interface{}{"range": map[string]interface{}{field: map[string]interface{}{bound: value}}}
+}
+
+// openSearchHighlight returns the request of the highlights, like bleve it asks for one fragment of
+// 200 characters per field. The highlights are html, so the html encoder has to escape the content
+// around the tags.
+func openSearchHighlight() map[string]interface{} {
+	fields := make(map[string]interface{}, len(highlightFields))
+	for _, field := range highlightFields {
+		fields[field] = map[string]interface{}{}
+	}
+	return map[string]interface{}{
+		"encoder":             "html",
+		"pre_tags":            []string{highlightPreTag},
+		"post_tags":           []string{highlightPostTag},
+		"fragment_size":       200,
+		"number_of_fragments": 1,
+		"fields":              fields,
+	}
+}
+
+// openSearchAggregation returns the aggregation which counts the matches of a facet, relative dates
+// are relative to now
+func openSearchAggregation(name string, now time.Time) map[string]interface{} {
+	switch name {
+	case FacetMimeType:
+		return termsAggregation("MimeType", maxFacetMimeTypes)
+	case FacetTag:
+		return termsAggregation("Tags", maxFacetTerms)
+	case FacetSpace:
+		return termsAggregation("RootID", maxFacetTerms)
+	case FacetSize:
+		ranges := make([]interface{}, 0, len(kql.SizeRanges))
+		for _, r := range kql.SizeRanges {
+			rng := map[string]interface{}{"key": r.Name, "from": r.Min}
+			if r.Max > 0 {
+				rng["to"] = r.Max
+			}
+			ranges = append(ranges, rng)
+		}
+		return map[string]interface{}{"range": map[string]interface{}{"field": "Size", "ranges": ranges}}
+	default:
+		var ranges []interface{}
+		for _, r := range dateRanges(now) {
+			ranges = append(ranges, map[string]interface{}{
+				"key":  r.name,
+				"from": r.from.Format(time.RFC3339Nano),
+				"to":   r.to.Format(time.RFC3339Nano),
+			})
+		}
+		return map[string]interface{}{"date_range": map[string]interface{}{"field": "Mtime", "ranges": ranges}}
+	}
+}
+
+func termsAggregation(field string, size int) map[string]interface{} {
+	return map[string]interface{}{"terms": map[string]interface{}{"field": field, "size": size}}
+}
+
+func termQuery(field string, value interface{}) map[string]interface{} {
+	return map[string]interface{}{"term": map[string]interface{}{field: value}}
+}
+
+func prefixQuery(field string, value string) map[string]interface{} {
+	return map[string]interface{}{"prefix": map[string]interface{}{field: value}}
+}
+
+func boolQuery(must []interface{}, filter []interface{}) map[string]interface{} {
+	q := map[string]interface{}{}
+	if len(must) > 0 {
+		q["must"] = must
+	}
+	if len(filter) > 0 {
+		q["filter"] = filter
+	}
+	return map[string]interface{}{"bool": q}
+}
@@ -0,0 +1,422 @@
+package engine_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+
+	sprovider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	searchmsg "github.com/owncloud/ocis/v2/protogen/gen/ocis/messages/search/v0"
+	searchsvc "github.com/owncloud/ocis/v2/protogen/gen/ocis/services/search/v0"
+	"github.com/owncloud/ocis/v2/services/search/pkg/config"
+	"github.com/owncloud/ocis/v2/services/search/pkg/content"
+	"github.com/owncloud/ocis/v2/services/search/pkg/engine"
+)
+
+var _ = Describe("OpenSearch", func() {
+	var (
+		eng     *engine.OpenSearch
+		cluster *recordedCluster
+		server  *httptest.Server
+		ctx     context.Context
+
+		rootRef = &searchmsg.Reference{
+			ResourceId: &searchmsg.ResourceID{StorageId: "1", SpaceId: "2", OpaqueId: "2"},
+		}
+
+		parentResource engine.Resource
+		childResource  engine.Resource
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		cluster = &recordedCluster{}
+		server = httptest.NewServer(cluster)
+
+		cluster.expect("index_exists")
+		var err error
+		eng, err = engine.NewOpenSearchEngine(config.EngineOpenSearch{
+			Addresses: []string{server.URL},
+			Index:     "ocis-resources",
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		parentResource = engine.Resource{
+			ID:       "1$2!3",
+			ParentID: "1$2!2",
+			RootID:   "1$2!2",
+			Path:     "./parent d!r",
+			Type:     uint64(sprovider.ResourceType_RESOURCE_TYPE_CONTAINER),
+			Document: content.Document{Name: "parent d!r"},
+		}
+
+		childResource = engine.Resource{
+			ID:       "1$2!4",
+			ParentID: parentResource.ID,
+			RootID:   "1$2!2",
+			Path:     "./parent d!r/child.pdf",
+			Type:     uint64(sprovider.ResourceType_RESOURCE_TYPE_FILE),
+			Document: content.Document{Name: "child.pdf"},
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+		cluster.verify()
+	})
+
+	Describe("New", func() {
+		It("creates the index with the mapping of the resources", func() {
+			cluster.expect("index_create")
+			_, err := engine.NewOpenSearchEngine(config.EngineOpenSearch{
+				Addresses: []string{server.URL},
+				Index:     "ocis-resources",
+			})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("uses an index which was created in the meantime", func() {
+			cluster.expect("index_create_conflict")
+			_, err := engine.NewOpenSearchEngine(config.EngineOpenSearch{
+				Addresses: []string{server.URL},
+				Index:     "ocis-resources",
+			})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("needs an address", func() {
+			_, err := engine.NewOpenSearchEngine(config.EngineOpenSearch{Index: "ocis-resources"})
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("skips nodes which can't be reached", func() {
+			down := httptest.NewServer(cluster)
+			down.Close()
+
+			cluster.expect("index_exists", "doc_count", "doc_count")
+			e, err := engine.NewOpenSearchEngine(config.EngineOpenSearch{
+				Addresses: []string{down.URL, server.URL},
+				Index:     "ocis-resources",
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			for i := 0; i < 2; i++ {
+				_, err := e.DocCount()
+				Expect(err).ToNot(HaveOccurred())
+			}
+		})
+	})
+
+	Describe("Search", func() {
+		It("translates the query and returns the matches", func() {
+			cluster.expect("search")
+
+			res, err := eng.Search(ctx, &searchsvc.SearchIndexRequest{Query: `foo tag:bar OR -size>=1mb`, Ref: rootRef})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.TotalMatches).To(Equal(int32(1)))
+			Expect(res.Matches).To(HaveLen(1))
+
+			match := res.Matches[0]
+			Expect(match.Entity.Ref.ResourceId.OpaqueId).To(Equal("2"))
+			Expect(match.Entity.Ref.Path).To(Equal("./foo.pdf"))
+			Expect(match.Entity.Id.OpaqueId).To(Equal("3"))
+			Expect(match.Entity.ParentId.OpaqueId).To(Equal("2"))
+			Expect(match.Entity.Name).To(Equal("foo.pdf"))
+			Expect(match.Entity.Size).To(Equal(uint64(12345)))
+			Expect(match.Entity.Type).To(Equal(uint64(sprovider.ResourceType_RESOURCE_TYPE_FILE)))
+			Expect(match.Entity.MimeType).To(Equal("application/pdf"))
+			Expect(match.Entity.Tags).To(Equal([]string{"bar"}))
+			Expect(match.Entity.LastModifiedTime.AsTime().Format("2006-01-02T15:04:05Z")).To(Equal("2023-04-17T16:42:00Z"))
+			Expect(match.Entity.Deleted).To(BeFalse())
+			Expect(match.Score).To(BeNumerically("~", 1.2039728, 1e-6))
+			Expect(match.Highlights).To(Equal([]string{"<mark>foo.pdf</mark>"}))
+		})
+
+		It("returns the highlights of the content and the name", func() {
+			cluster.expect("search_content")
+
+			res, err := eng.Search(ctx, &searchsvc.SearchIndexRequest{Query: "content:fox name:parent*", Ref: rootRef})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.Matches[0].Highlights).To(Equal([]string{
+				"The quick brown <mark>fox</mark> jumps over the lazy dog",
+				"<mark>parent d!r</mark>",
+			}))
+		})
+
+		It("has the content of the highlights escaped", func() {
+			cluster.expect("search_escaped")
+
+			res, err := eng.Search(ctx, &searchsvc.SearchIndexRequest{Query: "content:fox", Ref: rootRef})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.Matches[0].Highlights).To(Equal([]string{
+				"&lt;script&gt;alert(&#x27;<mark>fox</mark>&#x27;)&lt;&#x2F;script&gt; the <mark>fox</mark> &amp; the dog",
+			}))
+		})
+
+		It("pages through all matches", func() {
+			cluster.expect("search_all")
+
+			res, err := eng.Search(ctx, &searchsvc.SearchIndexRequest{Query: "name:*", Ref: rootRef, PageSize: -1})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.TotalMatches).To(Equal(int32(3)))
+			names := make([]string, 0, len(res.Matches))
+			for _, m := range res.Matches {
+				names = append(names, m.Entity.Name)
+			}
+			Expect(names).To(Equal([]string{"parent d!r", "child.pdf", "other.txt"}))
+		})
+
+		It("counts the matches by facet", func() {
+			cluster.expect("search_facets")
+
+			res, err := eng.Search(ctx, &searchsvc.SearchIndexRequest{
+				Query:  "name:*",
+				Facets: []string{"mimetype", "tag", "space", "size", "mtime"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.Facets).To(HaveLen(5))
+			Expect(facetValues(res.Facets[0])).To(Equal([]string{"folder=1", "pdf=1"}))
+			Expect(facetValues(res.Facets[1])).To(Equal([]string{"foo=2", "bar=1"}))
+			Expect(facetValues(res.Facets[2])).To(Equal([]string{"1$2!2=2"}))
+			Expect(facetValues(res.Facets[3])).To(Equal([]string{"empty=1", "small=1"}))
+			Expect(facetValues(res.Facets[4])).To(Equal([]string{"today=1", "this year=1"}))
+		})
+
+		It("rejects unknown facets", func() {
+			_, err := eng.Search(ctx, &searchsvc.SearchIndexRequest{
+				Query:  "name:*",
+				Facets: []string{"owner"},
+			})
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("returns the errors of the cluster", func() {
+			cluster.expect("search_error")
+
+			_, err := eng.Search(ctx, &searchsvc.SearchIndexRequest{Query: "name:foo", Ref: rootRef})
+			var osErr *engine.OpenSearchError
+			Expect(errors.As(err, &osErr)).To(BeTrue())
+			Expect(osErr.Status).To(Equal(http.StatusBadRequest))
+			Expect(osErr.Type).To(Equal("search_phase_execution_exception"))
+		})
+	})
+
+	Describe("Upsert", func() {
+		It("stores the resource in the index", func() {
+			cluster.expect("upsert")
+			Expect(eng.Upsert(childResource.ID, childResource)).To(Succeed())
+		})
+	})
+
+	Describe("Delete", func() {
+		It("marks a resource and its children as deleted", func() {
+			cluster.expect("delete")
+			Expect(eng.Delete(parentResource.ID)).To(Succeed())
+		})
+
+		It("fails for unknown resources", func() {
+			cluster.expect("delete_unknown")
+			Expect(eng.Delete("1$2!unknown")).ToNot(Succeed())
+		})
+	})
+
+	Describe("Restore", func() {
+		It("also marks child resources as restored", func() {
+			cluster.expect("restore")
+			Expect(eng.Restore(parentResource.ID)).To(Succeed())
+		})
+	})
+
+	Describe("Move", func() {
+		It("moves the parent and its child resources", func() {
+			cluster.expect("move")
+			Expect(eng.Move(parentResource.ID, "1$2!somewhereopaqueid", "./somewhere/else/newname")).To(Succeed())
+		})
+	})
+
+	Describe("Stats", func() {
+		It("counts the resources per space and returns the size of the index", func() {
+			cluster.expect("stats")
+
+			stats, err := eng.Stats()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stats.Spaces).To(Equal(map[string]uint64{"1$2!2": 2, "1$5!5": 1}))
+			Expect(stats.Size).To(Equal(uint64(4096)))
+		})
+	})
+
+	Describe("Paths", func() {
+		It("returns the paths of the resources of a space which aren't deleted", func() {
+			cluster.expect("paths")
+
+			paths, err := eng.Paths("1$2!2")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(paths).To(Equal(map[string]string{parentResource.ID: parentResource.Path}))
+		})
+	})
+
+	Describe("Purge", func() {
+		It("removes a resource from the index", func() {
+			cluster.expect("purge")
+			Expect(eng.Purge(childResource.ID)).To(Succeed())
+			Expect(eng.Purge(childResource.ID)).To(Succeed())
+		})
+	})
+
+	Describe("DocCount", func() {
+		It("returns the number of resources", func() {
+			cluster.expect("doc_count")
+
+			count, err := eng.DocCount()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(count).To(Equal(uint64(2)))
+		})
+	})
+})
+
+// exchange is a request to the cluster and its response
+type exchange struct {
+	Request struct {
+		Method string          `json:"method"`
+		Path   string          `json:"path"`
+		Body   json.RawMessage `json:"body"`
+	} `json:"request"`
+	Response struct {
+		Status int             `json:"status"`
+		Body   json.RawMessage `json:"body"`
+	} `json:"response"`
+}
+
+// recordedCluster answers the requests of the engine with the responses recorded in testdata/opensearch.
+// The requests have to be sent in the recorded order and have to match the recorded ones, the value
+// "<any>" in a recorded request body matches any value.
+type recordedCluster struct {
+	mu        sync.Mutex
+	exchanges []exchange
+	failures  []string
+}
+
+func (c *recordedCluster) expect(fixtures ...string) {
+	for _, name := range fixtures {
+		b, err := os.ReadFile(filepath.Join("testdata", "opensearch", name+".json"))
+		ExpectWithOffset(1, err).ToNot(HaveOccurred())
+		var exchanges []exchange
+		ExpectWithOffset(1, json.Unmarshal(b, &exchanges)).To(Succeed(), name)
+
+		c.mu.Lock()
+		c.exchanges = append(c.exchanges, exchanges...)
+		c.mu.Unlock()
+	}
+}
+
+// verify fails the test if a request didn't match or if recorded requests weren't sent
+func (c *recordedCluster) verify() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ExpectWithOffset(1, c.failures).To(BeEmpty())
+	ExpectWithOffset(1, c.exchanges).To(BeEmpty(), "not all recorded requests were sent")
+}
+
+func (c *recordedCluster) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	b, _ := io.ReadAll(r.Body)
+	body, err := decodeBody(b, r.Header.Get("Content-Type"))
+	if err != nil {
+		c.fail(w, "malformed body of %s %s: %s", r.Method, r.URL.RequestURI(), err)
+		return
+	}
+	if len(c.exchanges) == 0 {
+		c.fail(w, "unexpected request %s %s %s", r.Method, r.URL.RequestURI(), b)
+		return
+	}
+
+	e := c.exchanges[0]
+	c.exchanges = c.exchanges[1:]
+	var recorded interface{}
+	if len(e.Request.Body) > 0 {
+		_ = json.Unmarshal(e.Request.Body, &recorded)
+	}
+	if e.Request.Method != r.Method || e.Request.Path != r.URL.RequestURI() || !matchesRecorded(recorded, body) {
+		actual, _ := json.Marshal(body)
+		c.fail(w, "expected %s %s %s\ngot %s %s %s", e.Request.Method, e.Request.Path, e.Request.Body, r.Method, r.URL.RequestURI(), actual)
+		return
+	}
+
+	if len(e.Response.Body) > 0 {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.WriteHeader(e.Response.Status)
+	_, _ = w.Write(e.Response.Body)
+}
+
+func (c *recordedCluster) fail(w http.ResponseWriter, format string, args ...interface{}) {
+	c.failures = append(c.failures, fmt.Sprintf(format, args...))
+	w.WriteHeader(http.StatusInternalServerError)
+}
+
+// decodeBody decodes a json body, the documents of a ndjson body are returned as a list
+func decodeBody(b []byte, contentType string) (interface{}, error) {
+	if len(b) == 0 {
+		return nil, nil
+	}
+	if !strings.HasPrefix(contentType, "application/x-ndjson") {
+		var v interface{}
+		return v, json.Unmarshal(b, &v)
+	}
+
+	var docs []interface{}
+	for _, line := range bytes.Split(bytes.TrimSpace(b), []byte("\n")) {
+		var v interface{}
+		if err := json.Unmarshal(line, &v); err != nil {
+			return nil, err
+		}
+		docs = append(docs, v)
+	}
+	return docs, nil
+}
+
+func matchesRecorded(recorded, actual interface{}) bool {
+	switch r := recorded.(type) {
+	case string:
+		if r == "<any>" {
+			return true
+		}
+	case map[string]interface{}:
+		a, ok := actual.(map[string]interface{})
+		if !ok || len(a) != len(r) {
+			return false
+		}
+		for k, v := range r {
+			if av, ok := a[k]; !ok || !matchesRecorded(v, av) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		a, ok := actual.([]interface{})
+		if !ok || len(a) != len(r) {
+			return false
+		}
+		for i := range r {
+			if !matchesRecorded(r[i], a[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(recorded, actual)
+}
@@ -0,0 +1,102 @@
+// Package ast defines the syntax tree of search queries. Queries are parsed into the tree by the kql
+// package and translated into the queries of the search engines, which keeps the syntax clients use
+// independent of the engine.
+package ast
+
+import (
+	"time"
+)
+
+// Field names of the restrictions
+const (
+	FieldName     = "name"
+	FieldTag      = "tag"
+	FieldMimeType = "mimetype"
+	FieldSize     = "size"
+	FieldMtime    = "mtime"
+	FieldContent  = "content"
+	FieldHidden   = "hidden"
+	FieldID       = "id"
+)
+
+// Operator compares the value of a field with the value of a restriction
+type Operator string
+
+// Operators of the restrictions
+const (
+	OpEqual          Operator = ":"
+	OpLess           Operator = "<"
+	OpLessOrEqual    Operator = "<="
+	OpGreater        Operator = ">"
+	OpGreaterOrEqual Operator = ">="
+)
+
+// Node is a node of the syntax tree
+type Node interface {
+	node()
+}
+
+// And matches if all of its nodes match
+type And struct {
+	Nodes []Node
+}
+
+// Or matches if any of its nodes matches
+type Or struct {
+	Nodes []Node
+}
+
+// Not matches if its node doesn't match
+type Not struct {
+	Node Node
+}
+
+// Text restricts a text field. The value may contain the wildcards * and ?, unless it is a phrase.
+// Free text without a field restricts the name, it matches if the name contains the value.
+type Text struct {
+	Field  string
+	Value  string
+	Phrase bool
+}
+
+// Number restricts a numeric field
+type Number struct {
+	Field    string
+	Operator Operator
+	Value    int64
+}
+
+// DateTime restricts a date field. Only the operators <, <=, > and >= are used, equality is expressed
+// as a range of two restrictions.
+type DateTime struct {
+	Field    string
+	Operator Operator
+	Value    time.Time
+}
+
+// Boolean restricts a boolean field
+type Boolean struct {
+	Field string
+	Value bool
+}
+
+func (And) node()      {}
+func (Or) node()       {}
+func (Not) node()      {}
+func (Text) node()     {}
+func (Number) node()   {}
+func (DateTime) node() {}
+func (Boolean) node()  {}
+
+// HasWildcards tells if the value of a text restriction contains wildcards
+func (t Text) HasWildcards() bool {
+	if t.Phrase {
+		return false
+	}
+	for _, c := range t.Value {
+		if c == '*' || c == '?' {
+			return true
+		}
+	}
+	return false
+}
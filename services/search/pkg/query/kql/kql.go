@@ -0,0 +1,496 @@
+// Package kql parses search queries written in a subset of the Keyword Query Language into the syntax tree
+// of the ast package.
+//
+// A query consists of terms which are combined with AND, OR and NOT, adjacent terms are combined with AND.
+// Like in KQL, adjacent restrictions of the same field are combined with OR, tag:work tag:private matches
+// either tag. NOT binds stronger than AND, which binds stronger than OR, parentheses group terms. A term
+// is either free text, which matches the name of a resource, or a restriction of a field like
+// name:report.pdf. Words with a colon which don't start with a known field, like 10:30, are free text.
+// Values containing spaces are quoted, "annual report" is a phrase, or escaped with a backslash, like annual\ report.
+//
+// The comparisons of the previous query syntax, like Size:>1000, are accepted as well.
+package kql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/owncloud/ocis/v2/services/search/pkg/query/ast"
+)
+
+// maxDepth limits the nesting of groups and negations
+const maxDepth = 32
+
+// fields maps the field names of the query language, including aliases, to the fields of the syntax tree
+var fields = map[string]string{
+	"name":     ast.FieldName,
+	"tag":      ast.FieldTag,
+	"tags":     ast.FieldTag,
+	"mimetype": ast.FieldMimeType,
+	"size":     ast.FieldSize,
+	"mtime":    ast.FieldMtime,
+	"content":  ast.FieldContent,
+	"hidden":   ast.FieldHidden,
+	"id":       ast.FieldID,
+}
+
+// SyntaxError is returned for queries which don't follow the grammar
+type SyntaxError struct {
+	// Pos is the byte offset of the error in the query
+	Pos int
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("invalid query at position %d: %s", e.Pos+1, e.Msg)
+}
+
+// Parse parses a query, relative dates like today are relative to the current time
+func Parse(query string) (ast.Node, error) {
+	return ParseAt(query, time.Now())
+}
+
+// ParseAt parses a query, relative dates like today are relative to now
+func ParseAt(query string, now time.Time) (ast.Node, error) {
+	if !utf8.ValidString(query) {
+		return nil, &SyntaxError{Pos: invalidUTF8(query), Msg: "invalid UTF-8"}
+	}
+
+	p := &parser{input: query, now: now}
+	p.skipSpace()
+	if p.eof() {
+		return nil, p.errorf(p.pos, "empty query")
+	}
+
+	n, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if !p.eof() {
+		return nil, p.errorf(p.pos, "unexpected %q", p.input[p.pos])
+	}
+	return n, nil
+}
+
+type parser struct {
+	input string
+	pos   int
+	depth int
+	now   time.Time
+}
+
+func (p *parser) parseOr() (ast.Node, error) {
+	var nodes []ast.Node
+	for {
+		n, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, n)
+		if !p.keyword("OR") {
+			break
+		}
+	}
+	if len(nodes) == 1 {
+		return nodes[0], nil
+	}
+	return &ast.Or{Nodes: nodes}, nil
+}
+
+func (p *parser) parseAnd() (ast.Node, error) {
+	var (
+		nodes     []ast.Node
+		lastField string
+		implicit  bool
+	)
+	for {
+		n, field, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case implicit && field != "" && field == lastField:
+			// adjacent restrictions of the same field match any of their values
+			last := len(nodes) - 1
+			if or, ok := nodes[last].(*ast.Or); ok {
+				or.Nodes = append(or.Nodes, n)
+			} else {
+				nodes[last] = &ast.Or{Nodes: []ast.Node{nodes[last], n}}
+			}
+		default:
+			nodes = append(nodes, n)
+		}
+		lastField = field
+
+		p.skipSpace()
+		if p.eof() || p.peek() == ')' || p.peekKeyword("OR") {
+			break
+		}
+		// AND is optional between terms
+		implicit = !p.keyword("AND")
+	}
+	if len(nodes) == 1 {
+		return nodes[0], nil
+	}
+	return &ast.And{Nodes: nodes}, nil
+}
+
+// parseUnary parses a term with an optional negation. The field is returned for restrictions which are
+// neither negated nor required, so that adjacent restrictions of the same field can be combined.
+func (p *parser) parseUnary() (ast.Node, string, error) {
+	p.skipSpace()
+	start := p.pos
+	switch {
+	case p.keyword("NOT"):
+	case !p.eof() && p.peek() == '-':
+		p.pos++
+	case !p.eof() && p.peek() == '+':
+		// terms are required anyway
+		p.pos++
+		n, _, err := p.parsePrimary()
+		return n, "", err
+	default:
+		return p.parsePrimary()
+	}
+
+	if err := p.enter(start); err != nil {
+		return nil, "", err
+	}
+	defer p.leave()
+
+	n, _, err := p.parseUnary()
+	if err != nil {
+		return nil, "", err
+	}
+	return &ast.Not{Node: n}, "", nil
+}
+
+// parsePrimary parses a group, free text or a restriction, the field is returned for restrictions
+func (p *parser) parsePrimary() (ast.Node, string, error) {
+	p.skipSpace()
+	start := p.pos
+	if p.eof() {
+		return nil, "", p.errorf(start, "expected a term")
+	}
+
+	switch p.peek() {
+	case '(':
+		if err := p.enter(start); err != nil {
+			return nil, "", err
+		}
+		defer p.leave()
+
+		p.pos++
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, "", err
+		}
+		p.skipSpace()
+		if p.eof() || p.peek() != ')' {
+			return nil, "", p.errorf(start, "missing )")
+		}
+		p.pos++
+		return n, "", nil
+	case ')':
+		return nil, "", p.errorf(start, "unexpected )")
+	case '"':
+		value, err := p.scanPhrase()
+		if err != nil {
+			return nil, "", err
+		}
+		return &ast.Text{Value: value, Phrase: true}, "", nil
+	}
+
+	word := p.scanWhile(func(r rune) bool {
+		return !unicode.IsSpace(r) && !strings.ContainsRune(`()":<>=`, r)
+	})
+	if field, ok := fields[strings.ToLower(word)]; ok {
+		if op, ok := p.scanOperator(); ok {
+			n, err := p.parseRestriction(start, word, field, op)
+			return n, field, err
+		}
+	}
+
+	// anything else, including words with a colon like re: or 10:30, is free text
+	p.pos = start
+	word = p.scanValue()
+	if word == "" {
+		return nil, "", p.errorf(start, "unexpected %q", p.input[p.pos])
+	}
+	if raw := p.input[start:p.pos]; raw == "AND" || raw == "OR" {
+		return nil, "", p.errorf(start, "unexpected %s", word)
+	}
+	return &ast.Text{Value: word}, "", nil
+}
+
+func (p *parser) parseRestriction(start int, name string, field string, op ast.Operator) (ast.Node, error) {
+	if op == ast.OpEqual {
+		// the previous query syntax put the comparison after the colon, like Size:>1000
+		for _, cmp := range []ast.Operator{ast.OpLessOrEqual, ast.OpGreaterOrEqual, ast.OpLess, ast.OpGreater} {
+			if strings.HasPrefix(p.input[p.pos:], string(cmp)) {
+				p.pos += len(cmp)
+				op = cmp
+				break
+			}
+		}
+	}
+
+	valueStart := p.pos
+	var (
+		value  string
+		phrase bool
+	)
+	if !p.eof() && p.peek() == '"' {
+		var err error
+		if value, err = p.scanPhrase(); err != nil {
+			return nil, err
+		}
+		phrase = true
+	} else {
+		value = p.scanValue()
+		if value == "" {
+			return nil, p.errorf(valueStart, "expected a value for %s", name)
+		}
+	}
+
+	switch field {
+	case ast.FieldSize:
+		return p.parseSize(valueStart, field, op, value)
+	case ast.FieldMtime:
+		return p.parseDate(valueStart, field, op, value)
+	case ast.FieldHidden:
+		if op != ast.OpEqual {
+			return nil, p.errorf(start, "%s only supports :", name)
+		}
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, p.errorf(valueStart, "%s needs true or false", name)
+		}
+		return &ast.Boolean{Field: field, Value: b}, nil
+	default:
+		if op != ast.OpEqual {
+			return nil, p.errorf(start, "%s only supports :", name)
+		}
+		if patterns, ok := MimeTypeGroups[strings.ToLower(value)]; ok && field == ast.FieldMimeType && !phrase {
+			return mimeTypeGroup(patterns), nil
+		}
+		return &ast.Text{Field: field, Value: value, Phrase: phrase}, nil
+	}
+}
+
+// mimeTypeGroup matches any of the mime types of a group
+func mimeTypeGroup(patterns []string) ast.Node {
+	if len(patterns) == 1 {
+		return &ast.Text{Field: ast.FieldMimeType, Value: patterns[0]}
+	}
+	or := &ast.Or{}
+	for _, pattern := range patterns {
+		or.Nodes = append(or.Nodes, &ast.Text{Field: ast.FieldMimeType, Value: pattern})
+	}
+	return or
+}
+
+func (p *parser) parseSize(pos int, field string, op ast.Operator, value string) (ast.Node, error) {
+	for _, r := range SizeRanges {
+		if op == ast.OpEqual && strings.EqualFold(value, r.Name) {
+			return sizeRange(field, r), nil
+		}
+	}
+
+	if from, to, ok := strings.Cut(value, ".."); ok {
+		if op != ast.OpEqual {
+			return nil, p.errorf(pos, "ranges only support :")
+		}
+		min, err := parseSize(from)
+		if err != nil {
+			return nil, p.errorf(pos, "%s", err)
+		}
+		max, err := parseSize(to)
+		if err != nil {
+			return nil, p.errorf(pos, "%s", err)
+		}
+		return &ast.And{Nodes: []ast.Node{
+			&ast.Number{Field: field, Operator: ast.OpGreaterOrEqual, Value: min},
+			&ast.Number{Field: field, Operator: ast.OpLessOrEqual, Value: max},
+		}}, nil
+	}
+
+	n, err := parseSize(value)
+	if err != nil {
+		return nil, p.errorf(pos, "%s", err)
+	}
+	return &ast.Number{Field: field, Operator: op, Value: n}, nil
+}
+
+// sizeRange restricts a size to a named range
+func sizeRange(field string, r SizeRange) ast.Node {
+	var nodes []ast.Node
+	if r.Min > 0 {
+		nodes = append(nodes, &ast.Number{Field: field, Operator: ast.OpGreaterOrEqual, Value: r.Min})
+	}
+	if r.Max > 0 {
+		nodes = append(nodes, &ast.Number{Field: field, Operator: ast.OpLess, Value: r.Max})
+	}
+	if len(nodes) == 1 {
+		return nodes[0]
+	}
+	return &ast.And{Nodes: nodes}
+}
+
+// parseDate translates a date into restrictions. A date covers a period of time, a day for example,
+// and the operators compare with the start or the end of that period.
+func (p *parser) parseDate(pos int, field string, op ast.Operator, value string) (ast.Node, error) {
+	var from, to time.Time
+	if a, b, ok := strings.Cut(value, ".."); ok {
+		if op != ast.OpEqual {
+			return nil, p.errorf(pos, "ranges only support :")
+		}
+		var err error
+		if from, _, err = ParseDate(a, p.now); err != nil {
+			return nil, p.errorf(pos, "%s", err)
+		}
+		if _, to, err = ParseDate(b, p.now); err != nil {
+			return nil, p.errorf(pos, "%s", err)
+		}
+	} else {
+		var err error
+		if from, to, err = ParseDate(value, p.now); err != nil {
+			return nil, p.errorf(pos, "%s", err)
+		}
+	}
+
+	switch op {
+	case ast.OpGreater:
+		return &ast.DateTime{Field: field, Operator: ast.OpGreaterOrEqual, Value: to}, nil
+	case ast.OpGreaterOrEqual:
+		return &ast.DateTime{Field: field, Operator: ast.OpGreaterOrEqual, Value: from}, nil
+	case ast.OpLess:
+		return &ast.DateTime{Field: field, Operator: ast.OpLess, Value: from}, nil
+	case ast.OpLessOrEqual:
+		return &ast.DateTime{Field: field, Operator: ast.OpLess, Value: to}, nil
+	default:
+		return &ast.And{Nodes: []ast.Node{
+			&ast.DateTime{Field: field, Operator: ast.OpGreaterOrEqual, Value: from},
+			&ast.DateTime{Field: field, Operator: ast.OpLess, Value: to},
+		}}, nil
+	}
+}
+
+// enter descends into a group or negation
+func (p *parser) enter(pos int) error {
+	p.depth++
+	if p.depth > maxDepth {
+		return p.errorf(pos, "too deeply nested")
+	}
+	return nil
+}
+
+func (p *parser) leave() {
+	p.depth--
+}
+
+func (p *parser) scanOperator() (ast.Operator, bool) {
+	for _, op := range []string{"<=", ">=", "<", ">", ":", "="} {
+		if strings.HasPrefix(p.input[p.pos:], op) {
+			p.pos += len(op)
+			if op == "=" {
+				return ast.OpEqual, true
+			}
+			return ast.Operator(op), true
+		}
+	}
+	return "", false
+}
+
+func (p *parser) scanPhrase() (string, error) {
+	start := p.pos
+	p.pos++
+	end := strings.IndexByte(p.input[p.pos:], '"')
+	if end < 0 {
+		return "", p.errorf(start, "missing closing \"")
+	}
+	value := p.input[p.pos : p.pos+end]
+	p.pos += end + 1
+	return value, nil
+}
+
+func (p *parser) scanWhile(f func(r rune) bool) string {
+	start := p.pos
+	p.pos = len(p.input)
+	for i, r := range p.input[start:] {
+		if !f(r) {
+			p.pos = start + i
+			break
+		}
+	}
+	return p.input[start:p.pos]
+}
+
+// scanValue scans an unquoted value up to the next space, parenthesis or quote. A backslash makes the following
+// character part of the value, like the space in foo\ bar, and is removed.
+func (p *parser) scanValue() string {
+	var b strings.Builder
+	for !p.eof() {
+		r, size := utf8.DecodeRuneInString(p.input[p.pos:])
+		if r == '\\' && p.pos+size < len(p.input) {
+			p.pos += size
+			r, size = utf8.DecodeRuneInString(p.input[p.pos:])
+		} else if unicode.IsSpace(r) || strings.ContainsRune(`()"`, r) {
+			break
+		}
+		b.WriteRune(r)
+		p.pos += size
+	}
+	return b.String()
+}
+
+// keyword consumes the given keyword if it is the next word
+func (p *parser) keyword(k string) bool {
+	p.skipSpace()
+	if !p.peekKeyword(k) {
+		return false
+	}
+	p.pos += len(k)
+	return true
+}
+
+func (p *parser) peekKeyword(k string) bool {
+	if !strings.HasPrefix(p.input[p.pos:], k) {
+		return false
+	}
+	rest := p.input[p.pos+len(k):]
+	return rest == "" || rest[0] == '(' || unicode.IsSpace(rune(rest[0]))
+}
+
+func (p *parser) skipSpace() {
+	p.scanWhile(unicode.IsSpace)
+}
+
+func (p *parser) peek() byte {
+	return p.input[p.pos]
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.input)
+}
+
+// invalidUTF8 returns the byte offset of the first invalid UTF-8 sequence of s
+func invalidUTF8(s string) int {
+	for i, r := range s {
+		if r == utf8.RuneError {
+			if _, size := utf8.DecodeRuneInString(s[i:]); size == 1 {
+				return i
+			}
+		}
+	}
+	return len(s)
+}
+
+func (p *parser) errorf(pos int, format string, args ...interface{}) error {
+	return &SyntaxError{Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
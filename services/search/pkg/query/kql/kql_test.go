@@ -0,0 +1,147 @@
+package kql_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/owncloud/ocis/v2/services/search/pkg/query/ast"
+	"github.com/owncloud/ocis/v2/services/search/pkg/query/kql"
+)
+
+var _ = Describe("KQL", func() {
+	// a wednesday
+	now := time.Date(2023, 4, 19, 16, 42, 0, 0, time.UTC)
+	day := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	}
+	between := func(from, to time.Time) ast.Node {
+		return &ast.And{Nodes: []ast.Node{
+			&ast.DateTime{Field: ast.FieldMtime, Operator: ast.OpGreaterOrEqual, Value: from},
+			&ast.DateTime{Field: ast.FieldMtime, Operator: ast.OpLess, Value: to},
+		}}
+	}
+
+	DescribeTable("parses queries",
+		func(query string, expected ast.Node) {
+			n, err := kql.ParseAt(query, now)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(n).To(Equal(expected))
+		},
+
+		Entry("free text", "report", &ast.Text{Value: "report"}),
+		Entry("phrases", `"annual report"`, &ast.Text{Value: "annual report", Phrase: true}),
+		Entry("wildcards", "rep*rt", &ast.Text{Value: "rep*rt"}),
+		Entry("field restrictions", "name:report.pdf", &ast.Text{Field: ast.FieldName, Value: "report.pdf"}),
+		Entry("field names in any case", "Name:report.pdf", &ast.Text{Field: ast.FieldName, Value: "report.pdf"}),
+		Entry("field aliases", "tags:work", &ast.Text{Field: ast.FieldTag, Value: "work"}),
+		Entry("= as operator", "tag=work", &ast.Text{Field: ast.FieldTag, Value: "work"}),
+		Entry("phrases as values", `content:"quick fox"`, &ast.Text{Field: ast.FieldContent, Value: "quick fox", Phrase: true}),
+		Entry("values with colons", `mimetype:application/vnd.oasis:odt`, &ast.Text{Field: ast.FieldMimeType, Value: "application/vnd.oasis:odt"}),
+		Entry("booleans", "hidden:false", &ast.Boolean{Field: ast.FieldHidden, Value: false}),
+		Entry("unknown fields as text", "owner:me", &ast.Text{Value: "owner:me"}),
+		Entry("words with colons as text", "re: invoice", &ast.And{Nodes: []ast.Node{&ast.Text{Value: "re:"}, &ast.Text{Value: "invoice"}}}),
+		Entry("times as text", "10:30 meeting", &ast.And{Nodes: []ast.Node{&ast.Text{Value: "10:30"}, &ast.Text{Value: "meeting"}}}),
+		Entry("mime type groups", "mimetype:image", &ast.Text{Field: ast.FieldMimeType, Value: "image/*"}),
+		Entry("mime type groups with several patterns", "mimetype:Presentation", &ast.Or{Nodes: []ast.Node{
+			&ast.Text{Field: ast.FieldMimeType, Value: "application/vnd.ms-powerpoint*"},
+			&ast.Text{Field: ast.FieldMimeType, Value: "application/vnd.oasis.opendocument.presentation*"},
+			&ast.Text{Field: ast.FieldMimeType, Value: "application/vnd.openxmlformats-officedocument.presentationml.*"},
+		}}),
+		Entry("quoted mime type groups as text", `mimetype:"image"`, &ast.Text{Field: ast.FieldMimeType, Value: "image", Phrase: true}),
+
+		Entry("sizes", "size>1000", &ast.Number{Field: ast.FieldSize, Operator: ast.OpGreater, Value: 1000}),
+		Entry("sizes with units", "size<=10MB", &ast.Number{Field: ast.FieldSize, Operator: ast.OpLessOrEqual, Value: 10 << 20}),
+		Entry("comparisons after the colon", "Size:>1000", &ast.Number{Field: ast.FieldSize, Operator: ast.OpGreater, Value: 1000}),
+		Entry("size ranges", "size:1kb..2kb", &ast.And{Nodes: []ast.Node{
+			&ast.Number{Field: ast.FieldSize, Operator: ast.OpGreaterOrEqual, Value: 1024},
+			&ast.Number{Field: ast.FieldSize, Operator: ast.OpLessOrEqual, Value: 2048},
+		}}),
+		Entry("named sizes", "size:small", &ast.And{Nodes: []ast.Node{
+			&ast.Number{Field: ast.FieldSize, Operator: ast.OpGreaterOrEqual, Value: 16 << 10},
+			&ast.Number{Field: ast.FieldSize, Operator: ast.OpLess, Value: 1 << 20},
+		}}),
+		Entry("empty", "size:empty", &ast.Number{Field: ast.FieldSize, Operator: ast.OpLess, Value: 1}),
+		Entry("gigantic", "size:Gigantic", &ast.Number{Field: ast.FieldSize, Operator: ast.OpGreaterOrEqual, Value: 4 << 30}),
+
+		Entry("days", "mtime:2023-04-17", between(day(2023, 4, 17), day(2023, 4, 18))),
+		Entry("after a day", "mtime>2023-04-17", &ast.DateTime{Field: ast.FieldMtime, Operator: ast.OpGreaterOrEqual, Value: day(2023, 4, 18)}),
+		Entry("from a day", "mtime>=2023-04-17", &ast.DateTime{Field: ast.FieldMtime, Operator: ast.OpGreaterOrEqual, Value: day(2023, 4, 17)}),
+		Entry("before a day", "mtime<2023-04-17", &ast.DateTime{Field: ast.FieldMtime, Operator: ast.OpLess, Value: day(2023, 4, 17)}),
+		Entry("until a day", "mtime<=2023-04-17", &ast.DateTime{Field: ast.FieldMtime, Operator: ast.OpLess, Value: day(2023, 4, 18)}),
+		Entry("date ranges", "mtime:2023-01-01..2023-03-31", between(day(2023, 1, 1), day(2023, 4, 1))),
+		Entry("points in time", `mtime>="2023-04-17T16:42:00.5Z"`, &ast.DateTime{Field: ast.FieldMtime, Operator: ast.OpGreaterOrEqual, Value: time.Date(2023, 4, 17, 16, 42, 0, 500000000, time.UTC)}),
+		Entry("points in time after the colon", `Mtime:>="2023-04-17T16:42:00Z"`, &ast.DateTime{Field: ast.FieldMtime, Operator: ast.OpGreaterOrEqual, Value: time.Date(2023, 4, 17, 16, 42, 0, 0, time.UTC)}),
+		Entry("today", "mtime:today", between(day(2023, 4, 19), day(2023, 4, 20))),
+		Entry("yesterday", "mtime:yesterday", between(day(2023, 4, 18), day(2023, 4, 19))),
+		Entry("this week", `mtime:"this week"`, between(day(2023, 4, 17), day(2023, 4, 24))),
+		Entry("last week", `mtime:"last week"`, between(day(2023, 4, 10), day(2023, 4, 17))),
+		Entry("last month", `mtime:"last month"`, between(day(2023, 3, 1), day(2023, 4, 1))),
+		Entry("this year", `mtime:"this year"`, between(day(2023, 1, 1), day(2024, 1, 1))),
+
+		Entry("escaped spaces in values", `name:foo\ o*`, &ast.Text{Field: ast.FieldName, Value: "foo o*"}),
+		Entry("escaped spaces in free text", `annual\ report`, &ast.Text{Value: "annual report"}),
+		Entry("escaped parentheses and quotes", `name:\(1\)\"`, &ast.Text{Field: ast.FieldName, Value: `(1)"`}),
+		Entry("escaped backslashes", `name:a\\b`, &ast.Text{Field: ast.FieldName, Value: `a\b`}),
+		Entry("trailing backslashes", `foo\`, &ast.Text{Value: `foo\`}),
+		Entry("escaped operators as text", `foo \AND bar`, &ast.And{Nodes: []ast.Node{&ast.Text{Value: "foo"}, &ast.Text{Value: "AND"}, &ast.Text{Value: "bar"}}}),
+
+		Entry("implicit AND", "foo bar", &ast.And{Nodes: []ast.Node{&ast.Text{Value: "foo"}, &ast.Text{Value: "bar"}}}),
+		Entry("explicit AND", "foo AND bar", &ast.And{Nodes: []ast.Node{&ast.Text{Value: "foo"}, &ast.Text{Value: "bar"}}}),
+		Entry("implicit OR of the same field", "tag:foo tag:bar tag:baz", &ast.Or{Nodes: []ast.Node{
+			&ast.Text{Field: ast.FieldTag, Value: "foo"},
+			&ast.Text{Field: ast.FieldTag, Value: "bar"},
+			&ast.Text{Field: ast.FieldTag, Value: "baz"},
+		}}),
+		Entry("explicit AND of the same field", "tag:foo AND tag:bar", &ast.And{Nodes: []ast.Node{
+			&ast.Text{Field: ast.FieldTag, Value: "foo"},
+			&ast.Text{Field: ast.FieldTag, Value: "bar"},
+		}}),
+		Entry("implicit AND of different fields", "tag:foo name:bar tag:baz", &ast.And{Nodes: []ast.Node{
+			&ast.Text{Field: ast.FieldTag, Value: "foo"},
+			&ast.Text{Field: ast.FieldName, Value: "bar"},
+			&ast.Text{Field: ast.FieldTag, Value: "baz"},
+		}}),
+		Entry("OR", "foo OR bar", &ast.Or{Nodes: []ast.Node{&ast.Text{Value: "foo"}, &ast.Text{Value: "bar"}}}),
+		Entry("lower case operators as text", "foo or bar", &ast.And{Nodes: []ast.Node{&ast.Text{Value: "foo"}, &ast.Text{Value: "or"}, &ast.Text{Value: "bar"}}}),
+		Entry("NOT", "NOT foo", &ast.Not{Node: &ast.Text{Value: "foo"}}),
+		Entry("-", "-tag:foo", &ast.Not{Node: &ast.Text{Field: ast.FieldTag, Value: "foo"}}),
+		Entry("+", "+foo", &ast.Text{Value: "foo"}),
+		Entry("AND before OR", "a OR b c", &ast.Or{Nodes: []ast.Node{
+			&ast.Text{Value: "a"},
+			&ast.And{Nodes: []ast.Node{&ast.Text{Value: "b"}, &ast.Text{Value: "c"}}},
+		}}),
+		Entry("groups", "(a OR b) NOT c", &ast.And{Nodes: []ast.Node{
+			&ast.Or{Nodes: []ast.Node{&ast.Text{Value: "a"}, &ast.Text{Value: "b"}}},
+			&ast.Not{Node: &ast.Text{Value: "c"}},
+		}}),
+		Entry("NOT before groups", "NOT(a b)", &ast.Not{Node: &ast.And{Nodes: []ast.Node{&ast.Text{Value: "a"}, &ast.Text{Value: "b"}}}}),
+	)
+
+	DescribeTable("rejects invalid queries",
+		func(query string, pos int) {
+			_, err := kql.ParseAt(query, now)
+			var syntaxErr *kql.SyntaxError
+			Expect(errors.As(err, &syntaxErr)).To(BeTrue())
+			Expect(syntaxErr.Pos).To(Equal(pos))
+		},
+
+		Entry("empty queries", "  ", 2),
+		Entry("missing values", "name: foo", 5),
+		Entry("unsupported operators", "name>foo", 0),
+		Entry("invalid sizes", "size>big", 5),
+		Entry("named sizes with other operators", "size>small", 5),
+		Entry("invalid dates", "mtime>tomorrow", 6),
+		Entry("unclosed phrases", `name:"foo`, 5),
+		Entry("unclosed groups", "(foo OR bar", 0),
+		Entry("unopened groups", "foo)", 3),
+		Entry("dangling operators", "foo AND", 7),
+		Entry("leading operators", "OR foo", 0),
+		Entry("invalid UTF-8", "\xff", 0),
+		Entry("invalid UTF-8 in values", "name:\xffabc", 5),
+		Entry("invalid UTF-8 in sizes", "size:>\xff", 6),
+		Entry("invalid UTF-8 after valid runes", "größe ä\xc3", 10),
+		Entry("deep nesting", "((((((((((((((((((((((((((((((((((foo))))))))))))))))))))))))))))))))))", 32),
+	)
+})
@@ -0,0 +1,148 @@
+package kql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sizeUnits are the units sizes can be given in, they are binary multiples
+var sizeUnits = []struct {
+	suffix string
+	factor int64
+}{
+	{"kb", 1 << 10},
+	{"mb", 1 << 20},
+	{"gb", 1 << 30},
+	{"tb", 1 << 40},
+	{"k", 1 << 10},
+	{"m", 1 << 20},
+	{"g", 1 << 30},
+	{"t", 1 << 40},
+	{"b", 1},
+}
+
+// SizeRange is a named range of sizes, Max is exclusive and 0 if the range has no upper bound
+type SizeRange struct {
+	Name string
+	Min  int64
+	Max  int64
+}
+
+// SizeRanges are the named ranges of sizes, like size:small
+var SizeRanges = []SizeRange{
+	{"empty", 0, 1},
+	{"tiny", 1, 16 << 10},
+	{"small", 16 << 10, 1 << 20},
+	{"medium", 1 << 20, 128 << 20},
+	{"large", 128 << 20, 1 << 30},
+	{"huge", 1 << 30, 4 << 30},
+	{"gigantic", 4 << 30, 0},
+}
+
+// RelativeDates are the dates which are relative to the current time, like mtime:today
+var RelativeDates = []string{
+	"today",
+	"yesterday",
+	"this week",
+	"last week",
+	"this month",
+	"last month",
+	"this year",
+	"last year",
+}
+
+// MimeTypeGroups maps the groups of mime types, like mimetype:document, to the patterns of the
+// mime types they contain
+var MimeTypeGroups = map[string][]string{
+	"folder": {"httpd/unix-directory"},
+	"document": {
+		"application/msword",
+		"application/rtf",
+		"application/vnd.oasis.opendocument.text*",
+		"application/vnd.openxmlformats-officedocument.wordprocessingml.*",
+		"text/markdown",
+		"text/plain",
+	},
+	"spreadsheet": {
+		"application/vnd.ms-excel*",
+		"application/vnd.oasis.opendocument.spreadsheet*",
+		"application/vnd.openxmlformats-officedocument.spreadsheetml.*",
+		"text/csv",
+	},
+	"presentation": {
+		"application/vnd.ms-powerpoint*",
+		"application/vnd.oasis.opendocument.presentation*",
+		"application/vnd.openxmlformats-officedocument.presentationml.*",
+	},
+	"pdf":   {"application/pdf"},
+	"image": {"image/*"},
+	"video": {"video/*"},
+	"audio": {"audio/*"},
+	"archive": {
+		"application/gzip",
+		"application/vnd.rar",
+		"application/x-7z-compressed",
+		"application/x-bzip2",
+		"application/x-rar-compressed",
+		"application/x-tar",
+		"application/x-xz",
+		"application/zip",
+	},
+}
+
+// parseSize parses a size in bytes with an optional unit like 10mb
+func parseSize(s string) (int64, error) {
+	v := strings.ToLower(s)
+	factor := int64(1)
+	for _, u := range sizeUnits {
+		if strings.HasSuffix(v, u.suffix) {
+			v, factor = strings.TrimSuffix(v, u.suffix), u.factor
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return n * factor, nil
+}
+
+// ParseDate returns the period of time a date covers, the end is exclusive. Dates are either
+// relative like today or last week, days like 2023-04-17 or points in time in RFC 3339 format.
+func ParseDate(s string, now time.Time) (time.Time, time.Time, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	// weeks start on monday
+	week := today.AddDate(0, 0, -(int(today.Weekday())+6)%7)
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	year := time.Date(now.Year(), time.January, 1, 0, 0, 0, 0, now.Location())
+
+	switch strings.Join(strings.Fields(strings.ToLower(s)), " ") {
+	case "today":
+		return today, today.AddDate(0, 0, 1), nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), today, nil
+	case "this week":
+		return week, week.AddDate(0, 0, 7), nil
+	case "last week":
+		return week.AddDate(0, 0, -7), week, nil
+	case "this month":
+		return month, month.AddDate(0, 1, 0), nil
+	case "last month":
+		return month.AddDate(0, -1, 0), month, nil
+	case "this year":
+		return year, year.AddDate(1, 0, 0), nil
+	case "last year":
+		return year.AddDate(-1, 0, 0), year, nil
+	}
+
+	if d, err := time.ParseInLocation("2006-01-02", s, now.Location()); err == nil {
+		return d, d.AddDate(0, 0, 1), nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, t.Add(time.Nanosecond), nil
+	}
+	return time.Time{}, time.Time{}, fmt.Errorf("invalid date %q", s)
+}
@@ -5,11 +5,15 @@ package mocks
 import (
 	context "context"
 
+	engine "github.com/owncloud/ocis/v2/services/search/pkg/engine"
+
 	providerv1beta1 "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
 	mock "github.com/stretchr/testify/mock"
 
 	userv1beta1 "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
 
+	searchv0 "github.com/owncloud/ocis/v2/protogen/gen/ocis/messages/search/v0"
+
 	v0 "github.com/owncloud/ocis/v2/protogen/gen/ocis/services/search/v0"
 )
 
@@ -18,6 +22,29 @@ type Searcher struct {
 	mock.Mock
 }
 
+// IndexAllSpaces provides a mock function with given fields:
+func (_m *Searcher) IndexAllSpaces() ([]string, error) {
+	ret := _m.Called()
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func() []string); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // IndexSpace provides a mock function with given fields: rID, uID
 func (_m *Searcher) IndexSpace(rID *providerv1beta1.StorageSpaceId, uID *userv1beta1.UserId) error {
 	ret := _m.Called(rID, uID)
@@ -65,6 +92,29 @@ func (_m *Searcher) Search(ctx context.Context, req *v0.SearchRequest) (*v0.Sear
 	return r0, r1
 }
 
+// Stats provides a mock function with given fields:
+func (_m *Searcher) Stats() (*engine.Stats, error) {
+	ret := _m.Called()
+
+	var r0 *engine.Stats
+	if rf, ok := ret.Get(0).(func() *engine.Stats); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*engine.Stats)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // TrashItem provides a mock function with given fields: rID
 func (_m *Searcher) TrashItem(rID *providerv1beta1.ResourceId) {
 	_m.Called(rID)
@@ -75,6 +125,38 @@ func (_m *Searcher) UpsertItem(ref *providerv1beta1.Reference, uID *userv1beta1.
 	_m.Called(ref, uID)
 }
 
+// VerifySpace provides a mock function with given fields: rID, uID
+func (_m *Searcher) VerifySpace(rID *providerv1beta1.StorageSpaceId, uID *userv1beta1.UserId) ([]*searchv0.Entity, []*searchv0.Entity, error) {
+	ret := _m.Called(rID, uID)
+
+	var r0 []*searchv0.Entity
+	if rf, ok := ret.Get(0).(func(*providerv1beta1.StorageSpaceId, *userv1beta1.UserId) []*searchv0.Entity); ok {
+		r0 = rf(rID, uID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*searchv0.Entity)
+		}
+	}
+
+	var r1 []*searchv0.Entity
+	if rf, ok := ret.Get(1).(func(*providerv1beta1.StorageSpaceId, *userv1beta1.UserId) []*searchv0.Entity); ok {
+		r1 = rf(rID, uID)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).([]*searchv0.Entity)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(*providerv1beta1.StorageSpaceId, *userv1beta1.UserId) error); ok {
+		r2 = rf(rID, uID)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 type mockConstructorTestingTNewSearcher interface {
 	mock.TestingT
 	Cleanup(func())
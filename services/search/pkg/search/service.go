@@ -2,10 +2,12 @@ package search
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
@@ -23,6 +25,7 @@ import (
 	"github.com/owncloud/ocis/v2/services/search/pkg/config"
 	"github.com/owncloud/ocis/v2/services/search/pkg/content"
 	"github.com/owncloud/ocis/v2/services/search/pkg/engine"
+	"github.com/owncloud/ocis/v2/services/search/pkg/query/kql"
 )
 
 //go:generate mockery --name=Searcher
@@ -31,6 +34,9 @@ import (
 type Searcher interface {
 	Search(ctx context.Context, req *searchsvc.SearchRequest) (*searchsvc.SearchResponse, error)
 	IndexSpace(rID *provider.StorageSpaceId, uID *user.UserId) error
+	IndexAllSpaces() ([]string, error)
+	VerifySpace(rID *provider.StorageSpaceId, uID *user.UserId) ([]*searchmsg.Entity, []*searchmsg.Entity, error)
+	Stats() (*engine.Stats, error)
 	TrashItem(rID *provider.ResourceId)
 	UpsertItem(ref *provider.Reference, uID *user.UserId)
 	RestoreItem(ref *provider.Reference, uID *user.UserId)
@@ -40,21 +46,26 @@ type Searcher interface {
 // Service is responsible for indexing spaces and pass on a search
 // to it's underlying engine.
 type Service struct {
-	logger    log.Logger
-	gateway   gateway.GatewayAPIClient
-	engine    engine.Engine
-	extractor content.Extractor
-	secret    string
+	logger        log.Logger
+	gateway       gateway.GatewayAPIClient
+	engine        engine.Engine
+	extractor     content.Extractor
+	secret        string
+	serviceUserID string
+
+	// indexingAll is locked while all spaces are indexed
+	indexingAll sync.Mutex
 }
 
 // NewService creates a new Provider instance.
 func NewService(gw gateway.GatewayAPIClient, eng engine.Engine, extractor content.Extractor, logger log.Logger, cfg *config.Config) *Service {
 	var s = &Service{
-		gateway:   gw,
-		engine:    eng,
-		secret:    cfg.MachineAuthAPIKey,
-		logger:    logger,
-		extractor: extractor,
+		gateway:       gw,
+		engine:        eng,
+		secret:        cfg.MachineAuthAPIKey,
+		logger:        logger,
+		extractor:     extractor,
+		serviceUserID: cfg.ServiceUserID,
 	}
 
 	return s
@@ -65,6 +76,12 @@ func (s *Service) Search(ctx context.Context, req *searchsvc.SearchRequest) (*se
 	if req.Query == "" {
 		return nil, errtypes.BadRequest("empty query provided")
 	}
+	if _, err := kql.Parse(req.Query); err != nil {
+		return nil, errtypes.BadRequest(err.Error())
+	}
+	if err := engine.CheckFacets(req.Facets); err != nil {
+		return nil, errtypes.BadRequest(err.Error())
+	}
 	s.logger.Debug().Str("query", req.Query).Msg("performing a search")
 
 	listSpacesRes, err := s.gateway.ListStorageSpaces(ctx, &provider.ListStorageSpacesRequest{
@@ -94,8 +111,23 @@ func (s *Service) Search(ctx context.Context, req *searchsvc.SearchRequest) (*se
 		mountpointMap[grantSpaceID] = space.Id.OpaqueId
 	}
 
+	// the matches are counted by space here, the index doesn't know about mountpoints
+	var (
+		indexFacets []string
+		countSpaces bool
+	)
+	for _, f := range req.Facets {
+		if f == engine.FacetSpace {
+			countSpaces = true
+			continue
+		}
+		indexFacets = append(indexFacets, f)
+	}
+
 	matches := matchArray{}
 	total := int32(0)
+	facets := [][]*searchmsg.Facet{}
+	spaceFacet := &searchmsg.Facet{Name: engine.FacetSpace}
 	for _, space := range listSpacesRes.StorageSpaces {
 		searchRootID := &searchmsg.ResourceID{
 			StorageId: space.Root.StorageId,
@@ -114,6 +146,7 @@ func (s *Service) Search(ctx context.Context, req *searchsvc.SearchRequest) (*se
 			mountpointRootID *searchmsg.ResourceID
 			rootName         string
 			permissions      *provider.ResourcePermissions
+			spaceID          = space.Id.OpaqueId
 		)
 		mountpointPrefix := ""
 		switch space.SpaceType {
@@ -149,6 +182,7 @@ func (s *Service) Search(ctx context.Context, req *searchsvc.SearchRequest) (*se
 				SpaceId:   spid,
 				OpaqueId:  oid,
 			}
+			spaceID = mountpointID
 			rootName = space.GetRootInfo().GetPath()
 			permissions = space.GetRootInfo().GetPermissionSet()
 			s.logger.Debug().Interface("grantSpace", space).Interface("mountpointRootId", mountpointRootID).Msg("searching a grant")
@@ -163,6 +197,7 @@ func (s *Service) Search(ctx context.Context, req *searchsvc.SearchRequest) (*se
 				Path:       mountpointPrefix,
 			},
 			PageSize: req.PageSize,
+			Facets:   indexFacets,
 		})
 		if err != nil {
 			s.logger.Error().Err(err).Str("space", space.Id.OpaqueId).Msg("failed to search the index")
@@ -171,6 +206,10 @@ func (s *Service) Search(ctx context.Context, req *searchsvc.SearchRequest) (*se
 		s.logger.Debug().Str("space", space.Id.OpaqueId).Int("hits", len(res.Matches)).Msg("space search done")
 
 		total += res.TotalMatches
+		facets = append(facets, res.Facets)
+		if countSpaces {
+			spaceFacet.Values = append(spaceFacet.Values, &searchmsg.FacetValue{Value: spaceID, Count: res.TotalMatches})
+		}
 		for _, match := range res.Matches {
 			if mountpointPrefix != "" {
 				match.Entity.Ref.Path = utils.MakeRelativePath(strings.TrimPrefix(match.Entity.Ref.Path, mountpointPrefix))
@@ -198,10 +237,14 @@ func (s *Service) Search(ctx context.Context, req *searchsvc.SearchRequest) (*se
 		matches = matches[0:limit]
 	}
 
-	return &searchsvc.SearchResponse{
+	res := &searchsvc.SearchResponse{
 		Matches:      matches,
 		TotalMatches: total,
-	}, nil
+	}
+	if len(req.Facets) > 0 {
+		res.Facets = engine.MergeFacets(req.Facets, append(facets, []*searchmsg.Facet{spaceFacet})...)
+	}
+	return res, nil
 }
 
 // IndexSpace (re)indexes all resources of a given space.
@@ -211,16 +254,11 @@ func (s *Service) IndexSpace(spaceID *provider.StorageSpaceId, uID *user.UserId)
 		return err
 	}
 
-	rootID, err := storagespace.ParseID(spaceID.OpaqueId)
+	rootID, err := spaceRootID(spaceID)
 	if err != nil {
 		s.logger.Error().Err(err).Msg("invalid space id")
 		return err
 	}
-	if rootID.StorageId == "" || rootID.SpaceId == "" {
-		s.logger.Error().Err(err).Msg("invalid space id")
-		return fmt.Errorf("invalid space id")
-	}
-	rootID.OpaqueId = rootID.SpaceId
 
 	w := walker.NewWalker(s.gateway)
 	err = w.Walk(ownerCtx, &rootID, func(wd string, info *provider.ResourceInfo, err error) error {
@@ -266,6 +304,212 @@ func (s *Service) IndexSpace(spaceID *provider.StorageSpaceId, uID *user.UserId)
 	return nil
 }
 
+// IndexAllSpaces (re)indexes all personal and project spaces in the background and returns the ids of the spaces
+// which are going to be indexed. Personal spaces are accessed as their owner, project spaces as the service user.
+// Only one run is allowed at a time.
+func (s *Service) IndexAllSpaces() ([]string, error) {
+	if !s.indexingAll.TryLock() {
+		return nil, errtypes.AlreadyExists("all spaces are already being indexed")
+	}
+
+	spaces, err := s.listAllSpaces()
+	if err != nil {
+		s.indexingAll.Unlock()
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(spaces))
+	for _, space := range spaces {
+		ids = append(ids, space.GetId().GetOpaqueId())
+	}
+
+	go func() {
+		defer s.indexingAll.Unlock()
+
+		failed := 0
+		for _, space := range spaces {
+			if err := s.IndexSpace(space.GetId(), s.spaceUser(space)); err != nil {
+				s.logger.Error().Err(err).Str("space", space.GetId().GetOpaqueId()).Msg("failed to index space")
+				failed++
+			}
+		}
+		if failed > 0 {
+			s.logger.Error().Int("failed", failed).Int("spaces", len(spaces)).Msg("failed to index all spaces")
+			return
+		}
+		s.logger.Info().Int("spaces", len(spaces)).Msg("indexed all spaces")
+	}()
+	return ids, nil
+}
+
+// VerifySpace compares the index of a space with the storage. It returns the indexed resources which
+// don't exist anymore and the resources which are missing in the index. Without a user personal spaces are
+// accessed as their owner and project spaces as the service user.
+func (s *Service) VerifySpace(spaceID *provider.StorageSpaceId, uID *user.UserId) ([]*searchmsg.Entity, []*searchmsg.Entity, error) {
+	if uID.GetOpaqueId() == "" {
+		space, err := s.getSpace(spaceID)
+		if err != nil {
+			return nil, nil, err
+		}
+		uID = s.spaceUser(space)
+	}
+	ownerCtx, err := getAuthContext(&user.User{Id: uID}, s.gateway, s.secret, s.logger)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rootID, err := spaceRootID(spaceID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	indexed, err := s.engine.Paths(storagespace.FormatResourceID(rootID))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var missing []*searchmsg.Entity
+	w := walker.NewWalker(s.gateway)
+	err = w.Walk(ownerCtx, &rootID, func(wd string, info *provider.ResourceInfo, err error) error {
+		if err != nil {
+			s.logger.Error().Err(err).Msg("error walking the tree")
+			return err
+		}
+
+		if info == nil {
+			return nil
+		}
+
+		id := storagespace.FormatResourceID(*info.Id)
+		if _, ok := indexed[id]; ok {
+			delete(indexed, id)
+			return nil
+		}
+
+		missing = append(missing, indexEntity(rootID, *info.Id, utils.MakeRelativePath(filepath.Join(wd, info.Path))))
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// what is left in the index hasn't been found in the storage
+	stale := make([]*searchmsg.Entity, 0, len(indexed))
+	for id, p := range indexed {
+		rID, err := storagespace.ParseID(id)
+		if err != nil {
+			s.logger.Error().Err(err).Str("id", id).Msg("invalid resource id in the index")
+			continue
+		}
+		stale = append(stale, indexEntity(rootID, rID, p))
+	}
+	sort.Slice(stale, func(i, j int) bool {
+		return stale[i].GetRef().GetPath() < stale[j].GetRef().GetPath()
+	})
+
+	return stale, missing, nil
+}
+
+// Stats returns the number of resources per space and the size of the index.
+func (s *Service) Stats() (*engine.Stats, error) {
+	return s.engine.Stats()
+}
+
+// listAllSpaces lists the personal and project spaces of all users as the service user
+func (s *Service) listAllSpaces() ([]*provider.StorageSpace, error) {
+	return s.listSpaces(
+		&provider.ListStorageSpacesRequest_Filter{
+			Type: provider.ListStorageSpacesRequest_Filter_TYPE_SPACE_TYPE,
+			Term: &provider.ListStorageSpacesRequest_Filter_SpaceType{SpaceType: "personal"},
+		},
+		&provider.ListStorageSpacesRequest_Filter{
+			Type: provider.ListStorageSpacesRequest_Filter_TYPE_SPACE_TYPE,
+			Term: &provider.ListStorageSpacesRequest_Filter_SpaceType{SpaceType: "project"},
+		},
+	)
+}
+
+// getSpace looks up a space of any user as the service user
+func (s *Service) getSpace(spaceID *provider.StorageSpaceId) (*provider.StorageSpace, error) {
+	spaces, err := s.listSpaces(&provider.ListStorageSpacesRequest_Filter{
+		Type: provider.ListStorageSpacesRequest_Filter_TYPE_ID,
+		Term: &provider.ListStorageSpacesRequest_Filter_Id{Id: spaceID},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(spaces) == 0 {
+		return nil, errtypes.NotFound("space " + spaceID.GetOpaqueId())
+	}
+	return spaces[0], nil
+}
+
+// listSpaces lists the spaces of all users matching the filters as the service user
+func (s *Service) listSpaces(filters ...*provider.ListStorageSpacesRequest_Filter) ([]*provider.StorageSpace, error) {
+	if s.serviceUserID == "" {
+		return nil, errors.New("no service user configured to list all spaces")
+	}
+	ctx, err := getAuthContext(&user.User{Id: &user.UserId{OpaqueId: s.serviceUserID}}, s.gateway, s.secret, s.logger)
+	if err != nil {
+		return nil, err
+	}
+
+	// use the unrestricted flag to get the spaces of all users
+	res, err := s.gateway.ListStorageSpaces(ctx, &provider.ListStorageSpacesRequest{
+		Opaque:  utils.AppendPlainToOpaque(nil, "unrestricted", "T"),
+		Filters: filters,
+	})
+	if err != nil {
+		s.logger.Error().Err(err).Msg("failed to list storage spaces")
+		return nil, err
+	}
+	if res.GetStatus().GetCode() != rpcv1beta1.Code_CODE_OK {
+		return nil, errtypes.NewErrtypeFromStatus(res.GetStatus())
+	}
+
+	return res.GetStorageSpaces(), nil
+}
+
+// spaceUser returns the user to access a space as, project spaces don't have an owner who could log in
+func (s *Service) spaceUser(space *provider.StorageSpace) *user.UserId {
+	if space.GetSpaceType() == "personal" && space.GetOwner().GetId() != nil {
+		return space.GetOwner().GetId()
+	}
+	return &user.UserId{OpaqueId: s.serviceUserID}
+}
+
+// spaceRootID returns the id of the root of a space
+func spaceRootID(spaceID *provider.StorageSpaceId) (provider.ResourceId, error) {
+	rootID, err := storagespace.ParseID(spaceID.GetOpaqueId())
+	if err != nil {
+		return rootID, err
+	}
+	if rootID.StorageId == "" || rootID.SpaceId == "" {
+		return rootID, fmt.Errorf("invalid space id")
+	}
+	rootID.OpaqueId = rootID.SpaceId
+	return rootID, nil
+}
+
+func indexEntity(rootID, id provider.ResourceId, p string) *searchmsg.Entity {
+	return &searchmsg.Entity{
+		Ref: &searchmsg.Reference{
+			ResourceId: &searchmsg.ResourceID{
+				StorageId: rootID.StorageId,
+				SpaceId:   rootID.SpaceId,
+				OpaqueId:  rootID.OpaqueId,
+			},
+			Path: p,
+		},
+		Id: &searchmsg.ResourceID{
+			StorageId: id.StorageId,
+			SpaceId:   id.SpaceId,
+			OpaqueId:  id.OpaqueId,
+		},
+		Name: filepath.Base(p),
+	}
+}
+
 // TrashItem marks the item as deleted.
 func (s *Service) TrashItem(rID *provider.ResourceId) {
 	err := s.engine.Delete(storagespace.FormatResourceID(*rID))
@@ -2,12 +2,17 @@ package search_test
 
 import (
 	"context"
+	"sync"
 
 	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
 	userv1beta1 "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
 	sprovider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
 	typesv1beta1 "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	revactx "github.com/cs3org/reva/v2/pkg/ctx"
+	"github.com/cs3org/reva/v2/pkg/errtypes"
 	"github.com/cs3org/reva/v2/pkg/rgrpc/status"
+	"github.com/cs3org/reva/v2/pkg/storagespace"
+	"github.com/cs3org/reva/v2/pkg/utils"
 	cs3mocks "github.com/cs3org/reva/v2/tests/cs3mocks/mocks"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -116,6 +121,142 @@ var _ = Describe("Searchprovider", func() {
 		})
 	})
 
+	Describe("IndexAllSpaces", func() {
+		It("fails without a service user", func() {
+			_, err := s.IndexAllSpaces()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("indexes the personal and project spaces of all users in the background", func() {
+			s = search.NewService(gw, indexClient, extractor, logger, &config.Config{ServiceUserID: "admin"})
+			gw.On("ListStorageSpaces", mock.Anything, mock.MatchedBy(func(req *sprovider.ListStorageSpacesRequest) bool {
+				return utils.ReadPlainFromOpaque(req.Opaque, "unrestricted") == "T"
+			})).Return(&sprovider.ListStorageSpacesResponse{
+				Status: status.NewOK(ctx),
+				StorageSpaces: []*sprovider.StorageSpace{
+					{
+						Id:        &sprovider.StorageSpaceId{OpaqueId: "storageid$personalspace!personalspace"},
+						SpaceType: "personal",
+						Owner:     user,
+					},
+					{
+						Id:        &sprovider.StorageSpaceId{OpaqueId: "storageid$projectspace!projectspace"},
+						SpaceType: "project",
+					},
+				},
+			}, nil)
+			gw.On("GetUserByClaim", mock.Anything, mock.Anything).Return(&userv1beta1.GetUserByClaimResponse{
+				Status: status.NewOK(context.Background()),
+				User:   user,
+			}, nil)
+			extractor.On("Extract", mock.Anything, mock.Anything, mock.Anything).Return(content.Document{}, nil)
+
+			var (
+				lock     sync.Mutex
+				upserted int
+				release  = make(chan struct{})
+			)
+			indexClient.On("Upsert", mock.Anything, mock.Anything).Run(func(mock.Arguments) {
+				lock.Lock()
+				defer lock.Unlock()
+				upserted++
+			}).Return(nil)
+			indexClient.On("Search", mock.Anything, mock.Anything).Run(func(mock.Arguments) {
+				<-release
+			}).Return(&searchsvc.SearchIndexResponse{}, nil)
+
+			ids, err := s.IndexAllSpaces()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ids).To(Equal([]string{"storageid$personalspace!personalspace", "storageid$projectspace!projectspace"}))
+
+			// only one run is allowed at a time
+			_, err = s.IndexAllSpaces()
+			Expect(err).To(BeAssignableToTypeOf(errtypes.AlreadyExists("")))
+
+			close(release)
+			Eventually(func() int {
+				lock.Lock()
+				defer lock.Unlock()
+				return upserted
+			}).Should(Equal(2))
+			gw.AssertCalled(GinkgoT(), "Authenticate", mock.Anything, mock.MatchedBy(func(req *gateway.AuthenticateRequest) bool {
+				return req.ClientId == "userid:user"
+			}))
+			gw.AssertCalled(GinkgoT(), "Authenticate", mock.Anything, mock.MatchedBy(func(req *gateway.AuthenticateRequest) bool {
+				return req.ClientId == "userid:admin"
+			}))
+
+			Eventually(func() error {
+				_, err := s.IndexAllSpaces()
+				return err
+			}).Should(Succeed())
+		})
+	})
+
+	Describe("VerifySpace", func() {
+		It("returns the stale and the missing resources", func() {
+			indexClient.On("Paths", "storageid$spaceid!spaceid").Return(map[string]string{
+				"storageid$spaceid!gone": "./gone.txt",
+			}, nil)
+
+			stale, missing, err := s.VerifySpace(&sprovider.StorageSpaceId{OpaqueId: "storageid$spaceid!spaceid"}, user.Id)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stale).To(HaveLen(1))
+			Expect(stale[0].Id.OpaqueId).To(Equal("gone"))
+			Expect(stale[0].Ref.Path).To(Equal("./gone.txt"))
+			Expect(missing).To(HaveLen(1))
+			Expect(missing[0].Id.OpaqueId).To(Equal("opaqueid"))
+		})
+
+		DescribeTable("accesses the space as the user it is indexed as without a user",
+			func(spaceType string, owner *userv1beta1.User, clientID string) {
+				s = search.NewService(gw, indexClient, extractor, logger, &config.Config{ServiceUserID: "admin"})
+				gw.On("ListStorageSpaces", mock.Anything, mock.MatchedBy(func(req *sprovider.ListStorageSpacesRequest) bool {
+					return utils.ReadPlainFromOpaque(req.Opaque, "unrestricted") == "T" &&
+						req.Filters[0].GetId().GetOpaqueId() == "storageid$spaceid!spaceid"
+				})).Return(&sprovider.ListStorageSpacesResponse{
+					Status: status.NewOK(ctx),
+					StorageSpaces: []*sprovider.StorageSpace{{
+						Id:        &sprovider.StorageSpaceId{OpaqueId: "storageid$spaceid!spaceid"},
+						SpaceType: spaceType,
+						Owner:     owner,
+					}},
+				}, nil)
+				indexClient.On("Paths", "storageid$spaceid!spaceid").Return(map[string]string{}, nil)
+
+				_, _, err := s.VerifySpace(&sprovider.StorageSpaceId{OpaqueId: "storageid$spaceid!spaceid"}, &userv1beta1.UserId{})
+				Expect(err).ToNot(HaveOccurred())
+				gw.AssertCalled(GinkgoT(), "Stat", mock.MatchedBy(func(ctx context.Context) bool {
+					u, _ := revactx.ContextGetUser(ctx)
+					return u.GetId().GetOpaqueId() == clientID
+				}), mock.Anything)
+			},
+			Entry("the owner of a personal space", "personal", user, "user"),
+			Entry("the service user for a project space", "project", otherUser, "admin"),
+		)
+
+		It("fails without a user if the space doesn't exist", func() {
+			s = search.NewService(gw, indexClient, extractor, logger, &config.Config{ServiceUserID: "admin"})
+			gw.On("ListStorageSpaces", mock.Anything, mock.Anything).Return(&sprovider.ListStorageSpacesResponse{
+				Status: status.NewOK(ctx),
+			}, nil)
+
+			_, _, err := s.VerifySpace(&sprovider.StorageSpaceId{OpaqueId: "storageid$spaceid!spaceid"}, &userv1beta1.UserId{})
+			Expect(err).To(BeAssignableToTypeOf(errtypes.NotFound("")))
+		})
+
+		It("doesn't report indexed resources", func() {
+			indexClient.On("Paths", "storageid$spaceid!spaceid").Return(map[string]string{
+				storagespace.FormatResourceID(*ri.Id): "./foo.pdf",
+			}, nil)
+
+			stale, missing, err := s.VerifySpace(&sprovider.StorageSpaceId{OpaqueId: "storageid$spaceid!spaceid"}, user.Id)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stale).To(BeEmpty())
+			Expect(missing).To(BeEmpty())
+		})
+	})
+
 	Describe("Search", func() {
 		It("fails when an empty query is given", func() {
 			res, err := s.Search(ctx, &searchsvc.SearchRequest{
@@ -125,6 +266,23 @@ var _ = Describe("Searchprovider", func() {
 			Expect(res).To(BeNil())
 		})
 
+		It("fails when an invalid query is given", func() {
+			res, err := s.Search(ctx, &searchsvc.SearchRequest{
+				Query: "name:foo AND (",
+			})
+			Expect(err).To(BeAssignableToTypeOf(errtypes.BadRequest("")))
+			Expect(res).To(BeNil())
+		})
+
+		It("fails when an unknown facet is given", func() {
+			res, err := s.Search(ctx, &searchsvc.SearchRequest{
+				Query:  "foo",
+				Facets: []string{"owner"},
+			})
+			Expect(err).To(BeAssignableToTypeOf(errtypes.BadRequest("")))
+			Expect(res).To(BeNil())
+		})
+
 		Context("with a personal space", func() {
 			BeforeEach(func() {
 				gw.On("ListStorageSpaces", mock.Anything, mock.Anything).Return(&sprovider.ListStorageSpacesResponse{
@@ -306,6 +464,9 @@ var _ = Describe("Searchprovider", func() {
 								},
 							},
 						},
+						Facets: []*searchmsg.Facet{
+							{Name: "tag", Values: []*searchmsg.FacetValue{{Value: "foo", Count: 2}}},
+						},
 					}, nil)
 					indexClient.On("Search", mock.Anything, mock.MatchedBy(func(req *searchsvc.SearchIndexRequest) bool {
 						return req.Ref.ResourceId.OpaqueId == personalSpace.Root.OpaqueId &&
@@ -332,6 +493,9 @@ var _ = Describe("Searchprovider", func() {
 								},
 							},
 						},
+						Facets: []*searchmsg.Facet{
+							{Name: "tag", Values: []*searchmsg.FacetValue{{Value: "bar", Count: 1}, {Value: "foo", Count: 1}}},
+						},
 					}, nil)
 				})
 
@@ -374,6 +538,34 @@ var _ = Describe("Searchprovider", func() {
 					ids := []string{res.Matches[0].Entity.Id.OpaqueId, res.Matches[1].Entity.Id.OpaqueId}
 					Expect(ids).To(Equal([]string{"grant-shared-id", "foo-id"}))
 				})
+
+				It("counts the matches of all spaces by facet", func() {
+					res, err := s.Search(ctx, &searchsvc.SearchRequest{
+						Query:  "foo",
+						Facets: []string{"space", "tag"},
+					})
+					Expect(err).ToNot(HaveOccurred())
+					indexClient.AssertCalled(GinkgoT(), "Search", mock.Anything, mock.MatchedBy(func(req *searchsvc.SearchIndexRequest) bool {
+						return len(req.Facets) == 1 && req.Facets[0] == "tag"
+					}))
+					Expect(res.Facets).To(HaveLen(2))
+
+					spaces := res.Facets[0]
+					Expect(spaces.Name).To(Equal("space"))
+					Expect(spaces.Values).To(HaveLen(2))
+					Expect(spaces.Values[0].Value).To(Equal(mountpointSpace.Id.OpaqueId))
+					Expect(spaces.Values[0].Count).To(Equal(int32(2)))
+					Expect(spaces.Values[1].Value).To(Equal(personalSpace.Id.OpaqueId))
+					Expect(spaces.Values[1].Count).To(Equal(int32(1)))
+
+					tags := res.Facets[1]
+					Expect(tags.Name).To(Equal("tag"))
+					Expect(tags.Values).To(HaveLen(2))
+					Expect(tags.Values[0].Value).To(Equal("foo"))
+					Expect(tags.Values[0].Count).To(Equal(int32(3)))
+					Expect(tags.Values[1].Value).To(Equal("bar"))
+					Expect(tags.Values[1].Count).To(Equal(int32(1)))
+				})
 			})
 		})
 	})
@@ -7,6 +7,8 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"sort"
+	"strings"
 	"time"
 
 	user "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
@@ -50,6 +52,11 @@ func NewHandler(opts ...Option) (searchsvc.SearchProviderHandler, func(), error)
 		}
 
 		eng = engine.NewBleveEngine(idx)
+	case "opensearch":
+		var err error
+		if eng, err = engine.NewOpenSearchEngine(cfg.Engine.OpenSearch); err != nil {
+			return nil, teardown, err
+		}
 	default:
 		return nil, teardown, fmt.Errorf("unknown search engine: %s", cfg.Engine.Type)
 	}
@@ -71,6 +78,10 @@ func NewHandler(opts ...Option) (searchsvc.SearchProviderHandler, func(), error)
 		if extractor, err = content.NewTikaExtractor(gw, logger, cfg); err != nil {
 			return nil, teardown, err
 		}
+	case "native":
+		if extractor, err = content.NewNativeExtractor(gw, logger, cfg); err != nil {
+			return nil, teardown, err
+		}
 	default:
 		return nil, teardown, fmt.Errorf("unknown search extractor: %s", cfg.Extractor.Type)
 	}
@@ -145,7 +156,7 @@ func (s Service) Search(ctx context.Context, in *searchsvc.SearchRequest, out *s
 	ctx = grpcmetadata.AppendToOutgoingContext(ctx, revactx.TokenHeader, t)
 
 	u, _ := revactx.ContextGetUser(ctx)
-	key := cacheKey(in.Query, in.PageSize, in.Ref, u)
+	key := cacheKey(in.Query, in.PageSize, in.Ref, in.Facets, u)
 	res, ok := s.FromCache(key)
 	if !ok {
 		var err error
@@ -153,6 +164,7 @@ func (s Service) Search(ctx context.Context, in *searchsvc.SearchRequest, out *s
 			Query:    in.Query,
 			PageSize: in.PageSize,
 			Ref:      in.Ref,
+			Facets:   in.Facets,
 		})
 		if err != nil {
 			switch err.(type) {
@@ -169,6 +181,7 @@ func (s Service) Search(ctx context.Context, in *searchsvc.SearchRequest, out *s
 	out.Matches = res.Matches
 	out.TotalMatches = res.TotalMatches
 	out.NextPageToken = res.NextPageToken
+	out.Facets = res.Facets
 	return nil
 }
 
@@ -177,6 +190,52 @@ func (s Service) IndexSpace(ctx context.Context, in *searchsvc.IndexSpaceRequest
 	return s.searcher.IndexSpace(&provider.StorageSpaceId{OpaqueId: in.SpaceId}, &user.UserId{OpaqueId: in.UserId})
 }
 
+// IndexAllSpaces starts to (re)index all resources of all spaces in the background.
+func (s Service) IndexAllSpaces(_ context.Context, _ *searchsvc.IndexAllSpacesRequest, out *searchsvc.IndexAllSpacesResponse) error {
+	ids, err := s.searcher.IndexAllSpaces()
+	if err != nil {
+		switch err.(type) {
+		case errtypes.AlreadyExists:
+			return merrors.Conflict(s.id, err.Error())
+		default:
+			return merrors.InternalServerError(s.id, err.Error())
+		}
+	}
+
+	out.SpaceIds = ids
+	return nil
+}
+
+// VerifyIndex compares the index of a space with the storage.
+func (s Service) VerifyIndex(_ context.Context, in *searchsvc.VerifyIndexRequest, out *searchsvc.VerifyIndexResponse) error {
+	stale, missing, err := s.searcher.VerifySpace(&provider.StorageSpaceId{OpaqueId: in.SpaceId}, &user.UserId{OpaqueId: in.UserId})
+	if err != nil {
+		return merrors.InternalServerError(s.id, err.Error())
+	}
+
+	out.Stale = stale
+	out.Missing = missing
+	return nil
+}
+
+// IndexStats returns the number of resources per space and the size of the index.
+func (s Service) IndexStats(_ context.Context, _ *searchsvc.IndexStatsRequest, out *searchsvc.IndexStatsResponse) error {
+	stats, err := s.searcher.Stats()
+	if err != nil {
+		return merrors.InternalServerError(s.id, err.Error())
+	}
+
+	out.Size = stats.Size
+	for id, count := range stats.Spaces {
+		out.Documents += count
+		out.Spaces = append(out.Spaces, &v0.SpaceIndexStats{SpaceId: id, Documents: count})
+	}
+	sort.Slice(out.Spaces, func(i, j int) bool {
+		return out.Spaces[i].SpaceId < out.Spaces[j].SpaceId
+	})
+	return nil
+}
+
 // FromCache pulls a search result from cache
 func (s Service) FromCache(key string) (*searchsvc.SearchResponse, bool) {
 	v, err := s.cache.Get(key)
@@ -194,6 +253,6 @@ func (s Service) Cache(key string, res *searchsvc.SearchResponse) {
 	_ = s.cache.Set(key, res)
 }
 
-func cacheKey(query string, pagesize int32, ref *v0.Reference, user *user.User) string {
-	return fmt.Sprintf("%s|%d|%s$%s!%s/%s|%s", query, pagesize, ref.GetResourceId().GetStorageId(), ref.GetResourceId().GetSpaceId(), ref.GetResourceId().GetOpaqueId(), ref.GetPath(), user.GetId().GetOpaqueId())
+func cacheKey(query string, pagesize int32, ref *v0.Reference, facets []string, user *user.User) string {
+	return fmt.Sprintf("%s|%d|%s$%s!%s/%s|%s|%s", query, pagesize, ref.GetResourceId().GetStorageId(), ref.GetResourceId().GetSpaceId(), ref.GetResourceId().GetOpaqueId(), ref.GetPath(), strings.Join(facets, ","), user.GetId().GetOpaqueId())
 }
@@ -2,10 +2,17 @@ package command
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"os"
 
+	"github.com/cs3org/reva/v2/pkg/events"
+	"github.com/go-micro/plugins/v4/events/natsjs"
 	"github.com/oklog/run"
 	"github.com/owncloud/ocis/v2/ocis-pkg/config/configlog"
+	ociscrypto "github.com/owncloud/ocis/v2/ocis-pkg/crypto"
+	ocisevents "github.com/owncloud/ocis/v2/ocis-pkg/events"
 	ogrpc "github.com/owncloud/ocis/v2/ocis-pkg/service/grpc"
 	"github.com/owncloud/ocis/v2/ocis-pkg/version"
 	"github.com/owncloud/ocis/v2/services/settings/pkg/config"
@@ -51,6 +58,12 @@ func Server(cfg *config.Config) *cli.Command {
 			mtrcs := metrics.New()
 			mtrcs.BuildInfo.WithLabelValues(version.GetString()).Set(1)
 
+			publisher, err := eventsPublisher(cfg.Events)
+			if err != nil {
+				logger.Error().Err(err).Msg("Error initializing events publisher")
+				return fmt.Errorf("could not initialize events publisher: %w", err)
+			}
+
 			// prepare an HTTP server and add it to the group run.
 			httpServer, err := http.Server(
 				http.Name(cfg.Service.Name),
@@ -58,6 +71,7 @@ func Server(cfg *config.Config) *cli.Command {
 				http.Context(ctx),
 				http.Config(cfg),
 				http.Metrics(mtrcs),
+				http.EventsPublisher(publisher),
 			)
 			if err != nil {
 				logger.Error().
@@ -71,7 +85,7 @@ func Server(cfg *config.Config) *cli.Command {
 			})
 
 			// prepare a gRPC server and add it to the group run.
-			grpcServer := grpc.Server(grpc.Name(cfg.Service.Name), grpc.Logger(logger), grpc.Context(ctx), grpc.Config(cfg), grpc.Metrics(mtrcs))
+			grpcServer := grpc.Server(grpc.Name(cfg.Service.Name), grpc.Logger(logger), grpc.Context(ctx), grpc.Config(cfg), grpc.Metrics(mtrcs), grpc.EventsPublisher(publisher))
 			servers.Add(grpcServer.Run, func(_ error) {
 				logger.Info().Str("server", "grpc").Msg("Shutting down server")
 				cancel()
@@ -93,3 +107,41 @@ func Server(cfg *config.Config) *cli.Command {
 		},
 	}
 }
+
+// eventsPublisher returns a publisher which connects to the event system when the first event is published, so that
+// the service doesn't wait for the event system on startup. It returns nil if no endpoint is configured.
+func eventsPublisher(cfg config.Events) (events.Publisher, error) {
+	if cfg.Endpoint == "" {
+		return nil, nil
+	}
+
+	var tlsConf *tls.Config
+	if cfg.EnableTLS {
+		var rootCAPool *x509.CertPool
+		if cfg.TLSRootCACertificate != "" {
+			rootCrtFile, err := os.Open(cfg.TLSRootCACertificate)
+			if err != nil {
+				return nil, err
+			}
+
+			rootCAPool, err = ociscrypto.NewCertPoolFromPEM(rootCrtFile)
+			if err != nil {
+				return nil, err
+			}
+			cfg.TLSInsecure = false
+		}
+
+		tlsConf = &tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			InsecureSkipVerify: cfg.TLSInsecure, //nolint:gosec
+			RootCAs:            rootCAPool,
+		}
+	}
+	return ocisevents.NewLazyPublisher(func() (events.Publisher, error) {
+		return natsjs.NewStream(
+			natsjs.TLSConfig(tlsConf),
+			natsjs.Address(cfg.Endpoint),
+			natsjs.ClusterID(cfg.Cluster),
+		)
+	}), nil
+}
@@ -34,7 +34,7 @@ func Server(opts ...Option) grpc.Service {
 		options.Logger.Fatal().Err(err).Msg("Error creating settings service")
 	}
 
-	handle := svc.NewService(options.Config, options.Logger)
+	handle := svc.NewService(options.Config, options.Logger, options.Events)
 	if err := settingssvc.RegisterBundleServiceHandler(service.Server(), handle); err != nil {
 		options.Logger.Fatal().Err(err).Msg("could not register Bundle service handler")
 	}
@@ -3,6 +3,7 @@ package http
 import (
 	"context"
 
+	"github.com/cs3org/reva/v2/pkg/events"
 	"github.com/owncloud/ocis/v2/ocis-pkg/log"
 	"github.com/owncloud/ocis/v2/services/settings/pkg/config"
 	"github.com/owncloud/ocis/v2/services/settings/pkg/metrics"
@@ -19,6 +20,7 @@ type Options struct {
 	Context context.Context
 	Config  *config.Config
 	Metrics *metrics.Metrics
+	Events  events.Publisher
 	Flags   []cli.Flag
 }
 
@@ -68,6 +70,13 @@ func Metrics(val *metrics.Metrics) Option {
 	}
 }
 
+// EventsPublisher provides a function to set the events publisher option.
+func EventsPublisher(val events.Publisher) Option {
+	return func(o *Options) {
+		o.Events = val
+	}
+}
+
 // Flags provides a function to set the flags option.
 func Flags(val []cli.Flag) Option {
 	return func(o *Options) {
@@ -4,10 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
 	permissions "github.com/cs3org/go-cs3apis/cs3/permissions/v1beta1"
 	rpcv1beta1 "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	"github.com/cs3org/reva/v2/pkg/events"
 	"github.com/cs3org/reva/v2/pkg/rgrpc/status"
+	ocisevents "github.com/owncloud/ocis/v2/ocis-pkg/events"
 	"github.com/owncloud/ocis/v2/ocis-pkg/log"
 	"github.com/owncloud/ocis/v2/ocis-pkg/middleware"
 	"github.com/owncloud/ocis/v2/ocis-pkg/roles"
@@ -25,18 +29,21 @@ import (
 
 // Service represents a service.
 type Service struct {
-	id      string
-	config  *config.Config
-	logger  log.Logger
-	manager settings.Manager
+	id        string
+	config    *config.Config
+	logger    log.Logger
+	manager   settings.Manager
+	publisher events.Publisher
 }
 
 // NewService returns a service implementation for Service.
-func NewService(cfg *config.Config, logger log.Logger) Service {
+// Changes of role assignments are published to the given publisher, which may be nil.
+func NewService(cfg *config.Config, logger log.Logger, publisher events.Publisher) Service {
 	service := Service{
-		id:     "ocis-settings",
-		config: cfg,
-		logger: logger,
+		id:        "ocis-settings",
+		config:    cfg,
+		logger:    logger,
+		publisher: publisher,
 	}
 
 	switch cfg.StoreType {
@@ -425,6 +432,14 @@ func (g Service) AssignRoleToUser(ctx context.Context, req *settingssvc.AssignRo
 		return merrors.BadRequest(g.id, err.Error())
 	}
 	res.Assignment = r
+
+	g.publishEvent(ocisevents.RoleAssigned{
+		Executant:    &userpb.UserId{OpaqueId: ownAccountUUID},
+		UserID:       r.GetAccountUuid(),
+		RoleID:       r.GetRoleId(),
+		AssignmentID: r.GetId(),
+		Timestamp:    time.Now(),
+	})
 	return nil
 }
 
@@ -457,9 +472,18 @@ func (g Service) RemoveRoleFromUser(ctx context.Context, req *settingssvc.Remove
 		}
 	}
 
-	if err := g.manager.RemoveRoleAssignment(req.Id); err != nil {
+	r, err := g.manager.RemoveRoleAssignment(req.Id)
+	if err != nil {
 		return merrors.BadRequest(g.id, err.Error())
 	}
+
+	g.publishEvent(ocisevents.RoleUnassigned{
+		Executant:    &userpb.UserId{OpaqueId: ownAccountUUID},
+		UserID:       r.GetAccountUuid(),
+		RoleID:       r.GetRoleId(),
+		AssignmentID: req.Id,
+		Timestamp:    time.Now(),
+	})
 	return nil
 }
 
@@ -607,6 +631,15 @@ func (g Service) checkStaticPermissionsByBundleType(ctx context.Context, bundleT
 	return nil
 }
 
+func (g Service) publishEvent(ev interface{}) {
+	if g.publisher == nil {
+		return
+	}
+	if err := events.Publish(g.publisher, ev); err != nil {
+		g.logger.Error().Err(err).Str("event", fmt.Sprintf("%T", ev)).Msg("could not publish event")
+	}
+}
+
 func (g Service) isCurrentUser(ctx context.Context, accountID string) bool {
 	ownAccountID, ok := metadata.Get(ctx, middleware.AccountID)
 	if !ok {
@@ -157,7 +157,7 @@ func TestRemoveOwnRoleAssignment(t *testing.T) {
 
 	manager = &mocks.Manager{}
 	manager.On("ListRoleAssignments", mock.Anything).Return(nil, nil)
-	manager.On("RemoveRoleAssignment", mock.Anything).Return(nil)
+	manager.On("RemoveRoleAssignment", mock.Anything).Return(&settingsmsg.UserRoleAssignment{}, nil)
 	manager.On("ReadPermissionByID", mock.Anything, mock.Anything).Return(editRolePermission, nil)
 	svc = Service{
 		manager: manager,
@@ -267,17 +267,26 @@ func (_m *Manager) ReadValueByUniqueIdentifiers(accountUUID string, settingID st
 }
 
 // RemoveRoleAssignment provides a mock function with given fields: assignmentID
-func (_m *Manager) RemoveRoleAssignment(assignmentID string) error {
+func (_m *Manager) RemoveRoleAssignment(assignmentID string) (*v0.UserRoleAssignment, error) {
 	ret := _m.Called(assignmentID)
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(string) error); ok {
+	var r0 *v0.UserRoleAssignment
+	if rf, ok := ret.Get(0).(func(string) *v0.UserRoleAssignment); ok {
 		r0 = rf(assignmentID)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v0.UserRoleAssignment)
+		}
 	}
 
-	return r0
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(assignmentID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
 // RemoveSettingFromBundle provides a mock function with given fields: bundleID, settingID
@@ -55,7 +55,7 @@ type ValueManager interface {
 type RoleAssignmentManager interface {
 	ListRoleAssignments(accountUUID string) ([]*settingsmsg.UserRoleAssignment, error)
 	WriteRoleAssignment(accountUUID, roleID string) (*settingsmsg.UserRoleAssignment, error)
-	RemoveRoleAssignment(assignmentID string) error
+	RemoveRoleAssignment(assignmentID string) (*settingsmsg.UserRoleAssignment, error)
 }
 
 // PermissionManager is a permissions service interface for abstraction of storage implementations
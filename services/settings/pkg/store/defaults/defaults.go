@@ -68,7 +68,51 @@ const (
 	// SpaceAbilityPermissionName is the hardcoded setting name for the space ability permission
 	SpaceAbilityPermissionName string = "Drive.ReadWriteEnabled"
 
-	settingUUIDProfileLanguage = "aa8cfbe5-95d4-4f7e-a032-c3c01f5f062f"
+	// SettingUUIDProfileLanguage is the setting holding the language a user chose for the user interface
+	SettingUUIDProfileLanguage = "aa8cfbe5-95d4-4f7e-a032-c3c01f5f062f"
+
+	// BundleUUIDNotifications is the bundle holding the notification preferences of a user
+	BundleUUIDNotifications = "146cdb89-fdc3-4b1f-b38c-5990eeaa80a6"
+	// SettingUUIDNotificationDelivery is the setting for how a user receives notifications, one of the NotificationDelivery values
+	SettingUUIDNotificationDelivery = "2d6d1b62-5d78-48f7-85c0-1116fe723720"
+	// SettingUUIDNotifyShareCreated is the setting whether a user is notified about new shares
+	SettingUUIDNotifyShareCreated = "0f4ead95-e9d3-4532-a37f-54a42ed91e3d"
+	// SettingUUIDNotifyShareExpired is the setting whether a user is notified about expired shares
+	SettingUUIDNotifyShareExpired = "c6b57c9d-21d6-46e6-9dd0-a63fd928dd48"
+	// SettingUUIDNotifySpaceShared is the setting whether a user is notified about being added to a space
+	SettingUUIDNotifySpaceShared = "2588e541-b243-4263-9dc8-a8acb4168745"
+	// SettingUUIDNotifySpaceUnshared is the setting whether a user is notified about being removed from a space
+	SettingUUIDNotifySpaceUnshared = "43779ad4-df8a-4f28-bc8c-efe5a78bc890"
+	// SettingUUIDNotifySpaceMembershipExpired is the setting whether a user is notified about expired space memberships
+	SettingUUIDNotifySpaceMembershipExpired = "d2c619dc-b2ee-4933-a9f0-33e5af8e397b"
+	// SettingUUIDNotifyShareExpiring is the setting whether a user is notified about shares which expire soon
+	SettingUUIDNotifyShareExpiring = "6a18cce5-8718-42f5-9385-beacab81bffc"
+	// SettingUUIDNotifyUploadFailed is the setting whether a user is notified about uploads rejected by the virus scan or aborted
+	SettingUUIDNotifyUploadFailed = "b451997a-1763-4157-a596-996f8448eb65"
+	// SettingUUIDNotifySpaceQuota is the setting whether a user is notified about managed spaces running out of quota
+	SettingUUIDNotifySpaceQuota = "dccd274f-68f6-4c4d-b928-5be3a0e047dd"
+	// SettingUUIDNotificationChannels is the setting for the channels a user receives notifications through, NotificationChannel values
+	SettingUUIDNotificationChannels = "768d731c-edc7-448b-8243-c2737ba512e2"
+	// SettingUUIDNotificationChatWebhookURL is the setting for the incoming webhook of the chat a user receives notifications in
+	SettingUUIDNotificationChatWebhookURL = "117cd7c4-b72c-49ea-a3f1-00707de6f6ee"
+	// SettingUUIDNotificationPushSubscriptions is the setting for the Web Push subscriptions of the browsers of a user, as a json list
+	SettingUUIDNotificationPushSubscriptions = "efcd7a33-d9f0-452b-b913-106f501d6315"
+
+	// NotificationDeliveryInstant sends every notification immediately
+	NotificationDeliveryInstant = "instant"
+	// NotificationDeliveryDaily collects notifications and sends them once a day
+	NotificationDeliveryDaily = "daily"
+	// NotificationDeliveryWeekly collects notifications and sends them once a week
+	NotificationDeliveryWeekly = "weekly"
+
+	// NotificationChannelMail sends notifications as email
+	NotificationChannelMail = "mail"
+	// NotificationChannelWebhook posts notifications to the webhook configured by the admin
+	NotificationChannelWebhook = "webhook"
+	// NotificationChannelChat posts notifications to the chat webhook configured by the user
+	NotificationChannelChat = "chat"
+	// NotificationChannelPush sends notifications as Web Push messages to the browsers of the user
+	NotificationChannelPush = "push"
 
 	// AccountManagementPermissionID is the hardcoded setting UUID for the account management permission
 	AccountManagementPermissionID string = "8e587774-d929-4215-910b-a317b1e80f73"
@@ -92,6 +136,7 @@ func GenerateBundlesDefaultRoles() []*settingsmsg.Bundle {
 		generateBundleGuestRole(),
 		generateBundleProfileRequest(),
 		generateBundleSpaceAdminRole(),
+		GenerateBundleNotifications(),
 	}
 }
 
@@ -144,7 +189,7 @@ func generateBundleAdminRole() *settingsmsg.Bundle {
 				DisplayName: "Permission to read and set the language (anyone)",
 				Resource: &settingsmsg.Resource{
 					Type: settingsmsg.Resource_TYPE_SETTING,
-					Id:   settingUUIDProfileLanguage,
+					Id:   SettingUUIDProfileLanguage,
 				},
 				Value: &settingsmsg.Setting_PermissionValue{
 					PermissionValue: &settingsmsg.Permission{
@@ -356,7 +401,7 @@ func generateBundleSpaceAdminRole() *settingsmsg.Bundle {
 				DisplayName: "Permission to read and set the language (self)",
 				Resource: &settingsmsg.Resource{
 					Type: settingsmsg.Resource_TYPE_SETTING,
-					Id:   settingUUIDProfileLanguage,
+					Id:   SettingUUIDProfileLanguage,
 				},
 				Value: &settingsmsg.Setting_PermissionValue{
 					PermissionValue: &settingsmsg.Permission{
@@ -417,7 +462,7 @@ func generateBundleUserRole() *settingsmsg.Bundle {
 				DisplayName: "Permission to read and set the language (self)",
 				Resource: &settingsmsg.Resource{
 					Type: settingsmsg.Resource_TYPE_SETTING,
-					Id:   settingUUIDProfileLanguage,
+					Id:   SettingUUIDProfileLanguage,
 				},
 				Value: &settingsmsg.Setting_PermissionValue{
 					PermissionValue: &settingsmsg.Permission{
@@ -478,7 +523,7 @@ func generateBundleGuestRole() *settingsmsg.Bundle {
 				DisplayName: "Permission to read and set the language (self)",
 				Resource: &settingsmsg.Resource{
 					Type: settingsmsg.Resource_TYPE_SETTING,
-					Id:   settingUUIDProfileLanguage,
+					Id:   SettingUUIDProfileLanguage,
 				},
 				Value: &settingsmsg.Setting_PermissionValue{
 					PermissionValue: &settingsmsg.Permission{
@@ -503,7 +548,7 @@ func generateBundleProfileRequest() *settingsmsg.Bundle {
 		DisplayName: "Profile",
 		Settings: []*settingsmsg.Setting{
 			{
-				Id:          settingUUIDProfileLanguage,
+				Id:          SettingUUIDProfileLanguage,
 				Name:        "language",
 				DisplayName: "Language",
 				Description: "User language",
@@ -516,6 +561,169 @@ func generateBundleProfileRequest() *settingsmsg.Bundle {
 	}
 }
 
+// GenerateBundleNotifications returns the bundle holding the notification preferences of a user
+func GenerateBundleNotifications() *settingsmsg.Bundle {
+	return &settingsmsg.Bundle{
+		Id:        BundleUUIDNotifications,
+		Name:      "notifications",
+		Extension: "ocis-notifications",
+		Type:      settingsmsg.Bundle_TYPE_DEFAULT,
+		Resource: &settingsmsg.Resource{
+			Type: settingsmsg.Resource_TYPE_SYSTEM,
+		},
+		DisplayName: "Notifications",
+		Settings: []*settingsmsg.Setting{
+			{
+				Id:          SettingUUIDNotificationDelivery,
+				Name:        "notification-delivery",
+				DisplayName: "Delivery",
+				Description: "Receive notifications immediately or collected in a daily or weekly digest",
+				Resource: &settingsmsg.Resource{
+					Type: settingsmsg.Resource_TYPE_USER,
+				},
+				Value: &notificationDeliverySetting,
+			},
+			{
+				Id:          SettingUUIDNotificationChannels,
+				Name:        "notification-channels",
+				DisplayName: "Channels",
+				Description: "Receive notifications by email, in a chat or in the browser",
+				Resource: &settingsmsg.Resource{
+					Type: settingsmsg.Resource_TYPE_USER,
+				},
+				Value: &notificationChannelsSetting,
+			},
+			{
+				Id:          SettingUUIDNotificationChatWebhookURL,
+				Name:        "notification-chat-webhook-url",
+				DisplayName: "Chat webhook",
+				Description: "The URL of the incoming webhook of a Slack, Mattermost or Matrix chat to post notifications to",
+				Resource: &settingsmsg.Resource{
+					Type: settingsmsg.Resource_TYPE_USER,
+				},
+				Value: &settingsmsg.Setting_StringValue{
+					StringValue: &settingsmsg.String{
+						MaxLength:   2048,
+						Placeholder: "https://hooks.slack.com/services/...",
+					},
+				},
+			},
+			{
+				Id:          SettingUUIDNotificationPushSubscriptions,
+				Name:        "notification-push-subscriptions",
+				DisplayName: "Browser notifications",
+				Description: "The Web Push subscriptions of the browsers to send notifications to",
+				Resource: &settingsmsg.Resource{
+					Type: settingsmsg.Resource_TYPE_USER,
+				},
+				Value: &settingsmsg.Setting_StringValue{
+					StringValue: &settingsmsg.String{
+						MaxLength: 16384,
+					},
+				},
+			},
+			notifySetting(SettingUUIDNotifyShareCreated, "notify-share-created", "New shares", "Notify when something was shared with me"),
+			notifySetting(SettingUUIDNotifyShareExpired, "notify-share-expired", "Expired shares", "Notify when a share with me expired"),
+			notifySetting(SettingUUIDNotifySpaceShared, "notify-space-shared", "Space invitations", "Notify when I was added to a space"),
+			notifySetting(SettingUUIDNotifySpaceUnshared, "notify-space-unshared", "Space removals", "Notify when I was removed from a space"),
+			notifySetting(SettingUUIDNotifySpaceMembershipExpired, "notify-space-membership-expired", "Expired space memberships", "Notify when my membership of a space expired"),
+			notifySetting(SettingUUIDNotifyShareExpiring, "notify-share-expiring", "Expiring shares", "Notify when a share with me is about to expire"),
+			notifySetting(SettingUUIDNotifyUploadFailed, "notify-upload-failed", "Failed uploads", "Notify when a virus was found in my upload or the upload was aborted"),
+			notifySetting(SettingUUIDNotifySpaceQuota, "notify-space-quota", "Space quota", "Notify when a space I manage is running out of quota"),
+		},
+	}
+}
+
+func notifySetting(id, name, displayName, description string) *settingsmsg.Setting {
+	return &settingsmsg.Setting{
+		Id:          id,
+		Name:        name,
+		DisplayName: displayName,
+		Description: description,
+		Resource: &settingsmsg.Resource{
+			Type: settingsmsg.Resource_TYPE_USER,
+		},
+		Value: &settingsmsg.Setting_BoolValue{
+			BoolValue: &settingsmsg.Bool{
+				Default: true,
+				Label:   displayName,
+			},
+		},
+	}
+}
+
+var notificationDeliverySetting = settingsmsg.Setting_SingleChoiceValue{
+	SingleChoiceValue: &settingsmsg.SingleChoiceList{
+		Options: []*settingsmsg.ListOption{
+			{
+				Value: &settingsmsg.ListOptionValue{
+					Option: &settingsmsg.ListOptionValue_StringValue{
+						StringValue: NotificationDeliveryInstant,
+					},
+				},
+				DisplayValue: "Instant",
+				Default:      true,
+			},
+			{
+				Value: &settingsmsg.ListOptionValue{
+					Option: &settingsmsg.ListOptionValue_StringValue{
+						StringValue: NotificationDeliveryDaily,
+					},
+				},
+				DisplayValue: "Daily digest",
+			},
+			{
+				Value: &settingsmsg.ListOptionValue{
+					Option: &settingsmsg.ListOptionValue_StringValue{
+						StringValue: NotificationDeliveryWeekly,
+					},
+				},
+				DisplayValue: "Weekly digest",
+			},
+		},
+	},
+}
+
+var notificationChannelsSetting = settingsmsg.Setting_MultiChoiceValue{
+	MultiChoiceValue: &settingsmsg.MultiChoiceList{
+		Options: []*settingsmsg.ListOption{
+			{
+				Value: &settingsmsg.ListOptionValue{
+					Option: &settingsmsg.ListOptionValue_StringValue{
+						StringValue: NotificationChannelMail,
+					},
+				},
+				DisplayValue: "Email",
+				Default:      true,
+			},
+			{
+				Value: &settingsmsg.ListOptionValue{
+					Option: &settingsmsg.ListOptionValue_StringValue{
+						StringValue: NotificationChannelChat,
+					},
+				},
+				DisplayValue: "Chat",
+			},
+			{
+				Value: &settingsmsg.ListOptionValue{
+					Option: &settingsmsg.ListOptionValue_StringValue{
+						StringValue: NotificationChannelPush,
+					},
+				},
+				DisplayValue: "Browser",
+			},
+			{
+				Value: &settingsmsg.ListOptionValue{
+					Option: &settingsmsg.ListOptionValue_StringValue{
+						StringValue: NotificationChannelWebhook,
+					},
+				},
+				DisplayValue: "Webhook",
+			},
+		},
+	},
+}
+
 // TODO: languageSetting needed?
 var languageSetting = settingsmsg.Setting_SingleChoiceValue{
 	SingleChoiceValue: &settingsmsg.SingleChoiceList{
@@ -60,7 +60,13 @@ func (s Store) WriteRoleAssignment(accountUUID, roleID string) (*settingsmsg.Use
 }
 
 // RemoveRoleAssignment deletes the given role assignment from the existing assignments of the respective account.
-func (s Store) RemoveRoleAssignment(assignmentID string) error {
+// It returns the removed role assignment.
+func (s Store) RemoveRoleAssignment(assignmentID string) (*settingsmsg.UserRoleAssignment, error) {
 	filePath := s.buildFilePathForRoleAssignment(assignmentID, false)
-	return os.Remove(filePath)
+	assignment := &settingsmsg.UserRoleAssignment{Id: assignmentID}
+	_ = s.parseRecordFromFile(assignment, filePath)
+	if err := os.Remove(filePath); err != nil {
+		return nil, err
+	}
+	return assignment, nil
 }
@@ -162,14 +162,14 @@ func TestDeleteAssignment(t *testing.T) {
 			assert.NoError(t, err)
 			assert.Equal(t, 1, len(list))
 
-			err = s.RemoveRoleAssignment(assignment.Id)
+			_, err = s.RemoveRoleAssignment(assignment.Id)
 			assert.NoError(t, err)
 
 			list, err = s.ListRoleAssignments(scenario.userID)
 			assert.NoError(t, err)
 			assert.Equal(t, 0, len(list))
 
-			err = s.RemoveRoleAssignment(assignment.Id)
+			_, err = s.RemoveRoleAssignment(assignment.Id)
 			merr := &os.PathError{}
 			assert.Equal(t, true, errors.As(err, &merr))
 		})
@@ -82,7 +82,8 @@ func (s *Store) WriteRoleAssignment(accountUUID, roleID string) (*settingsmsg.Us
 }
 
 // RemoveRoleAssignment deletes the given role assignment from the existing assignments of the respective account.
-func (s *Store) RemoveRoleAssignment(assignmentID string) error {
+// It returns the removed role assignment.
+func (s *Store) RemoveRoleAssignment(assignmentID string) (*settingsmsg.UserRoleAssignment, error) {
 	s.Init()
 	ctx := context.TODO()
 	accounts, err := s.mdc.ReadDir(ctx, accountsFolderLocation)
@@ -90,9 +91,9 @@ func (s *Store) RemoveRoleAssignment(assignmentID string) error {
 	case nil:
 		// continue
 	case errtypes.NotFound:
-		return fmt.Errorf("assignmentID '%s' %w", assignmentID, settings.ErrNotFound)
+		return nil, fmt.Errorf("assignmentID '%s' %w", assignmentID, settings.ErrNotFound)
 	default:
-		return err
+		return nil, err
 	}
 
 	// TODO: use indexer to avoid spamming Metadata service
@@ -105,13 +106,18 @@ func (s *Store) RemoveRoleAssignment(assignmentID string) error {
 
 		for _, assID := range assIDs {
 			if assID == assignmentID {
+				ass := &settingsmsg.UserRoleAssignment{Id: assID, AccountUuid: accID}
+				if b, err := s.mdc.SimpleDownload(ctx, assignmentPath(accID, assID)); err == nil {
+					_ = json.Unmarshal(b, ass)
+				}
+
 				// as per https://github.com/owncloud/product/issues/103 "Each user can have exactly one role"
 				// we also have to delete the cached dir listing
-				return s.mdc.Delete(ctx, accountPath(accID))
+				return ass, s.mdc.Delete(ctx, accountPath(accID))
 			}
 		}
 	}
-	return fmt.Errorf("assignmentID '%s' %w", assignmentID, settings.ErrNotFound)
+	return nil, fmt.Errorf("assignmentID '%s' %w", assignmentID, settings.ErrNotFound)
 }
 
 func accountPath(accountUUID string) string {
@@ -178,7 +178,7 @@ func TestDeleteAssignment(t *testing.T) {
 			require.Equal(t, 1, len(list))
 			require.Equal(t, assignment.Id, list[0].Id)
 
-			err = s.RemoveRoleAssignment(assignment.Id)
+			_, err = s.RemoveRoleAssignment(assignment.Id)
 			require.NoError(t, err)
 			// TODO: uncomment
 			// require.False(t, mdc.IDExists(assignment.RoleId))
@@ -187,7 +187,7 @@ func TestDeleteAssignment(t *testing.T) {
 			require.NoError(t, err)
 			require.Equal(t, 0, len(list))
 
-			err = s.RemoveRoleAssignment(assignment.Id)
+			_, err = s.RemoveRoleAssignment(assignment.Id)
 			require.Error(t, err)
 			// TODO: do we want a custom error message?
 		})
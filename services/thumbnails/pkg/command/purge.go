@@ -0,0 +1,46 @@
+package command
+
+import (
+	"fmt"
+
+	"github.com/owncloud/ocis/v2/ocis-pkg/config/configlog"
+	"github.com/owncloud/ocis/v2/services/thumbnails/pkg/config"
+	"github.com/owncloud/ocis/v2/services/thumbnails/pkg/config/parser"
+	"github.com/owncloud/ocis/v2/services/thumbnails/pkg/logging"
+	"github.com/owncloud/ocis/v2/services/thumbnails/pkg/thumbnail/storage"
+	"github.com/urfave/cli/v2"
+)
+
+// Purge is the entrypoint for the purge command.
+func Purge(cfg *config.Config) *cli.Command {
+	return &cli.Command{
+		Name:     "purge",
+		Usage:    "remove thumbnails from the filesystem storage, they are recreated on request",
+		Category: "maintenance",
+		Flags: []cli.Flag{
+			&cli.DurationFlag{
+				Name:  "older-than",
+				Usage: "only remove the thumbnails which weren't accessed within the duration, e.g. 720h",
+			},
+			&cli.StringFlag{
+				Name:  "checksum",
+				Usage: "only remove the thumbnails of the source file with the checksum",
+			},
+		},
+		Before: func(c *cli.Context) error {
+			return configlog.ReturnError(parser.ParseConfig(cfg))
+		},
+		Action: func(c *cli.Context) error {
+			logger := logging.Configure(cfg.Service.Name, cfg.Log)
+			s := storage.NewFileSystemStorage(cfg.Thumbnail.FileSystemStorage, logger)
+
+			count, size, err := s.Purge(c.Duration("older-than"), c.String("checksum"))
+			fmt.Printf("removed %d thumbnails with a total size of %d bytes\n", count, size)
+			if err != nil {
+				fmt.Println("failed to purge the thumbnails: " + err.Error())
+				return err
+			}
+			return nil
+		},
+	}
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"context"
+	"time"
 
 	"github.com/owncloud/ocis/v2/ocis-pkg/shared"
 )
@@ -22,23 +23,39 @@ type Config struct {
 	GRPCClientTLS *shared.GRPCClientTLS `yaml:"grpc_client_tls"`
 
 	Thumbnail Thumbnail `yaml:"thumbnail"`
+	Events    Events    `yaml:"events"`
+
+	MachineAuthAPIKey string `yaml:"machine_auth_api_key" env:"OCIS_MACHINE_AUTH_API_KEY;THUMBNAILS_MACHINE_AUTH_API_KEY" desc:"Machine auth API key used to access the uploaded files when pregenerating their thumbnails."`
 
 	Context context.Context `yaml:"-"`
 }
 
 // FileSystemStorage defines the available filesystem storage configuration.
 type FileSystemStorage struct {
-	RootDirectory string `yaml:"root_directory" env:"THUMBNAILS_FILESYSTEMSTORAGE_ROOT" desc:"The directory where the filesystem storage will store the thumbnails. If not definied, the root directory derives from $OCIS_BASE_DATA_PATH:/thumbnails."`
+	RootDirectory string        `yaml:"root_directory" env:"THUMBNAILS_FILESYSTEMSTORAGE_ROOT" desc:"The directory where the filesystem storage will store the thumbnails. If not definied, the root directory derives from $OCIS_BASE_DATA_PATH:/thumbnails."`
+	MaxSize       int           `yaml:"max_size" env:"THUMBNAILS_FILESYSTEMSTORAGE_MAX_SIZE" desc:"The maximum total size of the stored thumbnails in megabytes. The least recently used thumbnails are removed when it is exceeded. 0 disables the limit."`
+	MaxAge        time.Duration `yaml:"max_age" env:"THUMBNAILS_FILESYSTEMSTORAGE_MAX_AGE" desc:"The time after which thumbnails which haven't been accessed are removed, like 720h for 30 days. The duration can be set as number followed by a unit identifier like s, m or h. 0 keeps thumbnails regardless of their age."`
+}
+
+// Pregeneration defines the configuration for generating thumbnails when files are uploaded.
+type Pregeneration struct {
+	Enabled bool     `yaml:"enabled" env:"THUMBNAILS_PREGENERATION_ENABLED" desc:"Generate the thumbnails of uploaded files in all configured resolutions ahead of time, so that they don't have to be generated when they are requested for the first time. This requires the event system."`
+	Types   []string `yaml:"types" env:"THUMBNAILS_PREGENERATION_TYPES" desc:"The thumbnail types which are pregenerated, any of png, jpg and webp. Separate multiple types by comma. Thumbnails of gif files are always pregenerated as gif."`
+	Workers int      `yaml:"workers" env:"THUMBNAILS_PREGENERATION_WORKERS" desc:"The number of uploaded files whose thumbnails are generated concurrently."`
 }
 
 // Thumbnail defines the available thumbnail related configuration.
 type Thumbnail struct {
 	Resolutions         []string          `yaml:"resolutions" env:"THUMBNAILS_RESOLUTIONS" desc:"The supported target resolutions in the format WidthxHeight e.g. 32x32. You can define any resolution as required and separate multiple resolutions by blank or comma."`
 	FileSystemStorage   FileSystemStorage `yaml:"filesystem_storage"`
+	Pregeneration       Pregeneration     `yaml:"pregeneration"`
 	WebdavAllowInsecure bool              `yaml:"webdav_allow_insecure" env:"OCIS_INSECURE;THUMBNAILS_WEBDAVSOURCE_INSECURE" desc:"Ignore untrusted SSL certificates when connecting to the webdav source."`
 	CS3AllowInsecure    bool              `yaml:"cs3_allow_insecure" env:"OCIS_INSECURE;THUMBNAILS_CS3SOURCE_INSECURE" desc:"Ignore untrusted SSL certificates when connecting to the CS3 source."`
 	RevaGateway         string            `yaml:"reva_gateway" env:"REVA_GATEWAY" desc:"CS3 gateway used to look up user metadata"`
 	FontMapFile         string            `yaml:"font_map_file" env:"THUMBNAILS_TXT_FONTMAP_FILE" desc:"The path to a font file for txt thumbnails."`
 	TransferSecret      string            `yaml:"transfer_secret" env:"THUMBNAILS_TRANSFER_TOKEN" desc:"The secret to sign JWT to download the actual thumbnail file."`
 	DataEndpoint        string            `yaml:"data_endpoint" env:"THUMBNAILS_DATA_ENDPOINT" desc:"The HTTP endpoint where the actual thumbnail file can be downloaded."`
+	PDFCommand          string            `yaml:"pdf_command" env:"THUMBNAILS_PDF_COMMAND" desc:"The command which renders the first page of pdf files, e.g. 'pdftoppm -png -singlefile -f 1 -l 1 -scale-to 1920 {file}'. The placeholder {file} is replaced by the path of the pdf file and the command has to write the image to stdout. Thumbnails of pdf files are disabled if no command is set."`
+	VideoCommand        string            `yaml:"video_command" env:"THUMBNAILS_VIDEO_COMMAND" desc:"The command which extracts a keyframe of videos, e.g. 'ffmpeg -loglevel error -i {file} -frames:v 1 -f image2pipe -c:v png -'. The placeholder {file} is replaced by the path of the video and the command has to write the image to stdout. Thumbnails of videos are disabled if no command is set."`
+	MaxCommands         int               `yaml:"max_commands" env:"THUMBNAILS_MAX_COMMANDS" desc:"The maximum number of pdf and video commands which run at the same time. Further thumbnails of pdf files and videos wait until a command finished. 0 disables the limit."`
 }
@@ -41,10 +41,20 @@ func DefaultConfig() *config.Config {
 			FileSystemStorage: config.FileSystemStorage{
 				RootDirectory: path.Join(defaults.BaseDataPath(), "thumbnails"),
 			},
+			Pregeneration: config.Pregeneration{
+				Enabled: false,
+				Types:   []string{"webp"},
+				Workers: 2,
+			},
 			WebdavAllowInsecure: false,
 			RevaGateway:         shared.DefaultRevaConfig().Address,
 			CS3AllowInsecure:    false,
 			DataEndpoint:        "http://127.0.0.1:9186/thumbnails/data",
+			MaxCommands:         2,
+		},
+		Events: config.Events{
+			Endpoint: "127.0.0.1:9233",
+			Cluster:  "ocis-cluster",
 		},
 	}
 }
@@ -89,6 +99,10 @@ func EnsureDefaults(cfg *config.Config) {
 		}
 	}
 
+	if cfg.MachineAuthAPIKey == "" && cfg.Commons != nil && cfg.Commons.MachineAuthAPIKey != "" {
+		cfg.MachineAuthAPIKey = cfg.Commons.MachineAuthAPIKey
+	}
+
 	if cfg.Commons != nil {
 		cfg.HTTP.TLS = cfg.Commons.HTTPServiceTLS
 	}
@@ -99,4 +113,7 @@ func Sanitize(cfg *config.Config) {
 	if len(cfg.Thumbnail.Resolutions) == 1 && strings.Contains(cfg.Thumbnail.Resolutions[0], ",") {
 		cfg.Thumbnail.Resolutions = strings.Split(cfg.Thumbnail.Resolutions[0], ",")
 	}
+	if len(cfg.Thumbnail.Pregeneration.Types) == 1 && strings.Contains(cfg.Thumbnail.Pregeneration.Types[0], ",") {
+		cfg.Thumbnail.Pregeneration.Types = strings.Split(cfg.Thumbnail.Pregeneration.Types[0], ",")
+	}
 }
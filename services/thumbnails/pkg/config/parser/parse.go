@@ -2,8 +2,11 @@ package parser
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 
 	ociscfg "github.com/owncloud/ocis/v2/ocis-pkg/config"
+	"github.com/owncloud/ocis/v2/ocis-pkg/shared"
 	"github.com/owncloud/ocis/v2/services/thumbnails/pkg/config"
 	"github.com/owncloud/ocis/v2/services/thumbnails/pkg/config/defaults"
 
@@ -34,5 +37,18 @@ func ParseConfig(cfg *config.Config) error {
 }
 
 func Validate(cfg *config.Config) error {
+	if !cfg.Thumbnail.Pregeneration.Enabled {
+		return nil
+	}
+	if cfg.MachineAuthAPIKey == "" {
+		return shared.MissingMachineAuthApiKeyError(cfg.Service.Name)
+	}
+	for _, t := range cfg.Thumbnail.Pregeneration.Types {
+		switch strings.ToLower(strings.TrimSpace(t)) {
+		case "png", "jpg", "jpeg", "webp":
+		default:
+			return fmt.Errorf("the thumbnail type %s can't be pregenerated, set THUMBNAILS_PREGENERATION_TYPES to png, jpg or webp", t)
+		}
+	}
 	return nil
 }
@@ -0,0 +1,143 @@
+package preprocessor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"io"
+
+	"github.com/disintegration/imaging"
+)
+
+// maxOrientationHeader is the number of bytes which are searched for the orientation tag.
+const maxOrientationHeader = 1 << 20
+
+// The values of the exif orientation tag. They describe how the image has to be transformed to be displayed upright.
+const (
+	orientationNormal     = 1
+	orientationFlipH      = 2
+	orientationRotate180  = 3
+	orientationFlipV      = 4
+	orientationTranspose  = 5
+	orientationRotate270  = 6
+	orientationTransverse = 7
+	orientationRotate90   = 8
+)
+
+// decodeOriented decodes an image and applies its exif orientation. Unlike the auto orientation of
+// imaging, which only supports jpeg files, the orientation of tiff files is applied as well.
+func decodeOriented(r io.Reader) (image.Image, error) {
+	header := &bytes.Buffer{}
+	o := readOrientation(io.TeeReader(io.LimitReader(r, maxOrientationHeader), header))
+	img, err := imaging.Decode(io.MultiReader(header, r))
+	if err != nil {
+		return nil, err
+	}
+	return applyOrientation(img, o), nil
+}
+
+// applyOrientation transforms the image so that it is displayed upright.
+func applyOrientation(img image.Image, o int) image.Image {
+	switch o {
+	case orientationFlipH:
+		return imaging.FlipH(img)
+	case orientationRotate180:
+		return imaging.Rotate180(img)
+	case orientationFlipV:
+		return imaging.FlipV(img)
+	case orientationTranspose:
+		return imaging.Transpose(img)
+	case orientationRotate270:
+		return imaging.Rotate270(img)
+	case orientationTransverse:
+		return imaging.Transverse(img)
+	case orientationRotate90:
+		return imaging.Rotate90(img)
+	default:
+		return img
+	}
+}
+
+// readOrientation returns the exif orientation of a jpeg or tiff file, or orientationNormal if the
+// file has none.
+func readOrientation(r io.Reader) int {
+	magic := make([]byte, 4)
+	if _, err := io.ReadFull(r, magic); err != nil {
+		return orientationNormal
+	}
+
+	switch {
+	case bytes.HasPrefix(magic, []byte("II*\x00")), bytes.HasPrefix(magic, []byte("MM\x00*")):
+		rest, err := io.ReadAll(r)
+		if err != nil {
+			return orientationNormal
+		}
+		return tiffOrientation(append(magic, rest...))
+	case magic[0] != 0xff || magic[1] != 0xd8:
+		return orientationNormal
+	}
+
+	// the exif data of jpeg files is stored in an APP1 segment in front of the image data
+	marker := magic[2:]
+	for {
+		if marker[0] != 0xff {
+			return orientationNormal
+		}
+		var length uint16
+		if err := binary.Read(r, binary.BigEndian, &length); err != nil || length < 2 {
+			return orientationNormal
+		}
+		switch marker[1] {
+		case 0xda, 0xd9:
+			// the image data starts, there is no exif segment
+			return orientationNormal
+		case 0xe1:
+			segment := make([]byte, length-2)
+			if _, err := io.ReadFull(r, segment); err != nil {
+				return orientationNormal
+			}
+			if bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+				return tiffOrientation(segment[6:])
+			}
+		default:
+			if _, err := io.CopyN(io.Discard, r, int64(length-2)); err != nil {
+				return orientationNormal
+			}
+		}
+		if _, err := io.ReadFull(r, marker); err != nil {
+			return orientationNormal
+		}
+	}
+}
+
+// tiffOrientation returns the orientation tag of the first image file directory of tiff data.
+func tiffOrientation(b []byte) int {
+	if len(b) < 8 {
+		return orientationNormal
+	}
+	var order binary.ByteOrder = binary.LittleEndian
+	if b[0] == 'M' {
+		order = binary.BigEndian
+	}
+
+	offset := int64(order.Uint32(b[4:]))
+	if offset+2 > int64(len(b)) {
+		return orientationNormal
+	}
+	n := int64(order.Uint16(b[offset:]))
+	for i := int64(0); i < n; i++ {
+		entry := offset + 2 + i*12
+		if entry+12 > int64(len(b)) {
+			return orientationNormal
+		}
+		// the orientation is a single short which is stored in the entry itself
+		if order.Uint16(b[entry:]) != 0x0112 || order.Uint16(b[entry+2:]) != 3 {
+			continue
+		}
+		if o := int(order.Uint16(b[entry+8:])); o >= orientationNormal && o <= orientationRotate90 {
+			return o
+		}
+		return orientationNormal
+	}
+	return orientationNormal
+}
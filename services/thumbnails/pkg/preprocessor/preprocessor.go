@@ -1,14 +1,22 @@
 package preprocessor
 
 import (
+	"archive/zip"
 	"bufio"
+	"bytes"
+	"context"
+	"encoding/xml"
 	"image"
 	"image/draw"
 	"image/gif"
 	"io"
 	"math"
 	"mime"
+	"os"
+	"os/exec"
+	"path"
 	"strings"
+	"time"
 
 	"github.com/disintegration/imaging"
 	"github.com/pkg/errors"
@@ -17,14 +25,21 @@ import (
 	"golang.org/x/image/math/fixed"
 )
 
+// commandTimeout is the time an external command has to render a thumbnail.
+const commandTimeout = time.Minute
+
+// ErrNoThumbnail is returned when a document doesn't contain an embedded thumbnail.
+var ErrNoThumbnail = errors.New("the document has no embedded thumbnail")
+
 type FileConverter interface {
 	Convert(r io.Reader) (interface{}, error)
 }
 
+// ImageDecoder decodes raster images and turns them upright according to their exif orientation.
 type ImageDecoder struct{}
 
 func (i ImageDecoder) Convert(r io.Reader) (interface{}, error) {
-	img, err := imaging.Decode(r, imaging.AutoOrientation(true))
+	img, err := decodeOriented(r)
 	if err != nil {
 		return nil, errors.Wrap(err, `could not decode the image`)
 	}
@@ -41,6 +56,163 @@ func (i GifDecoder) Convert(r io.Reader) (interface{}, error) {
 	return img, nil
 }
 
+// OfficeThumbnailExtractor extracts the preview image which office documents in the OOXML and ODF
+// formats embed in their package.
+type OfficeThumbnailExtractor struct{}
+
+func (o OfficeThumbnailExtractor) Convert(r io.Reader) (interface{}, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrap(err, `could not read the document`)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, errors.Wrap(err, `could not open the document`)
+	}
+
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+	// OOXML packages reference their thumbnail in the package relationships, ODF packages
+	// always store it at the same place
+	candidates := []string{"Thumbnails/thumbnail.png", "docProps/thumbnail.jpeg"}
+	if f, ok := files["_rels/.rels"]; ok {
+		candidates = append(thumbnailRelationships(f), candidates...)
+	}
+	for _, name := range candidates {
+		f, ok := files[name]
+		if !ok {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			continue
+		}
+		// thumbnails in the wmf or emf formats can't be decoded, the next candidate is tried instead
+		img, err := imaging.Decode(rc)
+		_ = rc.Close()
+		if err == nil {
+			return img, nil
+		}
+	}
+	return nil, ErrNoThumbnail
+}
+
+// thumbnailRelationships returns the targets of the thumbnail relationships of an OOXML package.
+func thumbnailRelationships(f *zip.File) []string {
+	rc, err := f.Open()
+	if err != nil {
+		return nil
+	}
+	defer rc.Close() // nolint:errcheck
+
+	var rels struct {
+		Relationships []struct {
+			Type   string `xml:"Type,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := xml.NewDecoder(io.LimitReader(rc, 1<<20)).Decode(&rels); err != nil {
+		return nil
+	}
+	var targets []string
+	for _, rel := range rels.Relationships {
+		if strings.HasSuffix(rel.Type, "/metadata/thumbnail") {
+			targets = append(targets, strings.TrimPrefix(path.Clean("/"+rel.Target), "/"))
+		}
+	}
+	return targets
+}
+
+// CommandLimiter limits the number of external commands which run at the same time. A nil CommandLimiter
+// doesn't limit them.
+type CommandLimiter chan struct{}
+
+// NewCommandLimiter returns a CommandLimiter which allows n commands to run at the same time, or nil if n is 0.
+func NewCommandLimiter(n int) CommandLimiter {
+	if n <= 0 {
+		return nil
+	}
+	return make(CommandLimiter, n)
+}
+
+// acquire waits until a command may run, or until the timeout passed. The returned function releases the slot.
+func (l CommandLimiter) acquire(timeout time.Duration) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+	select {
+	case l <- struct{}{}:
+		return func() { <-l }, nil
+	case <-t.C:
+		return nil, errors.New(`too many commands are running`)
+	}
+}
+
+// CommandConverter renders a file with an external command, e.g. the first page of a pdf or a keyframe of a video.
+// The file is stored in a temporary file whose path replaces the {file} placeholder of the command, or is appended
+// to the command if it has no placeholder. The command has to write the image to stdout. The Limiter limits the
+// number of commands running at the same time.
+type CommandConverter struct {
+	Command string
+	Limiter CommandLimiter
+}
+
+func (c CommandConverter) Convert(r io.Reader) (interface{}, error) {
+	args := strings.Fields(c.Command)
+	if len(args) == 0 {
+		return nil, errors.New(`no command configured`)
+	}
+
+	release, err := c.Limiter.acquire(commandTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	f, err := os.CreateTemp("", "thumbnail-source-*")
+	if err != nil {
+		return nil, errors.Wrap(err, `could not create a temporary file`)
+	}
+	defer os.Remove(f.Name()) // nolint:errcheck
+	_, err = io.Copy(f, r)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, `could not write the temporary file`)
+	}
+
+	replaced := false
+	for i, arg := range args[1:] {
+		if strings.Contains(arg, "{file}") {
+			args[i+1] = strings.ReplaceAll(arg, "{file}", f.Name())
+			replaced = true
+		}
+	}
+	if !replaced {
+		args = append(args, f.Name())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	if err := cmd.Run(); err != nil {
+		return nil, errors.Wrapf(err, `could not run %s: %s`, args[0], strings.TrimSpace(stderr.String()))
+	}
+
+	img, err := imaging.Decode(stdout)
+	if err != nil {
+		return nil, errors.Wrapf(err, `could not decode the output of %s`, args[0])
+	}
+	return img, nil
+}
+
 type TxtToImageConverter struct {
 	fontLoader *FontLoader
 }
@@ -165,6 +337,9 @@ func ForType(mimeType string, opts map[string]interface{}) FileConverter {
 	// We can ignore the error here because we parse it in IsMimeTypeSupported before and if it fails
 	// return the service call. So we should only get here when the mimeType parses fine.
 	mimeType, _, _ = mime.ParseMediaType(mimeType)
+	if strings.HasPrefix(mimeType, "video/") {
+		mimeType = "video/*"
+	}
 	switch mimeType {
 	case "text/plain":
 		fontFileMap := ""
@@ -197,6 +372,24 @@ func ForType(mimeType string, opts map[string]interface{}) FileConverter {
 		}
 	case "image/gif":
 		return GifDecoder{}
+	case "image/svg+xml":
+		return SvgToImageConverter{}
+	case "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+		"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+		"application/vnd.openxmlformats-officedocument.presentationml.presentation",
+		"application/vnd.oasis.opendocument.text",
+		"application/vnd.oasis.opendocument.spreadsheet",
+		"application/vnd.oasis.opendocument.presentation",
+		"application/vnd.oasis.opendocument.graphics":
+		return OfficeThumbnailExtractor{}
+	case "application/pdf":
+		command, _ := opts["pdfCommand"].(string)
+		limiter, _ := opts["commandLimiter"].(CommandLimiter)
+		return CommandConverter{Command: command, Limiter: limiter}
+	case "video/*":
+		command, _ := opts["videoCommand"].(string)
+		limiter, _ := opts["commandLimiter"].(CommandLimiter)
+		return CommandConverter{Command: command, Limiter: limiter}
 	default:
 		return ImageDecoder{}
 	}
@@ -0,0 +1,229 @@
+package preprocessor
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func pngImage(t *testing.T, w, h int) []byte {
+	buf := &bytes.Buffer{}
+	if err := png.Encode(buf, image.NewRGBA(image.Rect(0, 0, w, h))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func zipPackage(t *testing.T, files map[string][]byte) []byte {
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	for name, data := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestOfficeThumbnailExtractor(t *testing.T) {
+	tables := []struct {
+		name  string
+		files map[string][]byte
+		size  image.Rectangle
+	}{
+		{
+			name: "odf",
+			files: map[string][]byte{
+				"content.xml":              []byte("<office:document-content/>"),
+				"Thumbnails/thumbnail.png": pngImage(t, 20, 30),
+			},
+			size: image.Rect(0, 0, 20, 30),
+		},
+		{
+			name: "ooxml",
+			files: map[string][]byte{
+				"_rels/.rels": []byte(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/package/2006/relationships/metadata/thumbnail" Target="/docProps/preview.png"/>
+</Relationships>`),
+				"docProps/preview.png": pngImage(t, 40, 10),
+			},
+			size: image.Rect(0, 0, 40, 10),
+		},
+	}
+	for _, table := range tables {
+		img, err := OfficeThumbnailExtractor{}.Convert(bytes.NewReader(zipPackage(t, table.files)))
+		if assert.NoError(t, err, table.name) {
+			assert.Equal(t, table.size, img.(image.Image).Bounds(), table.name)
+		}
+	}
+
+	_, err := OfficeThumbnailExtractor{}.Convert(bytes.NewReader(zipPackage(t, map[string][]byte{
+		"docProps/thumbnail.wmf": []byte("not decodable"),
+	})))
+	assert.Equal(t, ErrNoThumbnail, err)
+}
+
+func TestCommandConverter(t *testing.T) {
+	if _, err := exec.LookPath("cat"); err != nil {
+		t.Skip("cat is not available")
+	}
+
+	img, err := CommandConverter{Command: "cat {file}"}.Convert(bytes.NewReader(pngImage(t, 12, 8)))
+	if assert.NoError(t, err) {
+		assert.Equal(t, image.Rect(0, 0, 12, 8), img.(image.Image).Bounds())
+	}
+
+	_, err = CommandConverter{Command: "cat"}.Convert(bytes.NewReader([]byte("no image")))
+	assert.Error(t, err)
+
+	_, err = CommandConverter{}.Convert(bytes.NewReader(pngImage(t, 1, 1)))
+	assert.Error(t, err)
+}
+
+func TestCommandLimiter(t *testing.T) {
+	assert.Nil(t, NewCommandLimiter(0))
+	release, err := CommandLimiter(nil).acquire(0)
+	if assert.NoError(t, err) {
+		release()
+	}
+
+	l := NewCommandLimiter(2)
+	first, err := l.acquire(time.Second)
+	assert.NoError(t, err)
+	second, err := l.acquire(time.Second)
+	assert.NoError(t, err)
+
+	// a third command has to wait until one of the others finished
+	_, err = l.acquire(10 * time.Millisecond)
+	assert.Error(t, err)
+
+	first()
+	third, err := l.acquire(time.Second)
+	assert.NoError(t, err)
+	second()
+	third()
+	assert.Len(t, l, 0)
+}
+
+func TestCommandConverterWaitsForTheLimiter(t *testing.T) {
+	if _, err := exec.LookPath("cat"); err != nil {
+		t.Skip("cat is not available")
+	}
+
+	l := NewCommandLimiter(1)
+	release, err := l.acquire(time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error)
+	go func() {
+		_, err := CommandConverter{Command: "cat {file}", Limiter: l}.Convert(bytes.NewReader(pngImage(t, 1, 1)))
+		done <- err
+	}()
+	select {
+	case <-done:
+		t.Fatal("the command ran although the limit was reached")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	release()
+	assert.NoError(t, <-done)
+	assert.Len(t, l, 0)
+}
+
+// exifSegment returns tiff data with an orientation tag, wrapped in a jpeg APP1 segment
+func exifSegment(order binary.ByteOrder, orientation uint16) []byte {
+	tiff := &bytes.Buffer{}
+	if order == binary.BigEndian {
+		tiff.WriteString("MM")
+	} else {
+		tiff.WriteString("II")
+	}
+	_ = binary.Write(tiff, order, uint16(42))
+	_ = binary.Write(tiff, order, uint32(8))
+	_ = binary.Write(tiff, order, uint16(2))
+	// a gps info pointer and the orientation
+	_ = binary.Write(tiff, order, []uint16{0x8825, 4})
+	_ = binary.Write(tiff, order, []uint32{1, 0})
+	_ = binary.Write(tiff, order, []uint16{0x0112, 3})
+	_ = binary.Write(tiff, order, []uint32{1})
+	_ = binary.Write(tiff, order, []uint16{orientation, 0})
+	_ = binary.Write(tiff, order, uint32(0))
+
+	segment := &bytes.Buffer{}
+	segment.Write([]byte{0xff, 0xe1})
+	_ = binary.Write(segment, binary.BigEndian, uint16(2+6+tiff.Len()))
+	segment.WriteString("Exif\x00\x00")
+	segment.Write(tiff.Bytes())
+	return segment.Bytes()
+}
+
+// orientedJpeg returns a jpeg with a red left and a blue right half and the given exif orientation
+func orientedJpeg(t *testing.T, orientation uint16) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, 64, 32))
+	draw.Draw(img, image.Rect(0, 0, 32, 32), image.NewUniform(color.RGBA{R: 0xff, A: 0xff}), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(32, 0, 64, 32), image.NewUniform(color.RGBA{B: 0xff, A: 0xff}), image.Point{}, draw.Src)
+	buf := &bytes.Buffer{}
+	if err := jpeg.Encode(buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	return append(append(append([]byte{}, data[:2]...), exifSegment(binary.BigEndian, orientation)...), data[2:]...)
+}
+
+func TestImageDecoderOrientation(t *testing.T) {
+	isRed := func(c color.Color) bool {
+		r, _, b, _ := c.RGBA()
+		return r > 0xc000 && b < 0x4000
+	}
+	tables := []struct {
+		orientation uint16
+		size        image.Rectangle
+		red         image.Point
+	}{
+		{orientationNormal, image.Rect(0, 0, 64, 32), image.Pt(8, 16)},
+		{orientationFlipH, image.Rect(0, 0, 64, 32), image.Pt(56, 16)},
+		{orientationRotate180, image.Rect(0, 0, 64, 32), image.Pt(56, 16)},
+		{orientationTranspose, image.Rect(0, 0, 32, 64), image.Pt(16, 8)},
+		{orientationRotate270, image.Rect(0, 0, 32, 64), image.Pt(16, 8)},
+		{orientationTransverse, image.Rect(0, 0, 32, 64), image.Pt(16, 56)},
+		{orientationRotate90, image.Rect(0, 0, 32, 64), image.Pt(16, 56)},
+	}
+	for _, table := range tables {
+		img, err := ImageDecoder{}.Convert(bytes.NewReader(orientedJpeg(t, table.orientation)))
+		if !assert.NoError(t, err) {
+			continue
+		}
+		m := img.(image.Image)
+		assert.Equal(t, table.size, m.Bounds(), "orientation %d", table.orientation)
+		assert.True(t, isRed(m.At(table.red.X, table.red.Y)), "orientation %d", table.orientation)
+	}
+}
+
+func TestReadOrientation(t *testing.T) {
+	tiff := exifSegment(binary.LittleEndian, orientationRotate90)[10:]
+	assert.Equal(t, orientationRotate90, readOrientation(bytes.NewReader(tiff)))
+	assert.Equal(t, orientationRotate270, readOrientation(bytes.NewReader(orientedJpeg(t, orientationRotate270))))
+	assert.Equal(t, orientationNormal, readOrientation(bytes.NewReader(pngImage(t, 1, 1))))
+	assert.Equal(t, orientationNormal, readOrientation(bytes.NewReader([]byte{0xff, 0xd8, 0xff, 0xda, 0, 2})))
+	assert.Equal(t, orientationNormal, readOrientation(bytes.NewReader(append([]byte{0xff, 0xd8}, exifSegment(binary.BigEndian, 42)...))))
+}
@@ -0,0 +1,1025 @@
+package preprocessor
+
+import (
+	"encoding/xml"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/image/vector"
+)
+
+// svgSize is the length of the longer side of a rasterized svg image.
+// Svg images scale without loss, so they are rendered big enough for all thumbnail resolutions
+// instead of at their nominal size which is often tiny for icons.
+const svgSize = 1920
+
+// SvgToImageConverter rasterizes svg images. It supports the basic shapes, paths with both fill rules, transformations
+// and solid colors which covers most icons, logos and diagrams. Text, embedded images, filters and
+// masks are skipped and gradients are painted with the color of their first stop.
+type SvgToImageConverter struct{}
+
+func (s SvgToImageConverter) Convert(r io.Reader) (interface{}, error) {
+	var root svgNode
+	if err := xml.NewDecoder(r).Decode(&root); err != nil {
+		return nil, errors.Wrap(err, `could not decode the svg`)
+	}
+	if root.XMLName.Local != "svg" {
+		return nil, errors.New(`could not decode the svg: the root element is not svg`)
+	}
+
+	attrs := root.attributes()
+	viewBox, hasViewBox := parseViewBox(attrs["viewBox"])
+	width, hasWidth := parseLength(attrs["width"], 0)
+	height, hasHeight := parseLength(attrs["height"], 0)
+	switch {
+	case hasWidth && hasHeight:
+	case hasViewBox && hasWidth:
+		height = width * viewBox[3] / viewBox[2]
+	case hasViewBox && hasHeight:
+		width = height * viewBox[2] / viewBox[3]
+	case hasViewBox:
+		width, height = viewBox[2], viewBox[3]
+	default:
+		// the default size of replaced elements in css
+		width, height = 300, 150
+	}
+	if width <= 0 || height <= 0 {
+		return nil, errors.New(`could not decode the svg: the image is empty`)
+	}
+	if !hasViewBox {
+		viewBox = [4]float64{0, 0, width, height}
+	}
+
+	scale := svgSize / math.Max(width, height)
+	w, h := int(math.Max(1, math.Round(width*scale))), int(math.Max(1, math.Round(height*scale)))
+
+	// map the view box to the image, centered and keeping the aspect ratio unless told otherwise
+	sx, sy := float64(w)/viewBox[2], float64(h)/viewBox[3]
+	if !strings.HasPrefix(attrs["preserveAspectRatio"], "none") {
+		sx = math.Min(sx, sy)
+		sy = sx
+	}
+	m := affine{sx, 0, 0, sy, (float64(w)-viewBox[2]*sx)/2 - viewBox[0]*sx, (float64(h)-viewBox[3]*sy)/2 - viewBox[1]*sy}
+
+	// the background is white like the one of text thumbnails, transparent areas would turn black in jpeg thumbnails
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+
+	sr := svgRenderer{
+		dst:        img,
+		rasterizer: vector.NewRasterizer(w, h),
+		gradients:  collectGradients(root),
+		viewBox:    viewBox,
+	}
+	sr.render(root, m, svgStyle{
+		color:         color.NRGBA{A: 0xff},
+		fill:          color.NRGBA{A: 0xff},
+		fillOpacity:   1,
+		strokeOpacity: 1,
+		opacity:       1,
+		strokeWidth:   1,
+	})
+	return img, nil
+}
+
+// svgNode is an element of the svg document.
+type svgNode struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Children []svgNode  `xml:",any"`
+}
+
+// attributes returns the attributes of the node, including the properties of its style attribute.
+func (n svgNode) attributes() map[string]string {
+	attrs := make(map[string]string, len(n.Attrs))
+	for _, a := range n.Attrs {
+		attrs[a.Name.Local] = strings.TrimSpace(a.Value)
+	}
+	for _, decl := range strings.Split(attrs["style"], ";") {
+		if k, v, ok := strings.Cut(decl, ":"); ok {
+			attrs[strings.TrimSpace(k)] = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(v), "!important"))
+		}
+	}
+	return attrs
+}
+
+// svgStyle contains the inherited properties which are needed to paint a shape.
+type svgStyle struct {
+	color, fill, stroke                              color.NRGBA
+	fillOpacity, strokeOpacity, opacity, strokeWidth float64
+	roundCaps, hidden, evenOdd                       bool
+}
+
+func (s svgStyle) inherit(attrs map[string]string, gradients map[string]color.NRGBA, ref float64) svgStyle {
+	if v, ok := attrs["color"]; ok {
+		if c, ok := parseColor(v, s.color, gradients); ok {
+			s.color = c
+		}
+	}
+	if v, ok := attrs["fill"]; ok {
+		if c, ok := parseColor(v, s.color, gradients); ok {
+			s.fill = c
+		}
+	}
+	if v, ok := attrs["stroke"]; ok {
+		if c, ok := parseColor(v, s.color, gradients); ok {
+			s.stroke = c
+		}
+	}
+	if v, ok := attrs["fill-opacity"]; ok {
+		s.fillOpacity = parseOpacity(v, s.fillOpacity)
+	}
+	if v, ok := attrs["stroke-opacity"]; ok {
+		s.strokeOpacity = parseOpacity(v, s.strokeOpacity)
+	}
+	if v, ok := attrs["opacity"]; ok {
+		// the opacity of groups is applied to each shape which differs only where shapes overlap
+		s.opacity *= parseOpacity(v, 1)
+	}
+	if v, ok := attrs["stroke-width"]; ok {
+		if w, ok := parseLength(v, ref); ok && w >= 0 {
+			s.strokeWidth = w
+		}
+	}
+	if v, ok := attrs["fill-rule"]; ok {
+		s.evenOdd = v == "evenodd"
+	}
+	if v, ok := attrs["stroke-linecap"]; ok {
+		s.roundCaps = v == "round"
+	}
+	if v, ok := attrs["visibility"]; ok {
+		s.hidden = v == "hidden" || v == "collapse"
+	}
+	return s
+}
+
+// svgRenderer paints the shapes of an svg document.
+type svgRenderer struct {
+	dst        *image.RGBA
+	rasterizer *vector.Rasterizer
+	gradients  map[string]color.NRGBA
+	viewBox    [4]float64
+}
+
+func (sr *svgRenderer) render(n svgNode, m affine, style svgStyle) {
+	attrs := n.attributes()
+	if attrs["display"] == "none" {
+		return
+	}
+	if t, ok := attrs["transform"]; ok {
+		m = m.mul(parseTransform(t))
+	}
+	// percentages refer to the width or height of the view box, or to its normalized diagonal
+	rw, rh := sr.viewBox[2], sr.viewBox[3]
+	rd := math.Sqrt((rw*rw + rh*rh) / 2)
+	style = style.inherit(attrs, sr.gradients, rd)
+	length := func(name string, ref float64) float64 {
+		v, _ := parseLength(attrs[name], ref)
+		return v
+	}
+
+	p := &svgPath{m: m}
+	switch n.XMLName.Local {
+	case "svg", "g", "a", "switch":
+		for _, c := range n.Children {
+			sr.render(c, m, style)
+		}
+		return
+	case "path":
+		p.parse(attrs["d"])
+	case "rect":
+		x, y, w, h := length("x", rw), length("y", rh), length("width", rw), length("height", rh)
+		rx, hasRx := parseLength(attrs["rx"], rw)
+		ry, hasRy := parseLength(attrs["ry"], rh)
+		if !hasRx {
+			rx = ry
+		}
+		if !hasRy {
+			ry = rx
+		}
+		rx, ry = math.Min(math.Max(rx, 0), w/2), math.Min(math.Max(ry, 0), h/2)
+		if w <= 0 || h <= 0 {
+			return
+		}
+		if rx == 0 || ry == 0 {
+			p.moveTo(x, y)
+			p.lineTo(x+w, y)
+			p.lineTo(x+w, y+h)
+			p.lineTo(x, y+h)
+			p.close()
+			break
+		}
+		p.moveTo(x+rx, y)
+		p.lineTo(x+w-rx, y)
+		p.arcTo(rx, ry, 0, false, true, x+w, y+ry)
+		p.lineTo(x+w, y+h-ry)
+		p.arcTo(rx, ry, 0, false, true, x+w-rx, y+h)
+		p.lineTo(x+rx, y+h)
+		p.arcTo(rx, ry, 0, false, true, x, y+h-ry)
+		p.lineTo(x, y+ry)
+		p.arcTo(rx, ry, 0, false, true, x+rx, y)
+		p.close()
+	case "circle", "ellipse":
+		cx, cy := length("cx", rw), length("cy", rh)
+		rx, ry := length("rx", rw), length("ry", rh)
+		if n.XMLName.Local == "circle" {
+			rx = length("r", rd)
+			ry = rx
+		}
+		if rx <= 0 || ry <= 0 {
+			return
+		}
+		p.moveTo(cx+rx, cy)
+		p.arcTo(rx, ry, 0, false, true, cx-rx, cy)
+		p.arcTo(rx, ry, 0, false, true, cx+rx, cy)
+		p.close()
+	case "line":
+		p.moveTo(length("x1", rw), length("y1", rh))
+		p.lineTo(length("x2", rw), length("y2", rh))
+	case "polyline", "polygon":
+		sc := &svgScanner{s: attrs["points"]}
+		for i := 0; ; i++ {
+			x, okX := sc.number()
+			y, okY := sc.number()
+			if !okX || !okY {
+				break
+			}
+			if i == 0 {
+				p.moveTo(x, y)
+			} else {
+				p.lineTo(x, y)
+			}
+		}
+		if n.XMLName.Local == "polygon" {
+			p.close()
+		}
+	default:
+		// definitions, text, images and all other elements are not painted
+		return
+	}
+	p.finish()
+	if style.hidden || len(p.subpaths) == 0 {
+		return
+	}
+
+	sr.fill(p, style)
+	sr.stroke(p, style, math.Sqrt(math.Abs(m[0]*m[3]-m[1]*m[2])))
+}
+
+// fill paints the inside of the path according to its fill rule.
+func (sr *svgRenderer) fill(p *svgPath, style svgStyle) {
+	c := style.fill
+	c.A = uint8(math.Round(float64(c.A) * style.fillOpacity * style.opacity))
+	if c.A == 0 {
+		return
+	}
+	var polygons [][]vec2
+	for _, sp := range p.subpaths {
+		if len(sp.points) > 2 {
+			polygons = append(polygons, sp.points)
+		}
+	}
+	if style.evenOdd {
+		sr.paintEvenOdd(polygons, c)
+		return
+	}
+	// subpaths keep their orientation, so that a subpath drawn in the opposite direction cuts a hole
+	sr.paint(polygons, c, false)
+}
+
+// stroke paints the outline of the path. The segments are painted as rectangles and the joins are
+// rounded, the caps of open subpaths are rounded or butt.
+func (sr *svgRenderer) stroke(p *svgPath, style svgStyle, scale float64) {
+	c := style.stroke
+	c.A = uint8(math.Round(float64(c.A) * style.strokeOpacity * style.opacity))
+	half := style.strokeWidth * scale / 2
+	if c.A == 0 || half <= 0 {
+		return
+	}
+
+	var polygons [][]vec2
+	for _, sp := range p.subpaths {
+		points := sp.points
+		if sp.closed && len(points) > 1 {
+			points = append(points[:len(points):len(points)], points[0])
+		}
+		for i := 1; i < len(points); i++ {
+			a, b := points[i-1], points[i]
+			dx, dy := b.x-a.x, b.y-a.y
+			l := math.Hypot(dx, dy)
+			if l == 0 {
+				continue
+			}
+			nx, ny := -dy/l*half, dx/l*half
+			polygons = append(polygons, []vec2{{a.x + nx, a.y + ny}, {b.x + nx, b.y + ny}, {b.x - nx, b.y - ny}, {a.x - nx, a.y - ny}})
+		}
+		for i, pt := range points {
+			isEnd := !sp.closed && (i == 0 || i == len(points)-1)
+			if len(points) > 1 && (!isEnd || style.roundCaps) {
+				polygons = append(polygons, circle(pt, half))
+			}
+		}
+	}
+	sr.paint(polygons, c, true)
+}
+
+// paint fills the polygons with the color using the nonzero rule. If orient is set, all polygons are drawn in
+// the same orientation so that overlapping areas add up instead of canceling each other out.
+func (sr *svgRenderer) paint(polygons [][]vec2, c color.NRGBA, orient bool) {
+	bounds := sr.bounds(polygons)
+	if bounds.Empty() {
+		return
+	}
+
+	sr.rasterizer.Reset(bounds.Dx(), bounds.Dy())
+	for _, polygon := range polygons {
+		sr.addPolygon(polygon, bounds.Min, orient && area(polygon) < 0)
+	}
+	sr.rasterizer.Draw(sr.dst, bounds, image.NewUniform(c), image.Point{})
+}
+
+// paintEvenOdd fills the polygons with the color using the evenodd rule. The rasterizer only implements the
+// nonzero rule, so every polygon is rasterized on its own and the coverages are combined, a pixel is painted
+// where it is covered by an odd number of polygons.
+func (sr *svgRenderer) paintEvenOdd(polygons [][]vec2, c color.NRGBA) {
+	bounds := sr.bounds(polygons)
+	if bounds.Empty() {
+		return
+	}
+
+	mask := image.NewAlpha(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	layer := image.NewAlpha(mask.Bounds())
+	for _, polygon := range polygons {
+		for i := range layer.Pix {
+			layer.Pix[i] = 0
+		}
+		sr.rasterizer.Reset(bounds.Dx(), bounds.Dy())
+		sr.addPolygon(polygon, bounds.Min, false)
+		sr.rasterizer.Draw(layer, layer.Bounds(), image.Opaque, image.Point{})
+
+		for i, a := range layer.Pix {
+			m := int(mask.Pix[i])
+			mask.Pix[i] = uint8(m + int(a) - 2*m*int(a)/0xff)
+		}
+	}
+	draw.DrawMask(sr.dst, bounds, image.NewUniform(c), image.Point{}, mask, image.Point{}, draw.Over)
+}
+
+// bounds returns the part of the image covered by the polygons, only this part is rasterized as the
+// rasterizer processes its whole area
+func (sr *svgRenderer) bounds(polygons [][]vec2) image.Rectangle {
+	minX, minY, maxX, maxY := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	for _, polygon := range polygons {
+		for _, pt := range polygon {
+			minX, minY = math.Min(minX, pt.x), math.Min(minY, pt.y)
+			maxX, maxY = math.Max(maxX, pt.x), math.Max(maxY, pt.y)
+		}
+	}
+	if len(polygons) == 0 {
+		return image.Rectangle{}
+	}
+	return image.Rect(int(math.Floor(minX)), int(math.Floor(minY)), int(math.Ceil(maxX)), int(math.Ceil(maxY))).Intersect(sr.dst.Bounds())
+}
+
+// addPolygon adds a closed polygon relative to the origin to the rasterizer, in reverse order if reversed is set.
+func (sr *svgRenderer) addPolygon(polygon []vec2, origin image.Point, reversed bool) {
+	ox, oy := float64(origin.X), float64(origin.Y)
+	for i := range polygon {
+		pt := polygon[i]
+		if reversed {
+			pt = polygon[len(polygon)-1-i]
+		}
+		if i == 0 {
+			sr.rasterizer.MoveTo(float32(pt.x-ox), float32(pt.y-oy))
+		} else {
+			sr.rasterizer.LineTo(float32(pt.x-ox), float32(pt.y-oy))
+		}
+	}
+	sr.rasterizer.ClosePath()
+}
+
+// area returns twice the signed area of a polygon, the sign tells its orientation.
+func area(polygon []vec2) float64 {
+	a := 0.0
+	for i, pt := range polygon {
+		next := polygon[(i+1)%len(polygon)]
+		a += pt.x*next.y - next.x*pt.y
+	}
+	return a
+}
+
+// circle returns a polygon which approximates a circle.
+func circle(center vec2, radius float64) []vec2 {
+	n := int(math.Min(math.Max(radius, 8), 32))
+	points := make([]vec2, n)
+	for i := range points {
+		a := 2 * math.Pi * float64(i) / float64(n)
+		points[i] = vec2{center.x + radius*math.Cos(a), center.y + radius*math.Sin(a)}
+	}
+	return points
+}
+
+type vec2 struct {
+	x, y float64
+}
+
+// affine is a transformation matrix [a b c d e f] which maps x, y to a*x + c*y + e, b*x + d*y + f.
+type affine [6]float64
+
+var identity = affine{1, 0, 0, 1, 0, 0}
+
+// mul returns the transformation which applies n first and then m.
+func (m affine) mul(n affine) affine {
+	return affine{
+		m[0]*n[0] + m[2]*n[1], m[1]*n[0] + m[3]*n[1],
+		m[0]*n[2] + m[2]*n[3], m[1]*n[2] + m[3]*n[3],
+		m[0]*n[4] + m[2]*n[5] + m[4], m[1]*n[4] + m[3]*n[5] + m[5],
+	}
+}
+
+func (m affine) apply(x, y float64) vec2 {
+	return vec2{m[0]*x + m[2]*y + m[4], m[1]*x + m[3]*y + m[5]}
+}
+
+// parseTransform parses a list of transformations like "translate(10 20) rotate(45)".
+func parseTransform(s string) affine {
+	m := identity
+	for {
+		name, rest, ok := strings.Cut(s, "(")
+		if !ok {
+			return m
+		}
+		args, rest, ok := strings.Cut(rest, ")")
+		if !ok {
+			return m
+		}
+		s = rest
+
+		var v []float64
+		sc := &svgScanner{s: args}
+		for n, ok := sc.number(); ok; n, ok = sc.number() {
+			v = append(v, n)
+		}
+		arg := func(i int, def float64) float64 {
+			if i < len(v) {
+				return v[i]
+			}
+			return def
+		}
+
+		var t affine
+		switch strings.Trim(strings.TrimSpace(name), ",") {
+		case "matrix":
+			if len(v) != 6 {
+				continue
+			}
+			copy(t[:], v)
+		case "translate":
+			t = affine{1, 0, 0, 1, arg(0, 0), arg(1, 0)}
+		case "scale":
+			t = affine{arg(0, 1), 0, 0, arg(1, arg(0, 1)), 0, 0}
+		case "rotate":
+			a := arg(0, 0) * math.Pi / 180
+			cx, cy := arg(1, 0), arg(2, 0)
+			t = affine{1, 0, 0, 1, cx, cy}.
+				mul(affine{math.Cos(a), math.Sin(a), -math.Sin(a), math.Cos(a), 0, 0}).
+				mul(affine{1, 0, 0, 1, -cx, -cy})
+		case "skewX":
+			t = affine{1, 0, math.Tan(arg(0, 0) * math.Pi / 180), 1, 0, 0}
+		case "skewY":
+			t = affine{1, math.Tan(arg(0, 0) * math.Pi / 180), 0, 1, 0, 0}
+		default:
+			continue
+		}
+		m = m.mul(t)
+	}
+}
+
+// svgSubpath is a flattened subpath in image coordinates.
+type svgSubpath struct {
+	points []vec2
+	closed bool
+}
+
+// svgPath builds the flattened subpaths of a shape. The coordinates are transformed to image coordinates
+// before curves are flattened, so that their precision matches the resolution of the image.
+type svgPath struct {
+	m        affine
+	subpaths []svgSubpath
+	current  []vec2
+	// the current point and the start of the current subpath in user coordinates
+	x, y, startX, startY float64
+}
+
+func (p *svgPath) finish() {
+	if len(p.current) > 0 {
+		p.subpaths = append(p.subpaths, svgSubpath{points: p.current})
+		p.current = nil
+	}
+}
+
+func (p *svgPath) moveTo(x, y float64) {
+	p.finish()
+	p.current = []vec2{p.m.apply(x, y)}
+	p.x, p.y, p.startX, p.startY = x, y, x, y
+}
+
+// begin starts a new subpath at the current point if the last one was closed.
+func (p *svgPath) begin() {
+	if p.current == nil {
+		p.current = []vec2{p.m.apply(p.x, p.y)}
+	}
+}
+
+func (p *svgPath) lineTo(x, y float64) {
+	p.begin()
+	p.current = append(p.current, p.m.apply(x, y))
+	p.x, p.y = x, y
+}
+
+func (p *svgPath) quadTo(x1, y1, x, y float64) {
+	// a quadratic curve is a cubic curve with control points two thirds of the way to the quadratic one
+	p.cubicTo(p.x+2*(x1-p.x)/3, p.y+2*(y1-p.y)/3, x+2*(x1-x)/3, y+2*(y1-y)/3, x, y)
+}
+
+func (p *svgPath) cubicTo(x1, y1, x2, y2, x, y float64) {
+	p.begin()
+	p0, p1, p2, p3 := p.current[len(p.current)-1], p.m.apply(x1, y1), p.m.apply(x2, y2), p.m.apply(x, y)
+	l := math.Hypot(p1.x-p0.x, p1.y-p0.y) + math.Hypot(p2.x-p1.x, p2.y-p1.y) + math.Hypot(p3.x-p2.x, p3.y-p2.y)
+	n := int(math.Min(math.Sqrt(l), 100)) + 1
+	for i := 1; i <= n; i++ {
+		t := float64(i) / float64(n)
+		u := 1 - t
+		a, b, c, d := u*u*u, 3*u*u*t, 3*u*t*t, t*t*t
+		p.current = append(p.current, vec2{a*p0.x + b*p1.x + c*p2.x + d*p3.x, a*p0.y + b*p1.y + c*p2.y + d*p3.y})
+	}
+	p.x, p.y = x, y
+}
+
+// arcTo adds an elliptical arc, it is converted to cubic curves as described in the implementation notes
+// of the svg specification.
+func (p *svgPath) arcTo(rx, ry, rotation float64, large, sweep bool, x, y float64) {
+	x1, y1 := p.x, p.y
+	if x1 == x && y1 == y {
+		return
+	}
+	rx, ry = math.Abs(rx), math.Abs(ry)
+	if rx == 0 || ry == 0 {
+		p.lineTo(x, y)
+		return
+	}
+
+	sin, cos := math.Sincos(rotation * math.Pi / 180)
+	dx, dy := (x1-x)/2, (y1-y)/2
+	x1p, y1p := cos*dx+sin*dy, -sin*dx+cos*dy
+	if lambda := x1p*x1p/(rx*rx) + y1p*y1p/(ry*ry); lambda > 1 {
+		rx, ry = rx*math.Sqrt(lambda), ry*math.Sqrt(lambda)
+	}
+	num := rx*rx*ry*ry - rx*rx*y1p*y1p - ry*ry*x1p*x1p
+	den := rx*rx*y1p*y1p + ry*ry*x1p*x1p
+	coef := math.Sqrt(math.Max(0, num/den))
+	if large == sweep {
+		coef = -coef
+	}
+	cxp, cyp := coef*rx*y1p/ry, -coef*ry*x1p/rx
+	cx, cy := cos*cxp-sin*cyp+(x1+x)/2, sin*cxp+cos*cyp+(y1+y)/2
+
+	theta := math.Atan2((y1p-cyp)/ry, (x1p-cxp)/rx)
+	delta := math.Atan2((-y1p-cyp)/ry, (-x1p-cxp)/rx) - theta
+	if !sweep && delta > 0 {
+		delta -= 2 * math.Pi
+	} else if sweep && delta < 0 {
+		delta += 2 * math.Pi
+	}
+
+	point := func(a float64) (float64, float64) {
+		return cx + rx*math.Cos(a)*cos - ry*math.Sin(a)*sin, cy + rx*math.Cos(a)*sin + ry*math.Sin(a)*cos
+	}
+	tangent := func(a float64) (float64, float64) {
+		return -rx*math.Sin(a)*cos - ry*math.Cos(a)*sin, -rx*math.Sin(a)*sin + ry*math.Cos(a)*cos
+	}
+	n := math.Ceil(math.Abs(delta) / (math.Pi / 2))
+	step := delta / n
+	k := 4.0 / 3 * math.Tan(step/4)
+	for i := 0.0; i < n; i++ {
+		a1, a2 := theta+i*step, theta+(i+1)*step
+		px1, py1 := point(a1)
+		tx1, ty1 := tangent(a1)
+		px2, py2 := point(a2)
+		tx2, ty2 := tangent(a2)
+		if i == n-1 {
+			px2, py2 = x, y
+		}
+		p.cubicTo(px1+k*tx1, py1+k*ty1, px2-k*tx2, py2-k*ty2, px2, py2)
+	}
+}
+
+func (p *svgPath) close() {
+	if len(p.current) > 0 {
+		p.subpaths = append(p.subpaths, svgSubpath{points: p.current, closed: true})
+		p.current = nil
+	}
+	p.x, p.y = p.startX, p.startY
+}
+
+// parse adds the commands of the path data. Parsing stops at the first error and the path
+// is rendered up to there, like the svg specification demands.
+func (p *svgPath) parse(d string) {
+	sc := &svgScanner{s: d}
+	var cmd, last byte
+	var ctrlX, ctrlY float64
+	for {
+		sc.skipSeparators()
+		if sc.i >= len(sc.s) {
+			return
+		}
+		if c := sc.s[sc.i]; (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') {
+			cmd = c
+			sc.i++
+		} else if cmd == 0 {
+			return
+		}
+
+		var ox, oy float64
+		if cmd >= 'a' {
+			ox, oy = p.x, p.y
+		}
+		var args [7]float64
+		read := func(from, n int) bool {
+			for i := from; i < from+n; i++ {
+				v, ok := sc.number()
+				if !ok {
+					return false
+				}
+				args[i] = v
+			}
+			return true
+		}
+
+		// reflect the last control point for smooth curves
+		reflect := func(curves ...byte) (float64, float64) {
+			for _, c := range curves {
+				if last == c {
+					return 2*p.x - ctrlX, 2*p.y - ctrlY
+				}
+			}
+			return p.x, p.y
+		}
+
+		upper := cmd &^ 0x20
+		switch upper {
+		case 'Z':
+			p.close()
+			cmd = 0
+		case 'M':
+			if !read(0, 2) {
+				return
+			}
+			p.moveTo(ox+args[0], oy+args[1])
+			// further coordinate pairs are implicit lines
+			cmd = 'L' | cmd&0x20
+		case 'L':
+			if !read(0, 2) {
+				return
+			}
+			p.lineTo(ox+args[0], oy+args[1])
+		case 'H':
+			if !read(0, 1) {
+				return
+			}
+			p.lineTo(ox+args[0], p.y)
+		case 'V':
+			if !read(0, 1) {
+				return
+			}
+			p.lineTo(p.x, oy+args[0])
+		case 'C':
+			if !read(0, 6) {
+				return
+			}
+			ctrlX, ctrlY = ox+args[2], oy+args[3]
+			p.cubicTo(ox+args[0], oy+args[1], ctrlX, ctrlY, ox+args[4], oy+args[5])
+		case 'S':
+			if !read(0, 4) {
+				return
+			}
+			x1, y1 := reflect('C', 'S')
+			ctrlX, ctrlY = ox+args[0], oy+args[1]
+			p.cubicTo(x1, y1, ctrlX, ctrlY, ox+args[2], oy+args[3])
+		case 'Q':
+			if !read(0, 4) {
+				return
+			}
+			ctrlX, ctrlY = ox+args[0], oy+args[1]
+			p.quadTo(ctrlX, ctrlY, ox+args[2], oy+args[3])
+		case 'T':
+			if !read(0, 2) {
+				return
+			}
+			ctrlX, ctrlY = reflect('Q', 'T')
+			p.quadTo(ctrlX, ctrlY, ox+args[0], oy+args[1])
+		case 'A':
+			var ok bool
+			if !read(0, 3) {
+				return
+			}
+			if args[3], ok = sc.flag(); !ok {
+				return
+			}
+			if args[4], ok = sc.flag(); !ok {
+				return
+			}
+			if !read(5, 2) {
+				return
+			}
+			p.arcTo(args[0], args[1], args[2], args[3] == 1, args[4] == 1, ox+args[5], oy+args[6])
+		default:
+			return
+		}
+		last = upper
+	}
+}
+
+// svgScanner reads the numbers of path data, point lists and transformations.
+type svgScanner struct {
+	s string
+	i int
+}
+
+func (sc *svgScanner) skipSeparators() {
+	for sc.i < len(sc.s) && strings.IndexByte(" \t\r\n,", sc.s[sc.i]) >= 0 {
+		sc.i++
+	}
+}
+
+// number reads the next number, which may directly follow the previous one like in "1.5.5" or "1-2".
+func (sc *svgScanner) number() (float64, bool) {
+	sc.skipSeparators()
+	s, start := sc.s, sc.i
+	i := start
+	if i < len(s) && (s[i] == '+' || s[i] == '-') {
+		i++
+	}
+	digits, dot := false, false
+	for ; i < len(s); i++ {
+		if s[i] >= '0' && s[i] <= '9' {
+			digits = true
+		} else if s[i] == '.' && !dot {
+			dot = true
+		} else {
+			break
+		}
+	}
+	if !digits {
+		return 0, false
+	}
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		j := i + 1
+		if j < len(s) && (s[j] == '+' || s[j] == '-') {
+			j++
+		}
+		if j < len(s) && s[j] >= '0' && s[j] <= '9' {
+			for i = j; i < len(s) && s[i] >= '0' && s[i] <= '9'; i++ {
+			}
+		}
+	}
+	v, err := strconv.ParseFloat(s[start:i], 64)
+	if err != nil {
+		return 0, false
+	}
+	sc.i = i
+	return v, true
+}
+
+// flag reads an arc flag, flags consist of a single digit and don't need a separator.
+func (sc *svgScanner) flag() (float64, bool) {
+	sc.skipSeparators()
+	if sc.i < len(sc.s) && (sc.s[sc.i] == '0' || sc.s[sc.i] == '1') {
+		sc.i++
+		return float64(sc.s[sc.i-1] - '0'), true
+	}
+	return 0, false
+}
+
+// svgUnits are the sizes of the absolute units in pixels.
+var svgUnits = map[string]float64{
+	"px": 1,
+	"pt": 4.0 / 3,
+	"pc": 16,
+	"mm": 96 / 25.4,
+	"cm": 96 / 2.54,
+	"in": 96,
+	"em": 16,
+	"ex": 8,
+}
+
+// parseLength parses a length, percentages refer to ref and are only valid if ref is positive.
+func parseLength(s string, ref float64) (float64, bool) {
+	s = strings.TrimSpace(s)
+	factor := 1.0
+	if strings.HasSuffix(s, "%") {
+		if ref <= 0 {
+			return 0, false
+		}
+		s, factor = strings.TrimSuffix(s, "%"), ref/100
+	} else if len(s) > 2 {
+		if f, ok := svgUnits[s[len(s)-2:]]; ok {
+			s, factor = s[:len(s)-2], f
+		}
+	}
+	v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0, false
+	}
+	return v * factor, true
+}
+
+func parseViewBox(s string) ([4]float64, bool) {
+	var vb [4]float64
+	sc := &svgScanner{s: s}
+	for i := range vb {
+		v, ok := sc.number()
+		if !ok {
+			return vb, false
+		}
+		vb[i] = v
+	}
+	return vb, vb[2] > 0 && vb[3] > 0
+}
+
+func parseOpacity(s string, def float64) float64 {
+	v, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+	if err != nil {
+		return def
+	}
+	if strings.HasSuffix(s, "%") {
+		v /= 100
+	}
+	return math.Min(math.Max(v, 0), 1)
+}
+
+// collectGradients returns the color of the first stop of each gradient by its id.
+func collectGradients(root svgNode) map[string]color.NRGBA {
+	gradients := map[string]color.NRGBA{}
+	refs := map[string]string{}
+
+	var walk func(n svgNode)
+	walk = func(n svgNode) {
+		if n.XMLName.Local == "linearGradient" || n.XMLName.Local == "radialGradient" {
+			attrs := n.attributes()
+			for _, c := range n.Children {
+				if c.XMLName.Local != "stop" {
+					continue
+				}
+				stop := c.attributes()
+				col, ok := parseColor(stop["stop-color"], color.NRGBA{A: 0xff}, nil)
+				if !ok {
+					col = color.NRGBA{A: 0xff}
+				}
+				if v, ok := stop["stop-opacity"]; ok {
+					col.A = uint8(math.Round(float64(col.A) * parseOpacity(v, 1)))
+				}
+				gradients[attrs["id"]] = col
+				break
+			}
+			if _, ok := gradients[attrs["id"]]; !ok && strings.HasPrefix(attrs["href"], "#") {
+				refs[attrs["id"]] = attrs["href"][1:]
+			}
+		}
+		for _, c := range n.Children {
+			walk(c)
+		}
+	}
+	walk(root)
+
+	// gradients without stops use the stops of the gradient they reference
+	for id, ref := range refs {
+		for i := 0; i < len(refs); i++ {
+			if c, ok := gradients[ref]; ok {
+				gradients[id] = c
+				break
+			}
+			if ref = refs[ref]; ref == "" {
+				break
+			}
+		}
+	}
+	return gradients
+}
+
+// parseColor parses a paint. It returns false if the paint is invalid and the inherited one should be kept.
+func parseColor(s string, current color.NRGBA, gradients map[string]color.NRGBA) (color.NRGBA, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch {
+	case s == "none" || s == "transparent":
+		return color.NRGBA{}, true
+	case s == "currentcolor":
+		return current, true
+	case strings.HasPrefix(s, "url("):
+		id, fallback, _ := strings.Cut(strings.TrimPrefix(s, "url("), ")")
+		if c, ok := gradients[strings.Trim(strings.TrimPrefix(strings.Trim(id, `'" `), "#"), `'" `)]; ok {
+			return c, true
+		}
+		if c, ok := parseColor(fallback, current, nil); ok {
+			return c, true
+		}
+		return color.NRGBA{}, true
+	case strings.HasPrefix(s, "#"):
+		hex := s[1:]
+		if len(hex) == 3 || len(hex) == 4 {
+			long := make([]byte, 0, 2*len(hex))
+			for i := 0; i < len(hex); i++ {
+				long = append(long, hex[i], hex[i])
+			}
+			hex = string(long)
+		}
+		if len(hex) == 6 {
+			hex += "ff"
+		}
+		v, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil || len(hex) != 8 {
+			return color.NRGBA{}, false
+		}
+		return color.NRGBA{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}, true
+	case strings.HasPrefix(s, "rgb"):
+		_, args, ok := strings.Cut(strings.TrimSuffix(s, ")"), "(")
+		if !ok {
+			return color.NRGBA{}, false
+		}
+		var v [4]float64
+		fields := strings.FieldsFunc(args, func(r rune) bool { return r == ',' || r == ' ' || r == '/' })
+		if len(fields) < 3 || len(fields) > 4 {
+			return color.NRGBA{}, false
+		}
+		v[3] = 1
+		for i, f := range fields {
+			n, err := strconv.ParseFloat(strings.TrimSuffix(f, "%"), 64)
+			if err != nil {
+				return color.NRGBA{}, false
+			}
+			switch {
+			case strings.HasSuffix(f, "%") && i < 3:
+				n = n * 255 / 100
+			case strings.HasSuffix(f, "%"):
+				n /= 100
+			}
+			v[i] = n
+		}
+		clamp := func(n, max float64) float64 { return math.Min(math.Max(n, 0), max) }
+		return color.NRGBA{
+			R: uint8(math.Round(clamp(v[0], 255))),
+			G: uint8(math.Round(clamp(v[1], 255))),
+			B: uint8(math.Round(clamp(v[2], 255))),
+			A: uint8(math.Round(clamp(v[3], 1) * 255)),
+		}, true
+	}
+	if c, ok := svgColors[s]; ok {
+		return c, true
+	}
+	return color.NRGBA{}, false
+}
+
+// svgColors are the most common of the named colors.
+var svgColors = map[string]color.NRGBA{
+	"black":     {0x00, 0x00, 0x00, 0xff},
+	"silver":    {0xc0, 0xc0, 0xc0, 0xff},
+	"gray":      {0x80, 0x80, 0x80, 0xff},
+	"grey":      {0x80, 0x80, 0x80, 0xff},
+	"white":     {0xff, 0xff, 0xff, 0xff},
+	"maroon":    {0x80, 0x00, 0x00, 0xff},
+	"red":       {0xff, 0x00, 0x00, 0xff},
+	"purple":    {0x80, 0x00, 0x80, 0xff},
+	"fuchsia":   {0xff, 0x00, 0xff, 0xff},
+	"magenta":   {0xff, 0x00, 0xff, 0xff},
+	"green":     {0x00, 0x80, 0x00, 0xff},
+	"lime":      {0x00, 0xff, 0x00, 0xff},
+	"olive":     {0x80, 0x80, 0x00, 0xff},
+	"yellow":    {0xff, 0xff, 0x00, 0xff},
+	"navy":      {0x00, 0x00, 0x80, 0xff},
+	"blue":      {0x00, 0x00, 0xff, 0xff},
+	"teal":      {0x00, 0x80, 0x80, 0xff},
+	"aqua":      {0x00, 0xff, 0xff, 0xff},
+	"cyan":      {0x00, 0xff, 0xff, 0xff},
+	"orange":    {0xff, 0xa5, 0x00, 0xff},
+	"brown":     {0xa5, 0x2a, 0x2a, 0xff},
+	"pink":      {0xff, 0xc0, 0xcb, 0xff},
+	"gold":      {0xff, 0xd7, 0x00, 0xff},
+	"darkgray":  {0xa9, 0xa9, 0xa9, 0xff},
+	"darkgrey":  {0xa9, 0xa9, 0xa9, 0xff},
+	"lightgray": {0xd3, 0xd3, 0xd3, 0xff},
+	"lightgrey": {0xd3, 0xd3, 0xd3, 0xff},
+	"darkblue":  {0x00, 0x00, 0x8b, 0xff},
+	"lightblue": {0xad, 0xd8, 0xe6, 0xff},
+	"darkgreen": {0x00, 0x64, 0x00, 0xff},
+	"darkred":   {0x8b, 0x00, 0x00, 0xff},
+	"indigo":    {0x4b, 0x00, 0x82, 0xff},
+	"violet":    {0xee, 0x82, 0xee, 0xff},
+	"steelblue": {0x46, 0x82, 0xb4, 0xff},
+	"tomato":    {0xff, 0x63, 0x47, 0xff},
+}
@@ -0,0 +1,106 @@
+package preprocessor
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func convertSvg(t *testing.T, svg string) *image.RGBA {
+	img, err := SvgToImageConverter{}.Convert(strings.NewReader(svg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return img.(*image.RGBA)
+}
+
+func TestSvgSize(t *testing.T) {
+	tables := []struct {
+		svg    string
+		width  int
+		height int
+	}{
+		{`<svg xmlns="http://www.w3.org/2000/svg" width="20" height="10"/>`, 1920, 960},
+		{`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 10 20"/>`, 960, 1920},
+		{`<svg xmlns="http://www.w3.org/2000/svg" width="100%" height="100%" viewBox="0 0 16 16"/>`, 1920, 1920},
+		{`<svg xmlns="http://www.w3.org/2000/svg"/>`, 1920, 960},
+	}
+	for _, table := range tables {
+		img := convertSvg(t, table.svg)
+		assert.Equal(t, image.Rect(0, 0, table.width, table.height), img.Bounds(), table.svg)
+	}
+}
+
+func TestSvgShapes(t *testing.T) {
+	img := convertSvg(t, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 100 100">
+	<defs><linearGradient id="g"><stop offset="0" stop-color="#00f"/><stop offset="1" stop-color="#fff"/></linearGradient></defs>
+	<rect x="0" y="0" width="50" height="50" fill="red"/>
+	<g transform="translate(50 0)"><circle cx="25" cy="25" r="20" style="fill: rgb(0, 128, 0)"/></g>
+	<path d="M0 50h50v50H0z" fill="url(#g)"/>
+	<path d="M60,60 l30,0 l0,30 z" fill="none" stroke="#000" stroke-width="4"/>
+	<text x="10" y="10">skipped</text>
+</svg>`)
+
+	at := func(x, y float64) color.RGBA {
+		return img.RGBAAt(int(x*19.2), int(y*19.2))
+	}
+	assert.Equal(t, color.RGBA{R: 0xff, A: 0xff}, at(25, 25), "rect")
+	assert.Equal(t, color.RGBA{G: 0x80, A: 0xff}, at(75, 25), "circle")
+	assert.Equal(t, color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}, at(52, 2), "outside of the circle")
+	assert.Equal(t, color.RGBA{B: 0xff, A: 0xff}, at(25, 75), "gradient")
+	assert.Equal(t, color.RGBA{A: 0xff}, at(75, 60), "stroke")
+	assert.Equal(t, color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}, at(85, 70), "unfilled path")
+}
+
+func TestSvgFillRule(t *testing.T) {
+	black, white := color.RGBA{A: 0xff}, color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	tables := []struct {
+		name   string
+		shape  string
+		center color.RGBA
+	}{
+		{"hole drawn in the opposite direction", `<path d="M0,0 H100 V100 H0 Z M25,25 V75 H75 V25 Z"/>`, white},
+		{"evenodd hole drawn in the opposite direction", `<path d="M0,0 H100 V100 H0 Z M25,25 V75 H75 V25 Z" fill-rule="evenodd"/>`, white},
+		{"nonzero subpaths drawn in the same direction", `<path d="M0,0 H100 V100 H0 Z M25,25 H75 V75 H25 Z"/>`, black},
+		{"evenodd hole drawn in the same direction", `<path d="M0,0 H100 V100 H0 Z M25,25 H75 V75 H25 Z" fill-rule="evenodd"/>`, white},
+		{"inherited evenodd", `<g fill-rule="evenodd"><path d="M0,0 H100 V100 H0 Z M25,25 H75 V75 H25 Z"/></g>`, white},
+		{"evenodd island in a hole", `<path d="M0,0 H100 V100 H0 Z M25,25 H75 V75 H25 Z M40,40 H60 V60 H40 Z" fill-rule="evenodd"/>`, black},
+	}
+	for _, table := range tables {
+		img := convertSvg(t, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 100 100">`+table.shape+`</svg>`)
+		assert.Equal(t, black, img.RGBAAt(10*19, 10*19), table.name+": ring")
+		assert.Equal(t, table.center, img.RGBAAt(50*19, 50*19), table.name+": center")
+	}
+
+	// the segments and joins of strokes overlap in any direction, they must not cut holes into each other
+	img := convertSvg(t, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 100 100">
+	<path d="M10,10 L90,10 L10,90 Z" fill="none" stroke="#000" stroke-width="10"/>
+</svg>`)
+	for _, pt := range []image.Point{{10, 10}, {90, 10}, {10, 90}} {
+		assert.Equal(t, black, img.RGBAAt(pt.X*19, pt.Y*19), "stroke join at %v", pt)
+	}
+}
+
+func TestSvgPathData(t *testing.T) {
+	p := &svgPath{m: identity}
+	p.parse("M1.5.5-1-2L3,4 h1 v-1 Z m1 1 2 2 a1 1 0 014 4")
+	p.finish()
+	assert.Equal(t, 2, len(p.subpaths))
+	assert.Equal(t, []vec2{{1.5, .5}, {-1, -2}, {3, 4}, {4, 4}, {4, 3}}, p.subpaths[0].points)
+	assert.True(t, p.subpaths[0].closed)
+	assert.Equal(t, vec2{2.5, 1.5}, p.subpaths[1].points[0])
+	assert.Equal(t, vec2{4.5, 3.5}, p.subpaths[1].points[1])
+	last := p.subpaths[1].points[len(p.subpaths[1].points)-1]
+	assert.InDelta(t, 8.5, last.x, 1e-9)
+	assert.InDelta(t, 7.5, last.y, 1e-9)
+}
+
+func TestSvgInvalid(t *testing.T) {
+	for _, svg := range []string{"", "<html></html>", `<svg width="0" height="10"/>`} {
+		_, err := SvgToImageConverter{}.Convert(strings.NewReader(svg))
+		assert.Error(t, err, svg)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	"github.com/cs3org/reva/v2/pkg/events"
 
 	"github.com/owncloud/ocis/v2/ocis-pkg/log"
 	"github.com/owncloud/ocis/v2/services/thumbnails/pkg/config"
@@ -23,6 +24,7 @@ type Options struct {
 	ImageSource      imgsource.Source
 	CS3Source        imgsource.Source
 	CS3Client        gateway.GatewayAPIClient
+	EventsConsumer   events.Consumer
 }
 
 // newOptions initializes the available default options.
@@ -82,3 +84,11 @@ func CS3Client(c gateway.GatewayAPIClient) Option {
 		o.CS3Client = c
 	}
 }
+
+// EventsConsumer provides a function to set the consumer of the upload events, which are used to
+// pregenerate thumbnails.
+func EventsConsumer(val events.Consumer) Option {
+	return func(o *Options) {
+		o.EventsConsumer = val
+	}
+}
@@ -0,0 +1,213 @@
+package svc
+
+import (
+	"context"
+	"image/gif"
+	"strings"
+	"sync"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	user "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	revactx "github.com/cs3org/reva/v2/pkg/ctx"
+	"github.com/cs3org/reva/v2/pkg/errtypes"
+	"github.com/cs3org/reva/v2/pkg/events"
+	"github.com/cs3org/reva/v2/pkg/storagespace"
+	"github.com/owncloud/ocis/v2/services/thumbnails/pkg/config"
+	"github.com/owncloud/ocis/v2/services/thumbnails/pkg/preprocessor"
+	"github.com/owncloud/ocis/v2/services/thumbnails/pkg/thumbnail"
+	"github.com/owncloud/ocis/v2/services/thumbnails/pkg/thumbnail/imgsource"
+	"github.com/pkg/errors"
+)
+
+// pregenerateOnUpload consumes the upload events and generates the thumbnails of the uploaded files in all
+// configured resolutions, so that they don't have to be generated when they are requested for the first time.
+// The files are processed by a fixed number of workers, the others wait in the event queue.
+func (g Thumbnail) pregenerateOnUpload(bus events.Consumer, cfg *config.Config) error {
+	evts := []events.Unmarshaller{events.FileUploaded{}}
+	if cfg.Events.AsyncUploads {
+		evts = []events.Unmarshaller{events.UploadReady{}}
+	}
+
+	ch, err := events.Consume(bus, "thumbnails", evts...)
+	if err != nil {
+		return err
+	}
+
+	types := make([]string, 0, len(cfg.Thumbnail.Pregeneration.Types))
+	for _, t := range cfg.Thumbnail.Pregeneration.Types {
+		types = append(types, strings.ToLower(strings.TrimSpace(t)))
+	}
+
+	startWorkers(ch, cfg.Thumbnail.Pregeneration.Workers, func(e interface{}) {
+		ref, users, ok := uploadedFile(e)
+		if !ok {
+			return
+		}
+		if err := g.pregenerate(ref, users, types, cfg.MachineAuthAPIKey); err != nil {
+			g.logger.Debug().Err(err).Interface("ref", ref).Msg("could not pregenerate the thumbnails")
+		}
+	})
+	return nil
+}
+
+// startWorkers starts the given number of workers, at least one, which handle the events until the channel is
+// closed. The returned channel is closed when all workers stopped.
+func startWorkers(ch <-chan interface{}, workers int, handle func(e interface{})) <-chan struct{} {
+	if workers < 1 {
+		workers = 1
+	}
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for e := range ch {
+				handle(e)
+			}
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	return done
+}
+
+// uploadedFile returns the file of an upload event and the users who might be able to access it. The user who
+// uploaded the file comes first, the owner of a project space is not a user who can be impersonated.
+func uploadedFile(e interface{}) (*provider.Reference, []*user.UserId, bool) {
+	switch ev := e.(type) {
+	case events.UploadReady:
+		if ev.Failed {
+			return nil, nil, false
+		}
+		return ev.FileRef, []*user.UserId{ev.ExecutingUser.GetId(), ev.SpaceOwner}, true
+	case events.FileUploaded:
+		return ev.Ref, []*user.UserId{ev.Executant, ev.SpaceOwner}, true
+	default:
+		return nil, nil, false
+	}
+}
+
+// pregenerate generates the missing thumbnails of a file for all configured resolutions. They are stored with
+// the same keys as the thumbnails which are generated on request.
+func (g Thumbnail) pregenerate(ref *provider.Reference, users []*user.UserId, types []string, machineAuthAPIKey string) error {
+	path, err := storagespace.FormatReference(ref)
+	if err != nil {
+		return err
+	}
+	auth, sRes, err := g.accessFile(path, users, machineAuthAPIKey)
+	if err != nil {
+		return err
+	}
+	checksum := sRes.GetInfo().GetChecksum().GetSum()
+	mimeType := sRes.GetInfo().GetMimeType()
+
+	// gifs are kept as gif, like for the requests via webdav
+	if strings.HasPrefix(mimeType, "image/gif") {
+		types = []string{"gif"}
+	}
+	var missing []thumbnail.Request
+	for _, t := range types {
+		generator, err := thumbnail.GeneratorForType(t)
+		if err != nil {
+			return err
+		}
+		encoder, err := thumbnail.EncoderForType(t)
+		if err != nil {
+			return err
+		}
+		for _, r := range g.resolutions {
+			tr := thumbnail.Request{
+				Resolution: r,
+				Generator:  generator,
+				Encoder:    encoder,
+				Checksum:   checksum,
+			}
+			if _, exists := g.manager.CheckThumbnail(tr); !exists {
+				missing = append(missing, tr)
+			}
+		}
+	}
+	if len(missing) == 0 || g.hasNoThumbnail(checksum) {
+		return nil
+	}
+
+	ctx := imgsource.ContextSetAuthorization(context.Background(), auth)
+	r, err := g.cs3Source.Get(ctx, path)
+	if err != nil {
+		return err
+	}
+	defer r.Close() // nolint:errcheck
+	ppOpts := map[string]interface{}{
+		"fontFileMap":    g.preprocessorOpts.TxtFontFileMap,
+		"pdfCommand":     g.preprocessorOpts.PDFCommand,
+		"videoCommand":   g.preprocessorOpts.VideoCommand,
+		"commandLimiter": g.preprocessorOpts.CommandLimiter,
+	}
+	img, err := preprocessor.ForType(mimeType, ppOpts).Convert(r)
+	if errors.Is(err, preprocessor.ErrNoThumbnail) {
+		g.rememberNoThumbnail(checksum)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, tr := range missing {
+		src := img
+		if m, ok := img.(*gif.GIF); ok {
+			// the gif generator scales the frames in place
+			c := *m
+			c.Image = append(c.Image[:0:0], m.Image...)
+			src = &c
+		}
+		if _, err := g.manager.Generate(tr, src); err != nil {
+			return err
+		}
+	}
+	g.logger.Debug().Str("path", path).Int("count", len(missing)).Msg("pregenerated thumbnails")
+	return nil
+}
+
+// accessFile authenticates as the first of the users who can stat the file and returns the token and the stat
+// response. The next user is tried if the machine auth or the stat fails for a user.
+func (g Thumbnail) accessFile(path string, users []*user.UserId, machineAuthAPIKey string) (string, *provider.StatResponse, error) {
+	err := errors.New("the event contains no user to access the file")
+	for _, u := range users {
+		if u == nil {
+			continue
+		}
+		var auth string
+		if auth, err = g.authenticate(u, machineAuthAPIKey); err != nil {
+			continue
+		}
+		var sRes *provider.StatResponse
+		if sRes, err = g.stat(path, auth); err != nil {
+			continue
+		}
+		return auth, sRes, nil
+	}
+	return "", nil, err
+}
+
+// authenticate returns a token of the user, obtained with the machine auth API key.
+func (g Thumbnail) authenticate(u *user.UserId, machineAuthAPIKey string) (string, error) {
+	ctx := revactx.ContextSetUser(context.Background(), &user.User{Id: u})
+	rsp, err := g.cs3Client.Authenticate(ctx, &gateway.AuthenticateRequest{
+		Type:         "machine",
+		ClientId:     "userid:" + u.GetOpaqueId(),
+		ClientSecret: machineAuthAPIKey,
+	})
+	if err != nil {
+		return "", err
+	}
+	if rsp.GetStatus().GetCode() != rpc.Code_CODE_OK {
+		return "", errtypes.NewErrtypeFromStatus(rsp.GetStatus())
+	}
+	return rsp.GetToken(), nil
+}
@@ -0,0 +1,228 @@
+package svc
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	user "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/v2/pkg/events"
+	cs3mocks "github.com/cs3org/reva/v2/tests/cs3mocks/mocks"
+	"github.com/owncloud/ocis/v2/ocis-pkg/log"
+	osync "github.com/owncloud/ocis/v2/ocis-pkg/sync"
+	"github.com/owncloud/ocis/v2/services/thumbnails/pkg/thumbnail"
+	"github.com/owncloud/ocis/v2/services/thumbnails/pkg/thumbnail/imgsource"
+	"github.com/owncloud/ocis/v2/services/thumbnails/pkg/thumbnail/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc"
+)
+
+// pngSource returns a png image, or the document if set, to the requests with the expected token
+type pngSource struct {
+	token    string
+	document []byte
+	gets     int
+}
+
+func (s *pngSource) Get(ctx context.Context, _ string) (io.ReadCloser, error) {
+	if auth, _ := imgsource.ContextGetAuthorization(ctx); auth != s.token {
+		return nil, errors.New("unauthorized")
+	}
+	s.gets++
+	if s.document != nil {
+		return io.NopCloser(bytes.NewReader(s.document)), nil
+	}
+	var b bytes.Buffer
+	if err := png.Encode(&b, image.NewRGBA(image.Rect(0, 0, 64, 64))); err != nil {
+		return nil, err
+	}
+	return io.NopCloser(&b), nil
+}
+
+// newPregenerationService returns a service whose gateway only lets the given user sign in with the machine auth
+func newPregenerationService(t *testing.T, uploader, mimeType string) (Thumbnail, *cs3mocks.GatewayAPIClient, *pngSource) {
+	resolutions, err := thumbnail.ParseResolutions([]string{"16x16", "32x32"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gwc := &cs3mocks.GatewayAPIClient{}
+	gwc.On("Authenticate", mock.Anything, mock.Anything).Return(func(_ context.Context, req *gateway.AuthenticateRequest, _ ...grpc.CallOption) *gateway.AuthenticateResponse {
+		if req.GetClientId() != "userid:"+uploader {
+			return &gateway.AuthenticateResponse{Status: &rpc.Status{Code: rpc.Code_CODE_PERMISSION_DENIED}}
+		}
+		return &gateway.AuthenticateResponse{Status: &rpc.Status{Code: rpc.Code_CODE_OK}, Token: "token-" + uploader}
+	}, nil)
+	gwc.On("Stat", mock.Anything, mock.Anything).Return(&provider.StatResponse{
+		Status: &rpc.Status{Code: rpc.Code_CODE_OK},
+		Info: &provider.ResourceInfo{
+			Type:     provider.ResourceType_RESOURCE_TYPE_FILE,
+			MimeType: mimeType,
+			Checksum: &provider.ResourceChecksum{Sum: "checksum"},
+		},
+	}, nil)
+
+	src := &pngSource{token: "token-" + uploader}
+	noThumbnail := osync.NewCache(10)
+	return Thumbnail{
+		manager:     thumbnail.NewSimpleManager(resolutions, storage.NewInMemoryStorage(storage.Limits{}), log.NewLogger()),
+		noThumbnail: &noThumbnail,
+		cs3Source:   src,
+		cs3Client:   gwc,
+		logger:      log.NewLogger(),
+		resolutions: resolutions,
+	}, gwc, src
+}
+
+func TestPregenerate(t *testing.T) {
+	g, _, src := newPregenerationService(t, "uploader", "image/png")
+	ref := &provider.Reference{ResourceId: &provider.ResourceId{StorageId: "storage", SpaceId: "space", OpaqueId: "file"}}
+	users := []*user.UserId{{OpaqueId: "uploader"}}
+
+	if err := g.pregenerate(ref, users, []string{"png", "jpg"}, "secret"); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []string{"png", "jpg"} {
+		generator, _ := thumbnail.GeneratorForType(tt)
+		encoder, _ := thumbnail.EncoderForType(tt)
+		for _, r := range g.resolutions {
+			_, exists := g.manager.CheckThumbnail(thumbnail.Request{Resolution: r, Generator: generator, Encoder: encoder, Checksum: "checksum"})
+			assert.True(t, exists, "missing %s thumbnail in %v", tt, r)
+		}
+	}
+	assert.Equal(t, 1, src.gets)
+
+	// existing thumbnails are not generated again
+	if err := g.pregenerate(ref, users, []string{"png"}, "secret"); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, src.gets)
+}
+
+func TestPregenerateTriesTheNextUser(t *testing.T) {
+	g, gwc, src := newPregenerationService(t, "uploader", "image/png")
+	ref := &provider.Reference{ResourceId: &provider.ResourceId{StorageId: "storage", SpaceId: "space", OpaqueId: "file"}}
+
+	// the owner of a project space can't sign in
+	err := g.pregenerate(ref, []*user.UserId{nil, {OpaqueId: "project-owner"}, {OpaqueId: "uploader"}}, []string{"png"}, "secret")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, src.gets)
+	gwc.AssertNumberOfCalls(t, "Authenticate", 2)
+
+	err = g.pregenerate(ref, []*user.UserId{{OpaqueId: "project-owner"}}, []string{"jpg"}, "secret")
+	assert.Error(t, err)
+	assert.Error(t, g.pregenerate(ref, []*user.UserId{nil}, []string{"jpg"}, "secret"))
+}
+
+func TestPregenerateRemembersDocumentsWithoutThumbnail(t *testing.T) {
+	g, _, src := newPregenerationService(t, "uploader", "application/vnd.oasis.opendocument.text")
+	ref := &provider.Reference{ResourceId: &provider.ResourceId{StorageId: "storage", SpaceId: "space", OpaqueId: "file"}}
+	users := []*user.UserId{{OpaqueId: "uploader"}}
+
+	var b bytes.Buffer
+	zw := zip.NewWriter(&b)
+	if _, err := zw.Create("content.xml"); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	src.document = b.Bytes()
+
+	assert.NoError(t, g.pregenerate(ref, users, []string{"png"}, "secret"))
+	assert.True(t, g.hasNoThumbnail("checksum"))
+
+	// the document is not downloaded again
+	assert.NoError(t, g.pregenerate(ref, users, []string{"png"}, "secret"))
+	assert.Equal(t, 1, src.gets)
+}
+
+func TestUploadedFilePrefersTheUploader(t *testing.T) {
+	ref := &provider.Reference{Path: "./file"}
+	uploader := &user.UserId{OpaqueId: "uploader"}
+	owner := &user.UserId{OpaqueId: "owner"}
+
+	r, users, ok := uploadedFile(events.UploadReady{FileRef: ref, ExecutingUser: &user.User{Id: uploader}, SpaceOwner: owner})
+	assert.True(t, ok)
+	assert.Equal(t, ref, r)
+	assert.Equal(t, []*user.UserId{uploader, owner}, users)
+
+	_, users, ok = uploadedFile(events.FileUploaded{Ref: ref, Executant: uploader, SpaceOwner: owner})
+	assert.True(t, ok)
+	assert.Equal(t, []*user.UserId{uploader, owner}, users)
+
+	_, _, ok = uploadedFile(events.UploadReady{FileRef: ref, Failed: true})
+	assert.False(t, ok)
+	_, _, ok = uploadedFile(events.ShareCreated{})
+	assert.False(t, ok)
+}
+
+func TestStartWorkers(t *testing.T) {
+	ch := make(chan interface{})
+	release := make(chan struct{})
+	var (
+		mu               sync.Mutex
+		running, maximum int
+		handled          []interface{}
+	)
+	done := startWorkers(ch, 3, func(e interface{}) {
+		mu.Lock()
+		running++
+		if running > maximum {
+			maximum = running
+		}
+		mu.Unlock()
+
+		<-release
+
+		mu.Lock()
+		running--
+		handled = append(handled, e)
+		mu.Unlock()
+	})
+
+	go func() {
+		for i := 0; i < 10; i++ {
+			ch <- i
+		}
+		close(ch)
+	}()
+
+	// three workers are busy, the other events wait
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return running == 3
+	}, time.Second, time.Millisecond)
+	close(release)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("the workers did not stop after the channel was closed")
+	}
+	assert.Equal(t, 3, maximum)
+	assert.ElementsMatch(t, []interface{}{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, handled)
+}
+
+func TestStartWorkersStartsAtLeastOneWorker(t *testing.T) {
+	ch := make(chan interface{}, 1)
+	handled := make(chan interface{}, 1)
+	done := startWorkers(ch, 0, func(e interface{}) { handled <- e })
+
+	ch <- "event"
+	close(ch)
+	<-done
+	assert.Equal(t, "event", <-handled)
+}
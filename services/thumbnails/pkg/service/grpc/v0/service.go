@@ -3,6 +3,7 @@ package svc
 import (
 	"context"
 	"image"
+	"mime"
 	"net/http"
 	"net/url"
 	"path"
@@ -17,6 +18,7 @@ import (
 	"github.com/cs3org/reva/v2/pkg/utils"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/owncloud/ocis/v2/ocis-pkg/log"
+	osync "github.com/owncloud/ocis/v2/ocis-pkg/sync"
 	thumbnailsmsg "github.com/owncloud/ocis/v2/protogen/gen/ocis/messages/thumbnails/v0"
 	thumbnailssvc "github.com/owncloud/ocis/v2/protogen/gen/ocis/services/thumbnails/v0"
 	"github.com/owncloud/ocis/v2/services/thumbnails/pkg/preprocessor"
@@ -29,6 +31,13 @@ import (
 	"google.golang.org/grpc/metadata"
 )
 
+const (
+	// noThumbnailCacheSize is the number of files without a thumbnail which are remembered
+	noThumbnailCacheSize = 10000
+	// noThumbnailTTL is the time files without a thumbnail are remembered
+	noThumbnailTTL = 24 * time.Hour
+)
+
 // NewService returns a service implementation for Service.
 func NewService(opts ...Option) decorators.DecoratedService {
 	options := newOptions(opts...)
@@ -37,6 +46,7 @@ func NewService(opts ...Option) decorators.DecoratedService {
 	if err != nil {
 		logger.Fatal().Err(err).Msg("resolutions not configured correctly")
 	}
+	noThumbnail := osync.NewCache(noThumbnailCacheSize)
 	svc := Thumbnail{
 		serviceID: options.Config.GRPC.Namespace + "." + options.Config.Service.Name,
 		manager: thumbnail.NewSimpleManager(
@@ -50,9 +60,20 @@ func NewService(opts ...Option) decorators.DecoratedService {
 		cs3Client:    options.CS3Client,
 		preprocessorOpts: PreprocessorOpts{
 			TxtFontFileMap: options.Config.Thumbnail.FontMapFile,
+			PDFCommand:     options.Config.Thumbnail.PDFCommand,
+			VideoCommand:   options.Config.Thumbnail.VideoCommand,
+			CommandLimiter: preprocessor.NewCommandLimiter(options.Config.Thumbnail.MaxCommands),
 		},
 		dataEndpoint:   options.Config.Thumbnail.DataEndpoint,
 		transferSecret: options.Config.Thumbnail.TransferSecret,
+		resolutions:    resolutions,
+		noThumbnail:    &noThumbnail,
+	}
+
+	if options.EventsConsumer != nil {
+		if err := svc.pregenerateOnUpload(options.EventsConsumer, options.Config); err != nil {
+			logger.Fatal().Err(err).Msg("could not consume the upload events")
+		}
 	}
 
 	return svc
@@ -69,10 +90,17 @@ type Thumbnail struct {
 	logger           log.Logger
 	cs3Client        gateway.GatewayAPIClient
 	preprocessorOpts PreprocessorOpts
+	resolutions      thumbnail.Resolutions
+	// noThumbnail remembers the checksums of the files which have no thumbnail, like office documents
+	// without a preview image, so that they are not downloaded for every request
+	noThumbnail *osync.Cache
 }
 
 type PreprocessorOpts struct {
 	TxtFontFileMap string
+	PDFCommand     string
+	VideoCommand   string
+	CommandLimiter preprocessor.CommandLimiter
 }
 
 // GetThumbnail retrieves a thumbnail for an image
@@ -147,6 +175,9 @@ func (g Thumbnail) handleCS3Source(ctx context.Context,
 	if key, exists := g.manager.CheckThumbnail(tr); exists {
 		return key, nil
 	}
+	if g.hasNoThumbnail(tr.Checksum) {
+		return "", merrors.NotFound(g.serviceID, "the file has no thumbnail")
+	}
 
 	ctx = imgsource.ContextSetAuthorization(ctx, src.Authorization)
 	r, err := g.cs3Source.Get(ctx, src.Path)
@@ -155,11 +186,19 @@ func (g Thumbnail) handleCS3Source(ctx context.Context,
 	}
 	defer r.Close() // nolint:errcheck
 	ppOpts := map[string]interface{}{
-		"fontFileMap": g.preprocessorOpts.TxtFontFileMap,
+		"fontFileMap":    g.preprocessorOpts.TxtFontFileMap,
+		"pdfCommand":     g.preprocessorOpts.PDFCommand,
+		"videoCommand":   g.preprocessorOpts.VideoCommand,
+		"commandLimiter": g.preprocessorOpts.CommandLimiter,
 	}
 	pp := preprocessor.ForType(sRes.GetInfo().GetMimeType(), ppOpts)
 	img, err := pp.Convert(r)
+	if errors.Is(err, preprocessor.ErrNoThumbnail) {
+		g.rememberNoThumbnail(tr.Checksum)
+		return "", merrors.NotFound(g.serviceID, "the file has no thumbnail")
+	}
 	if img == nil || err != nil {
+		g.logger.Debug().Err(err).Str("mimetype", sRes.GetInfo().GetMimeType()).Msg("could not convert the file")
 		return "", merrors.InternalServerError(g.serviceID, "could not get image")
 	}
 
@@ -227,6 +266,9 @@ func (g Thumbnail) handleWebdavSource(ctx context.Context,
 	if key, exists := g.manager.CheckThumbnail(tr); exists {
 		return key, nil
 	}
+	if g.hasNoThumbnail(tr.Checksum) {
+		return "", merrors.NotFound(g.serviceID, "the file has no thumbnail")
+	}
 
 	if src.WebdavAuthorization != "" {
 		ctx = imgsource.ContextSetAuthorization(ctx, src.WebdavAuthorization)
@@ -238,11 +280,19 @@ func (g Thumbnail) handleWebdavSource(ctx context.Context,
 	}
 	defer r.Close() // nolint:errcheck
 	ppOpts := map[string]interface{}{
-		"fontFileMap": g.preprocessorOpts.TxtFontFileMap,
+		"fontFileMap":    g.preprocessorOpts.TxtFontFileMap,
+		"pdfCommand":     g.preprocessorOpts.PDFCommand,
+		"videoCommand":   g.preprocessorOpts.VideoCommand,
+		"commandLimiter": g.preprocessorOpts.CommandLimiter,
 	}
 	pp := preprocessor.ForType(sRes.GetInfo().GetMimeType(), ppOpts)
 	img, err := pp.Convert(r)
+	if errors.Is(err, preprocessor.ErrNoThumbnail) {
+		g.rememberNoThumbnail(tr.Checksum)
+		return "", merrors.NotFound(g.serviceID, "the file has no thumbnail")
+	}
 	if img == nil || err != nil {
+		g.logger.Debug().Err(err).Str("mimetype", sRes.GetInfo().GetMimeType()).Msg("could not convert the file")
 		return "", merrors.InternalServerError(g.serviceID, "could not get image")
 	}
 
@@ -296,8 +346,35 @@ func (g Thumbnail) stat(path, auth string) (*provider.StatResponse, error) {
 		g.logger.Error().Msg("resource info is missing checksum")
 		return nil, merrors.NotFound(g.serviceID, "resource info is missing a checksum")
 	}
-	if !thumbnail.IsMimeTypeSupported(rsp.Info.MimeType) {
+	if !g.isMimeTypeSupported(rsp.Info.MimeType) {
 		return nil, merrors.NotFound(g.serviceID, "Unsupported file type")
 	}
 	return rsp, nil
 }
+
+// hasNoThumbnail tells if the file with the checksum is known to have no thumbnail
+func (g Thumbnail) hasNoThumbnail(checksum string) bool {
+	return g.noThumbnail != nil && g.noThumbnail.Load(checksum) != nil
+}
+
+// rememberNoThumbnail remembers that the file with the checksum has no thumbnail. The checksum changes with
+// the content of the file, but the entries expire anyway to keep the cache from growing.
+func (g Thumbnail) rememberNoThumbnail(checksum string) {
+	if g.noThumbnail != nil && checksum != "" {
+		g.noThumbnail.Store(checksum, struct{}{}, time.Now().Add(noThumbnailTTL))
+	}
+}
+
+// isMimeTypeSupported checks if a thumbnail can be generated for the mimetype. Pdf files and videos
+// are only supported when a command is configured to render them.
+func (g Thumbnail) isMimeTypeSupported(m string) bool {
+	mimeType, _, _ := mime.ParseMediaType(m)
+	switch {
+	case mimeType == "application/pdf":
+		return g.preprocessorOpts.PDFCommand != ""
+	case strings.HasPrefix(mimeType, "video/"):
+		return g.preprocessorOpts.VideoCommand != ""
+	default:
+		return thumbnail.IsMimeTypeSupported(m)
+	}
+}
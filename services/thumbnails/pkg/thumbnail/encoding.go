@@ -8,6 +8,8 @@ import (
 	"image/png"
 	"io"
 	"strings"
+
+	"github.com/owncloud/ocis/v2/services/thumbnails/pkg/thumbnail/webp"
 )
 
 const (
@@ -15,6 +17,7 @@ const (
 	typeJpg  = "jpg"
 	typeJpeg = "jpeg"
 	typeGif  = "gif"
+	typeWebp = "webp"
 )
 
 var (
@@ -25,6 +28,8 @@ var (
 )
 
 // Encoder encodes the thumbnail to a specific format.
+// Encoders only write the pixels of the thumbnail and never metadata like EXIF or GPS tags, since
+// thumbnails are also served over public links.
 type Encoder interface {
 	// Encode encodes the image to a format.
 	Encode(io.Writer, interface{}) error
@@ -96,6 +101,28 @@ func (e GifEncoder) MimeType() string {
 	return "image/gif"
 }
 
+// WebpEncoder encodes to webp.
+type WebpEncoder struct{}
+
+// Encode encodes to webp format
+func (e WebpEncoder) Encode(w io.Writer, img interface{}) error {
+	m, ok := img.(image.Image)
+	if !ok {
+		return ErrInvalidType
+	}
+	return webp.Encode(w, m, nil)
+}
+
+// Types returns the webp suffix.
+func (e WebpEncoder) Types() []string {
+	return []string{typeWebp}
+}
+
+// MimeType returns the mimetype for webp files.
+func (e WebpEncoder) MimeType() string {
+	return "image/webp"
+}
+
 // EncoderForType returns the encoder for a given file type
 // or nil if the type is not supported.
 func EncoderForType(fileType string) (Encoder, error) {
@@ -106,6 +133,8 @@ func EncoderForType(fileType string) (Encoder, error) {
 		return JpegEncoder{}, nil
 	case typeGif:
 		return GifEncoder{}, nil
+	case typeWebp:
+		return WebpEncoder{}, nil
 	default:
 		return nil, ErrNoEncoderForType
 	}
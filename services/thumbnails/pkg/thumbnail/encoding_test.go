@@ -1,6 +1,12 @@
 package thumbnail
 
-import "testing"
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"testing"
+)
 
 func TestEncoderForType(t *testing.T) {
 	table := map[string]Encoder{
@@ -10,6 +16,8 @@ func TestEncoderForType(t *testing.T) {
 		"JPEG":    JpegEncoder{},
 		"png":     PngEncoder{},
 		"PNG":     PngEncoder{},
+		"webp":    WebpEncoder{},
+		"WEBP":    WebpEncoder{},
 		"invalid": nil,
 	}
 
@@ -20,3 +28,32 @@ func TestEncoderForType(t *testing.T) {
 		}
 	}
 }
+
+func TestEncodersWriteNoMetadata(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 16, 16))
+	img.Set(4, 4, color.NRGBA{R: 0xff, A: 0x80})
+	paletted := image.NewPaletted(img.Bounds(), color.Palette{color.Black, color.White})
+
+	// the markers of exif and xmp data in jpeg, and the metadata chunks of png and webp files
+	markers := []string{"Exif", "http://ns.adobe.com/xap", "eXIf", "tEXt", "iTXt", "zTXt", "EXIF", "XMP "}
+	tables := []struct {
+		encoder Encoder
+		img     interface{}
+	}{
+		{PngEncoder{}, img},
+		{JpegEncoder{}, img},
+		{WebpEncoder{}, img},
+		{GifEncoder{}, &gif.GIF{Image: []*image.Paletted{paletted}, Delay: []int{0}}},
+	}
+	for _, table := range tables {
+		buf := &bytes.Buffer{}
+		if err := table.encoder.Encode(buf, table.img); err != nil {
+			t.Fatal(err)
+		}
+		for _, marker := range markers {
+			if bytes.Contains(buf.Bytes(), []byte(marker)) {
+				t.Errorf("%s thumbnail contains %q", table.encoder.MimeType(), marker)
+			}
+		}
+	}
+}
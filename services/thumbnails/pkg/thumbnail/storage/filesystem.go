@@ -4,7 +4,10 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
+	"sync"
+	"time"
 
 	"github.com/owncloud/ocis/v2/ocis-pkg/log"
 	"github.com/owncloud/ocis/v2/services/thumbnails/pkg/config"
@@ -13,6 +16,12 @@ import (
 
 const (
 	filesDir = "files"
+
+	// evictionInterval is the time after which stored thumbnails trigger an eviction of the expired ones.
+	evictionInterval = time.Hour
+	// evictionTarget is the share of the size limit which the thumbnails are reduced to by an eviction,
+	// so that not every stored thumbnail triggers another one.
+	evictionTarget = 0.9
 )
 
 // NewFileSystemStorage creates a new instance of FileSystem
@@ -20,13 +29,38 @@ func NewFileSystemStorage(cfg config.FileSystemStorage, logger log.Logger) FileS
 	return FileSystem{
 		root:   cfg.RootDirectory,
 		logger: logger,
+		limits: Limits{
+			MaxSize: int64(cfg.MaxSize) << 20,
+			MaxAge:  cfg.MaxAge,
+		},
+		eviction: &eviction{},
 	}
 }
 
 // FileSystem represents a storage for the thumbnails using the local file system.
+// The modification time of a thumbnail is updated whenever it is loaded, so that the least
+// recently used thumbnails can be evicted when the configured limits are exceeded.
 type FileSystem struct {
-	root   string
-	logger log.Logger
+	root     string
+	logger   log.Logger
+	limits   Limits
+	eviction *eviction
+}
+
+// eviction tracks the evictions which run in the background.
+type eviction struct {
+	mu      sync.Mutex
+	running bool
+	lastRun time.Time
+	// size is the total size of the thumbnails after the last eviction plus the ones stored since
+	size int64
+}
+
+// storedFile is a thumbnail in the filesystem.
+type storedFile struct {
+	path    string
+	size    int64
+	modTime time.Time
 }
 
 func (s FileSystem) Stat(key string) bool {
@@ -46,6 +80,12 @@ func (s FileSystem) Get(key string) ([]byte, error) {
 		}
 		return nil, err
 	}
+	// the access time of files isn't reliable because of mount options like noatime,
+	// so the modification time tracks the last access instead
+	now := time.Now()
+	if err := os.Chtimes(img, now, now); err != nil {
+		s.logger.Debug().Err(err).Str("key", key).Msg("could not update the access time of the thumbnail")
+	}
 	return content, nil
 }
 
@@ -66,6 +106,7 @@ func (s FileSystem) Put(key string, img []byte) error {
 		if _, err = f.Write(img); err != nil {
 			return errors.Wrapf(err, "could not write to file \"%s\"", key)
 		}
+		s.evictIfNeeded(int64(len(img)))
 	}
 
 	return nil
@@ -86,3 +127,149 @@ func (s FileSystem) BuildKey(r Request) string {
 
 	return filepath.Join(checksum[:2], checksum[2:4], checksum[4:], filename)
 }
+
+// evictIfNeeded starts an eviction in the background when the stored thumbnails exceed the size limit,
+// or when the last eviction is longer ago than the eviction interval and expired thumbnails are likely.
+func (s FileSystem) evictIfNeeded(added int64) {
+	if !s.limits.enabled() {
+		return
+	}
+
+	e := s.eviction
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.size += added
+	if e.running || (time.Since(e.lastRun) < evictionInterval && (s.limits.MaxSize <= 0 || e.size <= s.limits.MaxSize)) {
+		return
+	}
+	e.running = true
+	before := e.size
+
+	go func() {
+		size, err := s.Evict()
+		if err != nil {
+			s.logger.Error().Err(err).Msg("could not evict thumbnails")
+		}
+
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		e.running = false
+		e.lastRun = time.Now()
+		if err == nil {
+			// keep the thumbnails which were stored during the eviction
+			e.size = size + e.size - before
+		}
+	}()
+}
+
+// Evict removes the thumbnails which exceed the limits and returns the total size of the remaining ones.
+// Thumbnails which weren't accessed within the maximum age are removed first, then the least recently
+// used ones until the total size is well below the size limit.
+func (s FileSystem) Evict() (int64, error) {
+	files, err := s.list(filepath.Join(s.root, filesDir))
+	if err != nil {
+		return 0, err
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+
+	var total int64
+	for _, f := range files {
+		total += f.size
+	}
+	shrink := s.limits.MaxSize > 0 && total > s.limits.MaxSize
+	target := int64(float64(s.limits.MaxSize) * evictionTarget)
+
+	removed := 0
+	for _, f := range files {
+		expired := s.limits.MaxAge > 0 && time.Since(f.modTime) > s.limits.MaxAge
+		if !expired && (!shrink || total <= target) {
+			// the remaining thumbnails were accessed more recently
+			break
+		}
+		if err := s.remove(f.path); err != nil {
+			s.logger.Debug().Err(err).Str("path", f.path).Msg("could not remove thumbnail")
+			continue
+		}
+		total -= f.size
+		removed++
+	}
+	if removed > 0 {
+		s.logger.Info().Int("count", removed).Int64("size", total).Msg("evicted thumbnails")
+	}
+	return total, nil
+}
+
+// Purge removes thumbnails and returns their number and total size. If olderThan is positive only the
+// thumbnails which weren't accessed within that duration are removed, if checksum isn't empty only the
+// thumbnails of the source file with that checksum are removed.
+func (s FileSystem) Purge(olderThan time.Duration, checksum string) (int, int64, error) {
+	dir := filepath.Join(s.root, filesDir)
+	if checksum != "" {
+		if len(checksum) <= 4 || filepath.Base(checksum) != checksum {
+			return 0, 0, errors.Errorf("invalid checksum %s", checksum)
+		}
+		dir = filepath.Join(dir, checksum[:2], checksum[2:4], checksum[4:])
+	}
+
+	files, err := s.list(dir)
+	if err != nil {
+		return 0, 0, err
+	}
+	var (
+		count int
+		size  int64
+	)
+	for _, f := range files {
+		if olderThan > 0 && time.Since(f.modTime) <= olderThan {
+			continue
+		}
+		if err := s.remove(f.path); err != nil {
+			return count, size, err
+		}
+		count++
+		size += f.size
+	}
+	return count, size, nil
+}
+
+// list returns the thumbnails in the directory and its subdirectories.
+func (s FileSystem) list(dir string) ([]storedFile, error) {
+	var files []storedFile
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			// the thumbnail was removed in the meantime
+			return nil
+		}
+		files = append(files, storedFile{path: path, size: info.Size(), modTime: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not list the thumbnails in %s", dir)
+	}
+	return files, nil
+}
+
+// remove deletes a thumbnail and the directories which became empty.
+func (s FileSystem) remove(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	base := filepath.Join(s.root, filesDir)
+	for dir := filepath.Dir(path); dir != base && len(dir) > len(base); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			// the directory still contains other thumbnails
+			break
+		}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/owncloud/ocis/v2/ocis-pkg/log"
+	"github.com/owncloud/ocis/v2/services/thumbnails/pkg/config"
+)
+
+func newTestFileSystem(t *testing.T, maxSize int, maxAge time.Duration) FileSystem {
+	return NewFileSystemStorage(config.FileSystemStorage{
+		RootDirectory: t.TempDir(),
+		MaxSize:       maxSize,
+		MaxAge:        maxAge,
+	}, log.NewLogger())
+}
+
+// putAged stores a thumbnail which was last accessed the given time ago
+func putAged(t *testing.T, s FileSystem, key string, size int, age time.Duration) {
+	if err := s.Put(key, make([]byte, size)); err != nil {
+		t.Fatal(err)
+	}
+	accessed := time.Now().Add(-age)
+	if err := os.Chtimes(filepath.Join(s.root, filesDir, key), accessed, accessed); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFileSystemGetUpdatesAccessTime(t *testing.T) {
+	s := newTestFileSystem(t, 0, 0)
+	putAged(t, s, "ab/cd/ef/1x1.png", 10, time.Hour)
+
+	if _, err := s.Get("ab/cd/ef/1x1.png"); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(filepath.Join(s.root, filesDir, "ab/cd/ef/1x1.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(info.ModTime()) > time.Minute {
+		t.Errorf("expected the access time to be updated, got %v", info.ModTime())
+	}
+}
+
+func TestFileSystemEvict(t *testing.T) {
+	s := newTestFileSystem(t, 0, 0)
+	putAged(t, s, "aa/aa/aa/1x1.png", 1, 0)
+	putAged(t, s, "aa/aa/aa/2x2.png", 400<<10, 72*time.Hour)
+	putAged(t, s, "bb/bb/bb/1x1.png", 450<<10, 3*time.Hour)
+	putAged(t, s, "cc/cc/cc/1x1.png", 450<<10, 2*time.Hour)
+	putAged(t, s, "dd/dd/dd/1x1.png", 100<<10, time.Hour)
+
+	s.limits = Limits{MaxSize: 1 << 20, MaxAge: 48 * time.Hour}
+	size, err := s.Evict()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != 550<<10+1 {
+		t.Errorf("expected %d remaining bytes, got %d", 550<<10+1, size)
+	}
+	for key, exists := range map[string]bool{
+		"aa/aa/aa/1x1.png": true,
+		"aa/aa/aa/2x2.png": false,
+		"bb/bb/bb/1x1.png": false,
+		"cc/cc/cc/1x1.png": true,
+		"dd/dd/dd/1x1.png": true,
+	} {
+		if s.Stat(key) != exists {
+			t.Errorf("expected %s to exist: %v", key, exists)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(s.root, filesDir, "bb")); !os.IsNotExist(err) {
+		t.Errorf("expected the empty directories to be removed, got %v", err)
+	}
+}
+
+// waitForEviction waits until the eviction in the background is finished
+func waitForEviction(s FileSystem) {
+	for i := 0; i < 100; i++ {
+		s.eviction.mu.Lock()
+		running := s.eviction.running
+		s.eviction.mu.Unlock()
+		if !running {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestFileSystemEvictsInBackground(t *testing.T) {
+	s := newTestFileSystem(t, 1, 0)
+	putAged(t, s, "aa/aa/aa/1x1.png", 600<<10, time.Hour)
+	waitForEviction(s)
+	putAged(t, s, "bb/bb/bb/1x1.png", 600<<10, 0)
+	waitForEviction(s)
+
+	if s.Stat("aa/aa/aa/1x1.png") || !s.Stat("bb/bb/bb/1x1.png") {
+		t.Error("expected the least recently used thumbnail to be evicted")
+	}
+}
+
+func TestFileSystemPurge(t *testing.T) {
+	s := newTestFileSystem(t, 0, 0)
+	putAged(t, s, "aa/bb/cc/1x1.png", 10, 3*time.Hour)
+	putAged(t, s, "aa/bb/cc/2x2.png", 20, time.Hour)
+	putAged(t, s, "dd/ee/ff/1x1.png", 30, 3*time.Hour)
+
+	count, size, err := s.Purge(2*time.Hour, "aabbcc")
+	if err != nil || count != 1 || size != 10 {
+		t.Errorf("expected to purge one thumbnail of 10 bytes, got %d, %d, %v", count, size, err)
+	}
+	if !s.Stat("aa/bb/cc/2x2.png") || !s.Stat("dd/ee/ff/1x1.png") {
+		t.Error("expected the other thumbnails to be kept")
+	}
+
+	count, size, err = s.Purge(0, "")
+	if err != nil || count != 2 || size != 50 {
+		t.Errorf("expected to purge two thumbnails of 50 bytes, got %d, %d, %v", count, size, err)
+	}
+
+	if _, _, err := s.Purge(0, "../.."); err == nil {
+		t.Error("expected an invalid checksum to fail")
+	}
+}
@@ -1,35 +1,81 @@
 package storage
 
 import (
+	"container/list"
 	"strings"
+	"sync"
+	"time"
 )
 
 // NewInMemoryStorage creates a new InMemory instance.
-func NewInMemoryStorage() InMemory {
+func NewInMemoryStorage(limits Limits) InMemory {
 	return InMemory{
-		store: make(map[string][]byte),
+		limits: limits,
+		store: &inMemoryStore{
+			entries: make(map[string]*list.Element),
+			lru:     list.New(),
+		},
 	}
 }
 
 // InMemory represents an in memory storage for thumbnails
 // Can be used during development
 type InMemory struct {
-	store map[string][]byte
+	limits Limits
+	store  *inMemoryStore
+}
+
+type inMemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	// lru contains the entries, the most recently used one at the front
+	lru  *list.List
+	size int64
+}
+
+type inMemoryEntry struct {
+	key       string
+	thumbnail []byte
+	accessed  time.Time
 }
 
 func (s InMemory) Stat(key string) bool {
-	_, exists := s.store[key]
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+	s.evict()
+	_, exists := s.store.entries[key]
 	return exists
 }
 
 // Get loads the thumbnail from memory.
 func (s InMemory) Get(key string) ([]byte, error) {
-	return s.store[key], nil
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+	s.evict()
+	el, ok := s.store.entries[key]
+	if !ok {
+		return nil, nil
+	}
+	entry := el.Value.(*inMemoryEntry)
+	entry.accessed = time.Now()
+	s.store.lru.MoveToFront(el)
+	return entry.thumbnail, nil
 }
 
 // Set stores the thumbnail in memory.
 func (s InMemory) Put(key string, thumbnail []byte) error {
-	s.store[key] = thumbnail
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+	if el, ok := s.store.entries[key]; ok {
+		s.removeElement(el)
+	}
+	s.store.entries[key] = s.store.lru.PushFront(&inMemoryEntry{
+		key:       key,
+		thumbnail: thumbnail,
+		accessed:  time.Now(),
+	})
+	s.store.size += int64(len(thumbnail))
+	s.evict()
 	return nil
 }
 
@@ -42,3 +88,24 @@ func (s InMemory) BuildKey(r Request) string {
 	}
 	return strings.Join(parts, "+")
 }
+
+// evict removes the least recently used thumbnails while they are expired or exceed the size limit.
+// The most recently used thumbnail is kept even if it exceeds the size limit on its own.
+// The caller has to hold the lock.
+func (s InMemory) evict() {
+	for el := s.store.lru.Back(); el != nil; el = s.store.lru.Back() {
+		entry := el.Value.(*inMemoryEntry)
+		expired := s.limits.MaxAge > 0 && time.Since(entry.accessed) > s.limits.MaxAge
+		tooBig := s.limits.MaxSize > 0 && s.store.size > s.limits.MaxSize && el != s.store.lru.Front()
+		if !expired && !tooBig {
+			return
+		}
+		s.removeElement(el)
+	}
+}
+
+func (s InMemory) removeElement(el *list.Element) {
+	entry := s.store.lru.Remove(el).(*inMemoryEntry)
+	delete(s.store.entries, entry.key)
+	s.store.size -= int64(len(entry.thumbnail))
+}
@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInMemoryEvictsLeastRecentlyUsed(t *testing.T) {
+	s := NewInMemoryStorage(Limits{MaxSize: 30})
+	for _, key := range []string{"a", "b", "c"} {
+		_ = s.Put(key, make([]byte, 10))
+	}
+	// a becomes the most recently used thumbnail
+	if _, err := s.Get("a"); err != nil {
+		t.Fatal(err)
+	}
+	_ = s.Put("d", make([]byte, 10))
+
+	for key, exists := range map[string]bool{"a": true, "b": false, "c": true, "d": true} {
+		if s.Stat(key) != exists {
+			t.Errorf("expected %s to exist: %v", key, exists)
+		}
+	}
+
+	_ = s.Put("e", make([]byte, 40))
+	if !s.Stat("e") || s.Stat("a") {
+		t.Error("expected only the most recently stored thumbnail to be kept")
+	}
+}
+
+func TestInMemoryEvictsExpired(t *testing.T) {
+	s := NewInMemoryStorage(Limits{MaxAge: time.Hour})
+	_ = s.Put("a", []byte("a"))
+	_ = s.Put("b", []byte("b"))
+	s.store.entries["a"].Value.(*inMemoryEntry).accessed = time.Now().Add(-2 * time.Hour)
+
+	if s.Stat("a") || !s.Stat("b") {
+		t.Error("expected only the expired thumbnail to be removed")
+	}
+	if img, _ := s.Get("a"); img != nil {
+		t.Error("expected no expired thumbnail")
+	}
+}
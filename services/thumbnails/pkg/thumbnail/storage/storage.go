@@ -2,6 +2,7 @@ package storage
 
 import (
 	"image"
+	"time"
 )
 
 // Request combines different attributes needed for storage operations.
@@ -24,3 +25,16 @@ type Storage interface {
 	Put(string, []byte) error
 	BuildKey(Request) string
 }
+
+// Limits bound the thumbnails in a store, the least recently used thumbnails are removed when
+// a limit is exceeded. A zero value disables the limit.
+type Limits struct {
+	// MaxSize is the maximum total size of the thumbnails in bytes.
+	MaxSize int64
+	// MaxAge is the maximum time since a thumbnail was accessed.
+	MaxAge time.Duration
+}
+
+func (l Limits) enabled() bool {
+	return l.MaxSize > 0 || l.MaxAge > 0
+}
@@ -12,6 +12,7 @@ import (
 
 var (
 	// SupportedMimeTypes contains a all mimetypes which are supported by the thumbnailer.
+	// Pdf files and videos are only supported when a command is configured to render them.
 	SupportedMimeTypes = map[string]struct{}{
 		"image/png":      {},
 		"image/jpg":      {},
@@ -20,7 +21,15 @@ var (
 		"image/bmp":      {},
 		"image/x-ms-bmp": {},
 		"image/tiff":     {},
+		"image/svg+xml":  {},
 		"text/plain":     {},
+		"application/vnd.openxmlformats-officedocument.wordprocessingml.document":   {},
+		"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":         {},
+		"application/vnd.openxmlformats-officedocument.presentationml.presentation": {},
+		"application/vnd.oasis.opendocument.text":                                   {},
+		"application/vnd.oasis.opendocument.spreadsheet":                            {},
+		"application/vnd.oasis.opendocument.presentation":                           {},
+		"application/vnd.oasis.opendocument.graphics":                               {},
 	}
 )
 
@@ -0,0 +1,343 @@
+package webp
+
+import (
+	"math/bits"
+	"sort"
+)
+
+// This file implements the lossless compression of the alpha channel. The alpha values are the green
+// channel of a VP8L image stream without transforms, specified in the WebP lossless bitstream format.
+// Runs of equal values are coded as backward references, which suits the alpha channel of most images.
+
+const (
+	nLiteralCodes  = 256
+	nLengthCodes   = 24
+	nDistanceCodes = 40
+
+	// maxRunLength is the longest backward reference
+	maxRunLength = 4096
+	// minRunLength is the shortest backward reference which is cheaper than the literals
+	minRunLength = 3
+
+	// the distance codes of the pixel above and the pixel to the left
+	distanceAbove = 1
+	distanceLeft  = 2
+)
+
+// codeLengthCodeOrder is the order in which the code lengths of the code length code are written.
+var codeLengthCodeOrder = [19]uint8{17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// bitWriter writes bits starting with the least significant bit of each byte.
+type bitWriter struct {
+	buf  []byte
+	bits uint64
+	n    uint
+}
+
+func (w *bitWriter) write(v uint32, n uint) {
+	w.bits |= uint64(v) << w.n
+	w.n += n
+	for w.n >= 8 {
+		w.buf = append(w.buf, byte(w.bits))
+		w.bits >>= 8
+		w.n -= 8
+	}
+}
+
+func (w *bitWriter) bytes() []byte {
+	if w.n > 0 {
+		w.buf = append(w.buf, byte(w.bits))
+		w.bits, w.n = 0, 0
+	}
+	return w.buf
+}
+
+// alphaSymbol is either a literal alpha value or a backward reference.
+type alphaSymbol struct {
+	value    uint8
+	length   uint32
+	distance uint32
+}
+
+// encodeAlpha returns the compressed alpha values of an image of the given width.
+func encodeAlpha(alpha []uint8, width int) []byte {
+	var symbols []alphaSymbol
+	for p := 0; p < len(alpha); {
+		length, distance := 0, uint32(0)
+		if p > 0 {
+			length, distance = runLength(alpha, p, 1), distanceLeft
+		}
+		if p >= width {
+			if l := runLength(alpha, p, width); l > length {
+				length, distance = l, distanceAbove
+			}
+		}
+		if length < minRunLength {
+			symbols = append(symbols, alphaSymbol{value: alpha[p]})
+			p++
+			continue
+		}
+		symbols = append(symbols, alphaSymbol{length: uint32(length), distance: distance})
+		p += length
+	}
+
+	green := make([]int, nLiteralCodes+nLengthCodes)
+	distances := make([]int, nDistanceCodes)
+	for _, s := range symbols {
+		if s.length == 0 {
+			green[s.value]++
+			continue
+		}
+		code, _, _ := prefixCode(s.length)
+		green[nLiteralCodes+code]++
+		code, _, _ = prefixCode(s.distance)
+		distances[code]++
+	}
+
+	w := &bitWriter{}
+	w.write(0, 1) // no transforms
+	w.write(0, 1) // no color cache
+	w.write(0, 1) // no meta prefix codes
+	greenLengths, greenCodes := writePrefixCode(w, green)
+	for i := 0; i < 3; i++ {
+		// red, blue and alpha are always zero
+		writePrefixCode(w, []int{1})
+	}
+	distanceLengths, distanceCodes := writePrefixCode(w, distances)
+
+	for _, s := range symbols {
+		if s.length == 0 {
+			w.write(greenCodes[s.value], uint(greenLengths[s.value]))
+			continue
+		}
+		code, n, extra := prefixCode(s.length)
+		w.write(greenCodes[nLiteralCodes+code], uint(greenLengths[nLiteralCodes+code]))
+		w.write(extra, n)
+		code, n, extra = prefixCode(s.distance)
+		w.write(distanceCodes[code], uint(distanceLengths[code]))
+		w.write(extra, n)
+	}
+	return w.bytes()
+}
+
+// runLength returns how many values starting at p are equal to the values distance positions before them.
+func runLength(alpha []uint8, p, distance int) int {
+	n := 0
+	for p+n < len(alpha) && n < maxRunLength && alpha[p+n] == alpha[p+n-distance] {
+		n++
+	}
+	return n
+}
+
+// prefixCode returns the prefix code of a backward reference length or distance code with the number
+// and the value of its extra bits.
+func prefixCode(v uint32) (uint32, uint, uint32) {
+	v--
+	if v < 4 {
+		return v, 0, 0
+	}
+	highest := uint(bits.Len32(v) - 1)
+	second := v >> (highest - 1) & 1
+	n := highest - 1
+	return uint32(2*highest) + second, n, v & (1<<n - 1)
+}
+
+// writePrefixCode writes a prefix code for the symbol counts and returns the code length and the
+// bit reversed code of each symbol.
+func writePrefixCode(w *bitWriter, counts []int) ([]uint8, []uint32) {
+	var used []int
+	for s, c := range counts {
+		if c > 0 {
+			used = append(used, s)
+		}
+	}
+	if len(used) == 0 {
+		used = []int{0}
+	}
+
+	lengths := make([]uint8, len(counts))
+	if len(used) <= 2 && used[len(used)-1] < nLiteralCodes {
+		// a simple code of one or two symbols
+		w.write(1, 1)
+		w.write(uint32(len(used)-1), 1)
+		if used[0] < 2 {
+			w.write(0, 1)
+			w.write(uint32(used[0]), 1)
+		} else {
+			w.write(1, 1)
+			w.write(uint32(used[0]), 8)
+		}
+		if len(used) == 2 {
+			w.write(uint32(used[1]), 8)
+			lengths[used[0]], lengths[used[1]] = 1, 1
+		}
+		return lengths, canonicalCodes(lengths)
+	}
+
+	lengths = huffmanLengths(counts, 15)
+	w.write(0, 1)
+	writeCodeLengths(w, lengths)
+	return codeLengths(lengths), canonicalCodes(lengths)
+}
+
+// writeCodeLengths writes the code lengths of a normal prefix code, they are coded with a prefix code themselves.
+func writeCodeLengths(w *bitWriter, lengths []uint8) {
+	type token struct {
+		symbol uint8
+		n      uint
+		extra  uint32
+	}
+	var tokens []token
+	for i := 0; i < len(lengths); {
+		l := lengths[i]
+		run := 1
+		for i+run < len(lengths) && lengths[i+run] == l {
+			run++
+		}
+		i += run
+
+		if l == 0 {
+			for run >= 11 {
+				n := run
+				if n > 138 {
+					n = 138
+				}
+				tokens = append(tokens, token{18, 7, uint32(n - 11)})
+				run -= n
+			}
+			if run >= 3 {
+				tokens = append(tokens, token{17, 3, uint32(run - 3)})
+				run = 0
+			}
+		} else {
+			tokens = append(tokens, token{symbol: l})
+			run--
+			for run >= 3 {
+				n := run
+				if n > 6 {
+					n = 6
+				}
+				tokens = append(tokens, token{16, 2, uint32(n - 3)})
+				run -= n
+			}
+		}
+		for ; run > 0; run-- {
+			tokens = append(tokens, token{symbol: l})
+		}
+	}
+
+	counts := make([]int, len(codeLengthCodeOrder))
+	for _, t := range tokens {
+		counts[t.symbol]++
+	}
+	lengthLengths := huffmanLengths(counts, 7)
+	n := 4
+	for i, s := range codeLengthCodeOrder {
+		if lengthLengths[s] != 0 && i >= n {
+			n = i + 1
+		}
+	}
+	w.write(uint32(n-4), 4)
+	for _, s := range codeLengthCodeOrder[:n] {
+		w.write(uint32(lengthLengths[s]), 3)
+	}
+
+	w.write(0, 1) // all symbols have a code length
+	codes, lengthLengths := canonicalCodes(lengthLengths), codeLengths(lengthLengths)
+	for _, t := range tokens {
+		w.write(codes[t.symbol], uint(lengthLengths[t.symbol]))
+		w.write(t.extra, t.n)
+	}
+}
+
+// huffmanLengths returns the code lengths of a Huffman code for the symbol counts which are limited to maxLength.
+// Too long codes are avoided by raising the counts of the rare symbols until the code fits.
+func huffmanLengths(counts []int, maxLength int) []uint8 {
+	lengths := make([]uint8, len(counts))
+	for minCount := 1; ; minCount *= 2 {
+		var symbols, weights, parents, active []int
+		for s, c := range counts {
+			if c == 0 {
+				continue
+			}
+			if c < minCount {
+				c = minCount
+			}
+			symbols = append(symbols, s)
+			active = append(active, len(weights))
+			weights = append(weights, c)
+			parents = append(parents, -1)
+		}
+		switch len(symbols) {
+		case 0:
+			return lengths
+		case 1:
+			lengths[symbols[0]] = 1
+			return lengths
+		}
+
+		for len(active) > 1 {
+			sort.Slice(active, func(i, j int) bool { return weights[active[i]] < weights[active[j]] })
+			n := len(weights)
+			weights = append(weights, weights[active[0]]+weights[active[1]])
+			parents = append(parents, -1)
+			parents[active[0]], parents[active[1]] = n, n
+			active = append(active[2:], n)
+		}
+
+		longest := 0
+		for i, s := range symbols {
+			depth := 0
+			for p := parents[i]; p >= 0; p = parents[p] {
+				depth++
+			}
+			lengths[s] = uint8(depth)
+			if depth > longest {
+				longest = depth
+			}
+		}
+		if longest <= maxLength {
+			return lengths
+		}
+	}
+}
+
+// canonicalCodes returns the bit reversed canonical codes of the code lengths, the codes are written
+// starting with their most significant bit.
+func canonicalCodes(lengths []uint8) []uint32 {
+	var histogram [16]uint32
+	for _, l := range lengths {
+		histogram[l]++
+	}
+	histogram[0] = 0
+	var next [16]uint32
+	code := uint32(0)
+	for l := 1; l < len(next); l++ {
+		code = (code + histogram[l-1]) << 1
+		next[l] = code
+	}
+
+	codes := make([]uint32, len(lengths))
+	for s, l := range lengths {
+		if l > 0 {
+			codes[s] = bits.Reverse32(next[l]) >> (32 - l)
+			next[l]++
+		}
+	}
+	return codes
+}
+
+// codeLengths returns the number of bits which are written for each symbol, a code with a single
+// symbol needs no bits at all.
+func codeLengths(lengths []uint8) []uint8 {
+	used := 0
+	for _, l := range lengths {
+		if l > 0 {
+			used++
+		}
+	}
+	if used > 1 {
+		return lengths
+	}
+	return make([]uint8, len(lengths))
+}
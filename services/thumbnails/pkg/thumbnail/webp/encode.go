@@ -0,0 +1,131 @@
+// Package webp implements a WebP image encoder. The color channels are encoded lossy with VP8,
+// the alpha channel of images with transparency is encoded lossless.
+package webp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/draw"
+	"io"
+)
+
+// DefaultQuality is the default quality encoding parameter.
+const DefaultQuality = 75
+
+// maxSize is the largest width and height of a WebP image.
+const maxSize = 1 << 14
+
+// ErrInvalidSize is returned when the image is empty or too large to be encoded.
+var ErrInvalidSize = errors.New("webp: invalid image size")
+
+// Options are the encoding parameters.
+// Quality ranges from 1 to 100 inclusive, higher is better.
+type Options struct {
+	Quality int
+}
+
+// Encode writes the Image m to w in the WebP format with the given options.
+// Default parameters are used if a nil *Options is passed.
+func Encode(w io.Writer, m image.Image, o *Options) error {
+	quality := DefaultQuality
+	if o != nil {
+		quality = o.Quality
+		if quality < 1 {
+			quality = 1
+		} else if quality > 100 {
+			quality = 100
+		}
+	}
+
+	b := m.Bounds()
+	if b.Dx() < 1 || b.Dy() < 1 || b.Dx() >= maxSize || b.Dy() >= maxSize {
+		return ErrInvalidSize
+	}
+	img := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(img, img.Bounds(), m, b.Min, draw.Src)
+
+	e := newVP8Encoder(b.Dx(), b.Dy(), quality)
+	alpha := e.convert(img)
+	vp8 := e.encode()
+
+	body := &bytes.Buffer{}
+	body.WriteString("WEBP")
+	if alpha != nil {
+		// the extended format announces the alpha channel which precedes the image data
+		header := make([]byte, 10)
+		header[0] = 0x10
+		putUint24(header[4:], uint32(b.Dx()-1))
+		putUint24(header[7:], uint32(b.Dy()-1))
+		writeChunk(body, "VP8X", header)
+		// lossless compression without filtering
+		writeChunk(body, "ALPH", append([]byte{0x01}, encodeAlpha(alpha, b.Dx())...))
+	}
+	writeChunk(body, "VP8 ", vp8)
+
+	riff := &bytes.Buffer{}
+	writeChunk(riff, "RIFF", body.Bytes())
+	_, err := w.Write(riff.Bytes())
+	return err
+}
+
+// convert writes the image to the YCbCr planes of the encoder and returns the alpha values,
+// or nil if the image is opaque. The image is padded to whole macroblocks by repeating the
+// last column and row. The colors are converted to the BT.601 limited range like libwebp does.
+func (e *vp8Encoder) convert(img *image.NRGBA) []uint8 {
+	pixel := func(x, y int) (int32, int32, int32) {
+		if x >= e.width {
+			x = e.width - 1
+		}
+		if y >= e.height {
+			y = e.height - 1
+		}
+		i := y*img.Stride + 4*x
+		return int32(img.Pix[i]), int32(img.Pix[i+1]), int32(img.Pix[i+2])
+	}
+
+	for y := 0; y < 16*e.mbh; y++ {
+		for x := 0; x < 16*e.mbw; x++ {
+			r, g, b := pixel(x, y)
+			e.y[y*e.yStride+x] = uint8((16839*r + 33059*g + 6420*b + 16<<16 + 1<<15) >> 16)
+		}
+	}
+	for y := 0; y < 8*e.mbh; y++ {
+		for x := 0; x < 8*e.mbw; x++ {
+			var r, g, b int32
+			for _, d := range [4][2]int{{0, 0}, {1, 0}, {0, 1}, {1, 1}} {
+				pr, pg, pb := pixel(2*x+d[0], 2*y+d[1])
+				r, g, b = r+pr, g+pg, b+pb
+			}
+			// the sums of four pixels are scaled down by two more bits
+			e.u[y*e.uvStride+x] = clip8((-9719*r - 19081*g + 28800*b + 128<<18 + 1<<17) >> 18)
+			e.v[y*e.uvStride+x] = clip8((28800*r - 24116*g - 4684*b + 128<<18 + 1<<17) >> 18)
+		}
+	}
+
+	if img.Opaque() {
+		return nil
+	}
+	alpha := make([]uint8, e.width*e.height)
+	for y := 0; y < e.height; y++ {
+		for x := 0; x < e.width; x++ {
+			alpha[y*e.width+x] = img.Pix[y*img.Stride+4*x+3]
+		}
+	}
+	return alpha
+}
+
+// writeChunk writes a RIFF chunk, odd sized data is padded with a zero byte.
+func writeChunk(buf *bytes.Buffer, fourCC string, data []byte) {
+	buf.WriteString(fourCC)
+	_ = binary.Write(buf, binary.LittleEndian, uint32(len(data)))
+	buf.Write(data)
+	if len(data)%2 == 1 {
+		buf.WriteByte(0)
+	}
+}
+
+func putUint24(b []byte, v uint32) {
+	b[0], b[1], b[2] = byte(v), byte(v>>8), byte(v>>16)
+}
@@ -0,0 +1,151 @@
+package webp
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+
+	"golang.org/x/image/webp"
+)
+
+func testImage(w, h int, transparent bool) *image.NRGBA {
+	m := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			a := uint8(255)
+			if transparent && x < w/2 {
+				a = uint8(y * 255 / h)
+			}
+			m.SetNRGBA(x, y, color.NRGBA{R: uint8(x * 255 / w), G: uint8(y * 255 / h), B: 128, A: a})
+		}
+	}
+	return m
+}
+
+func TestEncode(t *testing.T) {
+	for _, size := range []image.Point{{1, 1}, {17, 9}, {64, 48}} {
+		m := testImage(size.X, size.Y, false)
+		buf := &bytes.Buffer{}
+		if err := Encode(buf, m, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		d, err := webp.Decode(buf)
+		if err != nil {
+			t.Fatalf("decoding a %v image failed: %v", size, err)
+		}
+		if d.Bounds() != m.Bounds() {
+			t.Fatalf("expected bounds %v, got %v", m.Bounds(), d.Bounds())
+		}
+		if _, ok := d.(*image.YCbCr); !ok {
+			t.Fatalf("expected an opaque image, got %T", d)
+		}
+	}
+}
+
+func TestEncodeAlpha(t *testing.T) {
+	m := testImage(40, 30, true)
+	buf := &bytes.Buffer{}
+	if err := Encode(buf, m, &Options{Quality: 90}); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := webp.Decode(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, ok := d.(*image.NYCbCrA)
+	if !ok {
+		t.Fatalf("expected an image with alpha, got %T", d)
+	}
+	for y := 0; y < 30; y++ {
+		for x := 0; x < 40; x++ {
+			if a := n.A[n.AOffset(x, y)]; a != m.NRGBAAt(x, y).A {
+				t.Fatalf("expected alpha %d at %d,%d, got %d", m.NRGBAAt(x, y).A, x, y, a)
+			}
+		}
+	}
+}
+
+func TestEncodeInvalidSize(t *testing.T) {
+	for _, r := range []image.Rectangle{image.Rect(0, 0, 0, 10), image.Rect(0, 0, maxSize, 1)} {
+		if err := Encode(&bytes.Buffer{}, image.NewNRGBA(r), nil); err != ErrInvalidSize {
+			t.Fatalf("expected ErrInvalidSize for %v, got %v", r, err)
+		}
+	}
+}
+
+// The encoder reconstructs the image like the decoder does, without the loop filter
+// both have to match exactly.
+func TestReconstruction(t *testing.T) {
+	m := testImage(50, 35, false)
+	for i := range m.Pix {
+		if i%4 != 3 && i%7 == 0 {
+			m.Pix[i] = uint8(i * 31)
+		}
+	}
+	e := newVP8Encoder(50, 35, 100)
+	e.convert(m)
+	body := &bytes.Buffer{}
+	body.WriteString("WEBP")
+	writeChunk(body, "VP8 ", e.encode())
+	buf := &bytes.Buffer{}
+	writeChunk(buf, "RIFF", body.Bytes())
+
+	d, err := webp.Decode(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ycc := d.(*image.YCbCr)
+	for y := 0; y < 35; y++ {
+		for x := 0; x < 50; x++ {
+			if ycc.Y[ycc.YOffset(x, y)] != e.ry[y*e.yStride+x] ||
+				ycc.Cb[ycc.COffset(x, y)] != e.ru[y/2*e.uvStride+x/2] ||
+				ycc.Cr[ycc.COffset(x, y)] != e.rv[y/2*e.uvStride+x/2] {
+				t.Fatalf("the reconstruction differs from the decoded image at %d,%d", x, y)
+			}
+		}
+	}
+}
+
+func TestBoolEncoder(t *testing.T) {
+	e := newBoolEncoder()
+	var want []bool
+	for i := 0; i < 10000; i++ {
+		bit := i%3 == 0 || i%7 == 0
+		want = append(want, bit)
+		e.putBit(bit, uint8(i%255+1))
+	}
+	buf := e.bytes()
+
+	// the decoder of RFC 6386, section 7.3
+	pos := 0
+	next := func() uint32 {
+		if pos == len(buf) {
+			return 0
+		}
+		pos++
+		return uint32(buf[pos-1])
+	}
+	value, rng, count := next()<<8|next(), uint32(255), 0
+	for i, bit := range want {
+		split := 1 + ((rng-1)*uint32(i%255+1))>>8
+		got := value >= split<<8
+		if got {
+			rng -= split
+			value -= split << 8
+		} else {
+			rng = split
+		}
+		for rng < 128 {
+			value, rng, count = value<<1, rng<<1, count+1
+			if count == 8 {
+				value, count = value|next(), 0
+			}
+		}
+		if got != bit {
+			t.Fatalf("bit %d was decoded as %v", i, got)
+		}
+	}
+}
@@ -0,0 +1,712 @@
+package webp
+
+import (
+	"encoding/binary"
+)
+
+// This file implements a lossy VP8 key frame encoder as specified in RFC 6386. Every macroblock
+// is predicted as a whole (16x16 luma and 8x8 chroma prediction) which keeps the encoder simple
+// and fast enough for thumbnails.
+
+// The intra prediction modes of the macroblocks, specified in section 11.2.
+const (
+	predDC = iota
+	predVE
+	predHE
+	predTM
+	nPred
+)
+
+// The planes of the residual coefficients, specified in section 13.3.
+const (
+	planeY1WithY2 = iota
+	planeY2
+	planeUV
+)
+
+// The blocks of the coefficients of a macroblock, 16 luma, 4 blue and 4 red chroma blocks
+// and the block of the second order luma coefficients.
+const (
+	blockU  = 16
+	blockV  = 20
+	blockY2 = 24
+	nBlocks = 25
+)
+
+// maxLevel is the largest quantized coefficient which can be coded by a dct_cat6 token.
+const maxLevel = 2048 + 66
+
+// boolEncoder is the boolean entropy encoder, specified in section 7.3.
+type boolEncoder struct {
+	buf      []byte
+	rng      uint32
+	bottom   uint32
+	bitCount int
+}
+
+func newBoolEncoder() *boolEncoder {
+	return &boolEncoder{rng: 255, bitCount: 24}
+}
+
+// putBit encodes a bit which is false with a probability of prob/256.
+func (e *boolEncoder) putBit(bit bool, prob uint8) {
+	split := 1 + ((e.rng-1)*uint32(prob))>>8
+	if bit {
+		e.bottom += split
+		e.rng -= split
+	} else {
+		e.rng = split
+	}
+	for e.rng < 128 {
+		e.rng <<= 1
+		if e.bottom&(1<<31) != 0 {
+			// propagate the carry into the bytes which have already been written
+			for i := len(e.buf) - 1; i >= 0; i-- {
+				e.buf[i]++
+				if e.buf[i] != 0 {
+					break
+				}
+			}
+		}
+		e.bottom <<= 1
+		e.bitCount--
+		if e.bitCount == 0 {
+			e.buf = append(e.buf, byte(e.bottom>>24))
+			e.bottom &= 1<<24 - 1
+			e.bitCount = 8
+		}
+	}
+}
+
+// putUint encodes the n lowest bits of v with a uniform probability, the most significant bit first.
+func (e *boolEncoder) putUint(v uint32, n int) {
+	for i := n - 1; i >= 0; i-- {
+		e.putBit(v>>uint(i)&1 != 0, 128)
+	}
+}
+
+// bytes flushes the encoder and returns the encoded data.
+func (e *boolEncoder) bytes() []byte {
+	for i := 0; i < 32; i++ {
+		e.putBit(false, 128)
+	}
+	return e.buf
+}
+
+// nzContext are the flags whether the blocks along an edge of a macroblock have non-zero coefficients.
+type nzContext struct {
+	y    [4]uint8
+	u, v [2]uint8
+	y2   uint8
+}
+
+// mbHeader are the prediction modes of a macroblock and whether it has no coefficients.
+type mbHeader struct {
+	yMode, uvMode int
+	skip          bool
+}
+
+// vp8Encoder encodes YCbCr 4:2:0 planes which are padded to whole macroblocks.
+type vp8Encoder struct {
+	width, height int
+	mbw, mbh      int
+
+	// y, u and v are the source planes, ry, ru and rv are reconstructed the same way the decoder does it,
+	// the prediction of the following macroblocks is based on them.
+	y, u, v           []uint8
+	ry, ru, rv        []uint8
+	yStride, uvStride int
+
+	q          int
+	y1, y2, uv [2]int32
+
+	upNz    []nzContext
+	leftNz  nzContext
+	headers []mbHeader
+	tokens  *boolEncoder
+}
+
+func newVP8Encoder(width, height, quality int) *vp8Encoder {
+	e := &vp8Encoder{
+		width:  width,
+		height: height,
+		mbw:    (width + 15) / 16,
+		mbh:    (height + 15) / 16,
+	}
+	e.yStride, e.uvStride = 16*e.mbw, 8*e.mbw
+	e.y = make([]uint8, e.yStride*16*e.mbh)
+	e.u = make([]uint8, e.uvStride*8*e.mbh)
+	e.v = make([]uint8, e.uvStride*8*e.mbh)
+	e.ry = make([]uint8, len(e.y))
+	e.ru = make([]uint8, len(e.u))
+	e.rv = make([]uint8, len(e.v))
+
+	// the quantizer index ranges from 0 (best quality) to 127
+	e.q = (100 - quality) * 127 / 100
+	e.y1 = [2]int32{int32(dequantTableDC[e.q]), int32(dequantTableAC[e.q])}
+	e.y2 = [2]int32{2 * int32(dequantTableDC[e.q]), 155 * int32(dequantTableAC[e.q]) / 100}
+	if e.y2[1] < 8 {
+		e.y2[1] = 8
+	}
+	uvq := e.q
+	if uvq > 117 {
+		uvq = 117
+	}
+	e.uv = [2]int32{int32(dequantTableDC[uvq]), int32(dequantTableAC[e.q])}
+	return e
+}
+
+// encode encodes the frame and returns the data of the VP8 chunk.
+func (e *vp8Encoder) encode() []byte {
+	e.upNz = make([]nzContext, e.mbw)
+	e.headers = make([]mbHeader, 0, e.mbw*e.mbh)
+	e.tokens = newBoolEncoder()
+	for mby := 0; mby < e.mbh; mby++ {
+		e.leftNz = nzContext{}
+		for mbx := 0; mbx < e.mbw; mbx++ {
+			e.headers = append(e.headers, e.encodeMacroblock(mbx, mby))
+		}
+	}
+	tokens := e.tokens.bytes()
+	first := e.firstPartition()
+
+	// the frame tag of a shown key frame and the key frame start code, specified in section 9.1
+	buf := make([]byte, 10, 10+len(first)+len(tokens))
+	tag := uint32(1)<<4 | uint32(len(first))<<5
+	buf[0], buf[1], buf[2] = byte(tag), byte(tag>>8), byte(tag>>16)
+	buf[3], buf[4], buf[5] = 0x9d, 0x01, 0x2a
+	binary.LittleEndian.PutUint16(buf[6:], uint16(e.width))
+	binary.LittleEndian.PutUint16(buf[8:], uint16(e.height))
+	buf = append(buf, first...)
+	return append(buf, tokens...)
+}
+
+// firstPartition encodes the frame header and the macroblock headers, specified in sections 9.2 to 9.11 and 19.3.
+func (e *vp8Encoder) firstPartition() []byte {
+	fp := newBoolEncoder()
+	fp.putUint(0, 2)      // color space and clamping type
+	fp.putBit(false, 128) // no segments
+
+	// the normal loop filter smoothes the block edges, its level grows with the quantizer
+	level := e.q * 5 / 8
+	if level > 63 {
+		level = 63
+	}
+	fp.putBit(false, 128)
+	fp.putUint(uint32(level), 6)
+	fp.putUint(0, 3)
+	fp.putBit(false, 128)
+
+	fp.putUint(0, 2) // a single token partition
+	fp.putUint(uint32(e.q), 7)
+	for i := 0; i < 5; i++ {
+		// no quantizer deltas
+		fp.putBit(false, 128)
+	}
+	fp.putBit(false, 128) // refresh_entropy_probs
+
+	for i := range tokenProbUpdateProb {
+		for j := range tokenProbUpdateProb[i] {
+			for k := range tokenProbUpdateProb[i][j] {
+				for l := range tokenProbUpdateProb[i][j][k] {
+					fp.putBit(false, tokenProbUpdateProb[i][j][k][l])
+				}
+			}
+		}
+	}
+
+	skipped := 0
+	for _, h := range e.headers {
+		if h.skip {
+			skipped++
+		}
+	}
+	skipProb := 255 - skipped*255/len(e.headers)
+	if skipProb < 1 {
+		skipProb = 1
+	}
+	fp.putBit(true, 128)
+	fp.putUint(uint32(skipProb), 8)
+
+	for _, h := range e.headers {
+		fp.putBit(h.skip, uint8(skipProb))
+		fp.putBit(true, 145) // 16x16 luma prediction
+		switch h.yMode {
+		case predDC:
+			fp.putBit(false, 156)
+			fp.putBit(false, 163)
+		case predVE:
+			fp.putBit(false, 156)
+			fp.putBit(true, 163)
+		case predHE:
+			fp.putBit(true, 156)
+			fp.putBit(false, 128)
+		case predTM:
+			fp.putBit(true, 156)
+			fp.putBit(true, 128)
+		}
+		switch h.uvMode {
+		case predDC:
+			fp.putBit(false, 142)
+		case predVE:
+			fp.putBit(true, 142)
+			fp.putBit(false, 114)
+		case predHE:
+			fp.putBit(true, 142)
+			fp.putBit(true, 114)
+			fp.putBit(false, 183)
+		case predTM:
+			fp.putBit(true, 142)
+			fp.putBit(true, 114)
+			fp.putBit(true, 183)
+		}
+	}
+	return fp.bytes()
+}
+
+// encodeMacroblock predicts, transforms and quantizes a macroblock, writes its coefficients to
+// the token partition and reconstructs it.
+func (e *vp8Encoder) encodeMacroblock(mbx, mby int) mbHeader {
+	var (
+		h     mbHeader
+		coeff [nBlocks][16]int32
+		pred  [256]uint8
+	)
+
+	// luma
+	h.yMode = e.predict(pred[:], 16, mbx, mby, []plane{{e.y, e.ry, e.yStride}})
+	offset := 16*mby*e.yStride + 16*mbx
+	var dc [16]int32
+	for b := 0; b < 16; b++ {
+		x, y := 4*(b%4), 4*(b/4)
+		c := fdct(e.y[offset+y*e.yStride+x:], e.yStride, pred[y*16+x:], 16)
+		dc[b] = c[0]
+		for k := 1; k < 16; k++ {
+			coeff[b][k] = quantize(c[k], e.y1[1], false)
+		}
+	}
+	wht := fwht(dc)
+	for k := range wht {
+		coeff[blockY2][k] = quantize(wht[k], e.y2[btoi(k > 0)], true)
+	}
+
+	var y2 [16]int32
+	for k := range y2 {
+		y2[k] = coeff[blockY2][k] * e.y2[btoi(k > 0)]
+	}
+	dc = iwht(y2)
+	for b := 0; b < 16; b++ {
+		x, y := 4*(b%4), 4*(b/4)
+		var c [16]int32
+		c[0] = dc[b]
+		for k := 1; k < 16; k++ {
+			c[k] = coeff[b][k] * e.y1[1]
+		}
+		idct(e.ry[offset+y*e.yStride+x:], e.yStride, pred[y*16+x:], 16, c)
+	}
+
+	// chroma
+	h.uvMode = e.predict(pred[:], 8, mbx, mby, []plane{{e.u, e.ru, e.uvStride}, {e.v, e.rv, e.uvStride}})
+	offset = 8*mby*e.uvStride + 8*mbx
+	for i, p := range []plane{{e.u, e.ru, e.uvStride}, {e.v, e.rv, e.uvStride}} {
+		for b := 0; b < 4; b++ {
+			x, y := 4*(b%2), 4*(b/2)
+			c := fdct(p.src[offset+y*p.stride+x:], p.stride, pred[64*i+y*8+x:], 8)
+			block := &coeff[blockU+4*i+b]
+			for k := range c {
+				block[k] = quantize(c[k], e.uv[btoi(k > 0)], k == 0)
+				c[k] = block[k] * e.uv[btoi(k > 0)]
+			}
+			idct(p.rec[offset+y*p.stride+x:], p.stride, pred[64*i+y*8+x:], 8, c)
+		}
+	}
+
+	h.skip = true
+	for b := range coeff {
+		for k := range coeff[b] {
+			if coeff[b][k] != 0 {
+				h.skip = false
+			}
+		}
+	}
+	if h.skip {
+		e.upNz[mbx], e.leftNz = nzContext{}, nzContext{}
+		return h
+	}
+
+	up, left := &e.upNz[mbx], &e.leftNz
+	nz := e.putResiduals(planeY2, up.y2+left.y2, &coeff[blockY2], 0)
+	up.y2, left.y2 = nz, nz
+	for b := 0; b < 16; b++ {
+		x, y := b%4, b/4
+		nz := e.putResiduals(planeY1WithY2, up.y[x]+left.y[y], &coeff[b], 1)
+		up.y[x], left.y[y] = nz, nz
+	}
+	for b := 0; b < 4; b++ {
+		x, y := b%2, b/2
+		nz := e.putResiduals(planeUV, up.u[x]+left.u[y], &coeff[blockU+b], 0)
+		up.u[x], left.u[y] = nz, nz
+	}
+	for b := 0; b < 4; b++ {
+		x, y := b%2, b/2
+		nz := e.putResiduals(planeUV, up.v[x]+left.v[y], &coeff[blockV+b], 0)
+		up.v[x], left.v[y] = nz, nz
+	}
+	return h
+}
+
+// putResiduals writes the tokens of the quantized coefficients of a block starting with the coefficient
+// first, it returns 1 if the block has non-zero coefficients. This mirrors the token parsing of section 13.
+func (e *vp8Encoder) putResiduals(plane int, context uint8, coeff *[16]int32, first int) uint8 {
+	last := -1
+	for n := first; n < 16; n++ {
+		if coeff[zigzag[n]] != 0 {
+			last = n
+		}
+	}
+
+	prob := &defaultTokenProb[plane]
+	p := prob[bands[first]][context]
+	if last < 0 {
+		e.tokens.putBit(false, p[0])
+		return 0
+	}
+	e.tokens.putBit(true, p[0])
+
+	for n := first; n <= last; {
+		c := coeff[zigzag[n]]
+		n++
+		if c == 0 {
+			e.tokens.putBit(false, p[1])
+			p = prob[bands[n]][0]
+			continue
+		}
+		e.tokens.putBit(true, p[1])
+
+		v := c
+		if v < 0 {
+			v = -v
+		}
+		if v > maxLevel {
+			v = maxLevel
+		}
+		e.putLevel(p, uint32(v))
+		if v == 1 {
+			p = prob[bands[n]][1]
+		} else {
+			p = prob[bands[n]][2]
+		}
+		e.tokens.putBit(c < 0, 128)
+
+		if n == 16 {
+			break
+		}
+		// the end of block follows the last non-zero coefficient
+		e.tokens.putBit(n <= last, p[0])
+	}
+	return 1
+}
+
+// putLevel writes the token tree of an absolute coefficient value greater than zero.
+func (e *vp8Encoder) putLevel(p [nProb]uint8, v uint32) {
+	t := e.tokens
+	if v == 1 {
+		t.putBit(false, p[2])
+		return
+	}
+	t.putBit(true, p[2])
+	switch {
+	case v <= 4:
+		t.putBit(false, p[3])
+		if v == 2 {
+			t.putBit(false, p[4])
+			return
+		}
+		t.putBit(true, p[4])
+		t.putBit(v == 4, p[5])
+	case v <= 10:
+		t.putBit(true, p[3])
+		t.putBit(false, p[6])
+		if v <= 6 {
+			// category 1
+			t.putBit(false, p[7])
+			t.putBit(v == 6, 159)
+			return
+		}
+		// category 2
+		t.putBit(true, p[7])
+		t.putBit((v-7)&2 != 0, 165)
+		t.putBit((v-7)&1 != 0, 145)
+	default:
+		t.putBit(true, p[3])
+		t.putBit(true, p[6])
+		// categories 3 to 6
+		cat := 0
+		for cat < 3 && v >= 3+(8<<uint(cat+1)) {
+			cat++
+		}
+		t.putBit(cat >= 2, p[8])
+		t.putBit(cat&1 != 0, p[9+cat/2])
+		tab := &cat3456[cat]
+		extra := v - 3 - (8 << uint(cat))
+		bits := 0
+		for tab[bits] != 0 {
+			bits++
+		}
+		for i := 0; i < bits; i++ {
+			t.putBit(extra>>uint(bits-1-i)&1 != 0, tab[i])
+		}
+	}
+}
+
+// plane is a source plane with its reconstruction.
+type plane struct {
+	src, rec []uint8
+	stride   int
+}
+
+// predict writes the prediction of a macroblock of the given size to pred and returns its mode, the mode with
+// the smallest difference to the source of all planes wins. The predictions of the planes follow each other.
+func (e *vp8Encoder) predict(pred []uint8, size, mbx, mby int, planes []plane) int {
+	n := size * size
+	candidate := make([]uint8, n*len(planes))
+	best, bestDiff := predDC, -1
+	for mode := 0; mode < nPred; mode++ {
+		diff := 0
+		for i, p := range planes {
+			offset := size*mby*p.stride + size*mbx
+			predictBlock(candidate[i*n:(i+1)*n], size, mode, p.rec, offset, p.stride, mbx > 0, mby > 0)
+			for y := 0; y < size; y++ {
+				for x := 0; x < size; x++ {
+					d := int(p.src[offset+y*p.stride+x]) - int(candidate[i*n+y*size+x])
+					if d < 0 {
+						d = -d
+					}
+					diff += d
+				}
+			}
+		}
+		if bestDiff < 0 || diff < bestDiff {
+			best, bestDiff = mode, diff
+			copy(pred, candidate)
+		}
+	}
+	return best
+}
+
+// predictBlock predicts the block at offset from the reconstructed pixels above and to the left of it. Missing pixels
+// above the frame are 127 and missing pixels left of it are 129, as specified in section 12.2.
+func predictBlock(pred []uint8, size, mode int, rec []uint8, offset, stride int, hasLeft, hasAbove bool) {
+	above, left := make([]int32, size), make([]int32, size)
+	corner := int32(127)
+	for i := 0; i < size; i++ {
+		above[i], left[i] = 127, 129
+		if hasAbove {
+			above[i] = int32(rec[offset-stride+i])
+		}
+		if hasLeft {
+			left[i] = int32(rec[offset+i*stride-1])
+		}
+	}
+	switch {
+	case hasAbove && hasLeft:
+		corner = int32(rec[offset-stride-1])
+	case hasAbove:
+		corner = 129
+	}
+
+	shift := uint(3)
+	if size == 16 {
+		shift = 4
+	}
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			var v int32
+			switch mode {
+			case predDC:
+				var sum int32
+				switch {
+				case hasAbove && hasLeft:
+					for i := 0; i < size; i++ {
+						sum += above[i] + left[i]
+					}
+					v = (sum + int32(size)) >> (shift + 1)
+				case hasAbove:
+					for i := 0; i < size; i++ {
+						sum += above[i]
+					}
+					v = (sum + int32(size/2)) >> shift
+				case hasLeft:
+					for i := 0; i < size; i++ {
+						sum += left[i]
+					}
+					v = (sum + int32(size/2)) >> shift
+				default:
+					v = 128
+				}
+			case predVE:
+				v = above[x]
+			case predHE:
+				v = left[y]
+			case predTM:
+				v = int32(clip8(left[y] + above[x] - corner))
+			}
+			pred[y*size+x] = uint8(v)
+		}
+	}
+}
+
+// quantize returns the quantized coefficient, AC coefficients are rounded towards zero a bit more
+// as small values are often noise.
+func quantize(c, q int32, dc bool) int32 {
+	sign := int32(1)
+	if c < 0 {
+		sign, c = -1, -c
+	}
+	bias := q * 3 / 8
+	if dc {
+		bias = q / 2
+	}
+	level := (c + bias) / q
+	if level > maxLevel {
+		level = maxLevel
+	}
+	return sign * level
+}
+
+// fdct returns the forward DCT of the difference of a 4x4 block and its prediction, it is the inverse of idct.
+func fdct(src []uint8, srcStride int, pred []uint8, predStride int) [16]int32 {
+	var tmp, out [16]int32
+	for i := 0; i < 4; i++ {
+		var d [4]int32
+		for j := range d {
+			d[j] = int32(src[i*srcStride+j]) - int32(pred[i*predStride+j])
+		}
+		a1 := (d[0] + d[3]) * 8
+		b1 := (d[1] + d[2]) * 8
+		c1 := (d[1] - d[2]) * 8
+		d1 := (d[0] - d[3]) * 8
+		tmp[i*4+0] = a1 + b1
+		tmp[i*4+2] = a1 - b1
+		tmp[i*4+1] = (c1*2217 + d1*5352 + 14500) >> 12
+		tmp[i*4+3] = (d1*2217 - c1*5352 + 7500) >> 12
+	}
+	for i := 0; i < 4; i++ {
+		a1 := tmp[i] + tmp[12+i]
+		b1 := tmp[4+i] + tmp[8+i]
+		c1 := tmp[4+i] - tmp[8+i]
+		d1 := tmp[i] - tmp[12+i]
+		out[i] = (a1 + b1 + 7) >> 4
+		out[8+i] = (a1 - b1 + 7) >> 4
+		out[4+i] = (c1*2217 + d1*5352 + 12000) >> 16
+		if d1 != 0 {
+			out[4+i]++
+		}
+		out[12+i] = (d1*2217 - c1*5352 + 51000) >> 16
+	}
+	return out
+}
+
+// idct adds the inverse DCT of the coefficients to the prediction of a 4x4 block, specified in section 14.3.
+func idct(dst []uint8, dstStride int, pred []uint8, predStride int, c [16]int32) {
+	const (
+		c1 = 85627 // 65536 * cos(pi/8) * sqrt(2)
+		c2 = 35468 // 65536 * sin(pi/8) * sqrt(2)
+	)
+	var m [4][4]int32
+	for i := 0; i < 4; i++ {
+		a := c[i] + c[8+i]
+		b := c[i] - c[8+i]
+		cc := (c[4+i]*c2)>>16 - (c[12+i]*c1)>>16
+		d := (c[4+i]*c1)>>16 + (c[12+i]*c2)>>16
+		m[i] = [4]int32{a + d, b + cc, b - cc, a - d}
+	}
+	for j := 0; j < 4; j++ {
+		dc := m[0][j] + 4
+		a := dc + m[2][j]
+		b := dc - m[2][j]
+		cc := (m[1][j]*c2)>>16 - (m[3][j]*c1)>>16
+		d := (m[1][j]*c1)>>16 + (m[3][j]*c2)>>16
+		p := pred[j*predStride:]
+		out := dst[j*dstStride:]
+		out[0] = clip8(int32(p[0]) + (a+d)>>3)
+		out[1] = clip8(int32(p[1]) + (b+cc)>>3)
+		out[2] = clip8(int32(p[2]) + (b-cc)>>3)
+		out[3] = clip8(int32(p[3]) + (a-d)>>3)
+	}
+}
+
+// fwht returns the forward Walsh-Hadamard transform of the DC coefficients of the luma blocks, it is the inverse of iwht.
+func fwht(dc [16]int32) [16]int32 {
+	var tmp, out [16]int32
+	for i := 0; i < 4; i++ {
+		a1 := (dc[i*4+0] + dc[i*4+2]) * 4
+		d1 := (dc[i*4+1] + dc[i*4+3]) * 4
+		c1 := (dc[i*4+1] - dc[i*4+3]) * 4
+		b1 := (dc[i*4+0] - dc[i*4+2]) * 4
+		tmp[i*4+0] = a1 + d1
+		if a1 != 0 {
+			tmp[i*4+0]++
+		}
+		tmp[i*4+1] = b1 + c1
+		tmp[i*4+2] = b1 - c1
+		tmp[i*4+3] = a1 - d1
+	}
+	for i := 0; i < 4; i++ {
+		a1 := tmp[i] + tmp[8+i]
+		d1 := tmp[4+i] + tmp[12+i]
+		c1 := tmp[4+i] - tmp[12+i]
+		b1 := tmp[i] - tmp[8+i]
+		for k, v := range [4]int32{a1 + d1, b1 + c1, b1 - c1, a1 - d1} {
+			if v < 0 {
+				v++
+			}
+			out[4*k+i] = (v + 3) >> 3
+		}
+	}
+	return out
+}
+
+// iwht returns the DC coefficients of the luma blocks, specified in section 14.3.
+func iwht(c [16]int32) [16]int32 {
+	var m, out [16]int32
+	for i := 0; i < 4; i++ {
+		a0 := c[i] + c[12+i]
+		a1 := c[4+i] + c[8+i]
+		a2 := c[4+i] - c[8+i]
+		a3 := c[i] - c[12+i]
+		m[i] = a0 + a1
+		m[8+i] = a0 - a1
+		m[4+i] = a3 + a2
+		m[12+i] = a3 - a2
+	}
+	for i := 0; i < 4; i++ {
+		dc := m[i*4] + 3
+		a0 := dc + m[i*4+3]
+		a1 := m[i*4+1] + m[i*4+2]
+		a2 := m[i*4+1] - m[i*4+2]
+		a3 := dc - m[i*4+3]
+		out[i*4+0] = (a0 + a1) >> 3
+		out[i*4+1] = (a3 + a2) >> 3
+		out[i*4+2] = (a0 - a1) >> 3
+		out[i*4+3] = (a3 - a2) >> 3
+	}
+	return out
+}
+
+func clip8(v int32) uint8 {
+	if v < 0 {
+		return 0
+	}
+	if v > 255 {
+		return 255
+	}
+	return uint8(v)
+}
+
+func btoi(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
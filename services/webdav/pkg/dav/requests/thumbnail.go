@@ -6,12 +6,14 @@ import (
 	"net/url"
 	"path/filepath"
 	"strconv"
+	"strings"
 
 	providerv1beta1 "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
 	"github.com/cs3org/reva/v2/pkg/storagespace"
 	"github.com/cs3org/reva/v2/pkg/utils"
 	"github.com/go-chi/chi/v5"
 
+	thumbnailsmsg "github.com/owncloud/ocis/v2/protogen/gen/ocis/messages/thumbnails/v0"
 	"github.com/owncloud/ocis/v2/services/webdav/pkg/constants"
 )
 
@@ -30,6 +32,8 @@ type ThumbnailRequest struct {
 	Filename string
 	// The file extension
 	Extension string
+	// The type of the thumbnail, either the requested type or the best type the client accepts
+	Type thumbnailsmsg.ThumbnailType
 	// The requested width of the thumbnail
 	Width int32
 	// The requested height of the thumbnail
@@ -67,10 +71,16 @@ func ParseThumbnailRequest(r *http.Request) (*ThumbnailRequest, error) {
 		return nil, err
 	}
 
+	tType, err := parseThumbnailType(q.Get("type"), r.Header.Get("Accept"), filepath.Ext(fp))
+	if err != nil {
+		return nil, err
+	}
+
 	return &ThumbnailRequest{
 		Filepath:        fp,
 		Filename:        filepath.Base(fp),
 		Extension:       filepath.Ext(fp),
+		Type:            tType,
 		Width:           int32(width),
 		Height:          int32(height),
 		PublicLinkToken: chi.URLParam(r, "token"),
@@ -101,3 +111,55 @@ func parseDimension(d, name string, defaultValue int64) (int64, error) {
 	}
 	return result, nil
 }
+
+// parseThumbnailType returns the explicitly requested thumbnail type. Without one webp is used if the client
+// accepts it, animated gifs stay gifs and everything else is encoded like the source file.
+func parseThumbnailType(t, accept, ext string) (thumbnailsmsg.ThumbnailType, error) {
+	if t != "" {
+		name := strings.ToUpper(t)
+		if name == "JPEG" {
+			name = "JPG"
+		}
+		v, ok := thumbnailsmsg.ThumbnailType_value[name]
+		if !ok {
+			return 0, fmt.Errorf("unsupported thumbnail type %s", t)
+		}
+		return thumbnailsmsg.ThumbnailType(v), nil
+	}
+
+	ext = strings.ToUpper(strings.TrimLeft(ext, "."))
+	if ext != "GIF" && acceptsMimeType(accept, "image/webp") {
+		return thumbnailsmsg.ThumbnailType_WEBP, nil
+	}
+
+	switch ext {
+	case "GIF":
+		return thumbnailsmsg.ThumbnailType_GIF, nil
+	case "PNG":
+		return thumbnailsmsg.ThumbnailType_PNG, nil
+	default:
+		return thumbnailsmsg.ThumbnailType_JPG, nil
+	}
+}
+
+// acceptsMimeType checks if an Accept header explicitly lists the mime type with a quality above zero,
+// wildcards don't count because most clients send them for any kind of request.
+func acceptsMimeType(accept, mimeType string) bool {
+	for _, mediaRange := range strings.Split(accept, ",") {
+		params := strings.Split(mediaRange, ";")
+		if !strings.EqualFold(strings.TrimSpace(params[0]), mimeType) {
+			continue
+		}
+		for _, p := range params[1:] {
+			k, v, _ := strings.Cut(strings.TrimSpace(p), "=")
+			if strings.TrimSpace(k) != "q" {
+				continue
+			}
+			if q, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err != nil || q <= 0 {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
@@ -208,6 +208,9 @@ func matchToPropResponse(ctx context.Context, match *searchmsg.Match) (*propfind
 	}
 	score := strconv.FormatFloat(float64(match.Score), 'f', -1, 64)
 	propstatOK.Prop = append(propstatOK.Prop, prop.Escaped("oc:score", score))
+	if len(match.Highlights) > 0 {
+		propstatOK.Prop = append(propstatOK.Prop, prop.Escaped("oc:highlights", strings.Join(match.Highlights, " … ")))
+	}
 
 	if len(propstatOK.Prop) > 0 {
 		response.Propstat = append(response.Propstat, propstatOK)
@@ -233,7 +233,7 @@ func (g Webdav) SpacesThumbnail(w http.ResponseWriter, r *http.Request) {
 	fullPath := filepath.Join(tr.Identifier, tr.Filepath)
 	rsp, err := g.thumbnailsClient.GetThumbnail(r.Context(), &thumbnailssvc.GetThumbnailRequest{
 		Filepath:      strings.TrimLeft(tr.Filepath, "/"),
-		ThumbnailType: extensionToThumbnailType(strings.TrimLeft(tr.Extension, ".")),
+		ThumbnailType: tr.Type,
 		Width:         tr.Width,
 		Height:        tr.Height,
 		Source: &thumbnailssvc.GetThumbnailRequest_Cs3Source{
@@ -319,7 +319,7 @@ func (g Webdav) Thumbnail(w http.ResponseWriter, r *http.Request) {
 	fullPath := filepath.Join(templates.WithUser(user, g.config.WebdavNamespace), tr.Filepath)
 	rsp, err := g.thumbnailsClient.GetThumbnail(r.Context(), &thumbnailssvc.GetThumbnailRequest{
 		Filepath:      strings.TrimLeft(tr.Filepath, "/"),
-		ThumbnailType: extensionToThumbnailType(strings.TrimLeft(tr.Extension, ".")),
+		ThumbnailType: tr.Type,
 		Width:         tr.Width,
 		Height:        tr.Height,
 		Source: &thumbnailssvc.GetThumbnailRequest_Cs3Source{
@@ -359,7 +359,7 @@ func (g Webdav) PublicThumbnail(w http.ResponseWriter, r *http.Request) {
 
 	rsp, err := g.thumbnailsClient.GetThumbnail(r.Context(), &thumbnailssvc.GetThumbnailRequest{
 		Filepath:      strings.TrimLeft(tr.Filepath, "/"),
-		ThumbnailType: extensionToThumbnailType(strings.TrimLeft(tr.Extension, ".")),
+		ThumbnailType: tr.Type,
 		Width:         tr.Width,
 		Height:        tr.Height,
 		Source: &thumbnailssvc.GetThumbnailRequest_WebdavSource{
@@ -400,7 +400,7 @@ func (g Webdav) PublicThumbnailHead(w http.ResponseWriter, r *http.Request) {
 
 	_, err = g.thumbnailsClient.GetThumbnail(r.Context(), &thumbnailssvc.GetThumbnailRequest{
 		Filepath:      strings.TrimLeft(tr.Filepath, "/"),
-		ThumbnailType: extensionToThumbnailType(strings.TrimLeft(tr.Extension, ".")),
+		ThumbnailType: tr.Type,
 		Width:         tr.Width,
 		Height:        tr.Height,
 		Source: &thumbnailssvc.GetThumbnailRequest_WebdavSource{
@@ -462,25 +462,16 @@ func (g Webdav) sendThumbnailResponse(rsp *thumbnailssvc.GetThumbnailResponse, w
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
 	w.Header().Set("Content-Type", rsp.Mimetype)
+	// the type of the thumbnail depends on the accept header if it wasn't requested explicitly
+	w.Header().Set("Vary", "Accept")
+	w.WriteHeader(http.StatusOK)
 	_, err = io.Copy(w, dlRsp.Body)
 	if err != nil {
 		logger.Error().Err(err).Msg("failed to write thumbnail to response writer")
 	}
 }
 
-func extensionToThumbnailType(ext string) thumbnailsmsg.ThumbnailType {
-	switch strings.ToUpper(ext) {
-	case "GIF":
-		return thumbnailsmsg.ThumbnailType_GIF
-	case "PNG":
-		return thumbnailsmsg.ThumbnailType_PNG
-	default:
-		return thumbnailsmsg.ThumbnailType_JPG
-	}
-}
-
 // http://www.webdav.org/specs/rfc4918.html#ELEMENT_error
 type errResponse struct {
 	HTTPStatusCode int      `json:"-" xml:"-"`